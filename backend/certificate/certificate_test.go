@@ -0,0 +1,72 @@
+package certificate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pathway/backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func testCertificate() *models.Certificate {
+	return &models.Certificate{
+		ID:          primitive.NewObjectID(),
+		UserID:      primitive.NewObjectID(),
+		CourseID:    primitive.NewObjectID(),
+		LearnerName: "Ada <Lovelace> (Byron)",
+		CourseTitle: "Git Fundamentals",
+		CompletedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		IssuedAt:    time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+	cert := testCertificate()
+	token := signer.Sign(cert)
+	if !signer.Verify(cert, token) {
+		t.Fatal("token should verify")
+	}
+
+	if NewSigner([]byte("other")).Verify(cert, token) {
+		t.Error("token should not verify with another key")
+	}
+	tampered := *cert
+	tampered.LearnerName = "Someone Else"
+	if signer.Verify(&tampered, token) {
+		t.Error("token should not verify a changed certificate")
+	}
+	if signer.Verify(cert, "") {
+		t.Error("an empty token should not verify")
+	}
+}
+
+func TestRender(t *testing.T) {
+	cert := testCertificate()
+	verifyURL := "https://example.com/api/certificates/" + cert.ID.Hex() + "/verify?token=abc&x=1"
+
+	svg := string(RenderSVG(cert, verifyURL))
+	for _, want := range []string{"<svg", "Ada &lt;Lovelace&gt; (Byron)", "Git Fundamentals", "March 1, 2025", "token=abc&amp;x=1"} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG should contain %q:\n%s", want, svg)
+		}
+	}
+
+	pdf := RenderPDF(cert, verifyURL)
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("not a PDF:\n%s", pdf)
+	}
+	for _, want := range []string{`(Ada <Lovelace> \(Byron\))`, "(Git Fundamentals)", "/URI (" + verifyURL + ")"} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("PDF should contain %q", want)
+		}
+	}
+}
+
+func TestPDFString(t *testing.T) {
+	if got := pdfString(`José \ 日本`); got != "Jos\xe9 \\\\ ??" {
+		t.Fatalf("unexpected escaping: %q", got)
+	}
+}
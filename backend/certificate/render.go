@@ -0,0 +1,102 @@
+package certificate
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/pathway/backend/models"
+)
+
+// dateLayout formats completion dates on certificates
+const dateLayout = "January 2, 2006"
+
+// RenderSVG renders the certificate as an A4 landscape SVG image that links to
+// verifyURL
+func RenderSVG(cert *models.Certificate, verifyURL string) []byte {
+	var b bytes.Buffer
+	text := func(y int, size int, weight string, s string) {
+		fmt.Fprintf(&b, `  <text x="561" y="%d" font-size="%d" font-weight="%s" text-anchor="middle">%s</text>`+"\n",
+			y, size, weight, html.EscapeString(s))
+	}
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" width="1123" height="794" viewBox="0 0 1123 794" font-family="Helvetica, Arial, sans-serif" fill="#1f2937">` + "\n")
+	b.WriteString(`  <rect width="1123" height="794" fill="#ffffff"/>` + "\n")
+	b.WriteString(`  <rect x="30" y="30" width="1063" height="734" fill="none" stroke="#6366f1" stroke-width="6"/>` + "\n")
+	text(200, 48, "bold", "Certificate of Completion")
+	text(290, 22, "normal", "This certifies that")
+	text(370, 44, "bold", cert.LearnerName)
+	text(440, 22, "normal", "has completed the course")
+	text(510, 34, "bold", cert.CourseTitle)
+	text(580, 20, "normal", "on "+cert.CompletedAt.UTC().Format(dateLayout))
+	text(690, 14, "normal", "Certificate "+cert.ID.Hex())
+	fmt.Fprintf(&b, `  <a href="%s">`+"\n", html.EscapeString(verifyURL))
+	text(715, 14, "normal", "Verify at "+verifyURL)
+	b.WriteString("  </a>\n</svg>\n")
+	return b.Bytes()
+}
+
+// RenderPDF renders the certificate as a single-page A4 landscape PDF that
+// links to verifyURL. Text uses the standard Helvetica fonts, so characters
+// outside Latin-1 are replaced with "?".
+func RenderPDF(cert *models.Certificate, verifyURL string) []byte {
+	var content bytes.Buffer
+	content.WriteString("0.39 0.4 0.95 RG 4 w 20 20 802 555 re S\n0.12 0.16 0.22 rg\n")
+	line := func(y int, font string, size int, s string) {
+		fmt.Fprintf(&content, "BT /%s %d Tf 60 %d Td (%s) Tj ET\n", font, size, y, pdfString(s))
+	}
+	line(460, "F2", 36, "Certificate of Completion")
+	line(400, "F1", 16, "This certifies that")
+	line(350, "F2", 30, cert.LearnerName)
+	line(300, "F1", 16, "has completed the course")
+	line(255, "F2", 24, cert.CourseTitle)
+	line(210, "F1", 14, "on "+cert.CompletedAt.UTC().Format(dateLayout))
+	line(90, "F1", 10, "Certificate "+cert.ID.Hex())
+	line(72, "F1", 10, "Verify at "+verifyURL)
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 842 595] /Contents 4 0 R " +
+			"/Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> /Annots [7 0 R] >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Type /Annot /Subtype /Link /Rect [60 66 782 84] /Border [0 0 0] /A << /S /URI /URI (%s) >> >>", pdfString(verifyURL)),
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// pdfString escapes s for a PDF literal string in WinAnsiEncoding, which
+// matches Latin-1 for the characters it keeps
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || (r >= 0x7f && r < 0xa0) || r > 0xff:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
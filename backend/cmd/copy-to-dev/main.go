@@ -85,14 +85,14 @@ func copyUserToDev(sourceRepo, targetRepo repository.Repository, email string) e
 
 	// 1. Get user from source (production)
 	log.Printf("Fetching user: %s from production database...", email)
-	sourceUser, err := sourceRepo.GetUserByEmail(email)
+	sourceUser, err := sourceRepo.GetUserByEmail(ctx, email)
 	if err != nil {
 		return fmt.Errorf("failed to get user from source: %v", err)
 	}
 	log.Printf("Found user: %s (ID: %s)", sourceUser.Email, sourceUser.ID.Hex())
 
 	// 2. Check if user already exists in target (dev)
-	targetUser, err := targetRepo.GetUserByEmail(email)
+	targetUser, err := targetRepo.GetUserByEmail(ctx, email)
 	var targetUserID primitive.ObjectID
 	if err == nil && targetUser != nil {
 		log.Printf("User %s already exists in dev database (ID: %s)", email, targetUser.ID.Hex())
@@ -104,12 +104,12 @@ func copyUserToDev(sourceRepo, targetRepo repository.Repository, email string) e
 		newUser := *sourceUser
 		newUser.ID = primitive.NilObjectID // Let MongoDB generate new ID
 		
-		if err := targetRepo.CreateUser(&newUser); err != nil {
+		if err := targetRepo.CreateUser(ctx, &newUser); err != nil {
 			return fmt.Errorf("failed to create user in dev: %v", err)
 		}
 		
 		// Get the newly created user to get its ID
-		createdUser, err := targetRepo.GetUserByEmail(email)
+		createdUser, err := targetRepo.GetUserByEmail(ctx, email)
 		if err != nil {
 			return fmt.Errorf("failed to get created user: %v", err)
 		}
@@ -119,27 +119,27 @@ func copyUserToDev(sourceRepo, targetRepo repository.Repository, email string) e
 
 	// 4. Get progress from source (production)
 	log.Println("Fetching progress from production database...")
-	sourceProgress, err := sourceRepo.GetUserProgress(sourceUser.ID.Hex())
+	sourceProgress, err := sourceRepo.GetUserProgress(ctx, sourceUser.ID.Hex())
 	if err != nil {
 		return fmt.Errorf("failed to get progress from source: %v", err)
 	}
 	log.Printf("Found %d progress records in production", len(sourceProgress))
 
 	// 5. Get all courses from both databases to map course IDs
-	sourceCourses, err := sourceRepo.GetAllCourses()
+	sourceCourses, err := sourceRepo.GetAllCourses(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get source courses: %v", err)
 	}
-	targetCourses, err := targetRepo.GetAllCourses()
+	targetCourses, err := targetRepo.GetAllCourses(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get target courses: %v", err)
 	}
 
-	// Create course ID mapping (source course ID -> target course ID) by title
+	// Create course ID mapping (source course ID -> target course ID) by slug, falling back to title
 	courseIDMap := make(map[primitive.ObjectID]primitive.ObjectID)
 	for _, sourceCourse := range sourceCourses {
 		for _, targetCourse := range targetCourses {
-			if sourceCourse.Title == targetCourse.Title {
+			if sourceCourse.SameAs(&targetCourse) {
 				courseIDMap[sourceCourse.ID] = targetCourse.ID
 				log.Printf("Mapped course: %s -> %s", sourceCourse.ID.Hex(), targetCourse.ID.Hex())
 				break
@@ -188,4 +188,3 @@ func copyUserToDev(sourceRepo, targetRepo repository.Repository, email string) e
 
 	return nil
 }
-
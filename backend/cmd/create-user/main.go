@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/pathway/backend/models"
@@ -42,6 +44,14 @@ func main() {
 		password = "test123456"
 	}
 
+	role := os.Getenv("USER_ROLE")
+	if role == "" {
+		role = models.RoleStudent
+	}
+	if !models.ValidRole(role) {
+		log.Fatalf("Invalid USER_ROLE %q (expected student, instructor or admin)", role)
+	}
+
 	// Initialize Repository
 	repo, err := repository.NewMongoRepository(mongoURI, dbName)
 	if err != nil {
@@ -49,8 +59,10 @@ func main() {
 	}
 	defer repo.Close()
 
+	ctx := context.Background()
+
 	// Check if user already exists
-	existingUser, err := repo.GetUserByEmail(email)
+	existingUser, err := repo.GetUserByEmail(ctx, email)
 	if err == nil && existingUser != nil {
 		log.Printf("User %s already exists (ID: %s)", email, existingUser.ID.Hex())
 		return
@@ -62,25 +74,29 @@ func main() {
 		log.Fatalf("Failed to hash password: %v", err)
 	}
 
-	// Create user
+	// Create user; the operator vouches for the address, so it's already verified
+	verifiedAt := time.Now().UTC()
 	user := &models.User{
-		Name:     name,
-		Email:    email,
-		Password: string(hashedPassword),
-		Role:     "student",
+		Name:            name,
+		Email:           email,
+		Password:        string(hashedPassword),
+		Role:            role,
+		EmailVerified:   true,
+		EmailVerifiedAt: &verifiedAt,
 	}
 
-	if err := repo.CreateUser(user); err != nil {
+	if err := repo.CreateUser(ctx, user); err != nil {
 		log.Fatalf("Failed to create user: %v", err)
 	}
 
 	log.Printf("✅ User created successfully!")
 	log.Printf("   Email: %s", user.Email)
 	log.Printf("   Name: %s", user.Name)
+	log.Printf("   Role: %s", user.Role)
 	log.Printf("   ID: %s", user.ID.Hex())
 
 	// Initialize progress
-	if err := repo.InitializeUserProgress(user.ID.Hex()); err != nil {
+	if err := repo.InitializeUserProgress(ctx, user.ID.Hex()); err != nil {
 		log.Printf("Warning: Failed to initialize progress: %v", err)
 	} else {
 		log.Println("✅ Progress initialized for all courses")
@@ -81,14 +81,14 @@ func migrateUserAndProgress(sourceRepo, targetRepo repository.Repository, email
 
 	// 1. Get user from source
 	log.Printf("Fetching user: %s from source database...", email)
-	sourceUser, err := sourceRepo.GetUserByEmail(email)
+	sourceUser, err := sourceRepo.GetUserByEmail(ctx, email)
 	if err != nil {
 		return fmt.Errorf("failed to get user from source: %v", err)
 	}
 	log.Printf("Found user: %s (ID: %s)", sourceUser.Email, sourceUser.ID.Hex())
 
 	// 2. Check if user already exists in target
-	targetUser, err := targetRepo.GetUserByEmail(email)
+	targetUser, err := targetRepo.GetUserByEmail(ctx, email)
 	var targetUserID primitive.ObjectID
 	if err == nil && targetUser != nil {
 		log.Printf("User %s already exists in target database (ID: %s)", email, targetUser.ID.Hex())
@@ -100,12 +100,12 @@ func migrateUserAndProgress(sourceRepo, targetRepo repository.Repository, email
 		newUser := *sourceUser
 		newUser.ID = primitive.NilObjectID // Let MongoDB generate new ID
 		
-		if err := targetRepo.CreateUser(&newUser); err != nil {
+		if err := targetRepo.CreateUser(ctx, &newUser); err != nil {
 			return fmt.Errorf("failed to create user in target: %v", err)
 		}
 		
 		// Get the newly created user to get its ID
-		createdUser, err := targetRepo.GetUserByEmail(email)
+		createdUser, err := targetRepo.GetUserByEmail(ctx, email)
 		if err != nil {
 			return fmt.Errorf("failed to get created user: %v", err)
 		}
@@ -115,27 +115,27 @@ func migrateUserAndProgress(sourceRepo, targetRepo repository.Repository, email
 
 	// 4. Get progress from source (using source user ID)
 	log.Println("Fetching progress from source database...")
-	sourceProgress, err := sourceRepo.GetUserProgress(sourceUser.ID.Hex())
+	sourceProgress, err := sourceRepo.GetUserProgress(ctx, sourceUser.ID.Hex())
 	if err != nil {
 		return fmt.Errorf("failed to get progress from source: %v", err)
 	}
 	log.Printf("Found %d progress records in source", len(sourceProgress))
 
 	// 5. Get all courses from both databases to map course IDs
-	sourceCourses, err := sourceRepo.GetAllCourses()
+	sourceCourses, err := sourceRepo.GetAllCourses(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get source courses: %v", err)
 	}
-	targetCourses, err := targetRepo.GetAllCourses()
+	targetCourses, err := targetRepo.GetAllCourses(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get target courses: %v", err)
 	}
 
-	// Create course ID mapping (source course ID -> target course ID) by title
+	// Create course ID mapping (source course ID -> target course ID) by slug, falling back to title
 	courseIDMap := make(map[primitive.ObjectID]primitive.ObjectID)
 	for _, sourceCourse := range sourceCourses {
 		for _, targetCourse := range targetCourses {
-			if sourceCourse.Title == targetCourse.Title {
+			if sourceCourse.SameAs(&targetCourse) {
 				courseIDMap[sourceCourse.ID] = targetCourse.ID
 				log.Printf("Mapped course: %s -> %s", sourceCourse.ID.Hex(), targetCourse.ID.Hex())
 				break
@@ -184,4 +184,3 @@ func migrateUserAndProgress(sourceRepo, targetRepo repository.Repository, email
 
 	return nil
 }
-
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/pathway/backend/oauth"
+)
+
+// mock-idp runs the mock OpenID Connect provider for trying sign-in locally.
+// Start the server with OIDC_ISSUER=http://localhost:9000 (or the address
+// given by MOCK_IDP_ADDR), OIDC_CLIENT_ID=pathway and OIDC_CLIENT_SECRET=pathway;
+// the sign-in page accepts any email.
+func main() {
+	addr := os.Getenv("MOCK_IDP_ADDR")
+	if addr == "" {
+		addr = "localhost:9000"
+	}
+
+	clientID := os.Getenv("OIDC_CLIENT_ID")
+	if clientID == "" {
+		clientID = "pathway"
+	}
+	clientSecret := os.Getenv("OIDC_CLIENT_SECRET")
+	if clientSecret == "" {
+		clientSecret = "pathway"
+	}
+
+	idp, err := oauth.NewMockIdP(clientID, clientSecret)
+	if err != nil {
+		log.Fatalf("Failed to create mock identity provider: %v", err)
+	}
+
+	log.Printf("Mock identity provider at http://%s for client %q", addr, clientID)
+	if err := http.ListenAndServe(addr, idp); err != nil {
+		log.Fatalf("Failed to run mock identity provider: %v", err)
+	}
+}
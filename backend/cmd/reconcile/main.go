@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/pathway/backend/models"
+	"github.com/pathway/backend/repository"
+)
+
+// Reconciles learners' progress with the current course content: completed
+// module IDs that no longer exist are pruned and course completion is
+// recomputed. Each learner whose progress changed is listed.
+func main() {
+	slug := flag.String("course", "", "only reconcile the course with this slug")
+	flag.Parse()
+
+	// Load environment variables
+	_ = godotenv.Load()
+
+	mongoURI := os.Getenv("MONGO_URI")
+	if mongoURI == "" {
+		mongoURI = "mongodb://localhost:27017"
+	}
+
+	dbName := os.Getenv("DB_NAME")
+	if dbName == "" {
+		dbName = "pathway"
+	}
+
+	repo, err := repository.NewMongoRepository(mongoURI, dbName)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	var courses []models.Course
+	if *slug != "" {
+		course, err := repo.GetCourseBySlug(ctx, *slug)
+		if err != nil {
+			log.Fatalf("Failed to find course %s: %v", *slug, err)
+		}
+		courses = append(courses, *course)
+	} else if courses, err = repo.GetAllCourses(ctx); err != nil {
+		log.Fatalf("Failed to fetch courses: %v", err)
+	}
+
+	total := 0
+	for _, course := range courses {
+		changes, err := repo.ReconcileCourseProgress(ctx, course.ID.Hex())
+		if err != nil {
+			log.Fatalf("Failed to reconcile course %s: %v", course.Slug, err)
+		}
+		for _, change := range changes {
+			line := "user " + change.UserID.Hex() + " in " + course.Slug
+			if len(change.RemovedModules) > 0 {
+				line += ": removed " + strings.Join(change.RemovedModules, ", ")
+			}
+			if change.WasCompleted != change.IsCompleted {
+				if change.IsCompleted {
+					line += ": now completed"
+				} else {
+					line += ": no longer completed"
+				}
+			}
+			log.Println(line)
+		}
+		total += len(changes)
+	}
+
+	log.Printf("✅ Reconciled %d courses; %d learners' progress changed", len(courses), total)
+}
@@ -1,18 +1,35 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"os"
 
 	"github.com/joho/godotenv"
+	"github.com/pathway/backend/content"
 	"github.com/pathway/backend/repository"
 	"github.com/pathway/backend/seed"
 )
 
 func main() {
+	dir := flag.String("dir", "", "seed from a content directory of course.yaml manifests and Markdown modules instead of the built-in courses")
+	flag.Parse()
+
 	// Load environment variables
 	_ = godotenv.Load()
 
+	// Load content before connecting so authoring mistakes are reported quickly
+	courses := seed.Courses()
+	if *dir != "" {
+		loaded, err := content.LoadDir(*dir)
+		if err != nil {
+			log.Fatalf("Failed to load content from %s: %v", *dir, err)
+		}
+		courses = loaded
+		log.Printf("Loaded %d courses from %s", len(courses), *dir)
+	}
+
 	mongoURI := os.Getenv("MONGO_URI")
 	if mongoURI == "" {
 		mongoURI = "mongodb://localhost:27017"
@@ -31,9 +48,13 @@ func main() {
 	defer repo.Close()
 
 	log.Println("Starting database seed...")
-	if err := seed.SeedCourses(repo); err != nil {
+	changes, err := seed.UpsertCourses(context.Background(), repo, courses)
+	if err != nil {
 		log.Fatalf("Failed to seed courses: %v", err)
 	}
+	if len(changes) > 0 {
+		log.Printf("Reconciled progress of %d learners with the new content", len(changes))
+	}
 
 	log.Println("✅ Database seeded successfully!")
 }
@@ -48,8 +48,11 @@ func main() {
 	}
 	defer repo.Close()
 
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
 	// Get user
-	user, err := repo.GetUserByEmail(email)
+	user, err := repo.GetUserByEmail(ctx, email)
 	if err != nil {
 		log.Fatalf("Failed to get user: %v", err)
 	}
@@ -65,9 +68,6 @@ func main() {
 	}
 
 	// Update password in database
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
 	objectID, err := primitive.ObjectIDFromHex(user.ID.Hex())
 	if err != nil {
 		log.Fatalf("Failed to parse user ID: %v", err)
@@ -92,6 +92,11 @@ func main() {
 		log.Fatal("User not found for update")
 	}
 
+	// Sign out existing sessions; access tokens lapse within ACCESS_TOKEN_TTL
+	if err := repo.RevokeUserRefreshTokens(ctx, user.ID.Hex()); err != nil {
+		log.Printf("Warning: Failed to revoke refresh tokens: %v", err)
+	}
+
 	log.Println("✅ Password updated successfully!")
 	log.Printf("   Email: %s", email)
 	log.Printf("   New Password: %s", password)
@@ -0,0 +1,27 @@
+package content
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pathway/backend/models"
+)
+
+// LoadBadges reads badge definitions from a YAML (or JSON) file holding a list
+// of badges, each with an id, title, description, criterion and the count or
+// course slug the criterion needs. The badges are validated.
+func LoadBadges(path string) ([]models.Badge, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var badges []models.Badge
+	if err := decodeYAML(data, &badges); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := models.ValidateBadges(badges); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return badges, nil
+}
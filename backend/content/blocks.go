@@ -0,0 +1,202 @@
+package content
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pathway/backend/models"
+)
+
+// fencePattern matches the opening line of a fenced block: the fence and its info string
+var fencePattern = regexp.MustCompile("^(`{3,}|~{3,})[ \t]*(.*)$")
+
+// directives maps a fence info word to the function that builds its block.
+// args is the rest of the info string; body is the text inside the fence.
+var directives = map[string]func(args, body string) (models.ContentBlock, error){
+	"callout":  calloutDirective,
+	"code":     codeDirective,
+	"exercise": exerciseDirective,
+	"image":    imageDirective,
+	"quiz":     quizDirective,
+	"video":    videoDirective,
+}
+
+// fence is an open fenced block
+type fence struct {
+	marker    string // The opening backticks or tildes
+	directive string // Empty for ordinary Markdown code fences
+	args      string
+	line      int
+	body      []string
+}
+
+// closedBy reports whether line closes the fence: the same character, at least
+// as many of them, and nothing else
+func (f *fence) closedBy(line string) bool {
+	trimmed := strings.TrimRight(line, " \t")
+	return len(trimmed) >= len(f.marker) &&
+		strings.Trim(trimmed, f.marker[:1]) == "" &&
+		trimmed[0] == f.marker[0]
+}
+
+// parseBlocks splits a module body into content blocks. Markdown between
+// directives becomes text blocks; fenced blocks whose info string starts with a
+// directive name become blocks of that type:
+//
+//	```callout tip
+//	Commit early, commit often.
+//	```
+//
+//	```code go
+//	fmt.Println("hello")
+//	```
+//
+//	```exercise
+//	prompt: Create a branch called feature
+//	solution: git switch -c feature
+//	hints:
+//	  - Look at git switch
+//	```
+//
+//	```image                     ```video
+//	url: /images/branches.png    url: https://example.com/intro.mp4
+//	alt: Two branches            title: Introduction
+//	caption: Branching           ```
+//	```
+//
+//	```quiz
+//	id: branches-check
+//	passing_score: 50
+//	questions:
+//	  - id: create
+//	    kind: single_choice
+//	    prompt: Which command creates a branch?
+//	    options: [git branch, git commit]
+//	    correct: [0]
+//	  - id: default
+//	    kind: short_answer
+//	    prompt: What is the default branch usually called?
+//	    accepted_answers: [main, master]
+//	```
+//
+// Ordinary code fences (```go) stay part of the surrounding text block, and
+// directive lines inside them are literal text. offset is added to line numbers
+// in errors.
+func parseBlocks(body string, offset int) ([]models.ContentBlock, error) {
+	blocks := []models.ContentBlock{}
+	var text []string
+	var open *fence
+
+	flushText := func() {
+		markdown := strings.Trim(strings.Join(text, "\n"), "\n")
+		if strings.TrimSpace(markdown) != "" {
+			blocks = append(blocks, models.NewContentBlock(&models.TextBlock{Markdown: markdown}))
+		}
+		text = nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	for i, line := range lines {
+		lineNo := offset + i + 1
+
+		if open != nil {
+			if !open.closedBy(line) {
+				if open.directive == "" {
+					text = append(text, line)
+				} else {
+					open.body = append(open.body, line)
+				}
+				continue
+			}
+
+			if open.directive == "" {
+				text = append(text, line)
+			} else {
+				block, err := directives[open.directive](open.args, strings.Join(open.body, "\n"))
+				if err == nil {
+					err = models.ValidateContentBlock(block)
+				}
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", open.line, err)
+				}
+				blocks = append(blocks, block)
+			}
+			open = nil
+			continue
+		}
+
+		match := fencePattern.FindStringSubmatch(line)
+		if match == nil {
+			text = append(text, line)
+			continue
+		}
+
+		info := strings.Fields(match[2])
+		open = &fence{marker: match[1], line: lineNo}
+		if len(info) > 0 {
+			if _, ok := directives[info[0]]; ok {
+				open.directive = info[0]
+				open.args = strings.Join(info[1:], " ")
+			}
+		}
+		if open.directive == "" {
+			text = append(text, line)
+		} else {
+			flushText()
+		}
+	}
+
+	if open != nil && open.directive != "" {
+		return nil, fmt.Errorf("line %d: unclosed %s block", open.line, open.directive)
+	}
+	flushText()
+	return blocks, nil
+}
+
+// calloutDirective builds a callout; args is the variant, "info" by default
+func calloutDirective(args, body string) (models.ContentBlock, error) {
+	variant := args
+	if variant == "" {
+		variant = models.CalloutInfo
+	}
+	return models.NewContentBlock(&models.CalloutBlock{
+		Variant: variant,
+		Text:    strings.TrimSpace(body),
+	}), nil
+}
+
+// codeDirective builds a code block; args is the language
+func codeDirective(args, body string) (models.ContentBlock, error) {
+	if args == "" {
+		return models.ContentBlock{}, fmt.Errorf("code block: language is required, e.g. ```code go")
+	}
+	return models.NewContentBlock(&models.CodeBlock{Language: args, Code: body}), nil
+}
+
+// The remaining directives are YAML documents decoded straight into the block
+// types. yaml.v3 matches keys to yaml tags or else the lowercased field names,
+// which are the same as the blocks' json names.
+
+func exerciseDirective(_, body string) (models.ContentBlock, error) {
+	return yamlDirective(&models.ExerciseBlock{}, body)
+}
+
+func imageDirective(_, body string) (models.ContentBlock, error) {
+	return yamlDirective(&models.ImageBlock{}, body)
+}
+
+func quizDirective(_, body string) (models.ContentBlock, error) {
+	return yamlDirective(&models.QuizBlock{}, body)
+}
+
+func videoDirective(_, body string) (models.ContentBlock, error) {
+	return yamlDirective(&models.VideoBlock{}, body)
+}
+
+func yamlDirective(data models.BlockData, body string) (models.ContentBlock, error) {
+	if err := decodeYAML([]byte(body), data); err != nil {
+		return models.ContentBlock{}, fmt.Errorf("%s block: %w", data.BlockType(), err)
+	}
+	return models.NewContentBlock(data), nil
+}
@@ -0,0 +1,218 @@
+// Package content loads courses from a directory of YAML manifests and Markdown
+// lessons, so curriculum can be edited without touching Go code.
+//
+// Each course is a subdirectory of the content root:
+//
+//	content/
+//	  git/
+//	    course.yaml        # slug, title, description, module order and prerequisites
+//	    01-intro.md        # one Markdown file per module
+//	    02-branches.md
+//
+// A module file starts with YAML front matter (id, title, video_url,
+// require_passing_quiz, prerequisites) followed by its Markdown body, in which fenced blocks
+// named callout, code, exercise, image, quiz or video become content blocks of
+// that type.
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pathway/backend/models"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile is the name of the file that marks a directory as a course
+const ManifestFile = "course.yaml"
+
+// Manifest is the contents of a course.yaml file
+type Manifest struct {
+	Slug          string   `yaml:"slug"` // Defaults to the directory name
+	Title         string   `yaml:"title"`
+	Description   string   `yaml:"description"`
+	Modules       []string `yaml:"modules"`       // Module files in order; defaults to every .md file sorted by name
+	Prerequisites []string `yaml:"prerequisites"` // Slugs of courses to complete first
+	Sequential    bool     `yaml:"sequential"`    // Each module requires the one before it
+}
+
+// FrontMatter is the YAML header of a module file
+type FrontMatter struct {
+	ID                 string   `yaml:"id"`
+	Title              string   `yaml:"title"`
+	VideoURL           string   `yaml:"video_url"`
+	RequirePassingQuiz bool     `yaml:"require_passing_quiz"`
+	Prerequisites      []string `yaml:"prerequisites"` // IDs of modules in the same course
+}
+
+// LoadDir loads every course under dir
+func LoadDir(dir string) ([]models.Course, error) {
+	return Load(os.DirFS(dir))
+}
+
+// Load loads every course in fsys, one per top-level directory containing a
+// course.yaml, ordered by directory name. Each course is validated.
+func Load(fsys fs.FS) ([]models.Course, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var courses []models.Course
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := fs.Stat(fsys, path.Join(entry.Name(), ManifestFile)); err != nil {
+			continue
+		}
+
+		course, err := LoadCourse(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		courses = append(courses, *course)
+	}
+
+	if len(courses) == 0 {
+		return nil, fmt.Errorf("no course directories with a %s found", ManifestFile)
+	}
+	return courses, nil
+}
+
+// LoadCourse loads the course in directory dir of fsys
+func LoadCourse(fsys fs.FS, dir string) (*models.Course, error) {
+	manifestPath := path.Join(dir, ManifestFile)
+	data, err := fs.ReadFile(fsys, manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := decodeYAML(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%s: %w", manifestPath, err)
+	}
+	if manifest.Slug == "" {
+		manifest.Slug = path.Base(dir)
+	}
+
+	files := manifest.Modules
+	if len(files) == 0 {
+		files, err = markdownFiles(fsys, dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	course := &models.Course{
+		Slug:          manifest.Slug,
+		Title:         manifest.Title,
+		Description:   manifest.Description,
+		Modules:       make([]models.Module, 0, len(files)),
+		Prerequisites: manifest.Prerequisites,
+		Sequential:    manifest.Sequential,
+	}
+	for _, file := range files {
+		modulePath := path.Join(dir, file)
+		data, err := fs.ReadFile(fsys, modulePath)
+		if err != nil {
+			return nil, err
+		}
+		module, err := ParseModule(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", modulePath, err)
+		}
+		course.Modules = append(course.Modules, *module)
+	}
+
+	if err := models.ValidateCourse(course); err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	return course, nil
+}
+
+// ParseModule parses a module file: YAML front matter followed by a Markdown body
+func ParseModule(data []byte) (*models.Module, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	header, body, err := splitFrontMatter(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var front FrontMatter
+	if err := decodeYAML([]byte(header), &front); err != nil {
+		return nil, fmt.Errorf("front matter: %w", err)
+	}
+	if front.ID == "" {
+		return nil, fmt.Errorf("front matter: id is required")
+	}
+
+	// Body line numbers are reported relative to the whole file
+	offset := strings.Count(header, "\n") + 2
+	blocks, err := parseBlocks(body, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Module{
+		ID:                 front.ID,
+		Title:              front.Title,
+		VideoURL:           front.VideoURL,
+		RequirePassingQuiz: front.RequirePassingQuiz,
+		Prerequisites:      front.Prerequisites,
+		Content:            blocks,
+	}, nil
+}
+
+// splitFrontMatter separates the YAML between the leading "---" lines from the body
+func splitFrontMatter(text string) (header, body string, err error) {
+	if !strings.HasPrefix(text, "---\n") {
+		return "", "", fmt.Errorf("missing front matter: file must start with a --- line")
+	}
+
+	rest := text[len("---\n"):]
+	if strings.HasPrefix(rest, "---\n") {
+		return "", rest[len("---\n"):], nil
+	}
+	end := strings.Index(rest, "\n---\n")
+	if end < 0 {
+		if strings.HasSuffix(rest, "\n---") {
+			return rest[:len(rest)-len("\n---")], "", nil
+		}
+		return "", "", fmt.Errorf("unterminated front matter: missing closing --- line")
+	}
+	return rest[:end+1], rest[end+len("\n---\n"):], nil
+}
+
+// markdownFiles lists the .md files in dir, sorted by name
+func markdownFiles(fsys fs.FS, dir string) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// decodeYAML decodes data into v, rejecting unknown fields so typos are caught
+func decodeYAML(data []byte, v interface{}) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	return decoder.Decode(v)
+}
@@ -0,0 +1,178 @@
+package content
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pathway/backend/models"
+)
+
+func TestLoadDir(t *testing.T) {
+	courses, err := LoadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(courses) != 2 {
+		t.Fatalf("expected 2 courses (drafts has no manifest), got %d", len(courses))
+	}
+
+	git := courses[0]
+	if git.Slug != "git" || git.Title != "Git Fundamentals" {
+		t.Fatalf("slug should default to the directory name: %+v", git)
+	}
+	if len(git.Modules) != 2 || git.Modules[0].ID != "git-2" || git.Modules[1].ID != "git-1" {
+		t.Fatalf("modules should follow the manifest order: %+v", git.Modules)
+	}
+	if courses[1].Slug != "http-basics" || len(courses[1].Modules) != 1 {
+		t.Fatalf("modules should default to the .md files: %+v", courses[1])
+	}
+
+	intro := git.Modules[1]
+	if intro.Title != "What is Git?" || intro.VideoURL != "https://example.com/git-intro.mp4" {
+		t.Fatalf("front matter not applied: %+v", intro)
+	}
+
+	want := []models.ContentBlock{
+		models.NewContentBlock(&models.TextBlock{
+			Markdown: "# What is Git?\n\nGit is a version control system. You’ll use it every day.\n\n```bash\ngit init\n```",
+		}),
+		models.NewContentBlock(&models.CalloutBlock{Variant: "tip", Text: "Commit early, commit often."}),
+		models.NewContentBlock(&models.ExerciseBlock{
+			ID:                  "init",
+			Prompt:              "Initialise a repository",
+			Solution:            "git init\n",
+			Hints:               []string{"Look at `git help`", "It's one command"},
+			RevealPolicy:        models.RevealAfterAttempts,
+			RevealAfterAttempts: 2,
+		}),
+	}
+	if !reflect.DeepEqual(intro.Content, want) {
+		t.Fatalf("unexpected blocks:\n got %#v\nwant %#v", intro.Content, want)
+	}
+}
+
+func TestParseModuleDirectives(t *testing.T) {
+	courses, err := LoadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := courses[0].Modules[0].Content
+
+	types := make([]string, len(blocks))
+	for i, block := range blocks {
+		types[i] = block.Type
+	}
+	if got := strings.Join(types, ","); got != "code,image,text,video" {
+		t.Fatalf("block types = %s", got)
+	}
+	if code := blocks[0].Data.(*models.CodeBlock); code.Language != "go" || code.Code != `fmt.Println("branches")` {
+		t.Fatalf("unexpected code block: %+v", blocks[0].Data)
+	}
+	if !strings.Contains(blocks[2].Data.(*models.TextBlock).Markdown, "```callout warning") {
+		t.Fatalf("directive inside an ordinary fence should stay literal: %+v", blocks[2].Data)
+	}
+}
+
+func TestParseModuleQuiz(t *testing.T) {
+	module, err := ParseModule([]byte("---\nid: a\ntitle: A\nrequire_passing_quiz: true\n---\n" +
+		"```quiz\nid: check\nquestions:\n  - id: q1\n    kind: short_answer\n    prompt: Name the tool\n    accepted_answers: [git]\n```\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	quiz, ok := module.Quiz("check")
+	if !module.RequirePassingQuiz || !ok || quiz.Questions[0].AcceptedAnswers[0] != "git" {
+		t.Fatalf("unexpected module: %+v", module)
+	}
+}
+
+func TestParseModuleErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		file string
+		want string
+	}{
+		{"no front matter", "# Title\n", "missing front matter"},
+		{"unterminated front matter", "---\nid: a\n", "unterminated front matter"},
+		{"missing id", "---\ntitle: A\n---\nText\n", "id is required"},
+		{"unknown field", "---\nid: a\ntitel: A\n---\n", "titel"},
+		{"unclosed directive", "---\nid: a\n---\ntext\n```callout\nhi\n", "line 5: unclosed callout block"},
+		{"invalid directive", "---\nid: a\n---\n```video\ntitle: No URL\n```\n", "line 4: video block: url is required"},
+		{"code without language", "---\nid: a\n---\n```code\nx\n```\n", "language is required"},
+		{"invalid callout variant", "---\nid: a\n---\n```callout note\nx\n```\n", "invalid callout variant"},
+		{"quiz without questions", "---\nid: a\n---\n```quiz\nid: q\n```\n", "line 4: quiz block: at least one question"},
+	}
+	for _, tc := range cases {
+		_, err := ParseModule([]byte(tc.file))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestParseModuleAcceptsCRLF(t *testing.T) {
+	module, err := ParseModule([]byte("---\r\nid: a\r\ntitle: A\r\n---\r\nHello\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if module.ID != "a" || len(module.Content) != 1 || module.Content[0].Data.(*models.TextBlock).Markdown != "Hello" {
+		t.Fatalf("unexpected module: %+v", module)
+	}
+}
+
+func TestLoadValidatesCourses(t *testing.T) {
+	fsys := fstest.MapFS{
+		"bad/course.yaml": {Data: []byte("title: Bad\n")},
+		"bad/1.md":        {Data: []byte("---\nid: Not_A_Slug\ntitle: A\n---\n")},
+	}
+	if _, err := Load(fsys); err == nil || !strings.Contains(err.Error(), "invalid module id") {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+
+	if _, err := Load(fstest.MapFS{"notes.md": {Data: []byte("hi")}}); err == nil {
+		t.Fatal("expected error when no courses are found")
+	}
+}
+
+func TestLoadPrerequisites(t *testing.T) {
+	fsys := fstest.MapFS{
+		"go/course.yaml": {Data: []byte("title: Go\nsequential: true\nprerequisites: [git]\n")},
+		"go/1.md":        {Data: []byte("---\nid: go-1\ntitle: One\n---\n")},
+		"go/2.md":        {Data: []byte("---\nid: go-2\ntitle: Two\nprerequisites: [go-1]\n---\n")},
+	}
+	courses, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	course := courses[0]
+	if !course.Sequential || len(course.Prerequisites) != 1 || course.Prerequisites[0] != "git" {
+		t.Fatalf("manifest prerequisites not applied: %+v", course)
+	}
+	if got := course.Modules[1].Prerequisites; len(got) != 1 || got[0] != "go-1" {
+		t.Fatalf("front matter prerequisites not applied: %+v", course.Modules[1])
+	}
+
+	fsys["go/1.md"] = &fstest.MapFile{Data: []byte("---\nid: go-1\ntitle: One\nprerequisites: [go-2]\n---\n")}
+	if _, err := Load(fsys); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
+
+func TestLoadBadges(t *testing.T) {
+	badges, err := LoadBadges("testdata/badges.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.Badge{
+		{ID: "first-course", Title: "First Course Done", Description: "Complete your first course", Criterion: models.BadgeCoursesCompleted, Count: 1},
+		{ID: "http-expert", Title: "HTTP Expert", Description: "Complete every module of the HTTP course", Criterion: models.BadgeCourseCompleted, Course: "http-basics"},
+	}
+	if !reflect.DeepEqual(badges, want) {
+		t.Fatalf("expected %+v, got %+v", want, badges)
+	}
+
+	if _, err := LoadBadges("testdata/missing.yaml"); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
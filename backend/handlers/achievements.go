@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetAchievements returns the learner's XP, streaks and badges, awarding any
+// badges they've earned since they last checked
+func (h *Handler) GetAchievements(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	achievements, _, err := h.updateAchievements(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch achievements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, achievements)
+}
+
+// updateAchievements computes the learner's achievements from their progress
+// and activity, and awards the badges they've newly earned, which it also
+// returns
+func (h *Handler) updateAchievements(ctx context.Context, userID primitive.ObjectID) (*models.Achievements, []models.UserBadge, error) {
+	courses, err := h.Repo.GetAllCourses(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	progress, err := h.Repo.GetUserProgress(ctx, userID.Hex())
+	if err != nil {
+		return nil, nil, err
+	}
+	activity, err := h.Repo.GetActivity(ctx, models.ActivityFilter{UserID: userID})
+	if err != nil {
+		return nil, nil, err
+	}
+	awarded, err := h.Repo.GetUserBadges(ctx, userID.Hex())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	record := models.LearningRecord{Courses: courses, Progress: progress, Activity: activity}
+	achievements := record.Achievements(h.Badges, awarded, time.Now().UTC())
+
+	newBadges := []models.UserBadge{}
+	for _, status := range achievements.Badges {
+		if !status.Earned || hasBadge(awarded, status.ID) {
+			continue
+		}
+		badge := models.UserBadge{UserID: userID, BadgeID: status.ID, EarnedAt: *status.EarnedAt}
+		isNew, err := h.Repo.AwardBadge(ctx, &badge)
+		if err != nil {
+			return nil, nil, err
+		}
+		if isNew {
+			newBadges = append(newBadges, badge)
+		}
+	}
+
+	return &achievements, newBadges, nil
+}
+
+func hasBadge(awarded []models.UserBadge, badgeID string) bool {
+	for _, badge := range awarded {
+		if badge.BadgeID == badgeID {
+			return true
+		}
+	}
+	return false
+}
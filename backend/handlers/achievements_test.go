@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pathway/backend/models"
+)
+
+func TestAchievementsAwardBadges(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestServer(t)
+	student := registerUser(t, r, "learner@example.com")
+	git, _ := repo.GetCourseBySlug(ctx, "git")
+
+	completeModules(t, r, student.Token, git.ID.Hex(), "git-1")
+	w := doJSON(r, http.MethodPost, "/api/user/progress/complete", student.Token, CompleteModuleRequest{CourseID: git.ID.Hex(), ModuleID: "git-2"})
+	var completed struct {
+		NewBadges []models.UserBadge `json:"new_badges"`
+	}
+	decode(t, w, &completed)
+	if len(completed.NewBadges) != 2 || completed.NewBadges[0].BadgeID != "first-course" || completed.NewBadges[1].BadgeID != "git-master" {
+		t.Fatalf("expected first-course and git-master to be awarded: %+v", completed.NewBadges)
+	}
+
+	var achievements models.Achievements
+	decode(t, doJSON(r, http.MethodGet, "/api/user/achievements", student.Token, nil), &achievements)
+	if achievements.XP != 2*models.XPPerModule+models.XPPerCourse || achievements.CoursesCompleted != 1 {
+		t.Fatalf("unexpected XP: %+v", achievements)
+	}
+	if achievements.CurrentStreak != 1 || achievements.LongestStreak != 1 {
+		t.Fatalf("unexpected streaks: %+v", achievements)
+	}
+	earned := map[string]bool{}
+	for _, badge := range achievements.Badges {
+		earned[badge.ID] = badge.Earned
+	}
+	if !earned["first-course"] || !earned["git-master"] || earned["streak-7"] || len(earned) != 3 {
+		t.Fatalf("unexpected badges: %+v", achievements.Badges)
+	}
+
+	// Resetting progress takes away XP but not badges
+	if w := doJSON(r, http.MethodDelete, "/api/user/progress/courses/git", student.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("reset: expected 200, got %d", w.Code)
+	}
+	decode(t, doJSON(r, http.MethodGet, "/api/user/achievements", student.Token, nil), &achievements)
+	if achievements.XP != 0 || !achievements.Badges[0].Earned || !achievements.Badges[2].Earned {
+		t.Fatalf("after reset: %+v", achievements)
+	}
+	badges, _ := repo.GetUserBadges(ctx, student.User.ID.Hex())
+	if len(badges) != 2 {
+		t.Fatalf("badges should be awarded once: %+v", badges)
+	}
+
+	if w := doJSON(r, http.MethodGet, "/api/user/achievements", "", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", w.Code)
+	}
+}
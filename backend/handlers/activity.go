@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/models"
+)
+
+const (
+	defaultActivityLimit = 100
+	maxActivityLimit     = 1000
+)
+
+// OpenModule records that the learner opened a module
+func (h *Handler) OpenModule(c *gin.Context) {
+	lm, ok := h.loadLearnerModule(c)
+	if !ok {
+		return
+	}
+
+	event := lm.activity(models.ActivityModuleOpened, "")
+	if err := h.Repo.RecordActivity(c.Request.Context(), &event); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record activity"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, event)
+}
+
+// GetActivity lists a learner's activity events, newest first. The since and
+// until query parameters (RFC 3339) bound the time range, type (repeatable)
+// selects event types and limit caps the number of events.
+func (h *Handler) GetActivity(c *gin.Context) {
+	learnerID, ok := h.requestLearner(c)
+	if !ok {
+		return
+	}
+
+	filter := models.ActivityFilter{
+		UserID: learnerID,
+		Types:  c.QueryArray("type"),
+		Limit:  defaultActivityLimit,
+	}
+	for name, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + ": use an RFC 3339 time"})
+			return
+		}
+		*target = t
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxActivityLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit: use 1 to " + strconv.Itoa(maxActivityLimit)})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	events, err := h.Repo.GetActivity(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch activity"})
+		return
+	}
+	if events == nil {
+		events = []models.ActivityEvent{}
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// recordActivity appends an event to the activity log. The action it
+// describes has already happened, so failures are only logged.
+func (h *Handler) recordActivity(c *gin.Context, event models.ActivityEvent) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+	if err := h.Repo.RecordActivity(c.Request.Context(), &event); err != nil {
+		log.Printf("Failed to record %s activity for user %s: %v", event.Type, event.UserID.Hex(), err)
+	}
+}
+
+// activity returns an event of the given type in the module, occurring now
+func (lm *learnerModule) activity(eventType string, itemID string) models.ActivityEvent {
+	return models.ActivityEvent{
+		UserID:     lm.userObjectID,
+		Type:       eventType,
+		CourseID:   lm.courseObjectID,
+		ModuleID:   lm.moduleID,
+		ItemID:     itemID,
+		OccurredAt: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pathway/backend/models"
+)
+
+func activityOf(t *testing.T, r http.Handler, token, path string) []models.ActivityEvent {
+	t.Helper()
+	w := doJSON(r, http.MethodGet, path, token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+	}
+	var events []models.ActivityEvent
+	decode(t, w, &events)
+	return events
+}
+
+func TestLearningActivityIsLogged(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestServer(t)
+	student := loginWithRole(t, r, repo, "learner@example.com", models.RoleStudent)
+	git, _ := repo.GetCourseBySlug(ctx, "git")
+
+	w := doJSON(r, http.MethodPost, "/api/user/courses/git/modules/git-1/open", student.Token, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("open: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(r, http.MethodPost, "/api/user/courses/git/modules/missing/open", student.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("open unknown module: expected 404, got %d", w.Code)
+	}
+	completeModules(t, r, student.Token, git.ID.Hex(), "git-1")
+
+	events := activityOf(t, r, student.Token, "/api/user/activity")
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Type)
+		if event.UserID != student.User.ID || event.OccurredAt.IsZero() {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	}
+	want := []string{models.ActivityModuleCompleted, models.ActivityModuleOpened, models.ActivityLogin}
+	if len(types) != len(want) {
+		t.Fatalf("expected %v, got %v", want, types)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, types)
+		}
+	}
+	if events[0].CourseID != git.ID || events[0].ModuleID != "git-1" {
+		t.Fatalf("unexpected completion event: %+v", events[0])
+	}
+
+	opened := activityOf(t, r, student.Token, "/api/user/activity?type=module_opened&type=module_completed&limit=1")
+	if len(opened) != 1 || opened[0].Type != models.ActivityModuleCompleted {
+		t.Fatalf("unexpected filtered events: %+v", opened)
+	}
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	if events := activityOf(t, r, student.Token, "/api/user/activity?since="+future); len(events) != 0 {
+		t.Fatalf("expected no events since %s, got %+v", future, events)
+	}
+	for _, query := range []string{"?since=yesterday", "?until=2024-01-01", "?limit=0", "?limit=5000"} {
+		if w := doJSON(r, http.MethodGet, "/api/user/activity"+query, student.Token, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+
+	p := progressOf(t, r, student.Token)[0]
+	if len(p.Modules) != 2 || p.Modules[0].CompletedAt == nil || p.Modules[1].CompletedAt != nil {
+		t.Fatalf("expected a completed_at for git-1 only: %+v", p.Modules)
+	}
+	if p.CompletedAt != nil {
+		t.Fatalf("incomplete course has completed_at: %+v", p)
+	}
+	completeModules(t, r, student.Token, git.ID.Hex(), "git-2")
+	if p := progressOf(t, r, student.Token)[0]; p.CompletedAt == nil || p.CompletedAt.Before(*p.Modules[0].CompletedAt) {
+		t.Fatalf("expected course completed_at: %+v", p)
+	}
+}
+
+func TestStaffViewLearnerActivity(t *testing.T) {
+	r, repo := newTestServer(t)
+	instructor := loginWithRole(t, r, repo, "instructor@example.com", models.RoleInstructor)
+	otherInstructor := loginWithRole(t, r, repo, "other-instructor@example.com", models.RoleInstructor)
+	admin := loginWithRole(t, r, repo, "admin@example.com", models.RoleAdmin)
+	student := loginWithRole(t, r, repo, "learner@example.com", models.RoleStudent)
+	other := registerUser(t, r, "other@example.com")
+	teachCohort(t, r, instructor.Token, []string{"git"}, "learner@example.com")
+
+	events := activityOf(t, r, instructor.Token, "/api/admin/users/"+student.User.ID.Hex()+"/activity")
+	if len(events) != 1 || events[0].Type != models.ActivityLogin || events[0].UserID != student.User.ID {
+		t.Fatalf("unexpected learner activity: %+v", events)
+	}
+
+	if w := doJSON(r, http.MethodGet, "/api/admin/users/"+student.User.ID.Hex()+"/activity", other.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("student viewing activity: expected 403, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodGet, "/api/admin/users/"+student.User.ID.Hex()+"/activity", otherInstructor.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("instructor outside the cohort: expected 403, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodGet, "/api/admin/users/"+other.User.ID.Hex()+"/activity", admin.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("admin viewing activity: expected 200, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodGet, "/api/admin/users/missing/activity", instructor.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown user: expected 404, got %d", w.Code)
+	}
+}
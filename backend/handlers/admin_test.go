@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pathway/backend/models"
+)
+
+func TestAdminUpdateUserRole(t *testing.T) {
+	r, repo := newTestServer(t)
+	admin := loginWithRole(t, r, repo, "admin@example.com", models.RoleAdmin)
+	student := registerUser(t, r, "learner@example.com")
+	path := "/api/admin/users/" + student.User.ID.Hex() + "/role"
+
+	w := doJSON(r, http.MethodPut, path, admin.Token, UpdateUserRoleRequest{Role: models.RoleInstructor})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var updated models.User
+	decode(t, w, &updated)
+	if updated.Role != models.RoleInstructor {
+		t.Fatalf("role = %q, want instructor", updated.Role)
+	}
+
+	if w := doJSON(r, http.MethodPut, path, admin.Token, UpdateUserRoleRequest{Role: "superuser"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid role: expected 400, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPut, "/api/admin/users/000000000000000000000000/role", admin.Token,
+		UpdateUserRoleRequest{Role: models.RoleStudent}); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown user: expected 404, got %d", w.Code)
+	}
+	selfPath := "/api/admin/users/" + admin.User.ID.Hex() + "/role"
+	if w := doJSON(r, http.MethodPut, selfPath, admin.Token, UpdateUserRoleRequest{Role: models.RoleStudent}); w.Code != http.StatusBadRequest {
+		t.Fatalf("self demotion: expected 400, got %d", w.Code)
+	}
+}
+
+func TestAdminRoutesRequireAdminRole(t *testing.T) {
+	r, repo := newTestServer(t)
+	instructor := loginWithRole(t, r, repo, "instructor@example.com", models.RoleInstructor)
+	path := "/api/admin/users/" + instructor.User.ID.Hex() + "/role"
+
+	if w := doJSON(r, http.MethodPut, path, "", UpdateUserRoleRequest{Role: models.RoleAdmin}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous: expected 401, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPut, path, instructor.Token, UpdateUserRoleRequest{Role: models.RoleAdmin}); w.Code != http.StatusForbidden {
+		t.Fatalf("instructor: expected 403, got %d", w.Code)
+	}
+}
@@ -1,13 +1,22 @@
 package handlers
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/pathway/backend/middleware"
 	"github.com/pathway/backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -22,11 +31,30 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest optionally names the refresh token to revoke alongside the access token.
+// AllSessions revokes every refresh token the user holds.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	AllSessions  bool   `json:"all_sessions"`
+}
+
 type AuthResponse struct {
-	Token string      `json:"token"`
-	User  models.User `json:"user"`
+	Token        string      `json:"token"` // Short-lived access token
+	RefreshToken string      `json:"refresh_token"`
+	ExpiresIn    int64       `json:"expires_in"` // Access token lifetime in seconds
+	User         models.User `json:"user"`
 }
 
+// Token lifetimes, overridable with ACCESS_TOKEN_TTL and REFRESH_TOKEN_TTL (Go durations)
+var (
+	accessTokenTTL  = durationFromEnv("ACCESS_TOKEN_TTL", 15*time.Minute)
+	refreshTokenTTL = durationFromEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+)
+
 // Register creates a new user account
 func (h *Handler) Register(c *gin.Context) {
 	var req RegisterRequest
@@ -36,7 +64,7 @@ func (h *Handler) Register(c *gin.Context) {
 	}
 
 	// Check if user already exists
-	existingUser, _ := h.Repo.GetUserByEmail(req.Email)
+	existingUser, _ := h.Repo.GetUserByEmail(c.Request.Context(), req.Email)
 	if existingUser != nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "User with this email already exists"})
 		return
@@ -54,31 +82,33 @@ func (h *Handler) Register(c *gin.Context) {
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: string(hashedPassword),
-		Role:     "student",
+		Role:     models.RoleStudent,
 	}
 
-	if err := h.Repo.CreateUser(user); err != nil {
+	if err := h.Repo.CreateUser(c.Request.Context(), user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
 
 	// Initialize progress for all courses
-	if err := h.Repo.InitializeUserProgress(user.ID.Hex()); err != nil {
+	if err := h.Repo.InitializeUserProgress(c.Request.Context(), user.ID.Hex()); err != nil {
 		// Log but don't fail registration
 		// Progress can be initialized on first dashboard load
 	}
 
-	// Generate JWT token
-	token, err := generateToken(user)
+	// Ask the user to confirm their address; they can request another link later
+	if err := h.sendEmailVerification(c.Request.Context(), user); err != nil {
+		log.Printf("Failed to start email verification for user %s: %v", user.ID.Hex(), err)
+	}
+
+	// Issue access and refresh tokens
+	resp, err := h.issueTokens(c.Request.Context(), user, "")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, AuthResponse{
-		Token: token,
-		User:  *user,
-	})
+	c.JSON(http.StatusCreated, resp)
 }
 
 // Login authenticates a user and returns a JWT token
@@ -89,30 +119,163 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
+	// Hold off clients that keep guessing
+	if h.Logins != nil {
+		wait, err := h.Logins.RetryAfter(c.Request.Context(), c.ClientIP(), req.Email)
+		if err != nil {
+			// Fail open: an unavailable store shouldn't stop everyone logging in
+			log.Printf("Failed to check login throttling: %v", err)
+		} else if wait > 0 {
+			retryAfter := int64((wait + time.Second - 1) / time.Second)
+			c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Too many failed login attempts, try again later",
+				"retry_after": retryAfter,
+			})
+			return
+		}
+	}
+
 	// Find user by email
-	user, err := h.Repo.GetUserByEmail(req.Email)
+	user, err := h.Repo.GetUserByEmail(c.Request.Context(), req.Email)
 	if err != nil || user == nil {
+		h.loginFailed(c, req.Email)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
 	// Compare password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		h.loginFailed(c, req.Email)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
+	if h.Logins != nil {
+		if err := h.Logins.Succeeded(c.Request.Context(), req.Email); err != nil {
+			log.Printf("Failed to clear failed logins: %v", err)
+		}
+	}
+
+	// Issue access and refresh tokens
+	resp, err := h.issueTokens(c.Request.Context(), user, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	h.recordActivity(c, models.ActivityEvent{UserID: user.ID, Type: models.ActivityLogin})
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// loginFailed counts a failed login towards throttling the account and client IP
+func (h *Handler) loginFailed(c *gin.Context, email string) {
+	if h.Logins == nil {
+		return
+	}
+	if err := h.Logins.Failed(c.Request.Context(), c.ClientIP(), email); err != nil {
+		log.Printf("Failed to record failed login: %v", err)
+	}
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh token.
+// Each refresh token works once; presenting one that was already used revokes every
+// token descended from the same login, since it means the token was stolen.
+func (h *Handler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tokenHash := hashToken(req.RefreshToken)
+
+	stored, err := h.Repo.GetRefreshTokenByHash(ctx, tokenHash)
+	if err != nil || stored == nil || time.Now().After(stored.ExpiresAt) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+
+	// Revoke the presented token; losing this race means it was already used
+	rotated, err := h.Repo.RevokeRefreshToken(ctx, tokenHash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+	if !rotated {
+		if err := h.Repo.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+			log.Printf("Failed to revoke refresh token family %s: %v", stored.FamilyID, err)
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+
+	user, err := h.Repo.GetUserByID(ctx, stored.UserID.Hex())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
 
-	// Generate JWT token
-	token, err := generateToken(user)
+	resp, err := h.issueTokens(ctx, user, stored.FamilyID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, AuthResponse{
-		Token: token,
-		User:  *user,
-	})
+	c.JSON(http.StatusOK, resp)
+}
+
+// Logout revokes the current access token and, if given, the session's refresh token
+func (h *Handler) Logout(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// The body is optional; without it only the access token is revoked
+	var req LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.Repo.RevokeAccessToken(ctx, &models.RevokedAccessToken{
+		JTI:       c.GetString("tokenID"),
+		UserID:    userObjectID,
+		ExpiresAt: c.GetTime("tokenExpiresAt"),
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	if req.AllSessions {
+		if err := h.Repo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+			return
+		}
+	} else if req.RefreshToken != "" {
+		// Only revoke the refresh token if it belongs to the caller
+		tokenHash := hashToken(req.RefreshToken)
+		stored, err := h.Repo.GetRefreshTokenByHash(ctx, tokenHash)
+		if err == nil && stored.UserID == userObjectID {
+			if _, err := h.Repo.RevokeRefreshToken(ctx, tokenHash); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+				return
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
 }
 
 // GetCurrentUser returns the current authenticated user
@@ -123,7 +286,7 @@ func (h *Handler) GetCurrentUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.Repo.GetUserByID(userID)
+	user, err := h.Repo.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
@@ -132,14 +295,57 @@ func (h *Handler) GetCurrentUser(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// issueTokens creates an access token and a refresh token for the user.
+// familyID continues an existing refresh token chain; empty starts a new one.
+func (h *Handler) issueTokens(ctx context.Context, user *models.User, familyID string) (AuthResponse, error) {
+	accessToken, err := generateToken(user)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	if familyID == "" {
+		if familyID, err = randomToken(); err != nil {
+			return AuthResponse{}, err
+		}
+	}
+
+	now := time.Now().UTC()
+	if err := h.Repo.CreateRefreshToken(ctx, &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		CreatedAt: now,
+		ExpiresAt: now.Add(refreshTokenTTL),
+	}); err != nil {
+		return AuthResponse{}, err
+	}
+
+	return AuthResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(accessTokenTTL / time.Second),
+		User:         *user,
+	}, nil
+}
+
 func generateToken(user *models.User) (string, error) {
+	jti, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
 	claims := &middleware.Claims{
 		UserID: user.ID.Hex(),
 		Email:  user.Email,
 		Name:   user.Name,
 		Role:   user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -148,3 +354,31 @@ func generateToken(user *models.User) (string, error) {
 	return token.SignedString(middleware.GetJWTSecret())
 }
 
+// randomToken returns 32 random bytes, base64url encoded
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 of a token; only hashes are stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// durationFromEnv parses a Go duration from an env var, falling back to def
+func durationFromEnv(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using %s", name, value, def)
+		return def
+	}
+	return d
+}
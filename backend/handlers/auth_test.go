@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pathway/backend/throttle"
+)
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	r, _ := newTestServer(t)
+	registered := registerUser(t, r, "learner@example.com")
+	if registered.RefreshToken == "" || registered.ExpiresIn <= 0 {
+		t.Fatalf("register should issue a refresh token: %+v", registered)
+	}
+
+	w := doJSON(r, http.MethodPost, "/api/auth/refresh", "", RefreshRequest{RefreshToken: registered.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var refreshed AuthResponse
+	decode(t, w, &refreshed)
+	if refreshed.RefreshToken == registered.RefreshToken || refreshed.Token == registered.Token {
+		t.Fatal("refresh should rotate both tokens")
+	}
+	if w := doJSON(r, http.MethodGet, "/api/user/me", refreshed.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("me with refreshed token: expected 200, got %d", w.Code)
+	}
+
+	// Replaying the used token is treated as theft and revokes the whole chain
+	w = doJSON(r, http.MethodPost, "/api/auth/refresh", "", RefreshRequest{RefreshToken: registered.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("reused refresh token: expected 401, got %d", w.Code)
+	}
+	w = doJSON(r, http.MethodPost, "/api/auth/refresh", "", RefreshRequest{RefreshToken: refreshed.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("descendant of reused token: expected 401, got %d", w.Code)
+	}
+}
+
+func TestRefreshRejectsUnknownToken(t *testing.T) {
+	r, _ := newTestServer(t)
+
+	w := doJSON(r, http.MethodPost, "/api/auth/refresh", "", RefreshRequest{RefreshToken: "nope"})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, "/api/auth/refresh", "", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("missing body: expected 400, got %d", w.Code)
+	}
+}
+
+func TestLogoutRevokesTokens(t *testing.T) {
+	r, _ := newTestServer(t)
+	session := registerUser(t, r, "learner@example.com")
+
+	w := doJSON(r, http.MethodPost, "/api/auth/logout", session.Token, LogoutRequest{RefreshToken: session.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("logout: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := doJSON(r, http.MethodGet, "/api/user/me", session.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked access token: expected 401, got %d", w.Code)
+	}
+	w = doJSON(r, http.MethodPost, "/api/auth/refresh", "", RefreshRequest{RefreshToken: session.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked refresh token: expected 401, got %d", w.Code)
+	}
+}
+
+func TestLogoutAllSessions(t *testing.T) {
+	r, _ := newTestServer(t)
+	first := registerUser(t, r, "learner@example.com")
+
+	w := doJSON(r, http.MethodPost, "/api/auth/login", "", LoginRequest{Email: "learner@example.com", Password: "password123"})
+	var second AuthResponse
+	decode(t, w, &second)
+
+	w = doJSON(r, http.MethodPost, "/api/auth/logout", second.Token, LogoutRequest{AllSessions: true})
+	if w.Code != http.StatusOK {
+		t.Fatalf("logout: expected 200, got %d", w.Code)
+	}
+
+	w = doJSON(r, http.MethodPost, "/api/auth/refresh", "", RefreshRequest{RefreshToken: first.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("other session's refresh token: expected 401, got %d", w.Code)
+	}
+	// Other sessions' access tokens stay valid until they expire
+	if w := doJSON(r, http.MethodGet, "/api/user/me", first.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("other session's access token: expected 200, got %d", w.Code)
+	}
+}
+
+func TestLogoutRequiresAuth(t *testing.T) {
+	r, _ := newTestServer(t)
+
+	if w := doJSON(r, http.MethodPost, "/api/auth/logout", "", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestLoginThrottling(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r, _ := newTestServerWith(t, func(h *Handler) {
+		h.Logins.Account = throttle.Policy{FreeAttempts: 1, BaseDelay: 30 * time.Second, MaxFailures: 3, Lockout: 10 * time.Minute, Window: time.Hour}
+		h.Logins.Now = func() time.Time { return now }
+	})
+	registerUser(t, r, "learner@example.com")
+	login := func(password string) *httptest.ResponseRecorder {
+		return doJSON(r, http.MethodPost, "/api/auth/login", "", LoginRequest{Email: "learner@example.com", Password: password})
+	}
+
+	if w := login("wrong"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("first failure: expected 401, got %d", w.Code)
+	}
+	if w := login("wrong"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("second failure: expected 401, got %d", w.Code)
+	}
+
+	// Backing off: even the right password has to wait
+	w := login("password123")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" {
+		t.Fatalf("during backoff: expected 429 with Retry-After 30, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	now = now.Add(30 * time.Second)
+	if w := login("wrong"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("after backoff: expected 401, got %d", w.Code)
+	}
+	w = login("password123")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "600" {
+		t.Fatalf("locked out: expected 429 with Retry-After 600, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Once the lockout ends, a successful login clears the account's failures
+	now = now.Add(10 * time.Minute)
+	if w := login("password123"); w.Code != http.StatusOK {
+		t.Fatalf("after lockout: expected 200, got %d", w.Code)
+	}
+	if w := login("wrong"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("failure after success: expected 401, got %d", w.Code)
+	}
+	if w := login("password123"); w.Code != http.StatusOK {
+		t.Fatalf("first failure after success should be free: expected 200, got %d", w.Code)
+	}
+
+	// Unknown accounts are throttled the same way, so lockouts don't reveal which exist
+	for i := 0; i < 3; i++ {
+		doJSON(r, http.MethodPost, "/api/auth/login", "", LoginRequest{Email: "nobody@example.com", Password: "wrong"})
+	}
+	if w := doJSON(r, http.MethodPost, "/api/auth/login", "", LoginRequest{Email: "nobody@example.com", Password: "wrong"}); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("unknown account: expected 429, got %d", w.Code)
+	}
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/certificate"
+	"github.com/pathway/backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CertificateResponse is a learner's view of one of their certificates
+type CertificateResponse struct {
+	models.Certificate
+	VerificationToken string `json:"verification_token"`
+	VerifyURL         string `json:"verify_url"`
+}
+
+// CertificateVerification is what the public verify endpoint reveals about a certificate
+type CertificateVerification struct {
+	Valid       bool      `json:"valid"`
+	ID          string    `json:"id"`
+	LearnerName string    `json:"learner_name"`
+	CourseTitle string    `json:"course_title"`
+	CompletedAt time.Time `json:"completed_at"`
+	IssuedAt    time.Time `json:"issued_at"`
+}
+
+// GetCertificates lists the learner's certificates, issuing any that are due
+// for courses completed before certificates were issued automatically
+func (h *Handler) GetCertificates(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if _, err := h.issueCertificates(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch certificates"})
+		return
+	}
+	certs, err := h.Repo.GetUserCertificates(c.Request.Context(), userID.Hex())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch certificates"})
+		return
+	}
+
+	responses := make([]CertificateResponse, 0, len(certs))
+	for i := range certs {
+		responses = append(responses, h.certificateResponse(&certs[i]))
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+// GetCertificate returns one of the learner's certificates
+func (h *Handler) GetCertificate(c *gin.Context) {
+	cert, ok := h.loadOwnCertificate(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, h.certificateResponse(cert))
+}
+
+// DownloadCertificate renders one of the learner's certificates as a PDF
+// (the default) or, with ?format=svg, an SVG image
+func (h *Handler) DownloadCertificate(c *gin.Context) {
+	cert, ok := h.loadOwnCertificate(c)
+	if !ok {
+		return
+	}
+
+	verifyURL := h.certificateResponse(cert).VerifyURL
+	filename := "certificate-" + cert.ID.Hex()
+	switch format := c.DefaultQuery("format", "pdf"); format {
+	case "pdf":
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.pdf"`)
+		c.Data(http.StatusOK, "application/pdf", certificate.RenderPDF(cert, verifyURL))
+	case "svg":
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.svg"`)
+		c.Data(http.StatusOK, "image/svg+xml", certificate.RenderSVG(cert, verifyURL))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format: use pdf or svg"})
+	}
+}
+
+// VerifyCertificate lets anyone holding a certificate's verification token,
+// such as an employer, confirm that the server issued it
+func (h *Handler) VerifyCertificate(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification token required"})
+		return
+	}
+
+	id := c.Param("id")
+	if !primitive.IsValidObjectID(id) {
+		c.JSON(http.StatusNotFound, gin.H{"valid": false, "error": "Certificate not found"})
+		return
+	}
+	cert, err := h.Repo.GetCertificate(c.Request.Context(), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"valid": false, "error": "Certificate not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify certificate"})
+		return
+	}
+	if !h.Certificates.Verify(cert, token) {
+		c.JSON(http.StatusForbidden, gin.H{"valid": false, "error": "Certificate could not be verified"})
+		return
+	}
+
+	c.JSON(http.StatusOK, CertificateVerification{
+		Valid:       true,
+		ID:          cert.ID.Hex(),
+		LearnerName: cert.LearnerName,
+		CourseTitle: cert.CourseTitle,
+		CompletedAt: cert.CompletedAt,
+		IssuedAt:    cert.IssuedAt,
+	})
+}
+
+// issueCertificates issues a certificate for each course the learner has
+// completed and doesn't have one for yet, and returns the new certificates
+func (h *Handler) issueCertificates(ctx context.Context, userID primitive.ObjectID) ([]models.Certificate, error) {
+	user, err := h.Repo.GetUserByID(ctx, userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	courses, err := h.Repo.GetAllCourses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := h.Repo.GetUserProgress(ctx, userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	issued := []models.Certificate{}
+	for _, p := range progress {
+		for _, course := range courses {
+			if course.ID != p.CourseID || !course.IsCompletedBy(p.CompletedModules) {
+				continue
+			}
+
+			cert := models.Certificate{
+				UserID:      userID,
+				CourseID:    course.ID,
+				LearnerName: user.Name,
+				CourseTitle: course.Title,
+				CompletedAt: now,
+				IssuedAt:    now,
+			}
+			if p.CompletedAt != nil {
+				cert.CompletedAt = *p.CompletedAt
+			}
+			isNew, err := h.Repo.IssueCertificate(ctx, &cert)
+			if err != nil {
+				return nil, err
+			}
+			if isNew {
+				issued = append(issued, cert)
+			}
+		}
+	}
+	return issued, nil
+}
+
+// loadOwnCertificate finds the authenticated learner's certificate named in
+// the URL. On failure it writes the error response and returns false.
+func (h *Handler) loadOwnCertificate(c *gin.Context) (*models.Certificate, bool) {
+	id := c.Param("id")
+	if !primitive.IsValidObjectID(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Certificate not found"})
+		return nil, false
+	}
+	cert, err := h.Repo.GetCertificate(c.Request.Context(), id)
+	if errors.Is(err, mongo.ErrNoDocuments) ||
+		(err == nil && cert.UserID.Hex() != c.GetString("userID")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Certificate not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch certificate"})
+		return nil, false
+	}
+	return cert, true
+}
+
+// certificateResponse adds the verification token and URL to a certificate.
+// The URL is under CertificateURL.
+func (h *Handler) certificateResponse(cert *models.Certificate) CertificateResponse {
+	token := h.Certificates.Sign(cert)
+	return CertificateResponse{
+		Certificate:       *cert,
+		VerificationToken: token,
+		VerifyURL:         fmt.Sprintf("%s/%s/verify?token=%s", strings.TrimSuffix(h.CertificateURL, "/"), cert.ID.Hex(), url.QueryEscape(token)),
+	}
+}
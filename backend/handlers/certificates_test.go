@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pathway/backend/models"
+)
+
+func TestCertificateIssuedOnCourseCompletion(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestServerWith(t, func(h *Handler) {
+		h.CertificateURL = "https://api.example.org/api/certificates/"
+	})
+	student := registerUser(t, r, "learner@example.com")
+	git, _ := repo.GetCourseBySlug(ctx, "git")
+
+	completeModules(t, r, student.Token, git.ID.Hex(), "git-1")
+	var certs []CertificateResponse
+	decode(t, doJSON(r, http.MethodGet, "/api/user/certificates", student.Token, nil), &certs)
+	if len(certs) != 0 {
+		t.Fatalf("no certificate before the course is complete: %+v", certs)
+	}
+
+	w := doJSON(r, http.MethodPost, "/api/user/progress/complete", student.Token, CompleteModuleRequest{CourseID: git.ID.Hex(), ModuleID: "git-2"})
+	var completed struct {
+		NewCertificates []models.Certificate `json:"new_certificates"`
+	}
+	decode(t, w, &completed)
+	if len(completed.NewCertificates) != 1 {
+		t.Fatalf("expected a certificate: %s", w.Body.String())
+	}
+	issued := completed.NewCertificates[0]
+	if issued.LearnerName != "Test User" || issued.CourseTitle != "Git" || issued.UserID != student.User.ID || issued.CompletedAt.IsZero() {
+		t.Fatalf("unexpected certificate: %+v", issued)
+	}
+
+	// Completing the course again doesn't issue another certificate
+	doJSON(r, http.MethodDelete, "/api/user/progress/courses/git/modules/git-2", student.Token, nil)
+	completeModules(t, r, student.Token, git.ID.Hex(), "git-2")
+	decode(t, doJSON(r, http.MethodGet, "/api/user/certificates", student.Token, nil), &certs)
+	if len(certs) != 1 || certs[0].ID != issued.ID || certs[0].VerificationToken == "" {
+		t.Fatalf("expected the one certificate: %+v", certs)
+	}
+
+	verifyURL, err := url.Parse(certs[0].VerifyURL)
+	if err != nil || verifyURL.Scheme != "https" || verifyURL.Host != "api.example.org" || verifyURL.Path != "/api/certificates/"+issued.ID.Hex()+"/verify" {
+		t.Fatalf("unexpected verify URL %q", certs[0].VerifyURL)
+	}
+	var verification CertificateVerification
+	decode(t, doJSON(r, http.MethodGet, verifyURL.RequestURI(), "", nil), &verification)
+	if !verification.Valid || verification.LearnerName != "Test User" || verification.CourseTitle != "Git" {
+		t.Fatalf("unexpected verification: %+v", verification)
+	}
+
+	for path, want := range map[string]int{
+		"/api/certificates/" + issued.ID.Hex() + "/verify?token=forged": http.StatusForbidden,
+		"/api/certificates/" + issued.ID.Hex() + "/verify":              http.StatusBadRequest,
+		"/api/certificates/missing/verify?token=x":                      http.StatusNotFound,
+		"/api/certificates/zzzzzzzzzzzzzzzzzzzzzzzz/verify?token=x":     http.StatusNotFound,
+	} {
+		if w := doJSON(r, http.MethodGet, path, "", nil); w.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, w.Code)
+		}
+	}
+
+	download := "/api/user/certificates/" + issued.ID.Hex() + "/download"
+	if w := doJSON(r, http.MethodGet, download, student.Token, nil); w.Code != http.StatusOK ||
+		w.Header().Get("Content-Type") != "application/pdf" || !strings.HasPrefix(w.Body.String(), "%PDF-") {
+		t.Fatalf("PDF download: %d %s", w.Code, w.Header())
+	}
+	if w := doJSON(r, http.MethodGet, download+"?format=svg", student.Token, nil); w.Code != http.StatusOK ||
+		w.Header().Get("Content-Type") != "image/svg+xml" || !strings.Contains(w.Body.String(), "Test User") {
+		t.Fatalf("SVG download: %d %s", w.Code, w.Header())
+	}
+	if w := doJSON(r, http.MethodGet, download+"?format=png", student.Token, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown format: expected 400, got %d", w.Code)
+	}
+
+	other := registerUser(t, r, "other@example.com")
+	if w := doJSON(r, http.MethodGet, "/api/user/certificates/"+issued.ID.Hex(), other.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("another learner's certificate: expected 404, got %d", w.Code)
+	}
+}
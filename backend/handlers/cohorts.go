@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CreateCohortRequest represents the request body for creating a cohort
+type CreateCohortRequest struct {
+	Name             string   `json:"name" binding:"required"`
+	InstructorEmails []string `json:"instructor_emails"` // Instructors creating a cohort teach it too
+	Courses          []string `json:"courses"`           // Course IDs or slugs
+	StartDate        string   `json:"start_date" binding:"required"`
+	EndDate          string   `json:"end_date" binding:"required"`
+}
+
+// EnrollCohortRequest represents the request body for enrolling users in a cohort
+type EnrollCohortRequest struct {
+	Emails []string `json:"emails" binding:"required,min=1"`
+}
+
+// EnrollCohortResponse reports which emails were enrolled
+type EnrollCohortResponse struct {
+	Cohort   *models.Cohort `json:"cohort"`
+	Enrolled []string       `json:"enrolled"`
+	NotFound []string       `json:"not_found"` // Emails without an account
+}
+
+// LearnerCohort is a cohort as its members see it, without the roster
+type LearnerCohort struct {
+	ID        primitive.ObjectID   `json:"id"`
+	Name      string               `json:"name"`
+	CourseIDs []primitive.ObjectID `json:"course_ids"`
+	StartDate string               `json:"start_date"`
+	EndDate   string               `json:"end_date"`
+}
+
+// CohortMember is a member as listed to the cohort's instructors
+type CohortMember struct {
+	ID    primitive.ObjectID `json:"id"`
+	Name  string             `json:"name"`
+	Email string             `json:"email"`
+	Role  string             `json:"role"`
+}
+
+// CreateCohort creates a cohort with its instructors and assigned courses
+func (h *Handler) CreateCohort(c *gin.Context) {
+	actorID, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateCohortRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	cohort := &models.Cohort{
+		Name:          strings.TrimSpace(req.Name),
+		InstructorIDs: []primitive.ObjectID{},
+		MemberIDs:     []primitive.ObjectID{},
+		CourseIDs:     []primitive.ObjectID{},
+		StartDate:     req.StartDate,
+		EndDate:       req.EndDate,
+		CreatedBy:     actorID,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if c.GetString("role") == models.RoleInstructor {
+		cohort.InstructorIDs = append(cohort.InstructorIDs, actorID)
+	}
+	for _, email := range req.InstructorEmails {
+		user, err := h.Repo.GetUserByEmail(c.Request.Context(), strings.TrimSpace(email))
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No user with email " + email})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cohort"})
+			return
+		}
+		if user.Role != models.RoleInstructor && user.Role != models.RoleAdmin {
+			c.JSON(http.StatusBadRequest, gin.H{"error": email + " is not an instructor"})
+			return
+		}
+		if !cohort.HasInstructor(user.ID) {
+			cohort.InstructorIDs = append(cohort.InstructorIDs, user.ID)
+		}
+	}
+	for _, ref := range req.Courses {
+		course, err := h.findCourse(c.Request.Context(), ref)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Course not found: " + ref})
+			return
+		}
+		cohort.CourseIDs = append(cohort.CourseIDs, course.ID)
+	}
+	if err := models.ValidateCohort(cohort); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.Repo.CreateCohort(c.Request.Context(), cohort); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cohort"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, cohort)
+}
+
+// GetCohorts lists every cohort for admins, and the cohorts they teach for instructors
+func (h *Handler) GetCohorts(c *gin.Context) {
+	var filter models.CohortFilter
+	if c.GetString("role") != models.RoleAdmin {
+		userID, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+		filter.InstructorID = userID
+	}
+	h.respondCohorts(c, filter)
+}
+
+// GetMyCohorts lists the cohorts the learner is a member of
+func (h *Handler) GetMyCohorts(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	cohorts, err := h.Repo.GetCohorts(c.Request.Context(), models.CohortFilter{MemberID: userID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cohorts"})
+		return
+	}
+
+	mine := make([]LearnerCohort, 0, len(cohorts))
+	for _, cohort := range cohorts {
+		mine = append(mine, LearnerCohort{
+			ID:        cohort.ID,
+			Name:      cohort.Name,
+			CourseIDs: cohort.CourseIDs,
+			StartDate: cohort.StartDate,
+			EndDate:   cohort.EndDate,
+		})
+	}
+	c.JSON(http.StatusOK, mine)
+}
+
+// GetCohort returns a cohort its instructor or an admin manages
+func (h *Handler) GetCohort(c *gin.Context) {
+	cohort, ok := h.loadManagedCohort(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, cohort)
+}
+
+// GetCohortMembers lists a cohort's members
+func (h *Handler) GetCohortMembers(c *gin.Context) {
+	cohort, ok := h.loadManagedCohort(c)
+	if !ok {
+		return
+	}
+
+	members := make([]CohortMember, 0, len(cohort.MemberIDs))
+	for _, memberID := range cohort.MemberIDs {
+		user, err := h.Repo.GetUserByID(c.Request.Context(), memberID.Hex())
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch members"})
+			return
+		}
+		members = append(members, CohortMember{ID: user.ID, Name: user.Name, Email: user.Email, Role: user.Role})
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// EnrollCohortMembers adds the users with the given emails to a cohort.
+// Emails without an account are reported rather than failing the request.
+func (h *Handler) EnrollCohortMembers(c *gin.Context) {
+	cohort, ok := h.loadManagedCohort(c)
+	if !ok {
+		return
+	}
+
+	var req EnrollCohortRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	resp := EnrollCohortResponse{Enrolled: []string{}, NotFound: []string{}}
+	var userIDs []primitive.ObjectID
+	for _, email := range req.Emails {
+		email = strings.TrimSpace(email)
+		user, err := h.Repo.GetUserByEmail(c.Request.Context(), email)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			resp.NotFound = append(resp.NotFound, email)
+			continue
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll members"})
+			return
+		}
+		userIDs = append(userIDs, user.ID)
+		resp.Enrolled = append(resp.Enrolled, email)
+	}
+
+	updated, err := h.Repo.AddCohortMembers(c.Request.Context(), cohort.ID.Hex(), userIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll members"})
+		return
+	}
+	resp.Cohort = updated
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RemoveCohortMember removes a user from a cohort
+func (h *Handler) RemoveCohortMember(c *gin.Context) {
+	cohort, ok := h.loadManagedCohort(c)
+	if !ok {
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil || !containsObjectID(cohort.MemberIDs, userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	updated, err := h.Repo.RemoveCohortMember(c.Request.Context(), cohort.ID.Hex(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *Handler) respondCohorts(c *gin.Context, filter models.CohortFilter) {
+	cohorts, err := h.Repo.GetCohorts(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cohorts"})
+		return
+	}
+	if cohorts == nil {
+		cohorts = []models.Cohort{}
+	}
+	c.JSON(http.StatusOK, cohorts)
+}
+
+// loadManagedCohort finds the cohort named in the URL, which admins and the
+// cohort's instructors can manage. On failure it writes the error response
+// and returns false.
+func (h *Handler) loadManagedCohort(c *gin.Context) (*models.Cohort, bool) {
+	return h.managedCohort(c, c.Param("id"))
+}
+
+// managedCohort finds a cohort the authenticated user can manage. On failure
+// it writes the error response and returns false.
+func (h *Handler) managedCohort(c *gin.Context, id string) (*models.Cohort, bool) {
+	if !primitive.IsValidObjectID(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cohort not found"})
+		return nil, false
+	}
+	cohort, err := h.Repo.GetCohort(c.Request.Context(), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cohort not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cohort"})
+		return nil, false
+	}
+
+	userID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if c.GetString("role") != models.RoleAdmin && !cohort.HasInstructor(userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the cohort's instructors can manage it"})
+		return nil, false
+	}
+	return cohort, true
+}
+
+// teachesLearner checks that the authenticated user can manage the learner:
+// admins manage everyone, instructors the members of cohorts they teach, as
+// with managedCohort. On failure it writes the error response and returns false.
+func (h *Handler) teachesLearner(c *gin.Context, learnerID primitive.ObjectID) bool {
+	if c.GetString("role") == models.RoleAdmin {
+		return true
+	}
+
+	userID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+	cohorts, err := h.Repo.GetCohorts(c.Request.Context(), models.CohortFilter{MemberID: learnerID, InstructorID: userID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cohorts"})
+		return false
+	}
+	if userID.IsZero() || len(cohorts) == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the instructors of the learner's cohorts can manage them"})
+		return false
+	}
+	return true
+}
+
+// inAnyCohort reports whether the user is a member of a cohort, which limits
+// them to the cohorts' assigned courses
+func (h *Handler) inAnyCohort(c *gin.Context, userID string) (bool, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, err
+	}
+	cohorts, err := h.Repo.GetCohorts(c.Request.Context(), models.CohortFilter{MemberID: id})
+	return len(cohorts) > 0, err
+}
+
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pathway/backend/models"
+)
+
+// teachCohort creates a cohort taught by the instructor, assigned the courses,
+// and enrolls the emails in it
+func teachCohort(t *testing.T, r http.Handler, instructorToken string, courses []string, emails ...string) models.Cohort {
+	t.Helper()
+	var cohort models.Cohort
+	decode(t, doJSON(r, http.MethodPost, "/api/admin/cohorts", instructorToken, CreateCohortRequest{
+		Name: "Bootcamp", Courses: courses, StartDate: "2025-01-01", EndDate: "2025-12-31",
+	}), &cohort)
+	if w := doJSON(r, http.MethodPost, "/api/admin/cohorts/"+cohort.ID.Hex()+"/members", instructorToken, EnrollCohortRequest{Emails: emails}); w.Code != http.StatusOK {
+		t.Fatalf("enroll: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	return cohort
+}
+
+func TestCohortsAssignCourses(t *testing.T) {
+	r, repo := newTestServer(t)
+	instructor := loginWithRole(t, r, repo, "instructor@example.com", models.RoleInstructor)
+	otherInstructor := loginWithRole(t, r, repo, "other-instructor@example.com", models.RoleInstructor)
+	student := registerUser(t, r, "learner@example.com")
+	selfServe := registerUser(t, r, "self-serve@example.com")
+
+	w := doJSON(r, http.MethodPost, "/api/admin/cohorts", instructor.Token, CreateCohortRequest{
+		Name:      "Spring bootcamp",
+		Courses:   []string{"http"},
+		StartDate: "2025-03-01",
+		EndDate:   "2025-05-31",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var cohort models.Cohort
+	decode(t, w, &cohort)
+	if len(cohort.InstructorIDs) != 1 || cohort.InstructorIDs[0] != instructor.User.ID || len(cohort.CourseIDs) != 1 {
+		t.Fatalf("unexpected cohort: %+v", cohort)
+	}
+	base := "/api/admin/cohorts/" + cohort.ID.Hex()
+
+	w = doJSON(r, http.MethodPost, base+"/members", instructor.Token, EnrollCohortRequest{Emails: []string{"learner@example.com", "nobody@example.com"}})
+	var enrolled EnrollCohortResponse
+	decode(t, w, &enrolled)
+	if len(enrolled.Enrolled) != 1 || len(enrolled.NotFound) != 1 || enrolled.NotFound[0] != "nobody@example.com" || len(enrolled.Cohort.MemberIDs) != 1 {
+		t.Fatalf("unexpected enrollment: %+v", enrolled)
+	}
+
+	var members []CohortMember
+	decode(t, doJSON(r, http.MethodGet, base+"/members", instructor.Token, nil), &members)
+	if len(members) != 1 || members[0].Email != "learner@example.com" || members[0].ID != student.User.ID {
+		t.Fatalf("unexpected members: %+v", members)
+	}
+
+	// Members see only their cohort's courses; everyone else sees every course
+	if progress := progressOf(t, r, student.Token); len(progress) != 1 || progress[0].Course.Slug != "http" {
+		t.Fatalf("member should only see http: %+v", progress)
+	}
+	if progress := progressOf(t, r, selfServe.Token); len(progress) != 2 {
+		t.Fatalf("self-serve learner should see every course: %+v", progress)
+	}
+	w = doJSON(r, http.MethodGet, "/api/user/cohorts", student.Token, nil)
+	var mine []LearnerCohort
+	decode(t, w, &mine)
+	if len(mine) != 1 || mine[0].ID != cohort.ID || mine[0].Name != "Spring bootcamp" || len(mine[0].CourseIDs) != 1 {
+		t.Fatalf("unexpected learner cohorts: %+v", mine)
+	}
+	if body := w.Body.String(); strings.Contains(body, "member_ids") || strings.Contains(body, "instructor_ids") {
+		t.Fatalf("learner cohorts should not include the roster: %s", body)
+	}
+
+	// Only the cohort's instructors and admins manage it
+	if w := doJSON(r, http.MethodGet, base+"/members", otherInstructor.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("other instructor: expected 403, got %d", w.Code)
+	}
+	var listed []models.Cohort
+	decode(t, doJSON(r, http.MethodGet, "/api/admin/cohorts", otherInstructor.Token, nil), &listed)
+	if len(listed) != 0 {
+		t.Fatalf("other instructor should not list the cohort: %+v", listed)
+	}
+	if w := doJSON(r, http.MethodGet, base, student.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("student: expected 403, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodGet, "/api/admin/cohorts/zzzzzzzzzzzzzzzzzzzzzzzz", instructor.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("malformed ID: expected 404, got %d", w.Code)
+	}
+
+	if w := doJSON(r, http.MethodDelete, base+"/members/"+student.User.ID.Hex(), instructor.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("remove: expected 200, got %d", w.Code)
+	}
+	if progress := progressOf(t, r, student.Token); len(progress) != 2 {
+		t.Fatalf("removed member should see every course again: %+v", progress)
+	}
+	if w := doJSON(r, http.MethodDelete, base+"/members/"+student.User.ID.Hex(), instructor.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("remove again: expected 404, got %d", w.Code)
+	}
+}
+
+func TestCohortWithoutCoursesListsNone(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestServer(t)
+	instructor := loginWithRole(t, r, repo, "instructor@example.com", models.RoleInstructor)
+	student := registerUser(t, r, "learner@example.com")
+
+	var cohort models.Cohort
+	decode(t, doJSON(r, http.MethodPost, "/api/admin/cohorts", instructor.Token, CreateCohortRequest{
+		Name:      "Orientation",
+		StartDate: "2025-03-01",
+		EndDate:   "2025-03-31",
+	}), &cohort)
+	doJSON(r, http.MethodPost, "/api/admin/cohorts/"+cohort.ID.Hex()+"/members", instructor.Token, EnrollCohortRequest{Emails: []string{"learner@example.com"}})
+
+	initialized, _ := repo.GetUserProgress(ctx, student.User.ID.Hex())
+	for i := 0; i < 2; i++ {
+		w := doJSON(r, http.MethodGet, "/api/user/progress", student.Token, nil)
+		if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "[]" {
+			t.Fatalf("expected an empty list, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	if progress, _ := repo.GetUserProgress(ctx, student.User.ID.Hex()); len(progress) != len(initialized) {
+		t.Fatalf("progress should not be initialized again: %+v", progress)
+	}
+}
+
+func TestCreateCohortValidation(t *testing.T) {
+	r, repo := newTestServer(t)
+	admin := loginWithRole(t, r, repo, "admin@example.com", models.RoleAdmin)
+	loginWithRole(t, r, repo, "instructor@example.com", models.RoleInstructor)
+	registerUser(t, r, "learner@example.com")
+
+	valid := CreateCohortRequest{
+		Name:             "Bootcamp",
+		InstructorEmails: []string{"instructor@example.com"},
+		Courses:          []string{"git"},
+		StartDate:        "2025-03-01",
+		EndDate:          "2025-03-31",
+	}
+	for name, mutate := range map[string]func(*CreateCohortRequest){
+		"no instructors":     func(req *CreateCohortRequest) { req.InstructorEmails = nil },
+		"student instructor": func(req *CreateCohortRequest) { req.InstructorEmails = []string{"learner@example.com"} },
+		"unknown instructor": func(req *CreateCohortRequest) { req.InstructorEmails = []string{"nobody@example.com"} },
+		"unknown course":     func(req *CreateCohortRequest) { req.Courses = []string{"missing"} },
+		"bad date":           func(req *CreateCohortRequest) { req.StartDate = "March 1st" },
+		"ends before start":  func(req *CreateCohortRequest) { req.EndDate = "2025-02-01" },
+	} {
+		req := valid
+		mutate(&req)
+		if w := doJSON(r, http.MethodPost, "/api/admin/cohorts", admin.Token, req); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", name, w.Code, w.Body.String())
+		}
+	}
+
+	if w := doJSON(r, http.MethodPost, "/api/admin/cohorts", admin.Token, valid); w.Code != http.StatusCreated {
+		t.Fatalf("valid cohort: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var cohorts []models.Cohort
+	decode(t, doJSON(r, http.MethodGet, "/api/admin/cohorts", admin.Token, nil), &cohorts)
+	if len(cohorts) != 1 || len(cohorts[0].InstructorIDs) != 1 {
+		t.Fatalf("admin should list every cohort: %+v", cohorts)
+	}
+}
@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/models"
+	"github.com/pathway/backend/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AddModuleRequest represents the request body for adding a module to a course
+type AddModuleRequest struct {
+	Module   models.Module `json:"module"`
+	Position *int          `json:"position"` // Omit to append
+}
+
+// ReorderModulesRequest lists every module ID of a course in its new order
+type ReorderModulesRequest struct {
+	ModuleIDs []string `json:"module_ids" binding:"required"`
+}
+
+// AddContentBlockRequest represents the request body for adding a block to a module
+type AddContentBlockRequest struct {
+	Block    models.ContentBlock `json:"block"`
+	Position *int                `json:"position"` // Omit to append
+}
+
+// ReorderContentBlocksRequest lists every current block index of a module in its new order
+type ReorderContentBlocksRequest struct {
+	Order []int `json:"order" binding:"required"`
+}
+
+// CreateCourse creates a new course, optionally with modules
+func (h *Handler) CreateCourse(c *gin.Context) {
+	var course models.Course
+	if err := c.ShouldBindJSON(&course); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	course.ID = primitive.NilObjectID
+	course.Version = 0
+	if course.Modules == nil {
+		course.Modules = []models.Module{}
+	}
+
+	if err := models.ValidateCourse(&course); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.Repo.CreateCourse(c.Request.Context(), &course); err != nil {
+		respondCourseEditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, course)
+}
+
+// AdminGetCourse returns a course by ID or slug including exercise solutions and hints, for editing
+func (h *Handler) AdminGetCourse(c *gin.Context) {
+	course, err := h.findCourse(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, course)
+}
+
+// UpdateCourse changes a course's slug, title or description; omitted fields are unchanged
+func (h *Handler) UpdateCourse(c *gin.Context) {
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var update models.CourseUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if update.Slug != nil && !models.ValidSlug(*update.Slug) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course slug: use lowercase letters, digits and hyphens"})
+		return
+	}
+	if update.Title != nil && *update.Title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Course title is required"})
+		return
+	}
+	// Prerequisites are checked against the rest of the course by the repository
+
+	course, err := h.Repo.UpdateCourse(c.Request.Context(), courseID, update)
+	if err != nil {
+		respondCourseEditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, course)
+}
+
+// DeleteCourse removes a course
+func (h *Handler) DeleteCourse(c *gin.Context) {
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Repo.DeleteCourse(c.Request.Context(), courseID); err != nil {
+		respondCourseEditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Course deleted"})
+}
+
+// AddModule adds a module to a course
+func (h *Handler) AddModule(c *gin.Context) {
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req AddModuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := models.ValidateModule(&req.Module); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	course, err := h.Repo.AddModule(c.Request.Context(), courseID, req.Module, positionOrAppend(req.Position))
+	if err != nil {
+		respondCourseEditError(c, err)
+		return
+	}
+	h.reconcileProgress(c, course)
+
+	c.JSON(http.StatusCreated, course)
+}
+
+// UpdateModule changes a module's title, video URL or content; omitted fields are unchanged
+func (h *Handler) UpdateModule(c *gin.Context) {
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var update models.ModuleUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if update.Title != nil && *update.Title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Module title is required"})
+		return
+	}
+	if update.Content != nil {
+		for i, block := range *update.Content {
+			if err := models.ValidateContentBlock(block); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Block " + strconv.Itoa(i) + ": " + err.Error()})
+				return
+			}
+		}
+	}
+
+	course, err := h.Repo.UpdateModule(c.Request.Context(), courseID, c.Param("moduleId"), update)
+	if err != nil {
+		respondCourseEditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, course)
+}
+
+// DeleteModule removes a module from a course
+func (h *Handler) DeleteModule(c *gin.Context) {
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	course, err := h.Repo.DeleteModule(c.Request.Context(), courseID, c.Param("moduleId"))
+	if err != nil {
+		respondCourseEditError(c, err)
+		return
+	}
+	h.reconcileProgress(c, course)
+
+	c.JSON(http.StatusOK, course)
+}
+
+// ReorderModules changes the order of a course's modules
+func (h *Handler) ReorderModules(c *gin.Context) {
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ReorderModulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	course, err := h.Repo.ReorderModules(c.Request.Context(), courseID, req.ModuleIDs)
+	if err != nil {
+		respondCourseEditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, course)
+}
+
+// AddContentBlock adds a content block to a module
+func (h *Handler) AddContentBlock(c *gin.Context) {
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req AddContentBlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := models.ValidateContentBlock(req.Block); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	course, err := h.Repo.AddContentBlock(c.Request.Context(), courseID, c.Param("moduleId"), req.Block, positionOrAppend(req.Position))
+	if err != nil {
+		respondCourseEditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, course)
+}
+
+// UpdateContentBlock replaces the content block at an index
+func (h *Handler) UpdateContentBlock(c *gin.Context) {
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content block not found"})
+		return
+	}
+
+	var block models.ContentBlock
+	if err := c.ShouldBindJSON(&block); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := models.ValidateContentBlock(block); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	course, err := h.Repo.UpdateContentBlock(c.Request.Context(), courseID, c.Param("moduleId"), index, block)
+	if err != nil {
+		respondCourseEditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, course)
+}
+
+// DeleteContentBlock removes the content block at an index
+func (h *Handler) DeleteContentBlock(c *gin.Context) {
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content block not found"})
+		return
+	}
+
+	course, err := h.Repo.DeleteContentBlock(c.Request.Context(), courseID, c.Param("moduleId"), index)
+	if err != nil {
+		respondCourseEditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, course)
+}
+
+// ReorderContentBlocks changes the order of a module's content blocks
+func (h *Handler) ReorderContentBlocks(c *gin.Context) {
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ReorderContentBlocksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	course, err := h.Repo.ReorderContentBlocks(c.Request.Context(), courseID, c.Param("moduleId"), req.Order)
+	if err != nil {
+		respondCourseEditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, course)
+}
+
+// positionOrAppend converts an optional insert position to the repository convention (-1 appends)
+func positionOrAppend(position *int) int {
+	if position == nil {
+		return -1
+	}
+	return *position
+}
+
+// reconcileProgress brings learners' progress in line with a course whose
+// modules changed. The edit is already saved, so failures are only logged.
+func (h *Handler) reconcileProgress(c *gin.Context, course *models.Course) {
+	changes, err := h.Repo.ReconcileCourseProgress(c.Request.Context(), course.ID.Hex())
+	if err != nil {
+		log.Printf("Failed to reconcile progress in course %s: %v", course.Slug, err)
+		return
+	}
+	if len(changes) > 0 {
+		log.Printf("Reconciled progress of %d learners in course %s", len(changes), course.Slug)
+	}
+}
+
+// courseIDParam returns the course ID in the URL. Authoring routes take IDs
+// only, so on anything else it writes a 404 and returns false.
+func courseIDParam(c *gin.Context) (string, bool) {
+	courseID := c.Param("id")
+	if !primitive.IsValidObjectID(courseID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return "", false
+	}
+	return courseID, true
+}
+
+// respondCourseEditError maps repository authoring errors to HTTP responses
+func respondCourseEditError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+	case errors.Is(err, repository.ErrModuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Module not found"})
+	case errors.Is(err, repository.ErrBlockNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content block not found"})
+	case errors.Is(err, repository.ErrDuplicateSlug),
+		errors.Is(err, repository.ErrDuplicateModule),
+		errors.Is(err, repository.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrInvalidOrder),
+		errors.Is(err, repository.ErrInvalidModule),
+		errors.Is(err, repository.ErrInvalidPrerequisites):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update course"})
+	}
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pathway/backend/models"
+)
+
+func TestCourseAuthoring(t *testing.T) {
+	r, repo := newTestServer(t)
+	instructor := loginWithRole(t, r, repo, "instructor@example.com", models.RoleInstructor)
+
+	w := doJSON(r, http.MethodPost, "/api/admin/courses", instructor.Token, models.Course{
+		Slug:  "testing",
+		Title: "Testing",
+		Modules: []models.Module{
+			{ID: "testing-1", Title: "Unit tests"},
+		},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var course models.Course
+	decode(t, w, &course)
+	base := "/api/admin/courses/" + course.ID.Hex()
+
+	w = doJSON(r, http.MethodPost, base+"/modules", instructor.Token, AddModuleRequest{
+		Module: models.Module{ID: "testing-2", Title: "Mocks"},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("add module: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	text := &models.TextBlock{Markdown: "Teh mocks"}
+	block := models.NewContentBlock(text)
+	w = doJSON(r, http.MethodPost, base+"/modules/testing-2/blocks", instructor.Token, AddContentBlockRequest{Block: block})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("add block: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	text.Markdown = "The mocks"
+	if w := doJSON(r, http.MethodPut, base+"/modules/testing-2/blocks/0", instructor.Token, block); w.Code != http.StatusOK {
+		t.Fatalf("update block: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(r, http.MethodPut, base+"/modules/order", instructor.Token, ReorderModulesRequest{
+		ModuleIDs: []string{"testing-2", "testing-1"},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("reorder: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Edits are visible through the public API
+	w = doJSON(r, http.MethodGet, "/api/courses/testing", "", nil)
+	decode(t, w, &course)
+	if len(course.Modules) != 2 || course.Modules[0].ID != "testing-2" {
+		t.Fatalf("unexpected modules: %+v", course.Modules)
+	}
+	if got := course.Modules[0].Content[0].Data.(*models.TextBlock).Markdown; got != "The mocks" {
+		t.Fatalf("block text = %v", got)
+	}
+
+	if w := doJSON(r, http.MethodDelete, base, instructor.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodGet, "/api/courses/testing", "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("deleted course: expected 404, got %d", w.Code)
+	}
+}
+
+func TestCourseAuthoringValidation(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestServer(t)
+	admin := loginWithRole(t, r, repo, "admin@example.com", models.RoleAdmin)
+	git, _ := repo.GetCourseBySlug(ctx, "git")
+	base := "/api/admin/courses/" + git.ID.Hex()
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		want   int
+	}{
+		{"bad slug", http.MethodPost, "/api/admin/courses", models.Course{Slug: "Not A Slug", Title: "x"}, http.StatusBadRequest},
+		{"duplicate slug", http.MethodPost, "/api/admin/courses", models.Course{Slug: "http", Title: "x"}, http.StatusConflict},
+		{"empty title", http.MethodPatch, base, map[string]string{"title": ""}, http.StatusBadRequest},
+		{"unknown block type", http.MethodPost, base + "/modules/git-1/blocks",
+			map[string]interface{}{"block": map[string]interface{}{"type": "marquee", "data": map[string]string{"text": "hi"}}}, http.StatusBadRequest},
+		{"missing required field", http.MethodPost, base + "/modules/git-1/blocks",
+			AddContentBlockRequest{Block: models.NewContentBlock(&models.ImageBlock{Alt: "no url"})}, http.StatusBadRequest},
+		{"duplicate module", http.MethodPost, base + "/modules", AddModuleRequest{Module: models.Module{ID: "git-1", Title: "x"}}, http.StatusConflict},
+		{"missing module", http.MethodDelete, base + "/modules/git-9", nil, http.StatusNotFound},
+		{"missing course", http.MethodPatch, "/api/admin/courses/000000000000000000000000", map[string]string{"title": "x"}, http.StatusNotFound},
+		{"malformed course ID", http.MethodDelete, "/api/admin/courses/zzzzzzzzzzzzzzzzzzzzzzzz", nil, http.StatusNotFound},
+		{"slug instead of ID", http.MethodPost, "/api/admin/courses/git/modules", AddModuleRequest{Module: models.Module{ID: "git-9", Title: "x"}}, http.StatusNotFound},
+		{"incomplete order", http.MethodPut, base + "/modules/order", ReorderModulesRequest{ModuleIDs: []string{"git-1"}}, http.StatusBadRequest},
+		{"bad block index", http.MethodDelete, base + "/modules/git-1/blocks/x", nil, http.StatusNotFound},
+		{"missing prerequisite", http.MethodPatch, base + "/modules/git-2", map[string][]string{"prerequisites": {"git-9"}}, http.StatusBadRequest},
+		{"course requiring another", http.MethodPost, "/api/admin/courses", models.Course{Slug: "git-pro", Title: "x", Prerequisites: []string{"git"}}, http.StatusCreated},
+		{"course cycle on edit", http.MethodPatch, base, map[string][]string{"prerequisites": {"git-pro"}}, http.StatusBadRequest},
+		{"rename course", http.MethodPatch, base, map[string]string{"slug": "git-basics"}, http.StatusOK},
+		{"course cycle through the old slug", http.MethodPost, "/api/admin/courses", models.Course{Slug: "git", Title: "x", Prerequisites: []string{"git-pro"}}, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		if w := doJSON(r, tc.method, tc.path, admin.Token, tc.body); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", tc.name, tc.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestAuthoringKeepsExerciseIDsUnique(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestServer(t)
+	admin := loginWithRole(t, r, repo, "admin@example.com", models.RoleAdmin)
+	git, _ := repo.GetCourseBySlug(ctx, "git")
+	base := "/api/admin/courses/" + git.ID.Hex() + "/modules/git-1"
+	exercise := func(prompt string) models.ContentBlock {
+		return models.NewContentBlock(&models.ExerciseBlock{ID: "commit", Prompt: prompt})
+	}
+
+	if w := doJSON(r, http.MethodPost, base+"/blocks", admin.Token, AddContentBlockRequest{Block: exercise("Make a commit")}); w.Code != http.StatusCreated {
+		t.Fatalf("first exercise: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(r, http.MethodPost, base+"/blocks", admin.Token, AddContentBlockRequest{Block: exercise("Make another")}); w.Code != http.StatusBadRequest {
+		t.Fatalf("add duplicate exercise: expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(r, http.MethodPatch, base, admin.Token, models.ModuleUpdate{
+		Content: &[]models.ContentBlock{exercise("One"), exercise("Two")},
+	}); w.Code != http.StatusBadRequest {
+		t.Fatalf("update module with duplicate exercises: expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+
+	course, _ := repo.GetCourseByID(ctx, git.ID.Hex())
+	module, _ := course.Module("git-1")
+	exercises := 0
+	for _, block := range module.Content {
+		if block.Type == models.BlockExercise {
+			exercises++
+		}
+	}
+	if exercises != 1 {
+		t.Fatalf("rejected edits should not be stored: %+v", module.Content)
+	}
+}
+
+func TestAuthoringValidatesQuizzes(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestServer(t)
+	admin := loginWithRole(t, r, repo, "admin@example.com", models.RoleAdmin)
+	git, _ := repo.GetCourseBySlug(ctx, "git")
+	base := "/api/admin/courses/" + git.ID.Hex() + "/modules/git-1"
+	quiz := models.NewContentBlock(&models.QuizBlock{
+		ID:    "basics",
+		Title: "Basics",
+		Questions: []models.QuizQuestion{
+			{ID: "init", Kind: models.QuestionSingleChoice, Prompt: "Start a repository?", Options: []string{"git init", "git start"}, Correct: []int{0}},
+		},
+	})
+	requirePassing := true
+
+	if w := doJSON(r, http.MethodPatch, base, admin.Token, models.ModuleUpdate{RequirePassingQuiz: &requirePassing}); w.Code != http.StatusBadRequest {
+		t.Fatalf("require_passing_quiz without a quiz: expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(r, http.MethodPost, base+"/blocks", admin.Token, AddContentBlockRequest{Block: quiz}); w.Code != http.StatusCreated {
+		t.Fatalf("add quiz: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(r, http.MethodPost, base+"/blocks", admin.Token, AddContentBlockRequest{Block: quiz}); w.Code != http.StatusBadRequest {
+		t.Fatalf("add duplicate quiz: expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(r, http.MethodPatch, base, admin.Token, models.ModuleUpdate{RequirePassingQuiz: &requirePassing}); w.Code != http.StatusOK {
+		t.Fatalf("require_passing_quiz with a quiz: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(r, http.MethodDelete, base+"/blocks/0", admin.Token, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("delete the only required quiz: expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestModuleEditsReconcileProgress(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestServer(t)
+	admin := loginWithRole(t, r, repo, "admin@example.com", models.RoleAdmin)
+	student := registerUser(t, r, "learner@example.com")
+	git, _ := repo.GetCourseBySlug(ctx, "git")
+	base := "/api/admin/courses/" + git.ID.Hex()
+	completeModules(t, r, student.Token, git.ID.Hex(), "git-1", "git-2")
+
+	w := doJSON(r, http.MethodPost, base+"/modules", admin.Token, AddModuleRequest{Module: models.Module{ID: "git-3", Title: "Rebasing"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("add module: expected 201, got %d", w.Code)
+	}
+	if p := progressOf(t, r, student.Token)[0]; p.IsCompleted {
+		t.Fatalf("course with a new module should no longer be complete: %+v", p)
+	}
+
+	for _, moduleID := range []string{"git-2", "git-3"} {
+		if w := doJSON(r, http.MethodDelete, base+"/modules/"+moduleID, admin.Token, nil); w.Code != http.StatusOK {
+			t.Fatalf("delete %s: expected 200, got %d", moduleID, w.Code)
+		}
+	}
+	p := progressOf(t, r, student.Token)[0]
+	if !p.IsCompleted || len(p.CompletedModules) != 1 || p.ProgressPercent != 100 {
+		t.Fatalf("deleted modules should be pruned and the course complete again: %+v", p)
+	}
+}
+
+func TestCourseAuthoringRequiresInstructorOrAdmin(t *testing.T) {
+	r, _ := newTestServer(t)
+	student := registerUser(t, r, "learner@example.com")
+
+	course := models.Course{Slug: "testing", Title: "Testing"}
+	if w := doJSON(r, http.MethodPost, "/api/admin/courses", "", course); w.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous: expected 401, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, "/api/admin/courses", student.Token, course); w.Code != http.StatusForbidden {
+		t.Fatalf("student: expected 403, got %d", w.Code)
+	}
+}
@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultStalledDays = 7
+	maxStalledDays     = 365
+)
+
+// CourseCompletionStats reports how many learners have started and completed a course
+type CourseCompletionStats struct {
+	CourseID          primitive.ObjectID `json:"course_id"`
+	Slug              string             `json:"slug"`
+	Title             string             `json:"title"`
+	Learners          int                `json:"learners"` // Cohort members, or everyone with a progress record for the course
+	Started           int                `json:"started"`
+	Completed         int                `json:"completed"`
+	CompletionPercent float64            `json:"completion_percent"` // Completed out of Learners
+}
+
+// ModuleDropOffResponse reports how far learners get through a course
+type ModuleDropOffResponse struct {
+	CourseID primitive.ObjectID `json:"course_id"`
+	Learners int                `json:"learners"`
+	Modules  []ModuleDropOff    `json:"modules"` // In course order
+}
+
+// ModuleDropOff counts the learners who completed a module, and how many fewer
+// that is than completed the module before it (or, for the first, than there are learners)
+type ModuleDropOff struct {
+	ModuleID          string  `json:"module_id"`
+	Title             string  `json:"title"`
+	Completed         int     `json:"completed"`
+	CompletionPercent float64 `json:"completion_percent"`
+	DropOff           int     `json:"drop_off"`
+}
+
+// CompletionMatrixResponse has a row per learner and a column per module
+type CompletionMatrixResponse struct {
+	CourseID primitive.ObjectID `json:"course_id"`
+	Modules  []string           `json:"modules"` // Module IDs, in course order
+	Learners []CompletionRow    `json:"learners"`
+}
+
+// CompletionRow is one learner's completion of each module, aligned with the matrix's modules
+type CompletionRow struct {
+	UserID      primitive.ObjectID `json:"user_id"`
+	Name        string             `json:"name"`
+	Email       string             `json:"email"`
+	Completed   []bool             `json:"completed"`
+	CompletedAt []*time.Time       `json:"completed_at"`
+}
+
+// StalledLearner is a learner with no recent activity
+type StalledLearner struct {
+	UserID       primitive.ObjectID `json:"user_id"`
+	Name         string             `json:"name"`
+	Email        string             `json:"email"`
+	LastActiveAt *time.Time         `json:"last_active_at"` // Null if they've never done anything
+}
+
+// dashboardScope is the learners and courses a dashboard request covers: a
+// cohort's members and courses, or, for admins who name no cohort, everyone
+type dashboardScope struct {
+	cohort *models.Cohort
+	models.ProgressScope
+}
+
+// GetCourseCompletionStats reports completion rates for each course in scope
+func (h *Handler) GetCourseCompletionStats(c *gin.Context) {
+	scope, ok := h.loadDashboardScope(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	courses, err := h.Repo.GetAllCourses(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch courses"})
+		return
+	}
+	counts, err := h.Repo.GetCourseCompletionCounts(ctx, scope.ProgressScope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch completion stats"})
+		return
+	}
+	byCourse := make(map[primitive.ObjectID]models.CourseCompletionCount)
+	for _, count := range counts {
+		byCourse[count.CourseID] = count
+	}
+
+	stats := []CourseCompletionStats{}
+	for _, course := range courses {
+		count, hasProgress := byCourse[course.ID]
+		if scope.cohort != nil && !containsObjectID(scope.cohort.CourseIDs, course.ID) {
+			continue
+		}
+		if scope.cohort == nil && !hasProgress {
+			continue
+		}
+		stat := CourseCompletionStats{
+			CourseID:  course.ID,
+			Slug:      course.Slug,
+			Title:     course.Title,
+			Learners:  scope.learners(count),
+			Started:   count.Started,
+			Completed: count.Completed,
+		}
+		stat.CompletionPercent = percent(stat.Completed, stat.Learners)
+		stats = append(stats, stat)
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetModuleDropOff reports how many learners in scope completed each module of a course
+func (h *Handler) GetModuleDropOff(c *gin.Context) {
+	scope, course, ok := h.loadDashboardCourse(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	counts, err := h.Repo.GetCourseCompletionCounts(ctx, scope.ProgressScope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch completion stats"})
+		return
+	}
+	moduleCounts, err := h.Repo.GetModuleCompletionCounts(ctx, course.ID, scope.ProgressScope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch completion stats"})
+		return
+	}
+
+	var count models.CourseCompletionCount
+	if len(counts) > 0 {
+		count = counts[0]
+	}
+	resp := ModuleDropOffResponse{CourseID: course.ID, Learners: scope.learners(count), Modules: []ModuleDropOff{}}
+	previous := resp.Learners
+	for _, module := range course.Modules {
+		completed := moduleCounts[module.ID]
+		dropOff := previous - completed
+		if dropOff < 0 {
+			dropOff = 0 // Modules needn't be done in order
+		}
+		resp.Modules = append(resp.Modules, ModuleDropOff{
+			ModuleID:          module.ID,
+			Title:             module.Title,
+			Completed:         completed,
+			CompletionPercent: percent(completed, resp.Learners),
+			DropOff:           dropOff,
+		})
+		previous = completed
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetCompletionMatrix reports which modules of a course each learner in scope has completed
+func (h *Handler) GetCompletionMatrix(c *gin.Context) {
+	scope, course, ok := h.loadDashboardCourse(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	progress, err := h.Repo.GetScopedProgress(ctx, scope.ProgressScope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch progress"})
+		return
+	}
+	byUser := make(map[primitive.ObjectID]models.Progress)
+	var userIDs []primitive.ObjectID
+	for _, p := range progress {
+		if _, ok := byUser[p.UserID]; !ok {
+			userIDs = append(userIDs, p.UserID)
+		}
+		byUser[p.UserID] = p
+	}
+	if scope.cohort != nil {
+		userIDs = scope.cohort.MemberIDs
+	}
+
+	resp := CompletionMatrixResponse{CourseID: course.ID, Modules: []string{}, Learners: []CompletionRow{}}
+	for _, module := range course.Modules {
+		resp.Modules = append(resp.Modules, module.ID)
+	}
+	users, err := h.usersByID(ctx, userIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch learners"})
+		return
+	}
+	for _, user := range users {
+		p := byUser[user.ID]
+		row := CompletionRow{UserID: user.ID, Name: user.Name, Email: user.Email}
+		for _, module := range course.Modules {
+			completed := containsString(p.CompletedModules, module.ID)
+			var completedAt *time.Time
+			if at, ok := p.ModuleCompletedAt[module.ID]; ok && completed {
+				completedAt = &at
+			}
+			row.Completed = append(row.Completed, completed)
+			row.CompletedAt = append(row.CompletedAt, completedAt)
+		}
+		resp.Learners = append(resp.Learners, row)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetStalledLearners lists learners in scope with no activity, other than
+// logging in, in the last ?days=N days (default 7), least recently active first
+func (h *Handler) GetStalledLearners(c *gin.Context) {
+	scope, ok := h.loadDashboardScope(c)
+	if !ok {
+		return
+	}
+	days := defaultStalledDays
+	if value := c.Query("days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxStalledDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days: use 1 to " + strconv.Itoa(maxStalledDays)})
+			return
+		}
+		days = n
+	}
+	ctx := c.Request.Context()
+
+	activity, err := h.Repo.GetLastActivity(ctx, scope.UserIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch activity"})
+		return
+	}
+	lastActive := make(map[primitive.ObjectID]time.Time)
+	for _, a := range activity {
+		lastActive[a.UserID] = a.LastActiveAt
+	}
+
+	// Without a cohort, the learners are everyone with progress or activity
+	userIDs := scope.UserIDs
+	if scope.cohort == nil {
+		progressUserIDs, err := h.Repo.GetProgressUserIDs(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch progress"})
+			return
+		}
+		seen := make(map[primitive.ObjectID]bool)
+		for _, userID := range progressUserIDs {
+			seen[userID] = true
+		}
+		for _, a := range activity {
+			seen[a.UserID] = true
+		}
+		for userID := range seen {
+			userIDs = append(userIDs, userID)
+		}
+	}
+
+	users, err := h.usersByID(ctx, userIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch learners"})
+		return
+	}
+	cutoff := time.Now().UTC().AddDate(0, 0, -days)
+	stalled := []StalledLearner{}
+	for _, user := range users {
+		learner := StalledLearner{UserID: user.ID, Name: user.Name, Email: user.Email}
+		if at, ok := lastActive[user.ID]; ok {
+			if !at.Before(cutoff) {
+				continue
+			}
+			learner.LastActiveAt = &at
+		}
+		stalled = append(stalled, learner)
+	}
+	sort.SliceStable(stalled, func(i, j int) bool {
+		a, b := stalled[i].LastActiveAt, stalled[j].LastActiveAt
+		return a == nil && b != nil || a != nil && b != nil && a.Before(*b)
+	})
+
+	c.JSON(http.StatusOK, stalled)
+}
+
+// loadDashboardScope works out the scope from the cohort query parameter.
+// Instructors must name a cohort they teach; admins may leave it out to cover
+// everyone. On failure it writes the error response and returns false.
+func (h *Handler) loadDashboardScope(c *gin.Context) (*dashboardScope, bool) {
+	cohortID := c.Query("cohort")
+	if cohortID == "" {
+		if c.GetString("role") != models.RoleAdmin {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Choose a cohort with ?cohort="})
+			return nil, false
+		}
+		return &dashboardScope{}, true
+	}
+
+	cohort, ok := h.managedCohort(c, cohortID)
+	if !ok {
+		return nil, false
+	}
+	return &dashboardScope{
+		cohort:        cohort,
+		ProgressScope: models.ProgressScope{UserIDs: cohort.MemberIDs, CourseIDs: cohort.CourseIDs},
+	}, true
+}
+
+// loadDashboardCourse works out the scope and finds the course in the URL,
+// narrowing the scope to it. A cohort's dashboard only covers its courses.
+func (h *Handler) loadDashboardCourse(c *gin.Context) (*dashboardScope, *models.Course, bool) {
+	scope, ok := h.loadDashboardScope(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	course, err := h.findCourse(c.Request.Context(), c.Param("courseId"))
+	if err != nil || (scope.cohort != nil && !containsObjectID(scope.cohort.CourseIDs, course.ID)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return nil, nil, false
+	}
+
+	scope.CourseIDs = []primitive.ObjectID{course.ID}
+	return scope, course, true
+}
+
+// learners returns how many learners a course's completion rate is out of:
+// the cohort's members, or everyone with a progress record for the course
+func (s *dashboardScope) learners(count models.CourseCompletionCount) int {
+	if s.cohort != nil {
+		return len(s.cohort.MemberIDs)
+	}
+	return count.Learners
+}
+
+// usersByID fetches users by ID, skipping any that no longer exist, ordered by name then email
+func (h *Handler) usersByID(ctx context.Context, userIDs []primitive.ObjectID) ([]models.User, error) {
+	users, err := h.Repo.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Name != users[j].Name {
+			return users[i].Name < users[j].Name
+		}
+		if users[i].Email != users[j].Email {
+			return users[i].Email < users[j].Email
+		}
+		return users[i].ID.Hex() < users[j].ID.Hex()
+	})
+	return users, nil
+}
+
+// percent returns part out of whole as a percentage, or 0 if whole is 0
+func percent(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole) * 100
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
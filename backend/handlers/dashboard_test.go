@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pathway/backend/models"
+)
+
+func TestInstructorDashboard(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestServer(t)
+	instructor := loginWithRole(t, r, repo, "instructor@example.com", models.RoleInstructor)
+	admin := loginWithRole(t, r, repo, "admin@example.com", models.RoleAdmin)
+	finisher := registerUser(t, r, "a-finisher@example.com")
+	starter := registerUser(t, r, "b-starter@example.com")
+	idle := registerUser(t, r, "c-idle@example.com")
+	lapsed := registerUser(t, r, "d-lapsed@example.com")
+	outsider := registerUser(t, r, "outsider@example.com")
+	git, _ := repo.GetCourseBySlug(ctx, "git")
+
+	var cohort models.Cohort
+	decode(t, doJSON(r, http.MethodPost, "/api/admin/cohorts", instructor.Token, CreateCohortRequest{
+		Name: "Bootcamp", Courses: []string{"git"}, StartDate: "2025-01-01", EndDate: "2025-12-31",
+	}), &cohort)
+	doJSON(r, http.MethodPost, "/api/admin/cohorts/"+cohort.ID.Hex()+"/members", instructor.Token, EnrollCohortRequest{
+		Emails: []string{"a-finisher@example.com", "b-starter@example.com", "c-idle@example.com", "d-lapsed@example.com"},
+	})
+
+	completeModules(t, r, finisher.Token, git.ID.Hex(), "git-1", "git-2")
+	completeModules(t, r, starter.Token, git.ID.Hex(), "git-1")
+	completeModules(t, r, outsider.Token, git.ID.Hex(), "git-1", "git-2")
+	if err := repo.RecordActivity(ctx, &models.ActivityEvent{
+		UserID: lapsed.User.ID, Type: models.ActivityModuleOpened, OccurredAt: time.Now().UTC().AddDate(0, 0, -10),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	query := "?cohort=" + cohort.ID.Hex()
+
+	var stats []CourseCompletionStats
+	decode(t, doJSON(r, http.MethodGet, "/api/admin/dashboard/courses"+query, instructor.Token, nil), &stats)
+	if len(stats) != 1 || stats[0].Slug != "git" || stats[0].Learners != 4 || stats[0].Started != 2 ||
+		stats[0].Completed != 1 || stats[0].CompletionPercent != 25 {
+		t.Fatalf("unexpected cohort stats: %+v", stats)
+	}
+
+	var dropOff ModuleDropOffResponse
+	decode(t, doJSON(r, http.MethodGet, "/api/admin/dashboard/courses/git/modules"+query, instructor.Token, nil), &dropOff)
+	if dropOff.Learners != 4 || len(dropOff.Modules) != 2 ||
+		dropOff.Modules[0].Completed != 2 || dropOff.Modules[0].DropOff != 2 ||
+		dropOff.Modules[1].Completed != 1 || dropOff.Modules[1].DropOff != 1 || dropOff.Modules[1].CompletionPercent != 25 {
+		t.Fatalf("unexpected drop-off: %+v", dropOff)
+	}
+
+	var matrix CompletionMatrixResponse
+	decode(t, doJSON(r, http.MethodGet, "/api/admin/dashboard/courses/git/matrix"+query, instructor.Token, nil), &matrix)
+	if len(matrix.Modules) != 2 || len(matrix.Learners) != 4 {
+		t.Fatalf("unexpected matrix: %+v", matrix)
+	}
+	first, second := matrix.Learners[0], matrix.Learners[1]
+	if first.UserID != finisher.User.ID || !first.Completed[0] || !first.Completed[1] || first.CompletedAt[1] == nil {
+		t.Fatalf("unexpected finisher row: %+v", first)
+	}
+	if second.UserID != starter.User.ID || !second.Completed[0] || second.Completed[1] || second.CompletedAt[1] != nil {
+		t.Fatalf("unexpected starter row: %+v", second)
+	}
+
+	var stalled []StalledLearner
+	decode(t, doJSON(r, http.MethodGet, "/api/admin/dashboard/stalled"+query+"&days=3", instructor.Token, nil), &stalled)
+	if len(stalled) != 2 || stalled[0].UserID != idle.User.ID || stalled[0].LastActiveAt != nil ||
+		stalled[1].UserID != lapsed.User.ID || stalled[1].LastActiveAt == nil {
+		t.Fatalf("unexpected stalled learners: %+v", stalled)
+	}
+	decode(t, doJSON(r, http.MethodGet, "/api/admin/dashboard/stalled"+query+"&days=30", instructor.Token, nil), &stalled)
+	if len(stalled) != 1 || stalled[0].UserID != idle.User.ID {
+		t.Fatalf("only the idle learner is stalled over 30 days: %+v", stalled)
+	}
+
+	// Admins can see everyone with progress; every account starts with a record per course
+	decode(t, doJSON(r, http.MethodGet, "/api/admin/dashboard/courses", admin.Token, nil), &stats)
+	if len(stats) != 2 || stats[0].Learners != 7 || stats[0].Started != 3 || stats[0].Completed != 2 ||
+		math.Abs(stats[0].CompletionPercent-200.0/7) > 0.01 || stats[1].Slug != "http" || stats[1].Started != 0 {
+		t.Fatalf("unexpected global stats: %+v", stats)
+	}
+
+	for path, want := range map[string]int{
+		"/api/admin/dashboard/courses":                      http.StatusBadRequest, // Instructors must name a cohort
+		"/api/admin/dashboard/courses/http/modules" + query: http.StatusNotFound,   // Not assigned to the cohort
+		"/api/admin/dashboard/stalled" + query + "&days=0":  http.StatusBadRequest,
+	} {
+		if w := doJSON(r, http.MethodGet, path, instructor.Token, nil); w.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, w.Code)
+		}
+	}
+	if w := doJSON(r, http.MethodGet, "/api/admin/dashboard/courses"+query, finisher.Token, nil); w.Code != http.StatusForbidden {
+		t.Errorf("student: expected 403, got %d", w.Code)
+	}
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxAnswerLength bounds the size of a submitted exercise answer
+const maxAnswerLength = 10000
+
+// SubmitExerciseRequest represents the request body for submitting an exercise answer
+type SubmitExerciseRequest struct {
+	Answer string `json:"answer" binding:"required"`
+}
+
+// ExerciseStateResponse is a learner's view of an exercise: how often they've
+// attempted it and the hints and solution they have revealed
+type ExerciseStateResponse struct {
+	ExerciseID          string   `json:"exercise_id"`
+	Attempts            int      `json:"attempts"`
+	HintCount           int      `json:"hint_count"`
+	Hints               []string `json:"hints"` // Revealed so far, in order
+	HasSolution         bool     `json:"has_solution"`
+	SolutionRevealed    bool     `json:"solution_revealed"`
+	Solution            string   `json:"solution,omitempty"`
+	CanRevealSolution   bool     `json:"can_reveal_solution"`
+	RevealPolicy        string   `json:"reveal_policy"`
+	RevealAfterAttempts int      `json:"reveal_after_attempts,omitempty"`
+	TestCount           int      `json:"test_count"` // Tests a Go submission is run against; 0 if the exercise isn't runnable
+}
+
+// exerciseContext identifies the exercise a request is about
+type exerciseContext struct {
+	*learnerModule
+	exerciseID string
+	exercise   *models.ExerciseBlock
+}
+
+// GetExercise returns the learner's state for an exercise
+func (h *Handler) GetExercise(c *gin.Context) {
+	ex, ok := h.loadExercise(c)
+	if !ok {
+		return
+	}
+	h.respondExerciseState(c, ex)
+}
+
+// SubmitExerciseAttempt records an answer to an exercise. Answers aren't graded;
+// they count towards the exercise's reveal policy.
+func (h *Handler) SubmitExerciseAttempt(c *gin.Context) {
+	ex, ok := h.loadExercise(c)
+	if !ok {
+		return
+	}
+
+	var req SubmitExerciseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if len(req.Answer) > maxAnswerLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Answer must be at most %d characters", maxAnswerLength)})
+		return
+	}
+
+	attempt := &models.ExerciseAttempt{
+		UserID:      ex.userObjectID,
+		CourseID:    ex.courseObjectID,
+		ModuleID:    ex.moduleID,
+		ExerciseID:  ex.exerciseID,
+		Answer:      req.Answer,
+		SubmittedAt: time.Now().UTC(),
+	}
+	if err := h.Repo.CreateExerciseAttempt(c.Request.Context(), attempt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record attempt"})
+		return
+	}
+	h.recordActivity(c, ex.activity(models.ActivityExerciseAttempted, ex.exerciseID))
+
+	h.respondExerciseState(c, ex)
+}
+
+// RevealExerciseHint reveals the learner's next hint, in order.
+// Once every hint is revealed it just returns the state.
+func (h *Handler) RevealExerciseHint(c *gin.Context) {
+	ex, ok := h.loadExercise(c)
+	if !ok {
+		return
+	}
+	if len(ex.exercise.Hints) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exercise has no hints"})
+		return
+	}
+
+	reveals, err := h.Repo.GetExerciseReveals(c.Request.Context(), ex.userID, ex.courseID, ex.moduleID, ex.exerciseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exercise"})
+		return
+	}
+
+	next := len(revealedHints(reveals, ex.exercise))
+	if next < len(ex.exercise.Hints) {
+		if err := h.recordReveal(c, ex, models.RevealKindHint, next); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reveal hint"})
+			return
+		}
+	}
+
+	h.respondExerciseState(c, ex)
+}
+
+// RevealExerciseSolution reveals the solution if the exercise's reveal policy allows it
+func (h *Handler) RevealExerciseSolution(c *gin.Context) {
+	ex, ok := h.loadExercise(c)
+	if !ok {
+		return
+	}
+	if ex.exercise.Solution == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exercise has no solution"})
+		return
+	}
+
+	attempts, err := h.Repo.CountExerciseAttempts(c.Request.Context(), ex.userID, ex.courseID, ex.moduleID, ex.exerciseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exercise"})
+		return
+	}
+	if !ex.exercise.CanRevealSolution(attempts) {
+		c.JSON(http.StatusForbidden, gin.H{"error": solutionLockedMessage(ex.exercise, attempts)})
+		return
+	}
+
+	if err := h.recordReveal(c, ex, models.RevealKindSolution, 0); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reveal solution"})
+		return
+	}
+
+	h.respondExerciseState(c, ex)
+}
+
+// loadExercise finds the exercise named in the URL. On failure it writes the
+// error response and returns false.
+func (h *Handler) loadExercise(c *gin.Context) (*exerciseContext, bool) {
+	lm, ok := h.loadLearnerModule(c)
+	if !ok {
+		return nil, false
+	}
+
+	exercise, ok := lm.module.Exercise(c.Param("exerciseId"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Exercise not found"})
+		return nil, false
+	}
+
+	return &exerciseContext{learnerModule: lm, exerciseID: c.Param("exerciseId"), exercise: exercise}, true
+}
+
+// learnerModule identifies the learner and the course module a request is about
+type learnerModule struct {
+	userID   string
+	courseID string
+	moduleID string
+	course   *models.Course
+	module   *models.Module
+
+	userObjectID   primitive.ObjectID
+	courseObjectID primitive.ObjectID
+}
+
+// loadLearnerModule finds the course and module named in the URL. On failure
+// it writes the error response and returns false.
+func (h *Handler) loadLearnerModule(c *gin.Context) (*learnerModule, bool) {
+	userID := c.GetString("userID")
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	course, err := h.findCourse(c.Request.Context(), c.Param("courseId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return nil, false
+	}
+
+	module, ok := course.Module(c.Param("moduleId"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Module not found"})
+		return nil, false
+	}
+
+	return &learnerModule{
+		userID:   userID,
+		courseID: course.ID.Hex(),
+		moduleID: module.ID,
+		course:   course,
+		module:   module,
+
+		userObjectID:   userObjectID,
+		courseObjectID: course.ID,
+	}, true
+}
+
+func (h *Handler) recordReveal(c *gin.Context, ex *exerciseContext, kind string, hintIndex int) error {
+	err := h.Repo.RecordExerciseReveal(c.Request.Context(), &models.ExerciseReveal{
+		UserID:     ex.userObjectID,
+		CourseID:   ex.courseObjectID,
+		ModuleID:   ex.moduleID,
+		ExerciseID: ex.exerciseID,
+		Kind:       kind,
+		HintIndex:  hintIndex,
+		RevealedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	eventType := models.ActivityHintRevealed
+	if kind == models.RevealKindSolution {
+		eventType = models.ActivitySolutionRevealed
+	}
+	h.recordActivity(c, ex.activity(eventType, ex.exerciseID))
+	return nil
+}
+
+// respondExerciseState writes the learner's current state for the exercise
+func (h *Handler) respondExerciseState(c *gin.Context, ex *exerciseContext) {
+	ctx := c.Request.Context()
+	attempts, err := h.Repo.CountExerciseAttempts(ctx, ex.userID, ex.courseID, ex.moduleID, ex.exerciseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exercise"})
+		return
+	}
+	reveals, err := h.Repo.GetExerciseReveals(ctx, ex.userID, ex.courseID, ex.moduleID, ex.exerciseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exercise"})
+		return
+	}
+
+	preview := ex.exercise.Redacted().(*models.ExercisePreview)
+	state := ExerciseStateResponse{
+		ExerciseID:          ex.exerciseID,
+		Attempts:            attempts,
+		HintCount:           len(ex.exercise.Hints),
+		Hints:               revealedHints(reveals, ex.exercise),
+		HasSolution:         ex.exercise.Solution != "",
+		CanRevealSolution:   ex.exercise.Solution != "" && ex.exercise.CanRevealSolution(attempts),
+		RevealPolicy:        preview.RevealPolicy,
+		RevealAfterAttempts: preview.RevealAfterAttempts,
+		TestCount:           preview.TestCount,
+	}
+	for _, reveal := range reveals {
+		if reveal.Kind == models.RevealKindSolution {
+			state.SolutionRevealed = true
+			state.Solution = ex.exercise.Solution
+		}
+	}
+
+	c.JSON(http.StatusOK, state)
+}
+
+// revealedHints returns the hints revealed so far. Hints are revealed in order,
+// so this stops at the first gap (e.g. if an author inserted a hint since).
+func revealedHints(reveals []models.ExerciseReveal, exercise *models.ExerciseBlock) []string {
+	revealed := make(map[int]bool)
+	for _, reveal := range reveals {
+		if reveal.Kind == models.RevealKindHint {
+			revealed[reveal.HintIndex] = true
+		}
+	}
+
+	hints := []string{}
+	for i, hint := range exercise.Hints {
+		if !revealed[i] {
+			break
+		}
+		hints = append(hints, hint)
+	}
+	return hints
+}
+
+func solutionLockedMessage(exercise *models.ExerciseBlock, attempts int) string {
+	if exercise.RevealPolicy == models.RevealAfterAttempts {
+		return fmt.Sprintf("Submit %d more attempt(s) to reveal the solution", exercise.RevealAfterAttempts-attempts)
+	}
+	return "Submit an answer to reveal the solution"
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/models"
+	"github.com/pathway/backend/repository"
+)
+
+// newExerciseServer adds a course whose module has one exercise per reveal policy
+func newExerciseServer(t *testing.T) (*gin.Engine, *repository.MemoryRepository) {
+	t.Helper()
+	r, repo := newTestServer(t)
+
+	course := &models.Course{
+		Slug:  "go",
+		Title: "Go",
+		Modules: []models.Module{{
+			ID:    "go-1",
+			Title: "Loops",
+			Content: []models.ContentBlock{
+				models.NewContentBlock(&models.ExerciseBlock{
+					ID:       "count",
+					Prompt:   "Print 1 to 10",
+					Solution: "for i := 1; i <= 10; i++ { fmt.Println(i) }",
+					Hints:    []string{"Use a for loop", "Start at 1"},
+				}),
+				models.NewContentBlock(&models.ExerciseBlock{
+					ID:           "sum",
+					Prompt:       "Sum a slice",
+					Solution:     "for _, v := range s { total += v }",
+					RevealPolicy: models.RevealAfterSubmission,
+				}),
+				models.NewContentBlock(&models.ExerciseBlock{
+					ID:                  "fizzbuzz",
+					Prompt:              "FizzBuzz",
+					Solution:            "switch { ... }",
+					RevealPolicy:        models.RevealAfterAttempts,
+					RevealAfterAttempts: 2,
+				}),
+			},
+		}},
+	}
+	if err := repo.CreateCourse(context.Background(), course); err != nil {
+		t.Fatal(err)
+	}
+	return r, repo
+}
+
+func TestCourseResponsesHideSolutions(t *testing.T) {
+	r, _ := newExerciseServer(t)
+	student := registerUser(t, r, "learner@example.com")
+
+	for _, req := range []struct{ path, token string }{
+		{"/api/courses", ""},
+		{"/api/courses/go", ""},
+		{"/api/user/progress", student.Token},
+	} {
+		w := doJSON(r, http.MethodGet, req.path, req.token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d", req.path, w.Code)
+		}
+		body := w.Body.String()
+		if strings.Contains(body, "fmt.Println") || strings.Contains(body, "Use a for loop") {
+			t.Fatalf("GET %s leaked a solution or hint: %s", req.path, body)
+		}
+	}
+
+	w := doJSON(r, http.MethodGet, "/api/courses/go", "", nil)
+	var course struct {
+		Modules []struct {
+			Content []struct {
+				Data models.ExercisePreview `json:"data"`
+			} `json:"content"`
+		} `json:"modules"`
+	}
+	decode(t, w, &course)
+	preview := course.Modules[0].Content[0].Data
+	if preview.ID != "count" || preview.HintCount != 2 || !preview.HasSolution || preview.RevealPolicy != models.RevealAlways {
+		t.Fatalf("unexpected preview: %+v", preview)
+	}
+}
+
+func TestRevealHintsInOrder(t *testing.T) {
+	r, _ := newExerciseServer(t)
+	student := registerUser(t, r, "learner@example.com")
+	path := "/api/user/courses/go/modules/go-1/exercises/count"
+
+	var state ExerciseStateResponse
+	for i, want := range []string{"Use a for loop", "Start at 1", "Start at 1"} {
+		w := doJSON(r, http.MethodPost, path+"/hints", student.Token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("hint %d: expected 200, got %d: %s", i, w.Code, w.Body.String())
+		}
+		decode(t, w, &state)
+		if last := state.Hints[len(state.Hints)-1]; last != want {
+			t.Fatalf("hint %d: got %q, want %q", i, last, want)
+		}
+	}
+	if len(state.Hints) != 2 || state.SolutionRevealed {
+		t.Fatalf("unexpected state: %+v", state)
+	}
+
+	// Another learner hasn't revealed anything
+	other := registerUser(t, r, "other@example.com")
+	decode(t, doJSON(r, http.MethodGet, path, other.Token, nil), &state)
+	if len(state.Hints) != 0 {
+		t.Fatalf("reveals leaked between learners: %+v", state)
+	}
+}
+
+func TestRevealSolutionPolicies(t *testing.T) {
+	r, _ := newExerciseServer(t)
+	student := registerUser(t, r, "learner@example.com")
+	base := "/api/user/courses/go/modules/go-1/exercises/"
+
+	// The default policy allows revealing straight away
+	w := doJSON(r, http.MethodPost, base+"count/solution", student.Token, nil)
+	var state ExerciseStateResponse
+	decode(t, w, &state)
+	if w.Code != http.StatusOK || !state.SolutionRevealed || !strings.Contains(state.Solution, "fmt.Println") {
+		t.Fatalf("always: expected solution, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := doJSON(r, http.MethodPost, base+"sum/solution", student.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("after_submission before submitting: expected 403, got %d", w.Code)
+	}
+	doJSON(r, http.MethodPost, base+"sum/attempts", student.Token, SubmitExerciseRequest{Answer: "total := 0"})
+	if w := doJSON(r, http.MethodPost, base+"sum/solution", student.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("after_submission after submitting: expected 200, got %d", w.Code)
+	}
+
+	for i := 1; i <= 2; i++ {
+		w := doJSON(r, http.MethodPost, base+"fizzbuzz/solution", student.Token, nil)
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("after_attempts with %d attempts: expected 403, got %d", i-1, w.Code)
+		}
+		w = doJSON(r, http.MethodPost, base+"fizzbuzz/attempts", student.Token, SubmitExerciseRequest{Answer: "attempt"})
+		decode(t, w, &state)
+		if state.Attempts != i {
+			t.Fatalf("attempts = %d, want %d", state.Attempts, i)
+		}
+	}
+	if w := doJSON(r, http.MethodPost, base+"fizzbuzz/solution", student.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("after_attempts after 2 attempts: expected 200, got %d", w.Code)
+	}
+}
+
+func TestExerciseEndpointErrors(t *testing.T) {
+	r, _ := newExerciseServer(t)
+	student := registerUser(t, r, "learner@example.com")
+
+	cases := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"unknown course", "/api/user/courses/rust/modules/go-1/exercises/sum", http.StatusNotFound},
+		{"unknown module", "/api/user/courses/go/modules/go-9/exercises/sum", http.StatusNotFound},
+		{"unknown exercise", "/api/user/courses/go/modules/go-1/exercises/missing", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		if w := doJSON(r, http.MethodGet, tc.path, student.Token, nil); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, w.Code)
+		}
+	}
+
+	path := "/api/user/courses/go/modules/go-1/exercises/sum"
+	if w := doJSON(r, http.MethodGet, path, "", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous: expected 401, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, path+"/hints", student.Token, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("no hints: expected 400, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, path+"/attempts", student.Token, SubmitExerciseRequest{}); w.Code != http.StatusBadRequest {
+		t.Fatalf("empty answer: expected 400, got %d", w.Code)
+	}
+}
+
+func TestAdminGetCourseIncludesSolutions(t *testing.T) {
+	r, repo := newExerciseServer(t)
+	instructor := loginWithRole(t, r, repo, "instructor@example.com", models.RoleInstructor)
+
+	w := doJSON(r, http.MethodGet, "/api/admin/courses/go", instructor.Token, nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "fmt.Println") {
+		t.Fatalf("expected full course, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -1,21 +1,71 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/certificate"
+	"github.com/pathway/backend/mailer"
+	"github.com/pathway/backend/middleware"
+	"github.com/pathway/backend/models"
+	"github.com/pathway/backend/oauth"
 	"github.com/pathway/backend/repository"
+	"github.com/pathway/backend/runner"
 	"github.com/pathway/backend/seed"
+	"github.com/pathway/backend/throttle"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Handler struct {
-	Repo repository.Repository
+	Repo         repository.Repository
+	Runner       runner.Runner       // Runs exercise submissions; nil disables code execution
+	Badges       []models.Badge      // Badges learners can earn
+	Certificates *certificate.Signer // Signs certificate verification tokens
+
+	// This API's public /api/certificates URL, for the verification links on
+	// certificates. It's configured rather than taken from the request, whose
+	// Host and X-Forwarded-Proto headers the client controls.
+	CertificateURL string
+
+	// Account emails. The links point at frontend pages, with the token added as ?token=.
+	Mailer                mailer.Sender
+	PasswordResetURL      string
+	EmailVerificationURL  string
+	VerifiedEmailRequired bool // Block progress and activity writes until the user verifies their email
+
+	Logins *throttle.Limiter // Throttles failed logins; nil disables throttling
+
+	// Sign-in with external identity providers, by name. Providers send users
+	// back to OAuthCallbackURL/<name>/callback, which must be registered with
+	// them; the tokens are then handed to the frontend page at OAuthRedirectURL.
+	OAuthProviders   map[string]*oauth.Provider
+	OAuthCallbackURL string
+	OAuthRedirectURL string
 }
 
+// NewHandler returns a handler with the default badges, signing certificates
+// with the JWT secret and linking them to the local API. Emails are written to the log, their links point at the
+// local frontend, unverified users may save progress, failed logins are
+// tracked in memory, and there are no sign-in providers.
 func NewHandler(repo repository.Repository) *Handler {
-	return &Handler{Repo: repo}
+	return &Handler{
+		Repo:                 repo,
+		Badges:               models.DefaultBadges(),
+		Certificates:         certificate.NewSigner(middleware.GetJWTSecret()),
+		CertificateURL:       "http://localhost:8080/api/certificates",
+		Mailer:               &mailer.LogSender{},
+		PasswordResetURL:     "http://localhost:5173/reset-password",
+		EmailVerificationURL: "http://localhost:5173/verify-email",
+		Logins:               throttle.NewLimiter(throttle.NewMemoryStore()),
+		OAuthProviders:       map[string]*oauth.Provider{},
+		OAuthCallbackURL:     "http://localhost:8080/api/auth/oauth",
+		OAuthRedirectURL:     "http://localhost:5173/oauth/callback",
+	}
 }
 
 func (h *Handler) HealthCheck(c *gin.Context) {
@@ -26,7 +76,7 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 }
 
 func (h *Handler) GetCourses(c *gin.Context) {
-	courses, err := h.Repo.GetAllCourses()
+	courses, err := h.Repo.GetAllCourses(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch courses",
@@ -34,10 +84,15 @@ func (h *Handler) GetCourses(c *gin.Context) {
 		return
 	}
 
+	// Exercise solutions and hints are only served through the exercise endpoints
+	for i := range courses {
+		courses[i] = courses[i].Public()
+	}
+
 	c.JSON(http.StatusOK, courses)
 }
 
-// GetCourseByID retrieves a single course by its ID
+// GetCourseByID retrieves a single course by its ID or slug
 func (h *Handler) GetCourseByID(c *gin.Context) {
 	courseID := c.Param("id")
 	if courseID == "" {
@@ -45,13 +100,21 @@ func (h *Handler) GetCourseByID(c *gin.Context) {
 		return
 	}
 
-	course, err := h.Repo.GetCourseByID(courseID)
+	course, err := h.findCourse(c.Request.Context(), courseID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, course)
+	c.JSON(http.StatusOK, course.Public())
+}
+
+// findCourse looks a course up by its ID, or by slug if the value isn't an ObjectID
+func (h *Handler) findCourse(ctx context.Context, idOrSlug string) (*models.Course, error) {
+	if primitive.IsValidObjectID(idOrSlug) {
+		return h.Repo.GetCourseByID(ctx, idOrSlug)
+	}
+	return h.Repo.GetCourseBySlug(ctx, idOrSlug)
 }
 
 // GetUserProgress retrieves the authenticated user's progress across all courses
@@ -63,26 +126,40 @@ func (h *Handler) GetUserProgress(c *gin.Context) {
 	}
 
 	// Get courses with progress
-	coursesWithProgress, err := h.Repo.GetUserProgressWithCourses(userID)
+	coursesWithProgress, err := h.Repo.GetUserProgressWithCourses(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch progress"})
 		return
 	}
 
-	// If no progress exists, initialize it
+	// If no progress exists, initialize it, unless the learner is in a cohort
+	// and the list is empty because their cohorts don't assign any courses
 	if len(coursesWithProgress) == 0 {
-		if err := h.Repo.InitializeUserProgress(userID); err != nil {
+		inCohort, err := h.inAnyCohort(c, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch progress"})
+			return
+		}
+		if inCohort {
+			c.JSON(http.StatusOK, coursesWithProgress)
+			return
+		}
+		if err := h.Repo.InitializeUserProgress(c.Request.Context(), userID); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initialize progress"})
 			return
 		}
 		// Fetch again after initialization
-		coursesWithProgress, err = h.Repo.GetUserProgressWithCourses(userID)
+		coursesWithProgress, err = h.Repo.GetUserProgressWithCourses(c.Request.Context(), userID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch progress"})
 			return
 		}
 	}
 
+	for i := range coursesWithProgress {
+		coursesWithProgress[i].Course = coursesWithProgress[i].Course.Public()
+	}
+
 	c.JSON(http.StatusOK, coursesWithProgress)
 }
 
@@ -105,46 +182,89 @@ func (h *Handler) CompleteModule(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
+	if !primitive.IsValidObjectID(req.CourseID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		return
+	}
 
-	if err := h.Repo.MarkModuleComplete(userID, req.CourseID, req.ModuleID); err != nil {
+	unpassed, err := h.unpassedQuizzes(c.Request.Context(), userID, req.CourseID, req.ModuleID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark module as complete"})
 		return
 	}
+	if len(unpassed) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "Pass the module's quizzes before completing it",
+			"quizzes": unpassed,
+		})
+		return
+	}
+
+	err = h.Repo.MarkModuleComplete(c.Request.Context(), userID, req.CourseID, req.ModuleID)
+	var unmet *repository.PrerequisitesError
+	switch {
+	case errors.As(err, &unmet):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "Complete the module's prerequisites first",
+			"courses": unmet.Courses,
+			"modules": unmet.Modules,
+		})
+		return
+	case errors.Is(err, mongo.ErrNoDocuments):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	case errors.Is(err, repository.ErrModuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Module not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark module as complete"})
+		return
+	}
+
+	userObjectID, _ := primitive.ObjectIDFromHex(userID)
+	courseObjectID, _ := primitive.ObjectIDFromHex(req.CourseID)
+	h.recordActivity(c, models.ActivityEvent{
+		UserID:   userObjectID,
+		Type:     models.ActivityModuleCompleted,
+		CourseID: courseObjectID,
+		ModuleID: req.ModuleID,
+	})
+
+	// Badges and certificates are awarded as they're earned; the completion
+	// stands if that fails
+	_, newBadges, err := h.updateAchievements(c.Request.Context(), userObjectID)
+	if err != nil {
+		log.Printf("Failed to update achievements for user %s: %v", userID, err)
+		newBadges = []models.UserBadge{}
+	}
+	newCertificates, err := h.issueCertificates(c.Request.Context(), userObjectID)
+	if err != nil {
+		log.Printf("Failed to issue certificates for user %s: %v", userID, err)
+		newCertificates = []models.Certificate{}
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":   "Module marked as complete",
-		"course_id": req.CourseID,
-		"module_id": req.ModuleID,
+		"message":          "Module marked as complete",
+		"course_id":        req.CourseID,
+		"module_id":        req.ModuleID,
+		"new_badges":       newBadges,
+		"new_certificates": newCertificates,
 	})
 }
 
 // AdminSeedCourses reseeds the courses collection.
-// This is intentionally protected by an env var + header token so it can't be triggered accidentally.
-//
-// To enable:
-// - Set ADMIN_SEED_TOKEN on the backend service
-// - Call POST /api/admin/seed with header X-Admin-Seed-Token: <token>
+// The route requires an admin JWT, or the X-Admin-Seed-Token header matching
+// ADMIN_SEED_TOKEN to bootstrap a deployment that has no admin account yet.
 func (h *Handler) AdminSeedCourses(c *gin.Context) {
-	seedToken := os.Getenv("ADMIN_SEED_TOKEN")
-	if seedToken == "" {
-		// Hide the endpoint if not configured
-		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
-		return
-	}
-
-	provided := c.GetHeader("X-Admin-Seed-Token")
-	if provided == "" || provided != seedToken {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
-		return
-	}
-
-	if err := seed.SeedCourses(h.Repo); err != nil {
+	changes, err := seed.SeedCourses(c.Request.Context(), h.Repo)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to seed courses"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":   "Courses seeded successfully",
-		"seeded_at": time.Now().UTC().Format(time.RFC3339),
+		"message":          "Courses seeded successfully",
+		"seeded_at":        time.Now().UTC().Format(time.RFC3339),
+		"progress_changes": changes,
 	})
 }
@@ -0,0 +1,514 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/middleware"
+	"github.com/pathway/backend/models"
+	"github.com/pathway/backend/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// testCourses returns a small curriculum so tests don't depend on the seed content
+func testCourses() []models.Course {
+	return []models.Course{
+		{
+			Slug:        "git",
+			Title:       "Git",
+			Description: "Version control",
+			Modules: []models.Module{
+				{ID: "git-1", Title: "Intro"},
+				{ID: "git-2", Title: "Branching"},
+			},
+		},
+		{
+			Slug:        "http",
+			Title:       "HTTP",
+			Description: "Networking",
+			Modules: []models.Module{
+				{ID: "http-1", Title: "Requests"},
+			},
+		},
+	}
+}
+
+// newTestServer builds a router with the same routes as main.go on top of an in-memory repository
+func newTestServer(t *testing.T) (*gin.Engine, *repository.MemoryRepository) {
+	t.Helper()
+	return newTestServerWith(t, func(*Handler) {})
+}
+
+// newTestServerWith is newTestServer with the handler adjusted by configure,
+// e.g. to run code or capture email
+func newTestServerWith(t *testing.T, configure func(h *Handler)) (*gin.Engine, *repository.MemoryRepository) {
+	t.Helper()
+	ctx := context.Background()
+
+	repo := repository.NewMemoryRepository()
+	for _, course := range testCourses() {
+		course := course
+		if err := repo.UpsertCourseBySlug(ctx, &course); err != nil {
+			t.Fatalf("seed course: %v", err)
+		}
+	}
+
+	h := NewHandler(repo)
+	configure(h)
+	rateLimits := middleware.DefaultRateLimitPolicies()
+	r := gin.New()
+	api := r.Group("/api", middleware.RateLimit(rateLimits.Global, middleware.ByIP))
+	api.GET("/health", h.HealthCheck)
+	public := api.Group("", middleware.RateLimit(rateLimits.Public, middleware.ByIP))
+	public.GET("/courses", h.GetCourses)
+	public.GET("/courses/:id", h.GetCourseByID)
+	public.GET("/certificates/:id/verify", h.VerifyCertificate)
+
+	auth := api.Group("/auth", middleware.RateLimit(rateLimits.Auth, middleware.ByIP))
+	auth.POST("/register", h.Register)
+	auth.POST("/login", h.Login)
+	auth.POST("/refresh", h.Refresh)
+	auth.POST("/logout", middleware.AuthMiddleware(repo), h.Logout)
+	auth.POST("/forgot-password", h.ForgotPassword)
+	auth.POST("/reset-password", h.ResetPassword)
+	auth.POST("/verify-email", h.VerifyEmail)
+	auth.POST("/resend-verification", middleware.AuthMiddleware(repo), h.ResendVerificationEmail)
+	auth.GET("/oauth/providers", h.GetOAuthProviders)
+	auth.GET("/oauth/:provider", h.StartOAuthLogin)
+	auth.GET("/oauth/:provider/callback", h.FinishOAuthLogin)
+
+	user := api.Group("/user")
+	user.Use(middleware.AuthMiddleware(repo), middleware.RateLimit(rateLimits.User, middleware.ByUserOrIP))
+	user.GET("/me", h.GetCurrentUser)
+	user.GET("/progress", h.GetUserProgress)
+	user.POST("/progress/complete", h.RequireVerifiedEmail, h.CompleteModule)
+	user.GET("/progress/resets", h.GetProgressResets)
+	user.DELETE("/progress/courses/:courseId", h.RequireVerifiedEmail, h.ResetCourseProgress)
+	user.DELETE("/progress/courses/:courseId/modules/:moduleId", h.RequireVerifiedEmail, h.UncompleteModule)
+	user.GET("/activity", h.GetActivity)
+	user.POST("/courses/:courseId/modules/:moduleId/open", h.RequireVerifiedEmail, h.OpenModule)
+	user.GET("/achievements", h.GetAchievements)
+	user.GET("/certificates", h.GetCertificates)
+	user.GET("/certificates/:id", h.GetCertificate)
+	user.GET("/certificates/:id/download", h.DownloadCertificate)
+	user.GET("/cohorts", h.GetMyCohorts)
+	exercise := "/courses/:courseId/modules/:moduleId/exercises/:exerciseId"
+	user.GET(exercise, h.GetExercise)
+	user.POST(exercise+"/attempts", h.RequireVerifiedEmail, h.SubmitExerciseAttempt)
+	user.POST(exercise+"/hints", h.RequireVerifiedEmail, h.RevealExerciseHint)
+	user.POST(exercise+"/solution", h.RequireVerifiedEmail, h.RevealExerciseSolution)
+	user.GET(exercise+"/runs", h.GetExerciseRuns)
+	user.POST(exercise+"/runs", h.RequireVerifiedEmail, h.RunExerciseCode)
+	quiz := "/courses/:courseId/modules/:moduleId/quizzes/:quizId"
+	user.GET(quiz, h.GetQuiz)
+	user.POST(quiz+"/attempts", h.RequireVerifiedEmail, h.SubmitQuizAttempt)
+
+	api.POST("/admin/seed", middleware.SeedTokenOrRole(repo, models.RoleAdmin), h.AdminSeedCourses)
+	admin := api.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(repo), middleware.RateLimit(rateLimits.Admin, middleware.ByUserOrIP))
+	users := admin.Group("/users", middleware.RequireRole(models.RoleAdmin))
+	users.PUT("/:id/role", h.AdminUpdateUserRole)
+	learners := admin.Group("/users/:id/progress", middleware.RequireRole(models.RoleAdmin, models.RoleInstructor))
+	learners.GET("/resets", h.GetProgressResets)
+	learners.DELETE("", middleware.RequireRole(models.RoleAdmin), h.ResetUserProgress)
+	learners.DELETE("/courses/:courseId", h.ResetCourseProgress)
+	learners.DELETE("/courses/:courseId/modules/:moduleId", h.UncompleteModule)
+	admin.GET("/users/:id/activity", middleware.RequireRole(models.RoleAdmin, models.RoleInstructor), h.GetActivity)
+	cohorts := admin.Group("/cohorts", middleware.RequireRole(models.RoleAdmin, models.RoleInstructor))
+	cohorts.POST("", h.CreateCohort)
+	cohorts.GET("", h.GetCohorts)
+	cohorts.GET("/:id", h.GetCohort)
+	cohorts.GET("/:id/members", h.GetCohortMembers)
+	cohorts.POST("/:id/members", h.EnrollCohortMembers)
+	cohorts.DELETE("/:id/members/:userId", h.RemoveCohortMember)
+	dashboard := admin.Group("/dashboard", middleware.RequireRole(models.RoleAdmin, models.RoleInstructor))
+	dashboard.GET("/courses", h.GetCourseCompletionStats)
+	dashboard.GET("/courses/:courseId/modules", h.GetModuleDropOff)
+	dashboard.GET("/courses/:courseId/matrix", h.GetCompletionMatrix)
+	dashboard.GET("/stalled", h.GetStalledLearners)
+	courses := admin.Group("/courses", middleware.RequireRole(models.RoleAdmin, models.RoleInstructor))
+	courses.POST("", h.CreateCourse)
+	courses.GET("/:id", h.AdminGetCourse)
+	courses.PATCH("/:id", h.UpdateCourse)
+	courses.DELETE("/:id", h.DeleteCourse)
+	courses.POST("/:id/modules", h.AddModule)
+	courses.PUT("/:id/modules/order", h.ReorderModules)
+	courses.PATCH("/:id/modules/:moduleId", h.UpdateModule)
+	courses.DELETE("/:id/modules/:moduleId", h.DeleteModule)
+	courses.POST("/:id/modules/:moduleId/blocks", h.AddContentBlock)
+	courses.PUT("/:id/modules/:moduleId/blocks/order", h.ReorderContentBlocks)
+	courses.PUT("/:id/modules/:moduleId/blocks/:index", h.UpdateContentBlock)
+	courses.DELETE("/:id/modules/:moduleId/blocks/:index", h.DeleteContentBlock)
+
+	return r, repo
+}
+
+// doJSON performs a request against the router, encoding body as JSON when non-nil
+func doJSON(r http.Handler, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+}
+
+// registerUser creates an account and returns its auth response
+func registerUser(t *testing.T, r http.Handler, email string) AuthResponse {
+	t.Helper()
+	w := doJSON(r, http.MethodPost, "/api/auth/register", "", RegisterRequest{
+		Name:     "Test User",
+		Email:    email,
+		Password: "password123",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp AuthResponse
+	decode(t, w, &resp)
+	return resp
+}
+
+// loginWithRole registers a user, gives them a role directly in the repository
+// and logs in again so the returned token carries the role
+func loginWithRole(t *testing.T, r http.Handler, repo *repository.MemoryRepository, email, role string) AuthResponse {
+	t.Helper()
+	registered := registerUser(t, r, email)
+	if err := repo.UpdateUserRole(context.Background(), registered.User.ID.Hex(), role); err != nil {
+		t.Fatalf("set role: %v", err)
+	}
+	w := doJSON(r, http.MethodPost, "/api/auth/login", "", LoginRequest{Email: email, Password: "password123"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: expected 200, got %d", w.Code)
+	}
+	var resp AuthResponse
+	decode(t, w, &resp)
+	return resp
+}
+
+func TestHealthCheck(t *testing.T) {
+	r, _ := newTestServer(t)
+
+	w := doJSON(r, http.MethodGet, "/api/health", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestGetCourses(t *testing.T) {
+	r, _ := newTestServer(t)
+
+	w := doJSON(r, http.MethodGet, "/api/courses", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var courses []models.Course
+	decode(t, w, &courses)
+	if len(courses) != 2 || courses[0].Slug != "git" || courses[1].Slug != "http" {
+		t.Fatalf("unexpected courses: %+v", courses)
+	}
+}
+
+func TestGetCourseByIDOrSlug(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestServer(t)
+	git, _ := repo.GetCourseBySlug(ctx, "git")
+
+	for _, id := range []string{git.ID.Hex(), "git"} {
+		w := doJSON(r, http.MethodGet, "/api/courses/"+id, "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d", id, w.Code)
+		}
+		var course models.Course
+		decode(t, w, &course)
+		if course.ID != git.ID {
+			t.Fatalf("GET %s: got course %s, want %s", id, course.ID.Hex(), git.ID.Hex())
+		}
+	}
+
+	for _, id := range []string{"000000000000000000000000", "no-such-course"} {
+		if w := doJSON(r, http.MethodGet, "/api/courses/"+id, "", nil); w.Code != http.StatusNotFound {
+			t.Fatalf("GET %s: expected 404, got %d", id, w.Code)
+		}
+	}
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	r, _ := newTestServer(t)
+
+	resp := registerUser(t, r, "learner@example.com")
+	if resp.Token == "" || resp.User.Role != "student" {
+		t.Fatalf("unexpected register response: %+v", resp)
+	}
+
+	w := doJSON(r, http.MethodPost, "/api/auth/register", "", RegisterRequest{
+		Name: "Again", Email: "learner@example.com", Password: "password123",
+	})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("duplicate register: expected 409, got %d", w.Code)
+	}
+
+	w = doJSON(r, http.MethodPost, "/api/auth/login", "", LoginRequest{
+		Email: "learner@example.com", Password: "wrong-password",
+	})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("bad password: expected 401, got %d", w.Code)
+	}
+
+	w = doJSON(r, http.MethodPost, "/api/auth/login", "", LoginRequest{
+		Email: "learner@example.com", Password: "password123",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: expected 200, got %d", w.Code)
+	}
+	var login AuthResponse
+	decode(t, w, &login)
+
+	w = doJSON(r, http.MethodGet, "/api/user/me", login.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("me: expected 200, got %d", w.Code)
+	}
+	var me models.User
+	decode(t, w, &me)
+	if me.Email != "learner@example.com" {
+		t.Fatalf("me: unexpected user %+v", me)
+	}
+}
+
+func TestUserRoutesRequireAuth(t *testing.T) {
+	r, _ := newTestServer(t)
+
+	if w := doJSON(r, http.MethodGet, "/api/user/me", "", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("no token: expected 401, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodGet, "/api/user/me", "not-a-jwt", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("bad token: expected 401, got %d", w.Code)
+	}
+}
+
+func TestCompleteModulesCompletesCourse(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestServer(t)
+	token := registerUser(t, r, "learner@example.com").Token
+	git, _ := repo.GetCourseBySlug(ctx, "git")
+
+	for _, moduleID := range []string{"git-1", "git-1", "git-2"} {
+		w := doJSON(r, http.MethodPost, "/api/user/progress/complete", token, CompleteModuleRequest{
+			CourseID: git.ID.Hex(), ModuleID: moduleID,
+		})
+		if w.Code != http.StatusOK {
+			t.Fatalf("complete %s: expected 200, got %d: %s", moduleID, w.Code, w.Body.String())
+		}
+	}
+
+	w := doJSON(r, http.MethodGet, "/api/user/progress", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("progress: expected 200, got %d", w.Code)
+	}
+	var progress []models.CourseWithProgress
+	decode(t, w, &progress)
+	if len(progress) != 2 {
+		t.Fatalf("expected progress for 2 courses, got %d", len(progress))
+	}
+	if p := progress[0]; !p.IsCompleted || len(p.CompletedModules) != 2 || p.ProgressPercent != 100 {
+		t.Fatalf("git progress: %+v", p)
+	}
+	if p := progress[1]; p.IsCompleted || p.ProgressPercent != 0 {
+		t.Fatalf("http progress: %+v", p)
+	}
+}
+
+func TestCompleteModuleRejectsBadBody(t *testing.T) {
+	r, _ := newTestServer(t)
+	token := registerUser(t, r, "learner@example.com").Token
+
+	w := doJSON(r, http.MethodPost, "/api/user/progress/complete", token, map[string]string{"course_id": "x"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestCompleteModuleRejectsUnknownModules(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestServer(t)
+	token := registerUser(t, r, "learner@example.com").Token
+	git, _ := repo.GetCourseBySlug(ctx, "git")
+
+	cases := []struct {
+		name     string
+		courseID string
+		moduleID string
+		want     int
+	}{
+		{"invalid course ID", "not-hex", "git-1", http.StatusBadRequest},
+		{"unknown course", primitive.NewObjectID().Hex(), "git-1", http.StatusNotFound},
+		{"module of another course", git.ID.Hex(), "http-1", http.StatusNotFound},
+		{"junk module", git.ID.Hex(), "junk", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		w := doJSON(r, http.MethodPost, "/api/user/progress/complete", token, CompleteModuleRequest{
+			CourseID: tc.courseID, ModuleID: tc.moduleID,
+		})
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", tc.name, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	// Rejected IDs aren't stored, so they can't add up to a completed course
+	w := doJSON(r, http.MethodPost, "/api/user/progress/complete", token, CompleteModuleRequest{
+		CourseID: git.ID.Hex(), ModuleID: "git-1",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var progress []models.CourseWithProgress
+	decode(t, doJSON(r, http.MethodGet, "/api/user/progress", token, nil), &progress)
+	if p := progress[0]; p.IsCompleted || len(p.CompletedModules) != 1 || p.ProgressPercent != 50 {
+		t.Fatalf("git progress: %+v", p)
+	}
+}
+
+func TestAdminSeedCoursesKeepsProgress(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestServer(t)
+	token := registerUser(t, r, "learner@example.com").Token
+	git, _ := repo.GetCourseBySlug(ctx, "git")
+
+	w := doJSON(r, http.MethodPost, "/api/user/progress/complete", token, CompleteModuleRequest{
+		CourseID: git.ID.Hex(), ModuleID: "git-1",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("complete: expected 200, got %d", w.Code)
+	}
+
+	if w := doJSON(r, http.MethodPost, "/api/admin/seed", "", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous seed: expected 401, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, "/api/admin/seed", token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("student seed: expected 403, got %d", w.Code)
+	}
+
+	t.Setenv("ADMIN_SEED_TOKEN", "seed-secret")
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/seed", nil)
+	req.Header.Set("X-Admin-Seed-Token", "wrong")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("wrong seed token: expected 403, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/admin/seed", nil)
+	req.Header.Set("X-Admin-Seed-Token", "seed-secret")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("seed: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The seed replaces the test "git" course in place, keeping its ID
+	reseeded, err := repo.GetCourseBySlug(ctx, "git")
+	if err != nil || reseeded.ID != git.ID {
+		t.Fatalf("git course ID changed after reseed: %v", err)
+	}
+
+	w = doJSON(r, http.MethodGet, "/api/user/progress", token, nil)
+	var progress []models.CourseWithProgress
+	decode(t, w, &progress)
+	for _, p := range progress {
+		if p.Course.ID == git.ID {
+			if len(p.CompletedModules) != 1 || p.CompletedModules[0] != "git-1" {
+				t.Fatalf("progress lost after reseed: %+v", p.CompletedModules)
+			}
+			return
+		}
+	}
+	t.Fatal("git course missing from progress after reseed")
+}
+
+func TestAdminSeedCoursesWithAdminToken(t *testing.T) {
+	r, repo := newTestServer(t)
+	admin := loginWithRole(t, r, repo, "admin@example.com", models.RoleAdmin)
+
+	if w := doJSON(r, http.MethodPost, "/api/admin/seed", admin.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("admin seed: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCompleteModuleRequiresPrerequisites(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestServer(t)
+	token := registerUser(t, r, "learner@example.com").Token
+	git, _ := repo.GetCourseBySlug(ctx, "git")
+	httpCourse, _ := repo.GetCourseBySlug(ctx, "http")
+
+	sequential, prerequisites := true, []string{"git"}
+	if _, err := repo.UpdateCourse(ctx, git.ID.Hex(), models.CourseUpdate{Sequential: &sequential}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.UpdateCourse(ctx, httpCourse.ID.Hex(), models.CourseUpdate{Prerequisites: &prerequisites}); err != nil {
+		t.Fatal(err)
+	}
+
+	complete := func(course *models.Course, moduleID string) *httptest.ResponseRecorder {
+		return doJSON(r, http.MethodPost, "/api/user/progress/complete", token, CompleteModuleRequest{
+			CourseID: course.ID.Hex(), ModuleID: moduleID,
+		})
+	}
+
+	var body struct {
+		Courses []string `json:"courses"`
+		Modules []string `json:"modules"`
+	}
+	w := complete(git, "git-2")
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("out of order: expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+	decode(t, w, &body)
+	if len(body.Courses) != 0 || len(body.Modules) != 1 || body.Modules[0] != "git-1" {
+		t.Fatalf("unexpected unmet prerequisites: %+v", body)
+	}
+	if w := complete(httpCourse, "http-1"); w.Code != http.StatusForbidden {
+		t.Fatalf("locked course: expected 403, got %d", w.Code)
+	}
+
+	var progress []models.CourseWithProgress
+	decode(t, doJSON(r, http.MethodGet, "/api/user/progress", token, nil), &progress)
+	if p := progress[0]; p.Locked || p.Modules[0].Locked || !p.Modules[1].Locked {
+		t.Fatalf("git progress: %+v", p)
+	}
+	if p := progress[1]; !p.Locked || len(p.UnmetPrerequisites) != 1 || !p.Modules[0].Locked {
+		t.Fatalf("http progress: %+v", p)
+	}
+
+	for _, moduleID := range []string{"git-1", "git-2"} {
+		if w := complete(git, moduleID); w.Code != http.StatusOK {
+			t.Fatalf("complete %s: expected 200, got %d", moduleID, w.Code)
+		}
+	}
+	if w := complete(httpCourse, "http-1"); w.Code != http.StatusOK {
+		t.Fatalf("unlocked course: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
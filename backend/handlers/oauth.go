@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pathway/backend/middleware"
+	"github.com/pathway/backend/models"
+	"github.com/pathway/backend/oauth"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// oauthStateCookie holds the state, nonce and PKCE verifier of a sign-in in
+// progress, binding the callback to the browser that started it
+const oauthStateCookie = "oauth_state"
+
+// oauthStateTTL is how long a user has to sign in at the provider
+const oauthStateTTL = 10 * time.Minute
+
+// errEmailNotVerified means the provider hasn't verified the account's email,
+// so it can't be matched to a user
+var errEmailNotVerified = errors.New("provider email not verified")
+
+// oauthStateClaims are signed into the state cookie
+type oauthStateClaims struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	jwt.RegisteredClaims
+}
+
+// GetOAuthProviders lists the providers users can sign in with
+func (h *Handler) GetOAuthProviders(c *gin.Context) {
+	names := make([]string, 0, len(h.OAuthProviders))
+	for name := range h.OAuthProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	c.JSON(http.StatusOK, gin.H{"providers": names})
+}
+
+// StartOAuthLogin redirects the browser to the provider's sign-in page
+func (h *Handler) StartOAuthLogin(c *gin.Context) {
+	provider, ok := h.OAuthProviders[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown sign-in provider"})
+		return
+	}
+
+	claims := oauthStateClaims{Provider: provider.Name}
+	var err error
+	if claims.State, err = oauth.NewState(); err == nil {
+		if claims.Nonce, err = oauth.NewState(); err == nil {
+			claims.Verifier, err = oauth.NewPKCE()
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sign-in"})
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), h.oauthCallbackURL(provider.Name), claims.State, claims.Nonce, claims.Verifier)
+	if err != nil {
+		log.Printf("Failed to start %s sign-in: %v", provider.Name, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Sign-in provider unavailable"})
+		return
+	}
+
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(oauthStateTTL))
+	cookie, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(oauthStateKey())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sign-in"})
+		return
+	}
+	h.setOAuthStateCookie(c, cookie, int(oauthStateTTL/time.Second))
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// FinishOAuthLogin handles the provider redirecting back after sign-in. It
+// finds or creates the user, then redirects to the frontend's
+// OAuthRedirectURL with the same tokens as login in the URL fragment, which
+// browsers don't send to servers: #token=...&refresh_token=...&expires_in=...
+// Failures redirect there too, with #error=access_denied, invalid_state,
+// email_not_verified or login_failed.
+func (h *Handler) FinishOAuthLogin(c *gin.Context) {
+	provider, ok := h.OAuthProviders[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown sign-in provider"})
+		return
+	}
+
+	// The state cookie works once, whatever happens next
+	cookie, _ := c.Cookie(oauthStateCookie)
+	h.setOAuthStateCookie(c, "", -1)
+
+	if c.Query("error") != "" {
+		h.finishOAuthRedirect(c, url.Values{"error": {"access_denied"}})
+		return
+	}
+
+	var claims oauthStateClaims
+	_, err := jwt.ParseWithClaims(cookie, &claims, func(*jwt.Token) (interface{}, error) {
+		return oauthStateKey(), nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithExpirationRequired())
+	if err != nil || claims.Provider != provider.Name || c.Query("code") == "" ||
+		subtle.ConstantTimeCompare([]byte(claims.State), []byte(c.Query("state"))) != 1 {
+		h.finishOAuthRedirect(c, url.Values{"error": {"invalid_state"}})
+		return
+	}
+
+	ctx := c.Request.Context()
+	identity, err := provider.Exchange(ctx, c.Query("code"), h.oauthCallbackURL(provider.Name), claims.Nonce, claims.Verifier)
+	if err != nil {
+		log.Printf("Failed to complete %s sign-in: %v", provider.Name, err)
+		h.finishOAuthRedirect(c, url.Values{"error": {"login_failed"}})
+		return
+	}
+
+	user, err := h.oauthUser(ctx, provider.Name, identity)
+	if errors.Is(err, errEmailNotVerified) {
+		h.finishOAuthRedirect(c, url.Values{"error": {"email_not_verified"}})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to find or create user for %s sign-in: %v", provider.Name, err)
+		h.finishOAuthRedirect(c, url.Values{"error": {"login_failed"}})
+		return
+	}
+
+	resp, err := h.issueTokens(ctx, user, "")
+	if err != nil {
+		h.finishOAuthRedirect(c, url.Values{"error": {"login_failed"}})
+		return
+	}
+	h.recordActivity(c, models.ActivityEvent{UserID: user.ID, Type: models.ActivityLogin})
+
+	h.finishOAuthRedirect(c, url.Values{
+		"token":         {resp.Token},
+		"refresh_token": {resp.RefreshToken},
+		"expires_in":    {strconv.FormatInt(resp.ExpiresIn, 10)},
+	})
+}
+
+// oauthUser returns the user linked to the provider account. On first sign-in
+// the account is linked to the user with the same email, or a new user if
+// there is none, as long as the provider has verified the email.
+func (h *Handler) oauthUser(ctx context.Context, provider string, identity oauth.Identity) (*models.User, error) {
+	linked, err := h.Repo.GetUserIdentity(ctx, provider, identity.Subject)
+	if err == nil {
+		return h.Repo.GetUserByID(ctx, linked.UserID.Hex())
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	if !identity.EmailVerified {
+		return nil, errEmailNotVerified
+	}
+
+	user, err := h.Repo.GetUserByEmail(ctx, identity.Email)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		user, err = h.createOAuthUser(ctx, identity)
+	case err == nil && !user.EmailVerified:
+		err = h.claimUnverifiedUser(ctx, user)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	linked = &models.UserIdentity{
+		UserID:    user.ID,
+		Provider:  provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: time.Now().UTC(),
+	}
+	created, err := h.Repo.LinkUserIdentity(ctx, linked)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		// A concurrent sign-in linked the account first
+		existing, err := h.Repo.GetUserIdentity(ctx, provider, identity.Subject)
+		if err != nil {
+			return nil, err
+		}
+		return h.Repo.GetUserByID(ctx, existing.UserID.Hex())
+	}
+	return user, nil
+}
+
+// createOAuthUser creates a student with the provider's verified email and no
+// password; they can set one with forgot-password
+func (h *Handler) createOAuthUser(ctx context.Context, identity oauth.Identity) (*models.User, error) {
+	name := identity.Name
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+	now := time.Now().UTC()
+	user := &models.User{
+		Name:            name,
+		Email:           identity.Email,
+		Role:            models.RoleStudent,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+	}
+	if err := h.Repo.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if err := h.Repo.InitializeUserProgress(ctx, user.ID.Hex()); err != nil {
+		// Progress is initialized on first dashboard load otherwise
+		log.Printf("Failed to initialize progress for user %s: %v", user.ID.Hex(), err)
+	}
+	return user, nil
+}
+
+// claimUnverifiedUser hands a user whose email was never verified to the
+// provider account that has verified it. Whoever registered the user hasn't
+// proven they own the address, and may have registered it in anticipation,
+// so its password and refresh tokens are dropped first. Users stored before
+// verification existed count as verified, so they are never claimed.
+func (h *Handler) claimUnverifiedUser(ctx context.Context, user *models.User) error {
+	userID := user.ID.Hex()
+	if err := h.Repo.UpdateUserPassword(ctx, userID, ""); err != nil {
+		return err
+	}
+	if err := h.Repo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if err := h.Repo.SetUserEmailVerified(ctx, userID, now); err != nil {
+		return err
+	}
+	user.EmailVerified, user.EmailVerifiedAt = true, &now
+	return nil
+}
+
+// oauthCallbackURL is where the provider sends the browser back to
+func (h *Handler) oauthCallbackURL(provider string) string {
+	return strings.TrimSuffix(h.OAuthCallbackURL, "/") + "/" + url.PathEscape(provider) + "/callback"
+}
+
+// setOAuthStateCookie sets the state cookie for the callback URL's path, or
+// deletes it with a negative maxAge
+func (h *Handler) setOAuthStateCookie(c *gin.Context, value string, maxAge int) {
+	path := "/"
+	secure := false
+	if callback, err := url.Parse(h.OAuthCallbackURL); err == nil {
+		path = callback.Path
+		secure = callback.Scheme == "https"
+	}
+	c.SetSameSite(http.SameSiteLaxMode) // Sent on the provider's top-level redirect back
+	c.SetCookie(oauthStateCookie, value, maxAge, path, "", secure, true)
+}
+
+// finishOAuthRedirect sends the browser to the frontend with the outcome in the fragment
+func (h *Handler) finishOAuthRedirect(c *gin.Context, fragment url.Values) {
+	target := h.OAuthRedirectURL
+	if i := strings.IndexByte(target, '#'); i >= 0 {
+		target = target[:i]
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, target+"#"+fragment.Encode())
+}
+
+// oauthStateKey signs state cookies. It is derived from the JWT secret so a
+// state cookie can never pass as an access token.
+func oauthStateKey() []byte {
+	mac := hmac.New(sha256.New, middleware.GetJWTSecret())
+	mac.Write([]byte("oauth-state"))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/models"
+	"github.com/pathway/backend/oauth"
+	"github.com/pathway/backend/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newOAuthTestServer is newTestServer with the mock identity provider signed in
+// to as "mock"
+func newOAuthTestServer(t *testing.T) (*gin.Engine, *repository.MemoryRepository) {
+	t.Helper()
+	idp, err := oauth.NewMockIdP("pathway", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(idp)
+	t.Cleanup(srv.Close)
+
+	return newTestServerWith(t, func(h *Handler) {
+		h.OAuthProviders["mock"] = oauth.NewOIDC("mock", srv.URL, "pathway", "s3cret")
+		h.OAuthRedirectURL = "https://app.example.com/oauth/callback"
+	})
+}
+
+// oauthStart begins a sign-in and returns the provider URL and the state cookie
+func oauthStart(t *testing.T, r http.Handler) (string, *http.Cookie) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/auth/oauth/mock", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("start: expected 302, got %d: %s", w.Code, w.Body.String())
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != oauthStateCookie || !cookies[0].HttpOnly || cookies[0].Path != "/api/auth/oauth" {
+		t.Fatalf("expected an HttpOnly state cookie, got %+v", cookies)
+	}
+	return w.Header().Get("Location"), cookies[0]
+}
+
+// oauthCallback sends the browser back from the provider and returns the
+// fragment of the frontend URL it is redirected to
+func oauthCallback(t *testing.T, r http.Handler, callback string, cookie *http.Cookie) url.Values {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, callback, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusFound {
+		t.Fatalf("callback: expected 302, got %d: %s", w.Code, w.Body.String())
+	}
+	location, _ := url.Parse(w.Header().Get("Location"))
+	if location.Host != "app.example.com" || location.Path != "/oauth/callback" {
+		t.Fatalf("callback redirected to %s", location)
+	}
+	fragment, err := url.ParseQuery(location.Fragment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fragment
+}
+
+// oauthSignIn signs in at the mock provider as the given account and returns
+// the fragment the frontend receives
+func oauthSignIn(t *testing.T, r http.Handler, account url.Values) url.Values {
+	t.Helper()
+	authURL, cookie := oauthStart(t, r)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL + "&" + account.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback, _ := url.Parse(resp.Header.Get("Location"))
+	if callback.Path != "/api/auth/oauth/mock/callback" {
+		t.Fatalf("provider redirected to %s", callback)
+	}
+	return oauthCallback(t, r, callback.RequestURI(), cookie)
+}
+
+func TestOAuthLogin(t *testing.T) {
+	r, repo := newOAuthTestServer(t)
+
+	w := doJSON(r, http.MethodGet, "/api/auth/oauth/providers", "", nil)
+	var listed struct{ Providers []string }
+	decode(t, w, &listed)
+	if len(listed.Providers) != 1 || listed.Providers[0] != "mock" {
+		t.Fatalf("unexpected providers: %s", w.Body.String())
+	}
+
+	// First sign-in creates a verified student
+	fragment := oauthSignIn(t, r, url.Values{"email": {"ada@example.com"}, "name": {"Ada Lovelace"}, "sub": {"ada"}})
+	if fragment.Get("error") != "" || fragment.Get("refresh_token") == "" || fragment.Get("expires_in") == "" {
+		t.Fatalf("unexpected fragment: %v", fragment)
+	}
+	w = doJSON(r, http.MethodGet, "/api/user/me", fragment.Get("token"), nil)
+	var user models.User
+	decode(t, w, &user)
+	if user.Email != "ada@example.com" || user.Name != "Ada Lovelace" || !user.EmailVerified || user.Role != models.RoleStudent {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+
+	// The refresh token works like one from a password login
+	if w := doJSON(r, http.MethodPost, "/api/auth/refresh", "", RefreshRequest{RefreshToken: fragment.Get("refresh_token")}); w.Code != http.StatusOK {
+		t.Fatalf("refresh: expected 200, got %d", w.Code)
+	}
+
+	// Signing in again, even with a changed email, finds the linked user
+	fragment = oauthSignIn(t, r, url.Values{"email": {"ada@new.example.com"}, "sub": {"ada"}})
+	w = doJSON(r, http.MethodGet, "/api/user/me", fragment.Get("token"), nil)
+	var again models.User
+	decode(t, w, &again)
+	if again.ID != user.ID {
+		t.Fatalf("expected the linked user %s, got %s", user.ID.Hex(), again.ID.Hex())
+	}
+
+	logins, err := repo.GetActivity(context.Background(), models.ActivityFilter{UserID: user.ID, Types: []string{models.ActivityLogin}})
+	if err != nil || len(logins) != 2 {
+		t.Fatalf("expected 2 login events, got %d: %v", len(logins), err)
+	}
+}
+
+func TestOAuthLoginLinksByEmail(t *testing.T) {
+	r, repo := newOAuthTestServer(t)
+	ctx := context.Background()
+
+	// A verified user keeps their password and sessions
+	verified := registerUser(t, r, "bob@example.com")
+	if err := repo.SetUserEmailVerified(ctx, verified.User.ID.Hex(), time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	fragment := oauthSignIn(t, r, url.Values{"email": {"bob@example.com"}, "sub": {"bob"}})
+	w := doJSON(r, http.MethodGet, "/api/user/me", fragment.Get("token"), nil)
+	var user models.User
+	decode(t, w, &user)
+	if user.ID != verified.User.ID {
+		t.Fatalf("expected to sign in as %s, got %s", verified.User.ID.Hex(), user.ID.Hex())
+	}
+	if w := doJSON(r, http.MethodPost, "/api/auth/login", "", LoginRequest{Email: "bob@example.com", Password: "password123"}); w.Code != http.StatusOK {
+		t.Fatalf("password login after linking: expected 200, got %d", w.Code)
+	}
+
+	// Whoever registered an unverified user loses it to the verified owner
+	squatter := registerUser(t, r, "carol@example.com")
+	fragment = oauthSignIn(t, r, url.Values{"email": {"carol@example.com"}, "sub": {"carol"}})
+	w = doJSON(r, http.MethodGet, "/api/user/me", fragment.Get("token"), nil)
+	decode(t, w, &user)
+	if user.ID != squatter.User.ID || !user.EmailVerified {
+		t.Fatalf("expected the unverified user to be claimed and verified, got %+v", user)
+	}
+	if w := doJSON(r, http.MethodPost, "/api/auth/login", "", LoginRequest{Email: "carol@example.com", Password: "password123"}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("squatter's password: expected 401, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, "/api/auth/refresh", "", RefreshRequest{RefreshToken: squatter.RefreshToken}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("squatter's refresh token: expected 401, got %d", w.Code)
+	}
+
+	// Emails the provider hasn't verified neither link nor create users
+	fragment = oauthSignIn(t, r, url.Values{"email": {"bob@example.com"}, "sub": {"mallory"}, "email_verified": {"false"}})
+	if fragment.Get("error") != "email_not_verified" || fragment.Get("token") != "" {
+		t.Fatalf("unverified email: unexpected fragment %v", fragment)
+	}
+	if _, err := repo.GetUserIdentity(ctx, "mock", "mallory"); err == nil {
+		t.Fatal("unverified email should not be linked")
+	}
+}
+
+func TestOAuthLoginKeepsLegacyUsers(t *testing.T) {
+	r, repo := newOAuthTestServer(t)
+	ctx := context.Background()
+
+	// Users stored before email verification existed have no email_verified field
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := bson.Marshal(bson.M{"name": "Dana", "email": "dana@example.com", "password": string(hashed), "role": models.RoleAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var legacy models.User
+	if err := bson.Unmarshal(raw, &legacy); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateUser(ctx, &legacy); err != nil {
+		t.Fatal(err)
+	}
+	w := doJSON(r, http.MethodPost, "/api/auth/login", "", LoginRequest{Email: "dana@example.com", Password: "password123"})
+	var session AuthResponse
+	decode(t, w, &session)
+
+	fragment := oauthSignIn(t, r, url.Values{"email": {"dana@example.com"}, "sub": {"dana"}})
+	w = doJSON(r, http.MethodGet, "/api/user/me", fragment.Get("token"), nil)
+	var user models.User
+	decode(t, w, &user)
+	if user.ID != legacy.ID || user.Role != models.RoleAdmin {
+		t.Fatalf("expected to sign in as the legacy user, got %+v", user)
+	}
+	if w := doJSON(r, http.MethodPost, "/api/auth/login", "", LoginRequest{Email: "dana@example.com", Password: "password123"}); w.Code != http.StatusOK {
+		t.Fatalf("legacy user's password: expected 200, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, "/api/auth/refresh", "", RefreshRequest{RefreshToken: session.RefreshToken}); w.Code != http.StatusOK {
+		t.Fatalf("legacy user's refresh token: expected 200, got %d", w.Code)
+	}
+}
+
+func TestOAuthLoginRejectsBadCallbacks(t *testing.T) {
+	r, _ := newOAuthTestServer(t)
+
+	if w := doJSON(r, http.MethodGet, "/api/auth/oauth/unknown", "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown provider: expected 404, got %d", w.Code)
+	}
+
+	authURL, cookie := oauthStart(t, r)
+	parsed, _ := url.Parse(authURL)
+	state := parsed.Query().Get("state")
+	if parsed.Query().Get("code_challenge_method") != "S256" || state == "" {
+		t.Fatalf("expected PKCE and state in %s", authURL)
+	}
+
+	for name, tc := range map[string]struct {
+		query  string
+		cookie *http.Cookie
+		want   string
+	}{
+		"denied at the provider": {"error=access_denied&state=" + state, cookie, "access_denied"},
+		"no state cookie":        {"code=abc&state=" + state, nil, "invalid_state"},
+		"mismatched state":       {"code=abc&state=forged", cookie, "invalid_state"},
+		"forged cookie":          {"code=abc&state=" + state, &http.Cookie{Name: oauthStateCookie, Value: cookie.Value + "x"}, "invalid_state"},
+		"unknown code":           {"code=abc&state=" + state, cookie, "login_failed"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fragment := oauthCallback(t, r, "/api/auth/oauth/mock/callback?"+tc.query, tc.cookie)
+			if fragment.Get("error") != tc.want || fragment.Get("token") != "" {
+				t.Fatalf("expected error %s, got %v", tc.want, fragment)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/mailer"
+	"github.com/pathway/backend/models"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// How long a password reset link works, overridable with PASSWORD_RESET_TTL (a Go duration)
+var passwordResetTTL = durationFromEnv("PASSWORD_RESET_TTL", time.Hour)
+
+// mailTimeout bounds how long sending one email may take
+const mailTimeout = 30 * time.Second
+
+// ForgotPassword emails a single-use password reset link. It responds the same
+// way whether or not the email belongs to an account, so it can't be used to
+// find out who has one.
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.Repo.GetUserByEmail(c.Request.Context(), req.Email)
+	if err == nil && user != nil {
+		if err := h.sendPasswordReset(c.Request.Context(), user); err != nil {
+			log.Printf("Failed to start password reset for user %s: %v", user.ID.Hex(), err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If an account exists for that email, a password reset link has been sent"})
+}
+
+// ResetPassword sets a new password using a token from a reset email. The token
+// and any others issued to the user stop working, and the user is signed out of
+// every session by revoking their refresh tokens.
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Hash first so a failure here doesn't use up the token
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	token, err := h.Repo.UsePasswordResetToken(ctx, hashToken(req.Token))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	userID := token.UserID.Hex()
+	if err := h.Repo.UpdateUserPassword(ctx, userID, string(hashedPassword)); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	if err := h.Repo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		log.Printf("Failed to revoke refresh tokens for user %s after password reset: %v", userID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+}
+
+// sendPasswordReset stores a new reset token for the user and emails them a link
+// to PasswordResetURL carrying it
+func (h *Handler) sendPasswordReset(ctx context.Context, user *models.User) error {
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	link, err := tokenLink(h.PasswordResetURL, token)
+	if err != nil {
+		return fmt.Errorf("invalid password reset URL: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if err := h.Repo.CreatePasswordResetToken(ctx, &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(passwordResetTTL),
+	}); err != nil {
+		return err
+	}
+
+	h.sendInBackground(user, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Pathway password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Someone asked to reset the password for your Pathway account. "+
+			"To choose a new password, open this link within %.0f minutes:\n\n"+
+			"%s\n\n"+
+			"If it wasn't you, ignore this email and your password won't change.\n",
+			user.Name, passwordResetTTL.Minutes(), link),
+	})
+	return nil
+}
+
+// sendInBackground emails the user without waiting, so responses take as long
+// whether or not an email is sent. Failures are logged.
+func (h *Handler) sendInBackground(user *models.User, msg mailer.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+		defer cancel()
+		if err := h.Mailer.Send(ctx, msg); err != nil {
+			log.Printf("Failed to send %q email to user %s: %v", msg.Subject, user.ID.Hex(), err)
+		}
+	}()
+}
+
+// tokenLink adds a token to a frontend URL as ?token=, keeping any existing query
+func tokenLink(base string, token string) (string, error) {
+	link, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pathway/backend/mailer"
+)
+
+// outbox is a mailer.Sender that hands sent messages to the test
+type outbox chan mailer.Message
+
+func (o outbox) Send(ctx context.Context, msg mailer.Message) error {
+	o <- msg
+	return nil
+}
+
+// next waits for the next email with the given subject, skipping others and
+// failing the test if none is sent
+func (o outbox) next(t *testing.T, subject string) mailer.Message {
+	t.Helper()
+	for {
+		select {
+		case msg := <-o:
+			if msg.Subject == subject {
+				return msg
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %q email sent", subject)
+			return mailer.Message{}
+		}
+	}
+}
+
+var emailLink = regexp.MustCompile(`https://app\.example\.com/\S*`)
+
+// emailToken extracts the token from the link in a reset or verification email
+func emailToken(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+	link, err := url.Parse(emailLink.FindString(msg.Body))
+	if err != nil || link.Query().Get("token") == "" {
+		t.Fatalf("no link in email:\n%s", msg.Body)
+	}
+	if link.Query().Get("lang") != "en" {
+		t.Fatalf("link should keep the configured query: %s", link)
+	}
+	return link.Query().Get("token")
+}
+
+func TestPasswordReset(t *testing.T) {
+	mail := make(outbox, 10)
+	r, _ := newTestServerWith(t, func(h *Handler) {
+		h.Mailer = mail
+		h.PasswordResetURL = "https://app.example.com/reset?lang=en"
+	})
+	session := registerUser(t, r, "learner@example.com")
+
+	w := doJSON(r, http.MethodPost, "/api/auth/forgot-password", "", ForgotPasswordRequest{Email: "learner@example.com"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("forgot password: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	msg := mail.next(t, "Reset your Pathway password")
+	if msg.To != "learner@example.com" {
+		t.Fatalf("email sent to %q", msg.To)
+	}
+	token := emailToken(t, msg)
+
+	// A weak password is rejected without using up the token
+	w = doJSON(r, http.MethodPost, "/api/auth/reset-password", "", ResetPasswordRequest{Token: token, Password: "abc"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("short password: expected 400, got %d", w.Code)
+	}
+
+	w = doJSON(r, http.MethodPost, "/api/auth/reset-password", "", ResetPasswordRequest{Token: token, Password: "new-password"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("reset password: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The old password no longer works, the new one does
+	if w := doJSON(r, http.MethodPost, "/api/auth/login", "", LoginRequest{Email: "learner@example.com", Password: "password123"}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("login with old password: expected 401, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, "/api/auth/login", "", LoginRequest{Email: "learner@example.com", Password: "new-password"}); w.Code != http.StatusOK {
+		t.Fatalf("login with new password: expected 200, got %d", w.Code)
+	}
+
+	// Existing sessions can't be refreshed
+	if w := doJSON(r, http.MethodPost, "/api/auth/refresh", "", RefreshRequest{RefreshToken: session.RefreshToken}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh after reset: expected 401, got %d", w.Code)
+	}
+
+	// Tokens are single-use
+	w = doJSON(r, http.MethodPost, "/api/auth/reset-password", "", ResetPasswordRequest{Token: token, Password: "another-password"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("reused token: expected 400, got %d", w.Code)
+	}
+}
+
+func TestPasswordResetUsesUpOtherTokens(t *testing.T) {
+	mail := make(outbox, 10)
+	r, _ := newTestServerWith(t, func(h *Handler) {
+		h.Mailer = mail
+		h.PasswordResetURL = "https://app.example.com/reset?lang=en"
+	})
+	registerUser(t, r, "learner@example.com")
+
+	var tokens []string
+	for i := 0; i < 2; i++ {
+		doJSON(r, http.MethodPost, "/api/auth/forgot-password", "", ForgotPasswordRequest{Email: "learner@example.com"})
+		tokens = append(tokens, emailToken(t, mail.next(t, "Reset your Pathway password")))
+	}
+
+	if w := doJSON(r, http.MethodPost, "/api/auth/reset-password", "", ResetPasswordRequest{Token: tokens[1], Password: "new-password"}); w.Code != http.StatusOK {
+		t.Fatalf("reset with newest token: expected 200, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, "/api/auth/reset-password", "", ResetPasswordRequest{Token: tokens[0], Password: "other-password"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("reset with older token: expected 400, got %d", w.Code)
+	}
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	mail := make(outbox, 10)
+	r, _ := newTestServerWith(t, func(h *Handler) { h.Mailer = mail })
+
+	w := doJSON(r, http.MethodPost, "/api/auth/forgot-password", "", ForgotPasswordRequest{Email: "nobody@example.com"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("unknown email: expected 200, got %d", w.Code)
+	}
+	select {
+	case msg := <-mail:
+		t.Fatalf("no email should be sent, got one to %s", msg.To)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if w := doJSON(r, http.MethodPost, "/api/auth/forgot-password", "", ForgotPasswordRequest{Email: "not-an-email"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid email: expected 400, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, "/api/auth/reset-password", "", ResetPasswordRequest{Token: "nope", Password: "new-password"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown token: expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/models"
+	"github.com/pathway/backend/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UncompleteModule removes a module from the learner's completed modules,
+// which also marks the course incomplete
+func (h *Handler) UncompleteModule(c *gin.Context) {
+	h.resetProgress(c, models.ResetModule)
+}
+
+// ResetCourseProgress clears the learner's progress in a course
+func (h *Handler) ResetCourseProgress(c *gin.Context) {
+	h.resetProgress(c, models.ResetCourse)
+}
+
+// ResetUserProgress clears a user's progress in every course
+func (h *Handler) ResetUserProgress(c *gin.Context) {
+	h.resetProgress(c, models.ResetAll)
+}
+
+// GetProgressResets lists the resets of the learner's progress, oldest first
+func (h *Handler) GetProgressResets(c *gin.Context) {
+	learnerID, ok := h.requestLearner(c)
+	if !ok {
+		return
+	}
+
+	resets, err := h.Repo.GetProgressResets(c.Request.Context(), learnerID.Hex())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch resets"})
+		return
+	}
+	if resets == nil {
+		resets = []models.ProgressReset{}
+	}
+
+	c.JSON(http.StatusOK, resets)
+}
+
+// resetProgress resets the progress named by the URL and scope, recording the
+// authenticated user as the one who reset it
+func (h *Handler) resetProgress(c *gin.Context, scope string) {
+	actorID, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	learnerID, ok := h.requestLearner(c)
+	if !ok {
+		return
+	}
+
+	reset := &models.ProgressReset{
+		UserID:   learnerID,
+		Scope:    scope,
+		ModuleID: c.Param("moduleId"),
+		ResetBy:  actorID,
+		ResetAt:  time.Now().UTC(),
+	}
+	if scope != models.ResetAll {
+		course, err := h.findCourse(c.Request.Context(), c.Param("courseId"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+			return
+		}
+		reset.CourseID = course.ID
+	}
+
+	err = h.Repo.ResetProgress(c.Request.Context(), reset)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+	case errors.Is(err, repository.ErrModuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Module not found"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset progress"})
+	default:
+		c.JSON(http.StatusOK, reset)
+	}
+}
+
+// requestLearner returns the user whose progress or activity the request is
+// about: the user in the URL on admin routes, otherwise the authenticated user.
+// Instructors can only name members of cohorts they teach. On failure it writes
+// the error response and returns false.
+func (h *Handler) requestLearner(c *gin.Context) (primitive.ObjectID, bool) {
+	userID := c.Param("id")
+	if userID == "" {
+		learnerID, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return primitive.NilObjectID, false
+		}
+		return learnerID, true
+	}
+
+	if !primitive.IsValidObjectID(userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return primitive.NilObjectID, false
+	}
+	user, err := h.Repo.GetUserByID(c.Request.Context(), userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return primitive.NilObjectID, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return primitive.NilObjectID, false
+	}
+	if !h.teachesLearner(c, user.ID) {
+		return primitive.NilObjectID, false
+	}
+	return user.ID, true
+}
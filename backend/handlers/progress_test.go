@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pathway/backend/models"
+)
+
+// completeModules marks modules of a course complete for the learner
+func completeModules(t *testing.T, r http.Handler, token string, courseID string, moduleIDs ...string) {
+	t.Helper()
+	for _, moduleID := range moduleIDs {
+		w := doJSON(r, http.MethodPost, "/api/user/progress/complete", token, CompleteModuleRequest{CourseID: courseID, ModuleID: moduleID})
+		if w.Code != http.StatusOK {
+			t.Fatalf("complete %s: expected 200, got %d: %s", moduleID, w.Code, w.Body.String())
+		}
+	}
+}
+
+func progressOf(t *testing.T, r http.Handler, token string) []models.CourseWithProgress {
+	t.Helper()
+	var progress []models.CourseWithProgress
+	decode(t, doJSON(r, http.MethodGet, "/api/user/progress", token, nil), &progress)
+	return progress
+}
+
+func TestLearnerResetsOwnProgress(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestServer(t)
+	student := registerUser(t, r, "learner@example.com")
+	git, _ := repo.GetCourseBySlug(ctx, "git")
+	completeModules(t, r, student.Token, git.ID.Hex(), "git-1", "git-2")
+
+	w := doJSON(r, http.MethodDelete, "/api/user/progress/courses/git/modules/git-2", student.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("uncomplete: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var reset models.ProgressReset
+	decode(t, w, &reset)
+	if reset.Scope != models.ResetModule || reset.ModuleID != "git-2" || reset.ResetBy != student.User.ID || reset.UserID != student.User.ID {
+		t.Fatalf("unexpected reset: %+v", reset)
+	}
+	if p := progressOf(t, r, student.Token)[0]; p.IsCompleted || len(p.CompletedModules) != 1 || p.CompletedModules[0] != "git-1" {
+		t.Fatalf("after uncomplete: %+v", p)
+	}
+
+	if w := doJSON(r, http.MethodDelete, "/api/user/progress/courses/"+git.ID.Hex(), student.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("reset course: expected 200, got %d", w.Code)
+	}
+	if p := progressOf(t, r, student.Token)[0]; p.IsCompleted || len(p.CompletedModules) != 0 || p.ProgressPercent != 0 {
+		t.Fatalf("after course reset: %+v", p)
+	}
+
+	var resets []models.ProgressReset
+	decode(t, doJSON(r, http.MethodGet, "/api/user/progress/resets", student.Token, nil), &resets)
+	if len(resets) != 2 || resets[0].Scope != models.ResetModule || resets[1].Scope != models.ResetCourse {
+		t.Fatalf("unexpected reset history: %+v", resets)
+	}
+
+	for path, want := range map[string]int{
+		"/api/user/progress/courses/missing":             http.StatusNotFound,
+		"/api/user/progress/courses/git/modules/missing": http.StatusNotFound,
+	} {
+		if w := doJSON(r, http.MethodDelete, path, student.Token, nil); w.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, w.Code)
+		}
+	}
+}
+
+func TestStaffResetLearnerProgress(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestServer(t)
+	admin := loginWithRole(t, r, repo, "admin@example.com", models.RoleAdmin)
+	instructor := loginWithRole(t, r, repo, "instructor@example.com", models.RoleInstructor)
+	otherInstructor := loginWithRole(t, r, repo, "other-instructor@example.com", models.RoleInstructor)
+	student := registerUser(t, r, "learner@example.com")
+	teachCohort(t, r, instructor.Token, []string{"git", "http"}, "learner@example.com")
+	git, _ := repo.GetCourseBySlug(ctx, "git")
+	httpCourse, _ := repo.GetCourseBySlug(ctx, "http")
+	completeModules(t, r, student.Token, git.ID.Hex(), "git-1", "git-2")
+	completeModules(t, r, student.Token, httpCourse.ID.Hex(), "http-1")
+	base := "/api/admin/users/" + student.User.ID.Hex() + "/progress"
+
+	// Instructors only manage learners in the cohorts they teach
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodDelete, base + "/courses/git/modules/git-1"},
+		{http.MethodDelete, base + "/courses/git"},
+		{http.MethodGet, base + "/resets"},
+	} {
+		if w := doJSON(r, tc.method, tc.path, otherInstructor.Token, nil); w.Code != http.StatusForbidden {
+			t.Fatalf("other instructor %s %s: expected 403, got %d", tc.method, tc.path, w.Code)
+		}
+	}
+
+	if w := doJSON(r, http.MethodDelete, base+"/courses/git/modules/git-1", instructor.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("instructor uncomplete: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if p := progressOf(t, r, student.Token); p[0].IsCompleted || !p[1].IsCompleted {
+		t.Fatalf("only git should be incomplete: %+v", p)
+	}
+
+	if w := doJSON(r, http.MethodDelete, base, instructor.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("instructor reset all: expected 403, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodDelete, base, student.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("student reset all: expected 403, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodDelete, base, admin.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("admin reset all: expected 200, got %d", w.Code)
+	}
+	for _, p := range progressOf(t, r, student.Token) {
+		if p.IsCompleted || len(p.CompletedModules) != 0 {
+			t.Fatalf("after reset all: %+v", p)
+		}
+	}
+
+	var resets []models.ProgressReset
+	decode(t, doJSON(r, http.MethodGet, base+"/resets", instructor.Token, nil), &resets)
+	if len(resets) != 2 || resets[0].ResetBy != instructor.User.ID || resets[1].ResetBy != admin.User.ID || resets[1].Scope != models.ResetAll {
+		t.Fatalf("unexpected reset history: %+v", resets)
+	}
+
+	if w := doJSON(r, http.MethodDelete, "/api/admin/users/000000000000000000000000/progress", admin.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown user: expected 404, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodDelete, "/api/admin/users/zzzzzzzzzzzzzzzzzzzzzzzz/progress", admin.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("malformed user ID: expected 404, got %d", w.Code)
+	}
+}
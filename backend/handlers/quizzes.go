@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SubmitQuizRequest represents the request body for submitting a quiz, with
+// answers keyed by question ID
+type SubmitQuizRequest struct {
+	Answers map[string]models.QuizAnswer `json:"answers" binding:"required"`
+}
+
+// QuizStateResponse is a learner's view of a quiz: its questions without the
+// answer key, and their scored attempts
+type QuizStateResponse struct {
+	Quiz     *models.QuizPreview  `json:"quiz"`
+	Attempts []models.QuizAttempt `json:"attempts"` // Oldest first
+	Passed   bool                 `json:"passed"`
+}
+
+// GetQuiz returns the quiz and the learner's attempts at it
+func (h *Handler) GetQuiz(c *gin.Context) {
+	lm, quiz, ok := h.loadQuiz(c)
+	if !ok {
+		return
+	}
+	h.respondQuizState(c, lm, quiz)
+}
+
+// SubmitQuizAttempt scores a learner's answers and stores the attempt. Until
+// the learner passes, the response only has the score; after that it says
+// which questions were right, never what the right answers are.
+func (h *Handler) SubmitQuizAttempt(c *gin.Context) {
+	lm, quiz, ok := h.loadQuiz(c)
+	if !ok {
+		return
+	}
+
+	var req SubmitQuizRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	for _, answer := range req.Answers {
+		if len(answer.Text) > maxAnswerLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Answers must be at most %d characters", maxAnswerLength)})
+			return
+		}
+	}
+
+	result, err := quiz.Score(req.Answers)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	previous, err := h.Repo.GetQuizAttempts(c.Request.Context(), lm.userID, lm.courseID, lm.moduleID, quiz.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quiz"})
+		return
+	}
+
+	attempt := &models.QuizAttempt{
+		UserID:      lm.userObjectID,
+		CourseID:    lm.courseObjectID,
+		ModuleID:    lm.moduleID,
+		QuizID:      quiz.ID,
+		Answers:     req.Answers,
+		Result:      result,
+		SubmittedAt: time.Now().UTC(),
+	}
+	if err := h.Repo.CreateQuizAttempt(c.Request.Context(), attempt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record attempt"})
+		return
+	}
+	h.recordActivity(c, lm.activity(models.ActivityQuizSubmitted, quiz.ID))
+
+	if !result.Passed && !anyPassed(previous) {
+		c.JSON(http.StatusCreated, attempt.WithoutQuestionResults())
+		return
+	}
+	c.JSON(http.StatusCreated, attempt)
+}
+
+// loadQuiz finds the quiz named in the URL. On failure it writes the error
+// response and returns false.
+func (h *Handler) loadQuiz(c *gin.Context) (*learnerModule, *models.QuizBlock, bool) {
+	lm, ok := h.loadLearnerModule(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	quiz, ok := lm.module.Quiz(c.Param("quizId"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+		return nil, nil, false
+	}
+	return lm, quiz, true
+}
+
+// respondQuizState writes the learner's current state for the quiz
+func (h *Handler) respondQuizState(c *gin.Context, lm *learnerModule, quiz *models.QuizBlock) {
+	attempts, err := h.Repo.GetQuizAttempts(c.Request.Context(), lm.userID, lm.courseID, lm.moduleID, quiz.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quiz"})
+		return
+	}
+
+	state := QuizStateResponse{
+		Quiz:     quiz.Redacted().(*models.QuizPreview),
+		Attempts: attempts,
+		Passed:   anyPassed(attempts),
+	}
+	if state.Attempts == nil {
+		state.Attempts = []models.QuizAttempt{}
+	}
+	if !state.Passed {
+		for i := range state.Attempts {
+			state.Attempts[i] = state.Attempts[i].WithoutQuestionResults()
+		}
+	}
+
+	c.JSON(http.StatusOK, state)
+}
+
+func anyPassed(attempts []models.QuizAttempt) bool {
+	for _, attempt := range attempts {
+		if attempt.Result.Passed {
+			return true
+		}
+	}
+	return false
+}
+
+// unpassedQuizzes returns the IDs of quizzes the learner must still pass before
+// completing the module. Modules that don't require passing quizzes have none,
+// and unknown courses or modules are left to MarkModuleComplete.
+func (h *Handler) unpassedQuizzes(ctx context.Context, userID string, courseID string, moduleID string) ([]string, error) {
+	course, err := h.Repo.GetCourseByID(ctx, courseID)
+	if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, primitive.ErrInvalidHex) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	module, ok := course.Module(moduleID)
+	if !ok || !module.RequirePassingQuiz {
+		return nil, nil
+	}
+
+	var unpassed []string
+	for _, quiz := range module.Quizzes() {
+		passed, err := h.Repo.HasPassedQuiz(ctx, userID, courseID, moduleID, quiz.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !passed {
+			unpassed = append(unpassed, quiz.ID)
+		}
+	}
+	return unpassed, nil
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/models"
+)
+
+// newQuizServer adds a course whose first module requires passing its quiz
+func newQuizServer(t *testing.T) (*gin.Engine, *models.Course) {
+	t.Helper()
+	r, repo := newTestServer(t)
+
+	course := &models.Course{
+		Slug:  "go",
+		Title: "Go",
+		Modules: []models.Module{
+			{
+				ID:                 "go-1",
+				Title:              "Loops",
+				RequirePassingQuiz: true,
+				Content: []models.ContentBlock{models.NewContentBlock(&models.QuizBlock{
+					ID:           "loops-check",
+					PassingScore: 50,
+					Questions: []models.QuizQuestion{
+						{ID: "keyword", Kind: models.QuestionSingleChoice, Prompt: "Loop keyword?", Options: []string{"while", "for"}, Correct: []int{1}},
+						{ID: "iterate", Kind: models.QuestionShortAnswer, Prompt: "Iterate a slice with?", AcceptedAnswers: []string{"range"}},
+					},
+				})},
+			},
+			{ID: "go-2", Title: "Slices"},
+		},
+	}
+	if err := repo.CreateCourse(context.Background(), course); err != nil {
+		t.Fatal(err)
+	}
+	return r, course
+}
+
+func TestQuizAnswerKeyStaysOnServer(t *testing.T) {
+	r, _ := newQuizServer(t)
+	student := registerUser(t, r, "learner@example.com")
+
+	for _, req := range []struct{ path, token string }{
+		{"/api/courses/go", ""},
+		{"/api/user/courses/go/modules/go-1/quizzes/loops-check", student.Token},
+	} {
+		w := doJSON(r, http.MethodGet, req.path, req.token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d", req.path, w.Code)
+		}
+		if body := w.Body.String(); strings.Contains(body, "correct") || strings.Contains(body, "range") {
+			t.Fatalf("GET %s leaked the answer key: %s", req.path, body)
+		}
+	}
+}
+
+func TestSubmitQuizAttempt(t *testing.T) {
+	r, _ := newQuizServer(t)
+	student := registerUser(t, r, "learner@example.com")
+	path := "/api/user/courses/go/modules/go-1/quizzes/loops-check"
+
+	w := doJSON(r, http.MethodPost, path+"/attempts", student.Token, SubmitQuizRequest{
+		Answers: map[string]models.QuizAnswer{"keyword": {Choices: []int{0}}, "iterate": {Text: "Range"}},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("submit: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var attempt models.QuizAttempt
+	decode(t, w, &attempt)
+	if attempt.Result.Score != 1 || attempt.Result.MaxScore != 2 || !attempt.Result.Passed || attempt.Result.Questions[0].Correct {
+		t.Fatalf("unexpected result: %+v", attempt.Result)
+	}
+
+	var state QuizStateResponse
+	decode(t, doJSON(r, http.MethodGet, path, student.Token, nil), &state)
+	if len(state.Attempts) != 1 || !state.Passed || state.Quiz.PassingScore != 50 {
+		t.Fatalf("unexpected state: %+v", state)
+	}
+
+	cases := []struct {
+		name string
+		path string
+		body interface{}
+		want int
+	}{
+		{"unknown quiz", "/api/user/courses/go/modules/go-1/quizzes/nope/attempts", SubmitQuizRequest{Answers: map[string]models.QuizAnswer{}}, http.StatusNotFound},
+		{"unknown question", path + "/attempts", SubmitQuizRequest{Answers: map[string]models.QuizAnswer{"nope": {Text: "x"}}}, http.StatusBadRequest},
+		{"invalid option", path + "/attempts", SubmitQuizRequest{Answers: map[string]models.QuizAnswer{"keyword": {Choices: []int{5}}}}, http.StatusBadRequest},
+		{"no answers", path + "/attempts", map[string]string{}, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		if w := doJSON(r, http.MethodPost, tc.path, student.Token, tc.body); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, w.Code)
+		}
+	}
+}
+
+func TestQuizQuestionResultsWithheldUntilPassed(t *testing.T) {
+	r, _ := newQuizServer(t)
+	student := registerUser(t, r, "learner@example.com")
+	path := "/api/user/courses/go/modules/go-1/quizzes/loops-check"
+	submit := func(answers map[string]models.QuizAnswer) models.QuizAttempt {
+		var attempt models.QuizAttempt
+		decode(t, doJSON(r, http.MethodPost, path+"/attempts", student.Token, SubmitQuizRequest{Answers: answers}), &attempt)
+		return attempt
+	}
+
+	failed := submit(map[string]models.QuizAnswer{"keyword": {Choices: []int{0}}, "iterate": {Text: "each"}})
+	if failed.Result.Passed || failed.Result.MaxScore != 2 || failed.Result.Questions != nil {
+		t.Fatalf("failed attempt should only show the score: %+v", failed.Result)
+	}
+	var state QuizStateResponse
+	decode(t, doJSON(r, http.MethodGet, path, student.Token, nil), &state)
+	if state.Passed || len(state.Attempts) != 1 || state.Attempts[0].Result.Questions != nil {
+		t.Fatalf("attempts before passing should only show scores: %+v", state)
+	}
+
+	passed := submit(map[string]models.QuizAnswer{"keyword": {Choices: []int{1}}})
+	if !passed.Result.Passed || len(passed.Result.Questions) != 2 {
+		t.Fatalf("passing attempt should show question results: %+v", passed.Result)
+	}
+	decode(t, doJSON(r, http.MethodGet, path, student.Token, nil), &state)
+	if !state.Passed || len(state.Attempts) != 2 || len(state.Attempts[0].Result.Questions) != 2 {
+		t.Fatalf("every attempt should show question results once passed: %+v", state)
+	}
+}
+
+func TestCompleteModuleRequiresPassingQuiz(t *testing.T) {
+	r, course := newQuizServer(t)
+	student := registerUser(t, r, "learner@example.com")
+	complete := func(moduleID string) int {
+		return doJSON(r, http.MethodPost, "/api/user/progress/complete", student.Token, CompleteModuleRequest{
+			CourseID: course.ID.Hex(), ModuleID: moduleID,
+		}).Code
+	}
+	submit := func(text string) {
+		doJSON(r, http.MethodPost, "/api/user/courses/go/modules/go-1/quizzes/loops-check/attempts", student.Token, SubmitQuizRequest{
+			Answers: map[string]models.QuizAnswer{"iterate": {Text: text}},
+		})
+	}
+
+	if code := complete("go-2"); code != http.StatusOK {
+		t.Fatalf("module without a quiz requirement: expected 200, got %d", code)
+	}
+	if code := complete("go-1"); code != http.StatusForbidden {
+		t.Fatalf("before any attempt: expected 403, got %d", code)
+	}
+	submit("while")
+	if code := complete("go-1"); code != http.StatusForbidden {
+		t.Fatalf("after a failed attempt: expected 403, got %d", code)
+	}
+	submit("range")
+	if code := complete("go-1"); code != http.StatusOK {
+		t.Fatalf("after passing: expected 200, got %d", code)
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/models"
+	"github.com/pathway/backend/runner"
+)
+
+// maxCodeLength bounds the size of a submitted program
+const maxCodeLength = 64 << 10
+
+// RunCodeRequest represents the request body for running a Go submission
+type RunCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// RunExerciseCode runs a learner's Go program against the exercise's tests and
+// stores the result. Each run also counts as an attempt towards the exercise's
+// reveal policy.
+func (h *Handler) RunExerciseCode(c *gin.Context) {
+	ex, ok := h.loadExercise(c)
+	if !ok {
+		return
+	}
+	if len(ex.exercise.Tests) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exercise has no tests to run"})
+		return
+	}
+	if h.Runner == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Code execution is not available"})
+		return
+	}
+
+	var req RunCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if len(req.Code) > maxCodeLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Code must be at most %d bytes", maxCodeLength)})
+		return
+	}
+
+	ctx := c.Request.Context()
+	result, err := h.Runner.Run(ctx, req.Code, ex.exercise.Tests)
+	if errors.Is(err, runner.ErrUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Code execution is not available"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to run code"})
+		return
+	}
+
+	now := time.Now().UTC()
+	run := &models.CodeRun{
+		UserID:      ex.userObjectID,
+		CourseID:    ex.courseObjectID,
+		ModuleID:    ex.moduleID,
+		ExerciseID:  ex.exerciseID,
+		Code:        req.Code,
+		Result:      *result,
+		SubmittedAt: now,
+	}
+	if err := h.Repo.CreateCodeRun(ctx, run); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record run"})
+		return
+	}
+	attempt := &models.ExerciseAttempt{
+		UserID:      ex.userObjectID,
+		CourseID:    ex.courseObjectID,
+		ModuleID:    ex.moduleID,
+		ExerciseID:  ex.exerciseID,
+		Answer:      req.Code,
+		SubmittedAt: now,
+	}
+	if err := h.Repo.CreateExerciseAttempt(ctx, attempt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record attempt"})
+		return
+	}
+	h.recordActivity(c, ex.activity(models.ActivityExerciseAttempted, ex.exerciseID))
+
+	c.JSON(http.StatusCreated, run)
+}
+
+// GetExerciseRuns returns the learner's runs of an exercise, oldest first
+func (h *Handler) GetExerciseRuns(c *gin.Context) {
+	ex, ok := h.loadExercise(c)
+	if !ok {
+		return
+	}
+
+	runs, err := h.Repo.GetCodeRuns(c.Request.Context(), ex.userID, ex.courseID, ex.moduleID, ex.exerciseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch runs"})
+		return
+	}
+	if runs == nil {
+		runs = []models.CodeRun{}
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
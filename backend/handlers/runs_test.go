@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/models"
+	"github.com/pathway/backend/runner"
+)
+
+// fakeRunner passes every test if the code contains "correct"
+type fakeRunner struct {
+	err   error
+	tests []models.ExerciseTest // Received by the last run
+}
+
+func (f *fakeRunner) Run(ctx context.Context, code string, tests []models.ExerciseTest) (*models.RunResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.tests = tests
+
+	result := &models.RunResult{Status: models.RunFailed, Total: len(tests)}
+	for _, test := range tests {
+		status := models.TestWrongOutput
+		if strings.Contains(code, "correct") {
+			status = models.TestPassed
+			result.Passed++
+		}
+		result.Tests = append(result.Tests, models.TestResult{Name: test.Name, Status: status})
+	}
+	if result.Passed == result.Total {
+		result.Status = models.RunPassed
+	}
+	return result, nil
+}
+
+// newRunServer adds a course with a runnable exercise that reveals its
+// solution after one submission, and a plain exercise
+func newRunServer(t *testing.T, codeRunner runner.Runner) *gin.Engine {
+	t.Helper()
+	r, repo := newTestServerWith(t, func(h *Handler) { h.Runner = codeRunner })
+
+	course := &models.Course{
+		Slug:  "go",
+		Title: "Go",
+		Modules: []models.Module{{
+			ID:    "go-1",
+			Title: "Hello",
+			Content: []models.ContentBlock{
+				models.NewContentBlock(&models.ExerciseBlock{
+					ID:           "hello",
+					Prompt:       "Print hello",
+					Solution:     `fmt.Println("hello")`,
+					RevealPolicy: models.RevealAfterSubmission,
+					Tests:        []models.ExerciseTest{{Name: "prints hello", Output: "hello"}, {Name: "secret", Output: "hello", Hidden: true}},
+				}),
+				models.NewContentBlock(&models.ExerciseBlock{ID: "think", Prompt: "Think about it"}),
+			},
+		}},
+	}
+	if err := repo.CreateCourse(context.Background(), course); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestRunExerciseCode(t *testing.T) {
+	fake := &fakeRunner{}
+	r := newRunServer(t, fake)
+	student := registerUser(t, r, "learner@example.com")
+	path := "/api/user/courses/go/modules/go-1/exercises/hello"
+
+	var state ExerciseStateResponse
+	decode(t, doJSON(r, http.MethodGet, path, student.Token, nil), &state)
+	if state.TestCount != 2 || state.CanRevealSolution {
+		t.Fatalf("unexpected state before running: %+v", state)
+	}
+
+	for _, code := range []string{"wrong", "correct"} {
+		w := doJSON(r, http.MethodPost, path+"/runs", student.Token, RunCodeRequest{Code: code})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("run %s: expected 201, got %d: %s", code, w.Code, w.Body.String())
+		}
+	}
+	if len(fake.tests) != 2 {
+		t.Fatalf("runner should get every test, got %+v", fake.tests)
+	}
+
+	var runs []models.CodeRun
+	decode(t, doJSON(r, http.MethodGet, path+"/runs", student.Token, nil), &runs)
+	if len(runs) != 2 || runs[0].Result.Status != models.RunFailed || runs[1].Result.Status != models.RunPassed || runs[1].Code != "correct" {
+		t.Fatalf("unexpected runs: %+v", runs)
+	}
+
+	// Runs count as attempts towards the reveal policy
+	decode(t, doJSON(r, http.MethodGet, path, student.Token, nil), &state)
+	if state.Attempts != 2 || !state.CanRevealSolution {
+		t.Fatalf("unexpected state after running: %+v", state)
+	}
+
+	// Course responses don't include the tests
+	if body := doJSON(r, http.MethodGet, "/api/courses/go", "", nil).Body.String(); strings.Contains(body, "secret") {
+		t.Fatalf("course response leaked tests: %s", body)
+	}
+}
+
+func TestRunExerciseCodeErrors(t *testing.T) {
+	r := newRunServer(t, &fakeRunner{})
+	student := registerUser(t, r, "learner@example.com")
+	base := "/api/user/courses/go/modules/go-1/exercises/"
+
+	if w := doJSON(r, http.MethodPost, base+"think/runs", student.Token, RunCodeRequest{Code: "x"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("exercise without tests: expected 400, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, base+"hello/runs", student.Token, RunCodeRequest{}); w.Code != http.StatusBadRequest {
+		t.Fatalf("empty code: expected 400, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, base+"hello/runs", student.Token, RunCodeRequest{Code: strings.Repeat("x", maxCodeLength+1)}); w.Code != http.StatusBadRequest {
+		t.Fatalf("oversized code: expected 400, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, base+"hello/runs", "", RunCodeRequest{Code: "x"}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous: expected 401, got %d", w.Code)
+	}
+
+	for name, codeRunner := range map[string]runner.Runner{
+		"no runner":           nil,
+		"sandbox unavailable": &fakeRunner{err: runner.ErrUnavailable},
+	} {
+		r := newRunServer(t, codeRunner)
+		student := registerUser(t, r, "learner@example.com")
+		if w := doJSON(r, http.MethodPost, base+"hello/runs", student.Token, RunCodeRequest{Code: "x"}); w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected 503, got %d", name, w.Code)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/mailer"
+	"github.com/pathway/backend/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// How long an email verification link works, overridable with EMAIL_VERIFICATION_TTL (a Go duration)
+var emailVerificationTTL = durationFromEnv("EMAIL_VERIFICATION_TTL", 48*time.Hour)
+
+// VerifyEmail confirms a user's email address with a token from a verification email
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	token, err := h.Repo.UseEmailVerificationToken(ctx, hashToken(req.Token))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	if err := h.Repo.SetUserEmailVerified(ctx, token.UserID.Hex(), time.Now().UTC()); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// ResendVerificationEmail sends the current user a new verification link
+func (h *Handler) ResendVerificationEmail(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	user, err := h.Repo.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.EmailVerified {
+		c.JSON(http.StatusOK, gin.H{"message": "Email is already verified"})
+		return
+	}
+
+	if err := h.sendEmailVerification(c.Request.Context(), user); err != nil {
+		log.Printf("Failed to start email verification for user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// RequireVerifiedEmail stops users who haven't verified their email address when
+// VerifiedEmailRequired is set. It runs after AuthMiddleware and looks the user
+// up, so verifying takes effect without a new access token.
+func (h *Handler) RequireVerifiedEmail(c *gin.Context) {
+	if !h.VerifiedEmailRequired {
+		c.Next()
+		return
+	}
+
+	user, err := h.Repo.GetUserByID(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	if !user.EmailVerified {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Verify your email address to save progress"})
+		return
+	}
+
+	c.Next()
+}
+
+// sendEmailVerification stores a new verification token for the user and emails
+// them a link to EmailVerificationURL carrying it
+func (h *Handler) sendEmailVerification(ctx context.Context, user *models.User) error {
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	link, err := tokenLink(h.EmailVerificationURL, token)
+	if err != nil {
+		return fmt.Errorf("invalid email verification URL: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if err := h.Repo.CreateEmailVerificationToken(ctx, &models.EmailVerificationToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(emailVerificationTTL),
+	}); err != nil {
+		return err
+	}
+
+	h.sendInBackground(user, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your Pathway email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Welcome to Pathway! To confirm this is your email address, open this link within %.0f hours:\n\n"+
+			"%s\n\n"+
+			"If you didn't sign up, ignore this email.\n",
+			user.Name, emailVerificationTTL.Hours(), link),
+	})
+	return nil
+}
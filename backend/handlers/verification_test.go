@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pathway/backend/models"
+)
+
+const verificationSubject = "Confirm your Pathway email address"
+
+func TestVerifyEmail(t *testing.T) {
+	mail := make(outbox, 10)
+	r, _ := newTestServerWith(t, func(h *Handler) {
+		h.Mailer = mail
+		h.EmailVerificationURL = "https://app.example.com/verify?lang=en"
+	})
+	session := registerUser(t, r, "learner@example.com")
+	if session.User.EmailVerified {
+		t.Fatal("new users should start unverified")
+	}
+	msg := mail.next(t, verificationSubject)
+	if msg.To != "learner@example.com" {
+		t.Fatalf("email sent to %q", msg.To)
+	}
+	token := emailToken(t, msg)
+
+	// Asking again sends a new link and uses up the first once either is used
+	if w := doJSON(r, http.MethodPost, "/api/auth/resend-verification", session.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("resend: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	resent := emailToken(t, mail.next(t, verificationSubject))
+
+	if w := doJSON(r, http.MethodPost, "/api/auth/verify-email", "", VerifyEmailRequest{Token: resent}); w.Code != http.StatusOK {
+		t.Fatalf("verify: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(r, http.MethodPost, "/api/auth/verify-email", "", VerifyEmailRequest{Token: token}); w.Code != http.StatusBadRequest {
+		t.Fatalf("older token: expected 400, got %d", w.Code)
+	}
+
+	var me models.User
+	decode(t, doJSON(r, http.MethodGet, "/api/user/me", session.Token, nil), &me)
+	if !me.EmailVerified || me.EmailVerifiedAt == nil {
+		t.Fatalf("user should be verified: %+v", me)
+	}
+
+	// Nothing more to send once verified
+	if w := doJSON(r, http.MethodPost, "/api/auth/resend-verification", session.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("resend when verified: expected 200, got %d", w.Code)
+	}
+	select {
+	case msg := <-mail:
+		t.Fatalf("no email should be sent, got %q", msg.Subject)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if w := doJSON(r, http.MethodPost, "/api/auth/verify-email", "", VerifyEmailRequest{Token: "nope"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown token: expected 400, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, "/api/auth/resend-verification", "", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("resend without auth: expected 401, got %d", w.Code)
+	}
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	mail := make(outbox, 10)
+	r, repo := newTestServerWith(t, func(h *Handler) {
+		h.Mailer = mail
+		h.EmailVerificationURL = "https://app.example.com/verify?lang=en"
+		h.VerifiedEmailRequired = true
+	})
+	session := registerUser(t, r, "learner@example.com")
+	git, _ := repo.GetCourseBySlug(context.Background(), "git")
+	complete := CompleteModuleRequest{CourseID: git.ID.Hex(), ModuleID: "git-1"}
+
+	// Reading is allowed, saving progress isn't
+	if w := doJSON(r, http.MethodGet, "/api/user/progress", session.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("progress: expected 200, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, "/api/user/progress/complete", session.Token, complete); w.Code != http.StatusForbidden {
+		t.Fatalf("complete while unverified: expected 403, got %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodDelete, "/api/user/progress/courses/"+git.ID.Hex(), session.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("reset while unverified: expected 403, got %d", w.Code)
+	}
+	module := "/api/user/courses/" + git.ID.Hex() + "/modules/git-1"
+	for _, path := range []string{module + "/open", module + "/exercises/commit/hints", module + "/exercises/commit/solution"} {
+		if w := doJSON(r, http.MethodPost, path, session.Token, nil); w.Code != http.StatusForbidden {
+			t.Fatalf("%s while unverified: expected 403, got %d", path, w.Code)
+		}
+	}
+
+	// Verifying takes effect without a new access token
+	token := emailToken(t, mail.next(t, verificationSubject))
+	if w := doJSON(r, http.MethodPost, "/api/auth/verify-email", "", VerifyEmailRequest{Token: token}); w.Code != http.StatusOK {
+		t.Fatalf("verify: expected 200, got %d", w.Code)
+	}
+	completeModules(t, r, session.Token, git.ID.Hex(), "git-1")
+}
@@ -0,0 +1,156 @@
+package mailer
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	data, err := format("Pathway <no-reply@example.com>", Message{
+		To:      "ada@example.com",
+		Subject: "Réinitialiser",
+		Body:    "Hello\nVisit the link",
+	}, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := string(data)
+	for _, want := range []string{
+		"From: Pathway <no-reply@example.com>\r\n",
+		"To: ada@example.com\r\n",
+		"Subject: =?UTF-8?q?R=C3=A9initialiser?=\r\n",
+		"Date: Fri, 02 Jan 2026 03:04:05 +0000\r\n",
+		"\r\n\r\nHello\r\nVisit the link\r\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("message missing %q:\n%s", want, text)
+		}
+	}
+
+	if _, err := format("a@example.com", Message{To: "b@example.com", Subject: "Hi\r\nBcc: c@example.com"}, time.Now()); err == nil {
+		t.Fatal("expected error for a subject containing a line break")
+	}
+}
+
+func TestLogSenderWritesFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	sender := &LogSender{Dir: dir}
+	if err := sender.Send(context.Background(), Message{To: "ada@example.com", Subject: "Hi", Body: "Hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*-ada@example.com.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one .eml file, got %v (%v)", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Subject: Hi\r\n") || !strings.HasSuffix(string(data), "Hello\r\n") {
+		t.Fatalf("unexpected message:\n%s", data)
+	}
+}
+
+func TestSMTPSenderSend(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// A minimal SMTP server that accepts one message without STARTTLS or AUTH
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		text := textproto.NewConn(conn)
+		text.PrintfLine("220 localhost ready")
+		var transcript []string
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			transcript = append(transcript, line)
+			switch verb := strings.ToUpper(strings.Fields(line + " ")[0]); verb {
+			case "EHLO", "HELO":
+				text.PrintfLine("250 localhost")
+			case "MAIL", "RCPT":
+				text.PrintfLine("250 OK")
+			case "DATA":
+				text.PrintfLine("354 Go ahead")
+				body, err := text.ReadDotLines()
+				if err != nil {
+					return
+				}
+				transcript = append(transcript, body...)
+				text.PrintfLine("250 Queued")
+			case "QUIT":
+				text.PrintfLine("221 Bye")
+				received <- transcript
+				return
+			default:
+				text.PrintfLine("502 Not implemented")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	sender := &SMTPSender{Host: "127.0.0.1", Port: addr.Port, From: "Pathway <no-reply@example.com>"}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sender.Send(ctx, Message{To: "ada@example.com", Subject: "Hi", Body: "Hello\n.hidden"}); err != nil {
+		t.Fatal(err)
+	}
+
+	transcript := strings.Join(<-received, "\n")
+	for _, want := range []string{"MAIL FROM:<no-reply@example.com>", "RCPT TO:<ada@example.com>", "Subject: Hi", "\nHello\n.hidden"} {
+		if !strings.Contains(transcript, want) {
+			t.Errorf("transcript missing %q:\n%s", want, transcript)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("SMTP_HOST", "")
+	t.Setenv("MAIL_DIR", "/tmp/mail")
+	sender, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logSender, ok := sender.(*LogSender); !ok || logSender.Dir != "/tmp/mail" {
+		t.Fatalf("expected a LogSender writing to /tmp/mail, got %#v", sender)
+	}
+
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_PORT", "2525")
+	t.Setenv("MAIL_FROM", "Pathway <no-reply@example.com>")
+	sender, err = FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if smtpSender, ok := sender.(*SMTPSender); !ok || smtpSender.Port != 2525 || smtpSender.Host != "smtp.example.com" {
+		t.Fatalf("unexpected sender %#v", sender)
+	}
+
+	t.Setenv("MAIL_FROM", "")
+	if _, err := FromEnv(); err == nil {
+		t.Fatal("expected error without MAIL_FROM")
+	}
+	t.Setenv("MAIL_FROM", "no-reply@example.com")
+	t.Setenv("SMTP_PORT", "smtp")
+	if _, err := FromEnv(); err == nil {
+		t.Fatal("expected error for a non-numeric SMTP_PORT")
+	}
+}
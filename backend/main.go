@@ -1,14 +1,25 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/pathway/backend/certificate"
+	"github.com/pathway/backend/content"
 	"github.com/pathway/backend/handlers"
+	"github.com/pathway/backend/mailer"
 	"github.com/pathway/backend/middleware"
+	"github.com/pathway/backend/models"
+	"github.com/pathway/backend/oauth"
 	"github.com/pathway/backend/repository"
+	"github.com/pathway/backend/runner"
+	"github.com/pathway/backend/seed"
+	"github.com/pathway/backend/throttle"
 )
 
 func main() {
@@ -25,21 +36,146 @@ func main() {
 		dbName = "pathway"
 	}
 
+	// Failed logins are counted per process unless they can be shared through MongoDB.
+	// LOGIN_THROTTLE_STORE=memory keeps them per process even with MongoDB.
+	throttleStore := os.Getenv("LOGIN_THROTTLE_STORE")
+	if throttleStore != "" && throttleStore != "memory" && throttleStore != "mongo" {
+		log.Fatalf("Invalid LOGIN_THROTTLE_STORE %q: must be memory or mongo", throttleStore)
+	}
+	var loginStore throttle.Store = throttle.NewMemoryStore()
+
 	// Initialize Repository
-	repo, err := repository.NewMongoRepository(mongoURI, dbName)
-	if err != nil {
-		// For development, we might want to continue even if DB fails, or panic.
-		// Let's log and panic for now as DB is critical.
-		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	// Set REPOSITORY_BACKEND=memory to run without MongoDB (data is lost on restart)
+	var repo repository.Repository
+	if os.Getenv("REPOSITORY_BACKEND") == "memory" {
+		log.Println("Using in-memory repository")
+		memoryRepo := repository.NewMemoryRepository()
+		if _, err := seed.SeedCourses(context.Background(), memoryRepo); err != nil {
+			log.Fatalf("Failed to seed in-memory repository: %v", err)
+		}
+		repo = memoryRepo
+	} else {
+		// DB_CONNECT_TIMEOUT, DB_OPERATION_TIMEOUT and DB_BULK_TIMEOUT override the defaults
+		timeouts, err := repository.TimeoutsFromEnv()
+		if err != nil {
+			log.Fatalf("Invalid database timeout configuration: %v", err)
+		}
+		mongoRepo, err := repository.NewMongoRepositoryWithTimeouts(mongoURI, dbName, timeouts)
+		if err != nil {
+			// For development, we might want to continue even if DB fails, or panic.
+			// Let's log and panic for now as DB is critical.
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+		repo = mongoRepo
+
+		if throttleStore != "memory" {
+			mongoStore := throttle.NewMongoStore(mongoRepo.GetDB(), timeouts.Operation)
+			if err := mongoStore.EnsureIndexes(context.Background()); err != nil {
+				log.Printf("Warning: failed to create login throttling indexes: %v", err)
+			}
+			loginStore = mongoStore
+		}
 	}
 	defer repo.Close()
 
 	// Initialize Handlers
 	h := handlers.NewHandler(repo)
 
+	// CERTIFICATE_SECRET signs certificate verification tokens; defaults to JWT_SECRET
+	if secret := os.Getenv("CERTIFICATE_SECRET"); secret != "" {
+		h.Certificates = certificate.NewSigner([]byte(secret))
+	}
+	// CERTIFICATE_URL is this API's public /api/certificates URL, which the
+	// verification links on certificates point at
+	if certificateURL := os.Getenv("CERTIFICATE_URL"); certificateURL != "" {
+		h.CertificateURL = certificateURL
+	}
+
+	// Password reset and verification emails go through SMTP when SMTP_HOST is
+	// set, otherwise to MAIL_DIR or the log. The *_URL variables are the frontend
+	// pages their links open.
+	sender, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatalf("Invalid mail configuration: %v", err)
+	}
+	h.Mailer = sender
+	if resetURL := os.Getenv("PASSWORD_RESET_URL"); resetURL != "" {
+		h.PasswordResetURL = resetURL
+	}
+	if verifyURL := os.Getenv("EMAIL_VERIFICATION_URL"); verifyURL != "" {
+		h.EmailVerificationURL = verifyURL
+	}
+
+	// REQUIRE_EMAIL_VERIFICATION=true stops unverified users saving progress or
+	// recording activity.
+	// Users stored before verification existed have no email_verified field
+	// and count as verified (see models.User.UnmarshalBSON), so turning this
+	// on doesn't need a migration.
+	if value := os.Getenv("REQUIRE_EMAIL_VERIFICATION"); value != "" {
+		required, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Fatalf("Invalid REQUIRE_EMAIL_VERIFICATION %q: %v", value, err)
+		}
+		h.VerifiedEmailRequired = required
+	}
+
+	// GITHUB_*, GOOGLE_* and OIDC_* variables enable sign-in providers.
+	// OAUTH_CALLBACK_URL is this API's public /api/auth/oauth URL, and
+	// OAUTH_REDIRECT_URL the frontend page that receives the tokens.
+	providers, err := oauth.ProvidersFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid OAuth configuration: %v", err)
+	}
+	for _, provider := range providers {
+		h.OAuthProviders[provider.Name] = provider
+	}
+	if callbackURL := os.Getenv("OAUTH_CALLBACK_URL"); callbackURL != "" {
+		h.OAuthCallbackURL = callbackURL
+	}
+	if redirectURL := os.Getenv("OAUTH_REDIRECT_URL"); redirectURL != "" {
+		h.OAuthRedirectURL = redirectURL
+	}
+
+	// LOGIN_ACCOUNT_* and LOGIN_IP_* variables override the throttling policies
+	accountPolicy, ipPolicy, err := throttle.PoliciesFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid login throttling configuration: %v", err)
+	}
+	h.Logins = throttle.NewLimiter(loginStore)
+	h.Logins.Account, h.Logins.IP = accountPolicy, ipPolicy
+
+	// BADGES_FILE replaces the default badges with definitions from a YAML or JSON file
+	if badgesFile := os.Getenv("BADGES_FILE"); badgesFile != "" {
+		badges, err := content.LoadBadges(badgesFile)
+		if err != nil {
+			log.Fatalf("Failed to load badges: %v", err)
+		}
+		h.Badges = badges
+	}
+
+	// Code execution for exercises with tests; RUNNER_* variables override the limits
+	limits, err := runner.LimitsFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid runner configuration: %v", err)
+	}
+	if goRunner, err := runner.NewGoRunner(limits); err != nil {
+		log.Printf("Code execution disabled: %v", err)
+	} else {
+		h.Runner = goRunner
+	}
+
 	// Setup Router
 	r := gin.Default()
 
+	// Client IPs (used to throttle logins and rate limit) come from X-Forwarded-For only when the
+	// request arrives through one of TRUSTED_PROXIES (comma-separated IPs or CIDRs).
+	// Unset trusts every proxy, which lets clients choose their own IP.
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		if err := r.SetTrustedProxies(strings.Split(proxies, ",")); err != nil {
+			log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+		}
+	}
+
 	// CORS Configuration
 	// Set ALLOWED_ORIGINS environment variable in production (e.g., "https://your-app.vercel.app")
 	// Leave unset for development (defaults to "*" - allows all)
@@ -62,7 +198,8 @@ func main() {
 
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -72,34 +209,125 @@ func main() {
 		c.Next()
 	})
 
+	// Rate limits per IP or, once authenticated, per user; RATE_LIMIT_* variables override them
+	rateLimits, err := middleware.RateLimitPoliciesFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid rate limit configuration: %v", err)
+	}
+
 	// Routes
-	api := r.Group("/api")
+	api := r.Group("/api", middleware.RateLimit(rateLimits.Global, middleware.ByIP))
 	{
 		// Public routes
 		api.GET("/health", h.HealthCheck)
-		api.GET("/courses", h.GetCourses)
-		api.GET("/courses/:id", h.GetCourseByID)
+		public := api.Group("", middleware.RateLimit(rateLimits.Public, middleware.ByIP))
+		public.GET("/courses", h.GetCourses)
+		public.GET("/courses/:id", h.GetCourseByID)
 
-		// Auth routes (public)
-		auth := api.Group("/auth")
+		// Employers verify certificates with the token printed on them
+		public.GET("/certificates/:id/verify", h.VerifyCertificate)
+
+		// Auth routes (public, except logout)
+		auth := api.Group("/auth", middleware.RateLimit(rateLimits.Auth, middleware.ByIP))
 		{
 			auth.POST("/register", h.Register)
 			auth.POST("/login", h.Login)
+			auth.POST("/refresh", h.Refresh)
+			auth.POST("/logout", middleware.AuthMiddleware(repo), h.Logout)
+			auth.POST("/forgot-password", h.ForgotPassword)
+			auth.POST("/reset-password", h.ResetPassword)
+			auth.POST("/verify-email", h.VerifyEmail)
+			auth.POST("/resend-verification", middleware.AuthMiddleware(repo), h.ResendVerificationEmail)
+
+			// Sign-in with GitHub, Google or another OpenID Connect provider
+			auth.GET("/oauth/providers", h.GetOAuthProviders)
+			auth.GET("/oauth/:provider", h.StartOAuthLogin)
+			auth.GET("/oauth/:provider/callback", h.FinishOAuthLogin)
 		}
 
 		// Protected routes (require authentication)
 		user := api.Group("/user")
-		user.Use(middleware.AuthMiddleware())
+		user.Use(middleware.AuthMiddleware(repo), middleware.RateLimit(rateLimits.User, middleware.ByUserOrIP))
 		{
 			user.GET("/me", h.GetCurrentUser)
 			user.GET("/progress", h.GetUserProgress)
-			user.POST("/progress/complete", h.CompleteModule)
+			// RequireVerifiedEmail only blocks writes, including recorded activity such
+			// as opening modules and revealing hints, when REQUIRE_EMAIL_VERIFICATION is on
+			user.POST("/progress/complete", h.RequireVerifiedEmail, h.CompleteModule)
+			user.GET("/progress/resets", h.GetProgressResets)
+			user.DELETE("/progress/courses/:courseId", h.RequireVerifiedEmail, h.ResetCourseProgress)
+			user.DELETE("/progress/courses/:courseId/modules/:moduleId", h.RequireVerifiedEmail, h.UncompleteModule)
+			user.GET("/activity", h.GetActivity)
+			user.POST("/courses/:courseId/modules/:moduleId/open", h.RequireVerifiedEmail, h.OpenModule)
+			user.GET("/achievements", h.GetAchievements)
+			user.GET("/certificates", h.GetCertificates)
+			user.GET("/certificates/:id", h.GetCertificate)
+			user.GET("/certificates/:id/download", h.DownloadCertificate)
+			user.GET("/cohorts", h.GetMyCohorts)
+
+			// Exercise hints and solutions, subject to each exercise's reveal policy
+			exercise := "/courses/:courseId/modules/:moduleId/exercises/:exerciseId"
+			user.GET(exercise, h.GetExercise)
+			user.POST(exercise+"/attempts", h.RequireVerifiedEmail, h.SubmitExerciseAttempt)
+			user.POST(exercise+"/hints", h.RequireVerifiedEmail, h.RevealExerciseHint)
+			user.POST(exercise+"/solution", h.RequireVerifiedEmail, h.RevealExerciseSolution)
+			user.GET(exercise+"/runs", h.GetExerciseRuns)
+			user.POST(exercise+"/runs", h.RequireVerifiedEmail, h.RunExerciseCode)
+
+			// Quizzes are scored on the server; the answer key is never sent
+			quiz := "/courses/:courseId/modules/:moduleId/quizzes/:quizId"
+			user.GET(quiz, h.GetQuiz)
+			user.POST(quiz+"/attempts", h.RequireVerifiedEmail, h.SubmitQuizAttempt)
 		}
 
-		// Admin maintenance routes (token-protected; disabled unless configured)
+		// Seeding accepts an admin JWT or, to bootstrap a fresh deployment, ADMIN_SEED_TOKEN
+		api.POST("/admin/seed", middleware.SeedTokenOrRole(repo, models.RoleAdmin), h.AdminSeedCourses)
+
+		// Admin routes (require a JWT; roles are checked per group)
 		admin := api.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(repo), middleware.RateLimit(rateLimits.Admin, middleware.ByUserOrIP))
 		{
-			admin.POST("/seed", h.AdminSeedCourses)
+			users := admin.Group("/users", middleware.RequireRole(models.RoleAdmin))
+			users.PUT("/:id/role", h.AdminUpdateUserRole)
+
+			// Progress resets and activity on behalf of a learner; resetting every course is admin-only
+			learners := admin.Group("/users/:id/progress", middleware.RequireRole(models.RoleAdmin, models.RoleInstructor))
+			learners.GET("/resets", h.GetProgressResets)
+			learners.DELETE("", middleware.RequireRole(models.RoleAdmin), h.ResetUserProgress)
+			learners.DELETE("/courses/:courseId", h.ResetCourseProgress)
+			learners.DELETE("/courses/:courseId/modules/:moduleId", h.UncompleteModule)
+			admin.GET("/users/:id/activity", middleware.RequireRole(models.RoleAdmin, models.RoleInstructor), h.GetActivity)
+
+			// Cohorts; instructors manage the cohorts they teach
+			cohorts := admin.Group("/cohorts", middleware.RequireRole(models.RoleAdmin, models.RoleInstructor))
+			cohorts.POST("", h.CreateCohort)
+			cohorts.GET("", h.GetCohorts)
+			cohorts.GET("/:id", h.GetCohort)
+			cohorts.GET("/:id/members", h.GetCohortMembers)
+			cohorts.POST("/:id/members", h.EnrollCohortMembers)
+			cohorts.DELETE("/:id/members/:userId", h.RemoveCohortMember)
+
+			// Progress dashboards over a cohort (?cohort=) or, for admins, every learner
+			dashboard := admin.Group("/dashboard", middleware.RequireRole(models.RoleAdmin, models.RoleInstructor))
+			dashboard.GET("/courses", h.GetCourseCompletionStats)
+			dashboard.GET("/courses/:courseId/modules", h.GetModuleDropOff)
+			dashboard.GET("/courses/:courseId/matrix", h.GetCompletionMatrix)
+			dashboard.GET("/stalled", h.GetStalledLearners)
+
+			// Course authoring (admins and instructors)
+			courses := admin.Group("/courses", middleware.RequireRole(models.RoleAdmin, models.RoleInstructor))
+			courses.POST("", h.CreateCourse)
+			courses.GET("/:id", h.AdminGetCourse)
+			courses.PATCH("/:id", h.UpdateCourse)
+			courses.DELETE("/:id", h.DeleteCourse)
+			courses.POST("/:id/modules", h.AddModule)
+			courses.PUT("/:id/modules/order", h.ReorderModules)
+			courses.PATCH("/:id/modules/:moduleId", h.UpdateModule)
+			courses.DELETE("/:id/modules/:moduleId", h.DeleteModule)
+			courses.POST("/:id/modules/:moduleId/blocks", h.AddContentBlock)
+			courses.PUT("/:id/modules/:moduleId/blocks/order", h.ReorderContentBlocks)
+			courses.PUT("/:id/modules/:moduleId/blocks/:index", h.UpdateContentBlock)
+			courses.DELETE("/:id/modules/:moduleId/blocks/:index", h.DeleteContentBlock)
 		}
 	}
 
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"strings"
@@ -24,6 +25,7 @@ func GetJWTSecret() []byte {
 }
 
 // Claims represents the JWT claims
+// RegisteredClaims.ID carries the token's unique ID (jti), used for revocation.
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
@@ -32,45 +34,76 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-// AuthMiddleware validates JWT tokens
-func AuthMiddleware() gin.HandlerFunc {
+// RevocationChecker reports whether an access token was revoked before it expired
+type RevocationChecker interface {
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// AuthMiddleware validates JWT tokens and rejects tokens that have been revoked.
+// A nil checker skips the revocation check.
+func AuthMiddleware(revocations RevocationChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+		if !authenticate(c, revocations) {
 			return
 		}
+		c.Next()
+	}
+}
 
-		// Expected format: "Bearer <token>"
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
-			return
-		}
+// authenticate validates the bearer token and stores its claims in the context.
+// On failure it writes the error response, aborts, and returns false.
+func authenticate(c *gin.Context, revocations RevocationChecker) bool {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+		c.Abort()
+		return false
+	}
 
-		tokenString := parts[1]
+	// Expected format: "Bearer <token>"
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
+		c.Abort()
+		return false
+	}
 
-		// Parse and validate the token
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
+	tokenString := parts[1]
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+	// Parse and validate the token
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+
+	// Tokens without an ID can't be revoked, so they aren't accepted
+	if err != nil || !token.Valid || claims.ID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		c.Abort()
+		return false
+	}
+
+	if revocations != nil {
+		revoked, err := revocations.IsAccessTokenRevoked(c.Request.Context(), claims.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate token"})
 			c.Abort()
-			return
+			return false
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return false
 		}
+	}
 
-		// Store user info in context for use in handlers
-		c.Set("userID", claims.UserID)
-		c.Set("email", claims.Email)
-		c.Set("name", claims.Name)
-		c.Set("role", claims.Role)
+	// Store user info in context for use in handlers
+	c.Set("userID", claims.UserID)
+	c.Set("email", claims.Email)
+	c.Set("name", claims.Name)
+	c.Set("role", claims.Role)
+	c.Set("tokenID", claims.ID)
+	c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
 
-		c.Next()
-	}
+	return true
 }
-
@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitPolicy is a token bucket: a client may make Limit requests at once,
+// and the bucket refills evenly over Period. A zero Limit disables the policy.
+type RateLimitPolicy struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParseRateLimitPolicy reads "<limit>/<period>", e.g. "100/1m", or "off"
+func ParseRateLimitPolicy(value string) (RateLimitPolicy, error) {
+	if value == "off" {
+		return RateLimitPolicy{}, nil
+	}
+	limit, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return RateLimitPolicy{}, fmt.Errorf("rate limit %q: expected <limit>/<period> or off", value)
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 {
+		return RateLimitPolicy{}, fmt.Errorf("rate limit %q: limit must be a positive integer", value)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return RateLimitPolicy{}, fmt.Errorf("rate limit %q: period must be a positive duration", value)
+	}
+	return RateLimitPolicy{Limit: n, Period: d}, nil
+}
+
+// RateLimitPolicies are the policies for each group of routes
+type RateLimitPolicies struct {
+	Global RateLimitPolicy // Every API request, per IP
+	Public RateLimitPolicy // The course catalogue and certificate verification, per IP
+	Auth   RateLimitPolicy // Registration, login and other account routes, per IP
+	User   RateLimitPolicy // Learner routes, per user
+	Admin  RateLimitPolicy // Admin and instructor routes, per user
+}
+
+// DefaultRateLimitPolicies allow for a classroom sharing one IP address
+func DefaultRateLimitPolicies() RateLimitPolicies {
+	return RateLimitPolicies{
+		Global: RateLimitPolicy{Limit: 600, Period: time.Minute},
+		Public: RateLimitPolicy{Limit: 120, Period: time.Minute},
+		Auth:   RateLimitPolicy{Limit: 60, Period: time.Minute},
+		User:   RateLimitPolicy{Limit: 300, Period: time.Minute},
+		Admin:  RateLimitPolicy{Limit: 300, Period: time.Minute},
+	}
+}
+
+// RateLimitPoliciesFromEnv returns DefaultRateLimitPolicies overridden by
+// RATE_LIMIT_GLOBAL, RATE_LIMIT_PUBLIC, RATE_LIMIT_AUTH, RATE_LIMIT_USER and
+// RATE_LIMIT_ADMIN, each "<limit>/<period>" or "off"
+func RateLimitPoliciesFromEnv() (RateLimitPolicies, error) {
+	policies := DefaultRateLimitPolicies()
+	for name, target := range map[string]*RateLimitPolicy{
+		"RATE_LIMIT_GLOBAL": &policies.Global,
+		"RATE_LIMIT_PUBLIC": &policies.Public,
+		"RATE_LIMIT_AUTH":   &policies.Auth,
+		"RATE_LIMIT_USER":   &policies.User,
+		"RATE_LIMIT_ADMIN":  &policies.Admin,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		policy, err := ParseRateLimitPolicy(value)
+		if err != nil {
+			return RateLimitPolicies{}, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		*target = policy
+	}
+	return policies, nil
+}
+
+// ByIP keys rate limits by client IP
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUserOrIP keys rate limits by the authenticated user, falling back to the
+// client IP. Use it after AuthMiddleware.
+func ByUserOrIP(c *gin.Context) string {
+	if userID := c.GetString("userID"); userID != "" {
+		return "user:" + userID
+	}
+	return ByIP(c)
+}
+
+// RateLimit limits each key to the policy, answering 429 with Retry-After once
+// its bucket is empty. Every response carries RateLimit-Limit,
+// RateLimit-Remaining, RateLimit-Reset (seconds until the bucket is full) and
+// RateLimit-Policy headers; when several limits apply, they report the one
+// with the fewest requests remaining. Buckets are kept in memory, so each server instance limits separately.
+func RateLimit(policy RateLimitPolicy, key func(*gin.Context) string) gin.HandlerFunc {
+	return rateLimit(policy, key, time.Now)
+}
+
+func rateLimit(policy RateLimitPolicy, key func(*gin.Context) string, now func() time.Time) gin.HandlerFunc {
+	if policy.Limit <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	limiter := &rateLimiter{policy: policy, now: now, buckets: make(map[string]*bucket)}
+	policyHeader := fmt.Sprintf("%d;w=%d", policy.Limit, int64(math.Ceil(policy.Period.Seconds())))
+	return func(c *gin.Context) {
+		allowed, remaining, reset, retry := limiter.take(key(c))
+
+		header := c.Writer.Header()
+		if reported, ok := c.Get("rateLimitRemaining"); !ok || !allowed || remaining < reported.(int) {
+			c.Set("rateLimitRemaining", remaining)
+			header.Set("RateLimit-Limit", strconv.Itoa(policy.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+			header.Set("RateLimit-Reset", strconv.FormatInt(seconds(reset), 10))
+			header.Set("RateLimit-Policy", policyHeader)
+		}
+		if !allowed {
+			header.Set("Retry-After", strconv.FormatInt(seconds(retry), 10))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Too many requests, try again later",
+				"retry_after": seconds(retry),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type rateLimiter struct {
+	policy    RateLimitPolicy
+	now       func() time.Time
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// take spends a token from the key's bucket if there is one. It returns whether
+// the request may go ahead, the whole tokens left, how long until the bucket is
+// full again and, if refused, how long until the next token.
+func (l *rateLimiter) take(key string) (allowed bool, remaining int, reset time.Duration, retry time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	limit := float64(l.policy.Limit)
+	perSecond := limit / l.policy.Period.Seconds()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit, updated: now}
+		l.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(limit, b.tokens+elapsed*perSecond)
+	}
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		allowed = true
+	} else {
+		retry = secondsDuration((1 - b.tokens) / perSecond)
+	}
+	return allowed, int(b.tokens), secondsDuration((limit - b.tokens) / perSecond), retry
+}
+
+// sweep drops buckets that have refilled, at most once a period; callers must
+// hold the lock
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.policy.Period {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= l.policy.Period {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func secondsDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// seconds rounds a duration up to whole seconds for headers
+func seconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := gin.New()
+	r.GET("/", rateLimit(RateLimitPolicy{Limit: 3, Period: 30 * time.Second}, ByIP, func() time.Time { return now }),
+		func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i, remaining := range []string{"2", "1", "0"} {
+		w := get("192.0.2.1")
+		if w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != remaining {
+			t.Fatalf("request %d: expected 200 with %s remaining, got %d %q", i+1, remaining, w.Code, w.Header().Get("RateLimit-Remaining"))
+		}
+	}
+
+	w := get("192.0.2.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("over the limit: expected 429, got %d", w.Code)
+	}
+	// One token refills every 10s; the bucket is full again after 30s
+	for header, want := range map[string]string{
+		"Retry-After":      "10",
+		"RateLimit-Limit":  "3",
+		"RateLimit-Reset":  "30",
+		"RateLimit-Policy": "3;w=30",
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s: expected %q, got %q", header, want, got)
+		}
+	}
+
+	// Other clients have their own buckets
+	if w := get("192.0.2.2"); w.Code != http.StatusOK {
+		t.Fatalf("other IP: expected 200, got %d", w.Code)
+	}
+
+	now = now.Add(10 * time.Second)
+	if w := get("192.0.2.1"); w.Code != http.StatusOK {
+		t.Fatalf("after refill: expected 200, got %d", w.Code)
+	}
+	if w := get("192.0.2.1"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("refilled token spent: expected 429, got %d", w.Code)
+	}
+}
+
+func TestRateLimitByUser(t *testing.T) {
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		c.Set("userID", c.GetHeader("X-User"))
+	}, RateLimit(RateLimitPolicy{Limit: 1, Period: time.Minute}, ByUserOrIP), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	codes := func(user string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-User", user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if codes("ada") != http.StatusOK || codes("bob") != http.StatusOK {
+		t.Fatal("each user should get their own bucket")
+	}
+	if code := codes("ada"); code != http.StatusTooManyRequests {
+		t.Fatalf("second request from ada: expected 429, got %d", code)
+	}
+	// Without a user, the IP is used
+	if codes("") != http.StatusOK || codes("") != http.StatusTooManyRequests {
+		t.Fatal("anonymous requests should share the IP's bucket")
+	}
+}
+
+func TestRateLimitReportsMostRestrictive(t *testing.T) {
+	r := gin.New()
+	r.GET("/", RateLimit(RateLimitPolicy{Limit: 2, Period: time.Minute}, ByIP),
+		RateLimit(RateLimitPolicy{Limit: 100, Period: time.Minute}, ByIP),
+		func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i, remaining := range []string{"1", "0"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Header().Get("RateLimit-Remaining") != remaining || w.Header().Get("RateLimit-Limit") != "2" {
+			t.Fatalf("request %d: expected the tighter limit with %s remaining, got %q of %q", i+1, remaining,
+				w.Header().Get("RateLimit-Remaining"), w.Header().Get("RateLimit-Limit"))
+		}
+	}
+}
+
+func TestRateLimitOff(t *testing.T) {
+	off := RateLimit(RateLimitPolicy{}, ByIP)
+	for i := 0; i < 5; i++ {
+		if code := serve("", "", off); code != http.StatusOK {
+			t.Fatalf("disabled limit: expected 200, got %d", code)
+		}
+	}
+}
+
+func TestRateLimitPoliciesFromEnv(t *testing.T) {
+	t.Setenv("RATE_LIMIT_PUBLIC", "10/1s")
+	t.Setenv("RATE_LIMIT_ADMIN", "off")
+	policies, err := RateLimitPoliciesFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policies.Public != (RateLimitPolicy{Limit: 10, Period: time.Second}) || policies.Admin.Limit != 0 || policies.User != DefaultRateLimitPolicies().User {
+		t.Fatalf("unexpected policies: %+v", policies)
+	}
+
+	for _, value := range []string{"10", "0/1m", "10/forever", "ten/1m"} {
+		t.Setenv("RATE_LIMIT_AUTH", value)
+		if _, err := RateLimitPoliciesFromEnv(); err == nil {
+			t.Errorf("expected error for RATE_LIMIT_AUTH=%q", value)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pathway/backend/models"
+)
+
+// RequireRole only lets requests through when the authenticated user has one of the given roles.
+// It must run after AuthMiddleware, which stores the role from the JWT.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireRole(c, roles) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// SeedTokenOrRole accepts the X-Admin-Seed-Token header when ADMIN_SEED_TOKEN is set, and
+// otherwise requires a JWT with one of the given roles. The seed token is a bootstrap
+// fallback for fresh deployments that don't have an admin account yet.
+func SeedTokenOrRole(revocations RevocationChecker, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		seedToken := os.Getenv("ADMIN_SEED_TOKEN")
+		provided := c.GetHeader("X-Admin-Seed-Token")
+
+		if seedToken != "" && provided != "" {
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(seedToken)) != 1 {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+				c.Abort()
+				return
+			}
+			c.Set("role", models.RoleAdmin)
+			c.Set("authMethod", "seed_token")
+			c.Next()
+			return
+		}
+
+		if !authenticate(c, revocations) || !requireRole(c, roles) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireRole checks the role stored by authenticate. On failure it writes
+// a 403 response, aborts, and returns false.
+func requireRole(c *gin.Context, roles []string) bool {
+	role := c.GetString("role")
+	for _, allowed := range roles {
+		if role == allowed {
+			return true
+		}
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	c.Abort()
+	return false
+}
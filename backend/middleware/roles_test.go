@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pathway/backend/models"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func signedToken(t *testing.T, role string) string {
+	t.Helper()
+	claims := &Claims{
+		UserID: "user-1",
+		Role:   role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "jti-" + role,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(GetJWTSecret())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// serve runs a request with one header through the middleware chain and returns the status
+func serve(header, value string, chain ...gin.HandlerFunc) int {
+	r := gin.New()
+	r.GET("/", append(chain, func(c *gin.Context) { c.Status(http.StatusOK) })...)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRequireRole(t *testing.T) {
+	auth := AuthMiddleware(nil)
+	require := RequireRole(models.RoleAdmin, models.RoleInstructor)
+
+	cases := []struct {
+		role string
+		want int
+	}{
+		{models.RoleAdmin, http.StatusOK},
+		{models.RoleInstructor, http.StatusOK},
+		{models.RoleStudent, http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		if got := serve("Authorization", "Bearer "+signedToken(t, tc.role), auth, require); got != tc.want {
+			t.Errorf("role %q: got %d, want %d", tc.role, got, tc.want)
+		}
+	}
+}
+
+func TestSeedTokenOrRole(t *testing.T) {
+	handler := SeedTokenOrRole(nil, models.RoleAdmin)
+
+	// Without ADMIN_SEED_TOKEN the header is ignored and a JWT is required
+	if got := serve("X-Admin-Seed-Token", "anything", handler); got != http.StatusUnauthorized {
+		t.Fatalf("unconfigured seed token: got %d, want 401", got)
+	}
+
+	t.Setenv("ADMIN_SEED_TOKEN", "bootstrap")
+	if got := serve("X-Admin-Seed-Token", "bootstrap", handler); got != http.StatusOK {
+		t.Fatalf("valid seed token: got %d, want 200", got)
+	}
+	if got := serve("X-Admin-Seed-Token", "wrong", handler); got != http.StatusForbidden {
+		t.Fatalf("wrong seed token: got %d, want 403", got)
+	}
+	if got := serve("Authorization", "Bearer "+signedToken(t, models.RoleAdmin), handler); got != http.StatusOK {
+		t.Fatalf("admin JWT: got %d, want 200", got)
+	}
+	if got := serve("Authorization", "Bearer "+signedToken(t, models.RoleStudent), handler); got != http.StatusForbidden {
+		t.Fatalf("student JWT: got %d, want 403", got)
+	}
+}
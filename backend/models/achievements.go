@@ -0,0 +1,289 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// XP awarded for completions
+const (
+	XPPerModule = 10
+	XPPerCourse = 100
+)
+
+// Badge criteria
+const (
+	BadgeCoursesCompleted = "courses_completed" // Complete Count courses
+	BadgeStreakDays       = "streak_days"       // Learn on Count days in a row
+	BadgeCourseCompleted  = "course_completed"  // Complete every module of Course
+)
+
+// Badge is an achievement learners earn by meeting its criterion
+type Badge struct {
+	ID          string `yaml:"id" json:"id"`
+	Title       string `yaml:"title" json:"title"`
+	Description string `yaml:"description" json:"description"`
+	Criterion   string `yaml:"criterion" json:"criterion"`
+	Count       int    `yaml:"count" json:"count,omitempty"`   // For courses_completed and streak_days
+	Course      string `yaml:"course" json:"course,omitempty"` // Course slug, for course_completed
+}
+
+// DefaultBadges returns the badges used when no badge file is configured
+func DefaultBadges() []Badge {
+	return []Badge{
+		{ID: "first-course", Title: "First Course Done", Description: "Complete your first course", Criterion: BadgeCoursesCompleted, Count: 1},
+		{ID: "streak-7", Title: "7-Day Streak", Description: "Learn on 7 days in a row", Criterion: BadgeStreakDays, Count: 7},
+		{ID: "git-master", Title: "Git Master", Description: "Complete every module of the Git course", Criterion: BadgeCourseCompleted, Course: "git"},
+	}
+}
+
+// ValidateBadges checks that each badge has a unique ID, a title and a
+// complete criterion
+func ValidateBadges(badges []Badge) error {
+	seen := make(map[string]bool)
+	for _, badge := range badges {
+		if !ValidSlug(badge.ID) {
+			return fmt.Errorf("invalid badge id %q", badge.ID)
+		}
+		if seen[badge.ID] {
+			return fmt.Errorf("duplicate badge id %q", badge.ID)
+		}
+		seen[badge.ID] = true
+		if badge.Title == "" {
+			return fmt.Errorf("badge %q: title is required", badge.ID)
+		}
+
+		switch badge.Criterion {
+		case BadgeCoursesCompleted, BadgeStreakDays:
+			if badge.Count < 1 {
+				return fmt.Errorf("badge %q: count must be at least 1", badge.ID)
+			}
+		case BadgeCourseCompleted:
+			if !ValidSlug(badge.Course) {
+				return fmt.Errorf("badge %q: invalid course slug %q", badge.ID, badge.Course)
+			}
+		default:
+			return fmt.Errorf("badge %q: unknown criterion %q", badge.ID, badge.Criterion)
+		}
+	}
+	return nil
+}
+
+// UserBadge records that a learner earned a badge. Badges are kept once
+// earned, even if the learner's progress is later reset.
+type UserBadge struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID   primitive.ObjectID `bson:"user_id" json:"user_id"`
+	BadgeID  string             `bson:"badge_id" json:"badge_id"`
+	EarnedAt time.Time          `bson:"earned_at" json:"earned_at"`
+}
+
+// BadgeStatus is a learner's standing towards a badge
+type BadgeStatus struct {
+	Badge
+	Earned   bool       `json:"earned"`
+	EarnedAt *time.Time `json:"earned_at,omitempty"`
+	Progress int        `json:"progress"` // Towards Goal, e.g. courses completed so far
+	Goal     int        `json:"goal"`
+}
+
+// Achievements summarises a learner's XP, streaks and badges
+type Achievements struct {
+	XP               int           `json:"xp"`
+	ModulesCompleted int           `json:"modules_completed"`
+	CoursesCompleted int           `json:"courses_completed"`
+	CurrentStreak    int           `json:"current_streak"` // Days in a row up to today or yesterday
+	LongestStreak    int           `json:"longest_streak"`
+	LastActiveOn     string        `json:"last_active_on,omitempty"` // UTC date, YYYY-MM-DD
+	Badges           []BadgeStatus `json:"badges"`
+}
+
+// LearningRecord is what achievements are computed from: the courses, the
+// learner's progress in them and their activity log
+type LearningRecord struct {
+	Courses  []Course
+	Progress []Progress
+	Activity []ActivityEvent
+}
+
+// Achievements computes the learner's achievements as of now. Badges in
+// awarded stay earned, with their recorded time, whatever the record says.
+func (r LearningRecord) Achievements(badges []Badge, awarded []UserBadge, now time.Time) Achievements {
+	completions := r.courseCompletions()
+	a := Achievements{CoursesCompleted: len(completions), Badges: []BadgeStatus{}}
+	for _, course := range r.Courses {
+		if p := r.progressIn(course.ID); p != nil {
+			a.ModulesCompleted += course.CompletedModuleCount(p.CompletedModules)
+		}
+	}
+	a.XP = a.ModulesCompleted*XPPerModule + a.CoursesCompleted*XPPerCourse
+
+	days := r.activeDays()
+	a.CurrentStreak, a.LongestStreak = streaks(days, now)
+	if len(days) > 0 {
+		a.LastActiveOn = days[len(days)-1].day.Format("2006-01-02")
+	}
+
+	for _, badge := range badges {
+		status := r.evaluate(badge, completions, days)
+		for _, award := range awarded {
+			if award.BadgeID == badge.ID {
+				earnedAt := award.EarnedAt
+				status.Earned, status.EarnedAt = true, &earnedAt
+			}
+		}
+		a.Badges = append(a.Badges, status)
+	}
+	return a
+}
+
+// evaluate works out the learner's progress towards a badge and, if it's
+// earned, when
+func (r LearningRecord) evaluate(badge Badge, completions []time.Time, days []activeDay) BadgeStatus {
+	status := BadgeStatus{Badge: badge, Goal: badge.Count}
+	var earnedAt time.Time
+
+	switch badge.Criterion {
+	case BadgeCoursesCompleted:
+		status.Progress = len(completions)
+		if len(completions) >= badge.Count {
+			earnedAt = completions[badge.Count-1]
+		}
+	case BadgeStreakDays:
+		run := 0
+		for i, d := range days {
+			if i > 0 && d.day.Equal(days[i-1].day.AddDate(0, 0, 1)) {
+				run++
+			} else {
+				run = 1
+			}
+			if run > status.Progress {
+				status.Progress = run
+			}
+			if run == badge.Count && earnedAt.IsZero() {
+				earnedAt = d.first
+			}
+		}
+	case BadgeCourseCompleted:
+		for _, course := range r.Courses {
+			if course.Slug != badge.Course {
+				continue
+			}
+			status.Goal = len(course.Modules)
+			if p := r.progressIn(course.ID); p != nil {
+				status.Progress = course.CompletedModuleCount(p.CompletedModules)
+				if course.IsCompletedBy(p.CompletedModules) {
+					earnedAt = completionTime(p)
+				}
+			}
+		}
+	}
+
+	if status.Progress > status.Goal {
+		status.Progress = status.Goal
+	}
+	if !earnedAt.IsZero() {
+		status.Earned, status.EarnedAt = true, &earnedAt
+	}
+	return status
+}
+
+// courseCompletions returns when each completed course was completed, oldest first
+func (r LearningRecord) courseCompletions() []time.Time {
+	var completions []time.Time
+	for _, course := range r.Courses {
+		if p := r.progressIn(course.ID); p != nil && course.IsCompletedBy(p.CompletedModules) {
+			completions = append(completions, completionTime(p))
+		}
+	}
+	sort.Slice(completions, func(i, j int) bool { return completions[i].Before(completions[j]) })
+	return completions
+}
+
+func (r LearningRecord) progressIn(courseID primitive.ObjectID) *Progress {
+	for i := range r.Progress {
+		if r.Progress[i].CourseID == courseID {
+			return &r.Progress[i]
+		}
+	}
+	return nil
+}
+
+// completionTime returns when a completed course was completed. Progress from
+// before completions were timestamped falls back to its latest module
+// completion, or the Unix epoch if there is none.
+func completionTime(p *Progress) time.Time {
+	if p.CompletedAt != nil {
+		return *p.CompletedAt
+	}
+	latest := time.Unix(0, 0).UTC()
+	for _, at := range p.ModuleCompletedAt {
+		if at.After(latest) {
+			latest = at
+		}
+	}
+	return latest
+}
+
+// activeDay is a UTC day on which the learner did something
+type activeDay struct {
+	day   time.Time // Midnight UTC
+	first time.Time // The first thing they did that day
+}
+
+// activeDays returns the days with learning activity, oldest first. Logins
+// don't count; module completions from the learner's progress do, so
+// completions from before the activity log existed still make a streak.
+func (r LearningRecord) activeDays() []activeDay {
+	byDay := make(map[time.Time]time.Time)
+	add := func(at time.Time) {
+		at = at.UTC()
+		day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+		if first, ok := byDay[day]; !ok || at.Before(first) {
+			byDay[day] = at
+		}
+	}
+	for _, event := range r.Activity {
+		if event.Type != ActivityLogin {
+			add(event.OccurredAt)
+		}
+	}
+	for _, p := range r.Progress {
+		for _, at := range p.ModuleCompletedAt {
+			add(at)
+		}
+	}
+
+	days := make([]activeDay, 0, len(byDay))
+	for day, first := range byDay {
+		days = append(days, activeDay{day: day, first: first})
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].day.Before(days[j].day) })
+	return days
+}
+
+// streaks returns the current run of consecutive active days, which is broken
+// once a whole day passes without activity, and the longest run
+func streaks(days []activeDay, now time.Time) (current int, longest int) {
+	run := 0
+	for i, d := range days {
+		if i > 0 && d.day.Equal(days[i-1].day.AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if len(days) > 0 && !days[len(days)-1].day.Before(today.AddDate(0, 0, -1)) {
+		current = run
+	}
+	return current, longest
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAchievements(t *testing.T) {
+	day := func(n int, hour int) time.Time {
+		return time.Date(2025, 3, n, hour, 0, 0, 0, time.UTC)
+	}
+	git := Course{ID: primitive.NewObjectID(), Slug: "git", Modules: []Module{{ID: "git-1"}, {ID: "git-2"}}}
+	http := Course{ID: primitive.NewObjectID(), Slug: "http", Modules: []Module{{ID: "http-1"}, {ID: "http-2"}}}
+	completedAt := day(3, 9)
+	record := LearningRecord{
+		Courses: []Course{git, http},
+		Progress: []Progress{
+			{
+				CourseID:          git.ID,
+				CompletedModules:  []string{"git-1", "git-2", "removed"},
+				ModuleCompletedAt: map[string]time.Time{"git-1": day(1, 10), "git-2": completedAt},
+				IsCompleted:       true,
+				CompletedAt:       &completedAt,
+			},
+			{CourseID: http.ID, CompletedModules: []string{"http-1"}, ModuleCompletedAt: map[string]time.Time{"http-1": day(5, 8)}},
+		},
+		Activity: []ActivityEvent{
+			{Type: ActivityModuleOpened, OccurredAt: day(2, 23)},
+			{Type: ActivityModuleOpened, OccurredAt: day(2, 7)},
+			{Type: ActivityLogin, OccurredAt: day(4, 12)}, // Logins don't count
+			{Type: ActivityQuizSubmitted, OccurredAt: day(6, 12)},
+		},
+	}
+	badges := []Badge{
+		{ID: "first-course", Criterion: BadgeCoursesCompleted, Count: 1},
+		{ID: "two-courses", Criterion: BadgeCoursesCompleted, Count: 2},
+		{ID: "streak-3", Criterion: BadgeStreakDays, Count: 3},
+		{ID: "streak-7", Criterion: BadgeStreakDays, Count: 7},
+		{ID: "http-master", Criterion: BadgeCourseCompleted, Course: "http"},
+	}
+
+	a := record.Achievements(badges, nil, day(7, 18))
+	if a.ModulesCompleted != 3 || a.CoursesCompleted != 1 || a.XP != 3*XPPerModule+XPPerCourse {
+		t.Fatalf("unexpected totals: %+v", a)
+	}
+	// Active on the 1st, 2nd, 3rd, 5th and 6th; still current on the 7th
+	if a.LongestStreak != 3 || a.CurrentStreak != 2 || a.LastActiveOn != "2025-03-06" {
+		t.Fatalf("unexpected streaks: %+v", a)
+	}
+	if later := record.Achievements(nil, nil, day(8, 0)); later.CurrentStreak != 0 {
+		t.Fatalf("streak should break after a day without activity: %+v", later)
+	}
+
+	want := map[string]struct {
+		earnedAt       *time.Time
+		progress, goal int
+	}{
+		"first-course": {&completedAt, 1, 1},
+		"two-courses":  {nil, 1, 2},
+		"streak-3":     {&completedAt, 3, 3},
+		"streak-7":     {nil, 3, 7},
+		"http-master":  {nil, 1, 2},
+	}
+	for _, status := range a.Badges {
+		w := want[status.ID]
+		if status.Earned != (w.earnedAt != nil) || status.Progress != w.progress || status.Goal != w.goal {
+			t.Errorf("%s: unexpected status %+v", status.ID, status)
+		}
+		if w.earnedAt != nil && !status.EarnedAt.Equal(*w.earnedAt) {
+			t.Errorf("%s: expected earned at %v, got %v", status.ID, w.earnedAt, status.EarnedAt)
+		}
+	}
+
+	// Awarded badges stay earned after progress is reset
+	awardedAt := day(1, 0)
+	reset := LearningRecord{Courses: record.Courses}.Achievements(badges, []UserBadge{{BadgeID: "first-course", EarnedAt: awardedAt}}, day(7, 0))
+	if first := reset.Badges[0]; !first.Earned || !first.EarnedAt.Equal(awardedAt) || first.Progress != 0 {
+		t.Fatalf("awarded badge should stay earned: %+v", first)
+	}
+}
+
+func TestValidateBadges(t *testing.T) {
+	if err := ValidateBadges(DefaultBadges()); err != nil {
+		t.Fatalf("default badges: %v", err)
+	}
+
+	for name, badge := range map[string]Badge{
+		"bad id":            {ID: "Bad Id", Title: "x", Criterion: BadgeStreakDays, Count: 1},
+		"no title":          {ID: "b", Criterion: BadgeStreakDays, Count: 1},
+		"unknown criterion": {ID: "b", Title: "x", Criterion: "xp", Count: 1},
+		"no count":          {ID: "b", Title: "x", Criterion: BadgeCoursesCompleted},
+		"no course":         {ID: "b", Title: "x", Criterion: BadgeCourseCompleted},
+	} {
+		if err := ValidateBadges([]Badge{badge}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	duplicate := Badge{ID: "b", Title: "x", Criterion: BadgeStreakDays, Count: 1}
+	if err := ValidateBadges([]Badge{duplicate, duplicate}); err == nil {
+		t.Error("duplicate ids: expected an error")
+	}
+}
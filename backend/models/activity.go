@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Activity event types
+const (
+	ActivityLogin             = "login"
+	ActivityModuleOpened      = "module_opened"
+	ActivityModuleCompleted   = "module_completed"
+	ActivityExerciseAttempted = "exercise_attempted" // Answers and code runs
+	ActivityHintRevealed      = "hint_revealed"
+	ActivitySolutionRevealed  = "solution_revealed"
+	ActivityQuizSubmitted     = "quiz_submitted"
+)
+
+// ActivityEvent records something a learner did. Events are append-only.
+type ActivityEvent struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Type       string             `bson:"type" json:"type"`
+	CourseID   primitive.ObjectID `bson:"course_id,omitempty" json:"course_id,omitempty"`
+	ModuleID   string             `bson:"module_id,omitempty" json:"module_id,omitempty"`
+	ItemID     string             `bson:"item_id,omitempty" json:"item_id,omitempty"` // Exercise or quiz ID
+	OccurredAt time.Time          `bson:"occurred_at" json:"occurred_at"`
+}
+
+// ActivityFilter selects activity events; zero fields match everything
+type ActivityFilter struct {
+	UserID   primitive.ObjectID
+	CourseID primitive.ObjectID
+	Types    []string
+	Since    time.Time // Inclusive
+	Until    time.Time // Exclusive
+	Limit    int       // Newest events first
+}
+
+// Matches reports whether the event passes the filter, ignoring Limit
+func (f ActivityFilter) Matches(event ActivityEvent) bool {
+	switch {
+	case !f.UserID.IsZero() && event.UserID != f.UserID,
+		!f.CourseID.IsZero() && event.CourseID != f.CourseID,
+		len(f.Types) > 0 && !containsString(f.Types, event.Type),
+		!f.Since.IsZero() && event.OccurredAt.Before(f.Since),
+		!f.Until.IsZero() && !event.OccurredAt.Before(f.Until):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,330 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// Built-in content block types
+const (
+	BlockText     = "text"
+	BlockCode     = "code"
+	BlockImage    = "image"
+	BlockCallout  = "callout"
+	BlockExercise = "exercise"
+	BlockVideo    = "video"
+	BlockQuiz     = "quiz"
+)
+
+// BlockData is the typed payload of a content block. Implementations are
+// pointers to structs whose json and bson field names form the block's "data"
+// object, and are registered with RegisterBlockType.
+type BlockData interface {
+	BlockType() string
+	Validate() error
+}
+
+var blockTypes = map[string]func() BlockData{}
+
+// RegisterBlockType makes a block type known to decoding and validation.
+// newData returns an empty payload to decode into.
+func RegisterBlockType(blockType string, newData func() BlockData) {
+	if _, exists := blockTypes[blockType]; exists {
+		panic("models: block type registered twice: " + blockType)
+	}
+	blockTypes[blockType] = newData
+}
+
+// BlockTypes returns the registered block types, sorted
+func BlockTypes() []string {
+	types := make([]string, 0, len(blockTypes))
+	for blockType := range blockTypes {
+		types = append(types, blockType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func init() {
+	RegisterBlockType(BlockText, func() BlockData { return &TextBlock{} })
+	RegisterBlockType(BlockCode, func() BlockData { return &CodeBlock{} })
+	RegisterBlockType(BlockImage, func() BlockData { return &ImageBlock{} })
+	RegisterBlockType(BlockCallout, func() BlockData { return &CalloutBlock{} })
+	RegisterBlockType(BlockExercise, func() BlockData { return &ExerciseBlock{} })
+	RegisterBlockType(BlockVideo, func() BlockData { return &VideoBlock{} })
+	RegisterBlockType(BlockQuiz, func() BlockData { return &QuizBlock{} })
+}
+
+// NewContentBlock wraps a typed payload in a content block
+func NewContentBlock(data BlockData) ContentBlock {
+	return ContentBlock{Type: data.BlockType(), Data: data}
+}
+
+// UnknownBlock holds the data of a block whose type isn't registered, so
+// documents with such blocks can still be read and written back unchanged.
+// It never passes validation.
+type UnknownBlock map[string]interface{}
+
+func (UnknownBlock) BlockType() string { return "" }
+func (UnknownBlock) Validate() error   { return errors.New("unsupported block type") }
+
+// newBlockData returns an empty payload for blockType, or an UnknownBlock
+func newBlockData(blockType string) BlockData {
+	if newData, ok := blockTypes[blockType]; ok {
+		return newData()
+	}
+	return UnknownBlock{}
+}
+
+// contentBlockDoc is the stored and wire shape of a content block
+type contentBlockDoc struct {
+	Type string    `bson:"type" json:"type"`
+	Data BlockData `bson:"data" json:"data"`
+}
+
+// MarshalJSON writes the block as {"type": ..., "data": {...}}
+func (b ContentBlock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(contentBlockDoc{Type: b.Type, Data: b.Data})
+}
+
+// UnmarshalJSON decodes "data" into the payload type registered for "type"
+func (b *ContentBlock) UnmarshalJSON(data []byte) error {
+	var doc struct {
+		Type string          `json:"type"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	b.Type = doc.Type
+	b.Data = nil
+	if len(doc.Data) == 0 || bytes.Equal(doc.Data, []byte("null")) {
+		return nil
+	}
+
+	payload := newBlockData(doc.Type)
+	if unknown, ok := payload.(UnknownBlock); ok {
+		if err := json.Unmarshal(doc.Data, &unknown); err != nil {
+			return err
+		}
+		b.Data = unknown
+		return nil
+	}
+	if err := json.Unmarshal(doc.Data, payload); err != nil {
+		return fmt.Errorf("%s block: %w", doc.Type, err)
+	}
+	b.Data = payload
+	return nil
+}
+
+// MarshalBSON writes the block as {type: ..., data: {...}}, the same shape
+// documents had when data was an untyped map
+func (b ContentBlock) MarshalBSON() ([]byte, error) {
+	return bson.Marshal(contentBlockDoc{Type: b.Type, Data: b.Data})
+}
+
+// UnmarshalBSON decodes data into the payload type registered for type.
+// Fields that aren't part of the payload are ignored.
+func (b *ContentBlock) UnmarshalBSON(data []byte) error {
+	var doc struct {
+		Type string        `bson:"type"`
+		Data bson.RawValue `bson:"data"`
+	}
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	b.Type = doc.Type
+	b.Data = nil
+	if doc.Data.Type != bsontype.EmbeddedDocument {
+		return nil
+	}
+
+	payload := newBlockData(doc.Type)
+	if unknown, ok := payload.(UnknownBlock); ok {
+		if err := doc.Data.Unmarshal(&unknown); err != nil {
+			return err
+		}
+		b.Data = unknown
+		return nil
+	}
+	if err := doc.Data.Unmarshal(payload); err != nil {
+		return fmt.Errorf("%s block: %w", doc.Type, err)
+	}
+	b.Data = payload
+	return nil
+}
+
+// TextBlock is Markdown text
+type TextBlock struct {
+	Markdown string `bson:"markdown" json:"markdown"`
+}
+
+func (*TextBlock) BlockType() string { return BlockText }
+
+func (t *TextBlock) Validate() error {
+	if strings.TrimSpace(t.Markdown) == "" {
+		return errors.New("markdown is required")
+	}
+	return nil
+}
+
+// CodeBlock is a syntax-highlighted code listing
+type CodeBlock struct {
+	Language string `bson:"language" json:"language"`
+	Code     string `bson:"code" json:"code"`
+}
+
+func (*CodeBlock) BlockType() string { return BlockCode }
+
+func (c *CodeBlock) Validate() error {
+	if c.Language == "" {
+		return errors.New("language is required")
+	}
+	if strings.TrimSpace(c.Code) == "" {
+		return errors.New("code is required")
+	}
+	return nil
+}
+
+// ImageBlock is an image with alt text and an optional caption
+type ImageBlock struct {
+	URL     string `bson:"url" json:"url"`
+	Alt     string `bson:"alt" json:"alt"`
+	Caption string `bson:"caption" json:"caption"`
+}
+
+func (*ImageBlock) BlockType() string { return BlockImage }
+
+func (i *ImageBlock) Validate() error {
+	if err := validateURL(i.URL); err != nil {
+		return err
+	}
+	if i.Alt == "" {
+		return errors.New("alt text is required")
+	}
+	return nil
+}
+
+// Callout variants
+const (
+	CalloutInfo    = "info"
+	CalloutTip     = "tip"
+	CalloutWarning = "warning"
+	CalloutDanger  = "danger"
+)
+
+// CalloutBlock is a highlighted note
+type CalloutBlock struct {
+	Variant string `bson:"variant" json:"variant"` // "info", "tip", "warning", "danger"
+	Text    string `bson:"text" json:"text"`
+}
+
+func (*CalloutBlock) BlockType() string { return BlockCallout }
+
+func (c *CalloutBlock) Validate() error {
+	switch c.Variant {
+	case CalloutInfo, CalloutTip, CalloutWarning, CalloutDanger:
+	default:
+		return fmt.Errorf("invalid callout variant %q: use info, tip, warning or danger", c.Variant)
+	}
+	if strings.TrimSpace(c.Text) == "" {
+		return errors.New("text is required")
+	}
+	return nil
+}
+
+// ExerciseBlock is a practice task with optional hints and a solution, and
+// optionally test cases that learners' Go submissions are run against.
+// Hints, the solution and tests are only served to learners through the
+// exercise endpoints, which apply the reveal policy; see exercises.go.
+type ExerciseBlock struct {
+	ID                  string         `bson:"id,omitempty" json:"id,omitempty" yaml:"id"` // Unique within the module; attempts and reveals are stored against it
+	Prompt              string         `bson:"prompt" json:"prompt" yaml:"prompt"`
+	Solution            string         `bson:"solution" json:"solution" yaml:"solution"`
+	Hints               []string       `bson:"hints" json:"hints,omitempty" yaml:"hints"`
+	RevealPolicy        string         `bson:"reveal_policy,omitempty" json:"reveal_policy,omitempty" yaml:"reveal_policy"` // "always" (default), "after_submission", "after_attempts"
+	RevealAfterAttempts int            `bson:"reveal_after_attempts,omitempty" json:"reveal_after_attempts,omitempty" yaml:"reveal_after_attempts"`
+	Tests               []ExerciseTest `bson:"tests,omitempty" json:"tests,omitempty" yaml:"tests"` // Makes the exercise runnable as a Go program
+}
+
+func (*ExerciseBlock) BlockType() string { return BlockExercise }
+
+func (e *ExerciseBlock) Validate() error {
+	if !ValidSlug(e.ID) {
+		return fmt.Errorf("invalid exercise id %q: use lowercase letters, digits and hyphens", e.ID)
+	}
+	if strings.TrimSpace(e.Prompt) == "" {
+		return errors.New("prompt is required")
+	}
+	for i, hint := range e.Hints {
+		if strings.TrimSpace(hint) == "" {
+			return fmt.Errorf("hint %d is empty", i)
+		}
+	}
+
+	switch e.RevealPolicy {
+	case "", RevealAlways, RevealAfterSubmission:
+		if e.RevealAfterAttempts != 0 {
+			return errors.New("reveal_after_attempts only applies to the after_attempts policy")
+		}
+	case RevealAfterAttempts:
+		if e.RevealAfterAttempts < 1 {
+			return errors.New("reveal_after_attempts must be at least 1")
+		}
+	default:
+		return fmt.Errorf("invalid reveal policy %q: use always, after_submission or after_attempts", e.RevealPolicy)
+	}
+
+	names := make(map[string]bool)
+	for i, test := range e.Tests {
+		if strings.TrimSpace(test.Name) == "" {
+			return fmt.Errorf("test %d: name is required", i)
+		}
+		if names[test.Name] {
+			return fmt.Errorf("duplicate test name %q", test.Name)
+		}
+		names[test.Name] = true
+	}
+	return nil
+}
+
+// VideoBlock is an embedded video
+type VideoBlock struct {
+	URL   string `bson:"url" json:"url"`
+	Title string `bson:"title" json:"title"`
+}
+
+func (*VideoBlock) BlockType() string { return BlockVideo }
+
+func (v *VideoBlock) Validate() error {
+	return validateURL(v.URL)
+}
+
+// validateURL accepts absolute http(s) URLs and site-relative paths like /images/a.png
+func validateURL(raw string) error {
+	if raw == "" {
+		return errors.New("url is required")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid url %q", raw)
+	}
+	if u.Scheme == "" && u.Host == "" && strings.HasPrefix(u.Path, "/") {
+		return nil
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url %q: use an http(s) URL or a path starting with /", raw)
+	}
+	return nil
+}
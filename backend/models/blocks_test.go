@@ -0,0 +1,112 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// legacyModule is a module as stored before blocks were typed, when data was a plain map
+var legacyModule = bson.M{
+	"id":    "git-1",
+	"title": "Intro",
+	"content": bson.A{
+		bson.M{"type": "text", "data": bson.M{"markdown": "Hello"}},
+		bson.M{"type": "exercise", "data": bson.M{"prompt": "Try it", "solution": "git init", "hints": bson.A{"One"}}},
+		bson.M{"type": "poll", "data": bson.M{"question": "Why?"}},
+	},
+	"video_url": "",
+}
+
+func TestContentBlockReadsLegacyBSON(t *testing.T) {
+	raw, err := bson.Marshal(legacyModule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var module Module
+	if err := bson.Unmarshal(raw, &module); err != nil {
+		t.Fatal(err)
+	}
+
+	text, ok := module.Content[0].Data.(*TextBlock)
+	if !ok || text.Markdown != "Hello" {
+		t.Fatalf("text block not decoded: %#v", module.Content[0].Data)
+	}
+	exercise, ok := module.Content[1].Data.(*ExerciseBlock)
+	if !ok || exercise.Prompt != "Try it" || !reflect.DeepEqual(exercise.Hints, []string{"One"}) {
+		t.Fatalf("exercise block not decoded: %#v", module.Content[1].Data)
+	}
+	unknown, ok := module.Content[2].Data.(UnknownBlock)
+	if !ok || unknown["question"] != "Why?" {
+		t.Fatalf("unknown block not preserved: %#v", module.Content[2].Data)
+	}
+
+	// Writing the module back produces the same document
+	again, err := bson.Marshal(module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want bson.M
+	_ = bson.Unmarshal(again, &got)
+	_ = bson.Unmarshal(raw, &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("BSON changed on round trip:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestContentBlockJSON(t *testing.T) {
+	block := NewContentBlock(&CalloutBlock{Variant: CalloutTip, Text: "Commit often"})
+	data, err := json.Marshal(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"type":"callout","data":{"variant":"tip","text":"Commit often"}}` {
+		t.Fatalf("unexpected JSON: %s", data)
+	}
+
+	var decoded ContentBlock
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, block) {
+		t.Fatalf("JSON round trip: got %#v, want %#v", decoded, block)
+	}
+
+	if err := json.Unmarshal([]byte(`{"type":"exercise","data":{"hints":"not a list"}}`), &decoded); err == nil {
+		t.Fatal("expected error for mistyped field")
+	}
+}
+
+func TestValidateContentBlock(t *testing.T) {
+	cases := []struct {
+		name  string
+		block ContentBlock
+		want  string
+	}{
+		{"valid", NewContentBlock(&ImageBlock{URL: "https://example.com/a.png", Alt: "A"}), ""},
+		{"relative url", NewContentBlock(&VideoBlock{URL: "/videos/intro.mp4"}), ""},
+		{"unknown type", ContentBlock{Type: "marquee", Data: UnknownBlock{"text": "hi"}}, "unsupported block type"},
+		{"no data", ContentBlock{Type: BlockText}, "has no data"},
+		{"mismatched data", ContentBlock{Type: BlockText, Data: &CodeBlock{Language: "go", Code: "x"}}, "has code data"},
+		{"missing markdown", NewContentBlock(&TextBlock{}), "markdown is required"},
+		{"bad variant", NewContentBlock(&CalloutBlock{Variant: "note", Text: "x"}), "invalid callout variant"},
+		{"bad scheme", NewContentBlock(&ImageBlock{URL: "javascript:alert(1)", Alt: "A"}), "invalid url"},
+		{"protocol relative", NewContentBlock(&VideoBlock{URL: "//example.com/v.mp4"}), "invalid url"},
+		{"missing alt", NewContentBlock(&ImageBlock{URL: "/a.png"}), "alt text is required"},
+		{"empty hint", NewContentBlock(&ExerciseBlock{ID: "x", Prompt: "x", Hints: []string{" "}}), "hint 0 is empty"},
+		{"missing exercise id", NewContentBlock(&ExerciseBlock{Prompt: "x"}), "invalid exercise id"},
+	}
+	for _, tc := range cases {
+		err := ValidateContentBlock(tc.block)
+		if tc.want == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+		if tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
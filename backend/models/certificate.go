@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Certificate records that a learner completed a course. It is issued once per
+// learner and course, and keeps the names as they were when it was issued.
+type Certificate struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	CourseID    primitive.ObjectID `bson:"course_id" json:"course_id"`
+	LearnerName string             `bson:"learner_name" json:"learner_name"`
+	CourseTitle string             `bson:"course_title" json:"course_title"`
+	CompletedAt time.Time          `bson:"completed_at" json:"completed_at"`
+	IssuedAt    time.Time          `bson:"issued_at" json:"issued_at"`
+}
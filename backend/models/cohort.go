@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DateLayout is the format of cohort start and end dates
+const DateLayout = "2006-01-02"
+
+// Cohort is a group of learners taught together by instructors. A learner in
+// any cohort sees only the courses assigned to their cohorts.
+type Cohort struct {
+	ID            primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Name          string               `bson:"name" json:"name"`
+	InstructorIDs []primitive.ObjectID `bson:"instructor_ids" json:"instructor_ids"`
+	MemberIDs     []primitive.ObjectID `bson:"member_ids" json:"member_ids"`
+	CourseIDs     []primitive.ObjectID `bson:"course_ids" json:"course_ids"` // Assigned courses
+	StartDate     string               `bson:"start_date" json:"start_date"` // YYYY-MM-DD
+	EndDate       string               `bson:"end_date" json:"end_date"`     // YYYY-MM-DD, inclusive
+	CreatedBy     primitive.ObjectID   `bson:"created_by" json:"created_by"`
+	CreatedAt     time.Time            `bson:"created_at" json:"created_at"`
+}
+
+// CohortFilter selects cohorts; zero fields match every cohort
+type CohortFilter struct {
+	MemberID     primitive.ObjectID
+	InstructorID primitive.ObjectID
+}
+
+// Matches reports whether the cohort passes the filter
+func (f CohortFilter) Matches(cohort Cohort) bool {
+	return (f.MemberID.IsZero() || containsObjectID(cohort.MemberIDs, f.MemberID)) &&
+		(f.InstructorID.IsZero() || containsObjectID(cohort.InstructorIDs, f.InstructorID))
+}
+
+// HasInstructor reports whether the user teaches the cohort
+func (c *Cohort) HasInstructor(userID primitive.ObjectID) bool {
+	return containsObjectID(c.InstructorIDs, userID)
+}
+
+// ValidateCohort checks that a cohort has a name, at least one instructor and
+// well-formed dates that don't end before they start
+func ValidateCohort(cohort *Cohort) error {
+	if cohort.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(cohort.InstructorIDs) == 0 {
+		return errors.New("a cohort needs at least one instructor")
+	}
+
+	start, err := time.Parse(DateLayout, cohort.StartDate)
+	if err != nil {
+		return errors.New("start_date must be a date like 2025-01-31")
+	}
+	end, err := time.Parse(DateLayout, cohort.EndDate)
+	if err != nil {
+		return errors.New("end_date must be a date like 2025-01-31")
+	}
+	if end.Before(start) {
+		return errors.New("end_date is before start_date")
+	}
+	return nil
+}
+
+// AssignedCourseIDs returns the courses assigned to any of the cohorts, or nil
+// if there are no cohorts, meaning every course is available
+func AssignedCourseIDs(cohorts []Cohort) map[primitive.ObjectID]bool {
+	if len(cohorts) == 0 {
+		return nil
+	}
+	assigned := make(map[primitive.ObjectID]bool)
+	for _, cohort := range cohorts {
+		for _, id := range cohort.CourseIDs {
+			assigned[id] = true
+		}
+	}
+	return assigned
+}
+
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
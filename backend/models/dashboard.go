@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProgressScope selects the learners and courses a dashboard aggregates over;
+// a nil list matches every learner or course
+type ProgressScope struct {
+	UserIDs   []primitive.ObjectID
+	CourseIDs []primitive.ObjectID
+}
+
+// Includes reports whether the progress record is in scope
+func (s ProgressScope) Includes(p Progress) bool {
+	return (s.UserIDs == nil || containsObjectID(s.UserIDs, p.UserID)) &&
+		(s.CourseIDs == nil || containsObjectID(s.CourseIDs, p.CourseID))
+}
+
+// CourseCompletionCount counts the learners in scope who have progress in,
+// have started and have completed a course
+type CourseCompletionCount struct {
+	CourseID  primitive.ObjectID `bson:"_id" json:"course_id"`
+	Learners  int                `bson:"learners" json:"learners"`
+	Started   int                `bson:"started" json:"started"` // Completed at least one module
+	Completed int                `bson:"completed" json:"completed"`
+}
+
+// LearnerLastActivity is when a learner last did something other than log in
+type LearnerLastActivity struct {
+	UserID       primitive.ObjectID `bson:"_id" json:"user_id"`
+	LastActiveAt time.Time          `bson:"last_active_at" json:"last_active_at"`
+}
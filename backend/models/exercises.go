@@ -0,0 +1,150 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Exercise reveal policies control when a learner may reveal the solution.
+// Hints can always be revealed, one at a time and in order.
+const (
+	RevealAlways          = "always"           // Any time (the default)
+	RevealAfterSubmission = "after_submission" // Once the learner has submitted an answer
+	RevealAfterAttempts   = "after_attempts"   // Once the learner has submitted RevealAfterAttempts answers
+)
+
+// What an ExerciseReveal revealed
+const (
+	RevealKindHint     = "hint"
+	RevealKindSolution = "solution"
+)
+
+// ExerciseAttempt is an answer a learner submitted for an exercise
+type ExerciseAttempt struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	CourseID    primitive.ObjectID `bson:"course_id" json:"course_id"`
+	ModuleID    string             `bson:"module_id" json:"module_id"`
+	ExerciseID  string             `bson:"exercise_id" json:"exercise_id"`
+	Answer      string             `bson:"answer" json:"answer"`
+	SubmittedAt time.Time          `bson:"submitted_at" json:"submitted_at"`
+}
+
+// ExerciseReveal records when a learner first revealed a hint or an exercise's solution
+type ExerciseReveal struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	CourseID   primitive.ObjectID `bson:"course_id" json:"course_id"`
+	ModuleID   string             `bson:"module_id" json:"module_id"`
+	ExerciseID string             `bson:"exercise_id" json:"exercise_id"`
+	Kind       string             `bson:"kind" json:"kind"`             // "hint" or "solution"
+	HintIndex  int                `bson:"hint_index" json:"hint_index"` // Which hint; 0 for solutions
+	RevealedAt time.Time          `bson:"revealed_at" json:"revealed_at"`
+}
+
+// CanRevealSolution reports whether the policy lets a learner who has submitted
+// attempts answers see the solution
+func (e *ExerciseBlock) CanRevealSolution(attempts int) bool {
+	switch e.RevealPolicy {
+	case RevealAfterSubmission:
+		return attempts >= 1
+	case RevealAfterAttempts:
+		return attempts >= e.RevealAfterAttempts
+	default:
+		return true
+	}
+}
+
+// Exercise returns the exercise with the ID
+func (m *Module) Exercise(id string) (*ExerciseBlock, bool) {
+	for _, block := range m.Content {
+		if exercise, ok := block.Data.(*ExerciseBlock); ok && exercise.ID == id {
+			return exercise, true
+		}
+	}
+	return nil, false
+}
+
+// validateExerciseIDs checks that no two exercises in a module share an ID
+func validateExerciseIDs(module *Module) error {
+	seen := make(map[string]bool)
+	for _, block := range module.Content {
+		exercise, ok := block.Data.(*ExerciseBlock)
+		if !ok {
+			continue
+		}
+		if seen[exercise.ID] {
+			return fmt.Errorf("module %q: duplicate exercise id %q", module.ID, exercise.ID)
+		}
+		seen[exercise.ID] = true
+	}
+	return nil
+}
+
+// ExercisePreview is what learners see of an exercise in course responses:
+// the prompt, without hints, the solution or test cases
+type ExercisePreview struct {
+	ID                  string `json:"id"`
+	Prompt              string `json:"prompt"`
+	HintCount           int    `json:"hint_count"`
+	HasSolution         bool   `json:"has_solution"`
+	RevealPolicy        string `json:"reveal_policy"`
+	RevealAfterAttempts int    `json:"reveal_after_attempts,omitempty"`
+	TestCount           int    `json:"test_count,omitempty"` // Submissions can be run against this many tests
+}
+
+func (*ExercisePreview) BlockType() string { return BlockExercise }
+
+// Validate rejects previews so a course read from a public response can't be stored
+func (*ExercisePreview) Validate() error {
+	return errors.New("exercise preview has no solution or hints")
+}
+
+// Redactable is implemented by block data holding answers that learners must
+// not see in course responses
+type Redactable interface {
+	Redacted() BlockData
+}
+
+// Redacted returns the exercise without its hints, solution and tests
+func (e *ExerciseBlock) Redacted() BlockData {
+	policy := e.RevealPolicy
+	if policy == "" {
+		policy = RevealAlways
+	}
+	return &ExercisePreview{
+		ID:                  e.ID,
+		Prompt:              e.Prompt,
+		HintCount:           len(e.Hints),
+		HasSolution:         e.Solution != "",
+		RevealPolicy:        policy,
+		RevealAfterAttempts: e.RevealAfterAttempts,
+		TestCount:           len(e.Tests),
+	}
+}
+
+// Public returns a copy of the course that is safe to send to learners:
+// blocks holding answers are redacted
+func (c Course) Public() Course {
+	modules := make([]Module, len(c.Modules))
+	for i, module := range c.Modules {
+		content := make([]ContentBlock, len(module.Content))
+		for j, block := range module.Content {
+			content[j] = block
+			if redactable, ok := block.Data.(Redactable); ok {
+				content[j].Data = redactable.Redacted()
+			}
+		}
+		if module.Content != nil {
+			module.Content = content
+		}
+		modules[i] = module
+	}
+	if c.Modules != nil {
+		c.Modules = modules
+	}
+	return c
+}
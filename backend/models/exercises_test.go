@@ -0,0 +1,84 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func exerciseModule(exercises ...*ExerciseBlock) *Module {
+	module := &Module{ID: "go-1", Title: "Loops"}
+	module.Content = append(module.Content, NewContentBlock(&TextBlock{Markdown: "Intro"}))
+	for _, exercise := range exercises {
+		module.Content = append(module.Content, NewContentBlock(exercise))
+	}
+	return module
+}
+
+func TestModuleExerciseIDs(t *testing.T) {
+	named := &ExerciseBlock{ID: "sum", Prompt: "Sum"}
+	module := exerciseModule(&ExerciseBlock{ID: "count", Prompt: "First"}, named)
+
+	if exercise, ok := module.Exercise("sum"); !ok || exercise != named {
+		t.Fatal("exercise not found by ID")
+	}
+	if _, ok := module.Exercise("exercise-2"); ok {
+		t.Fatal("exercises have no positional IDs")
+	}
+
+	duplicate := exerciseModule(&ExerciseBlock{ID: "sum", Prompt: "First"}, &ExerciseBlock{ID: "sum", Prompt: "Clash"})
+	if err := ValidateModule(duplicate); err == nil || !strings.Contains(err.Error(), "duplicate exercise id") {
+		t.Fatalf("expected duplicate exercise id error, got %v", err)
+	}
+
+	missing := exerciseModule(&ExerciseBlock{Prompt: "First"})
+	if err := ValidateModule(missing); err == nil || !strings.Contains(err.Error(), "invalid exercise id") {
+		t.Fatalf("expected invalid exercise id error, got %v", err)
+	}
+}
+
+func TestExerciseRevealPolicy(t *testing.T) {
+	cases := []struct {
+		exercise ExerciseBlock
+		attempts int
+		want     bool
+	}{
+		{ExerciseBlock{}, 0, true},
+		{ExerciseBlock{RevealPolicy: RevealAfterSubmission}, 0, false},
+		{ExerciseBlock{RevealPolicy: RevealAfterSubmission}, 1, true},
+		{ExerciseBlock{RevealPolicy: RevealAfterAttempts, RevealAfterAttempts: 3}, 2, false},
+		{ExerciseBlock{RevealPolicy: RevealAfterAttempts, RevealAfterAttempts: 3}, 3, true},
+	}
+	for _, tc := range cases {
+		if got := tc.exercise.CanRevealSolution(tc.attempts); got != tc.want {
+			t.Errorf("%s/%d with %d attempts: got %v", tc.exercise.RevealPolicy, tc.exercise.RevealAfterAttempts, tc.attempts, got)
+		}
+	}
+
+	invalid := []ExerciseBlock{
+		{ID: "x", Prompt: "x", RevealPolicy: "never"},
+		{ID: "x", Prompt: "x", RevealPolicy: RevealAfterAttempts},
+		{ID: "x", Prompt: "x", RevealAfterAttempts: 2},
+	}
+	for _, exercise := range invalid {
+		if err := exercise.Validate(); err == nil {
+			t.Errorf("expected error for %+v", exercise)
+		}
+	}
+}
+
+func TestCoursePublicRedactsExercises(t *testing.T) {
+	exercise := &ExerciseBlock{ID: "count", Prompt: "First", Solution: "secret", Hints: []string{"hint"}}
+	course := Course{Slug: "go", Title: "Go", Modules: []Module{*exerciseModule(exercise)}}
+
+	public := course.Public()
+	preview, ok := public.Modules[0].Content[1].Data.(*ExercisePreview)
+	if !ok || preview.ID != "count" || preview.HintCount != 1 || !preview.HasSolution {
+		t.Fatalf("unexpected preview: %#v", public.Modules[0].Content[1].Data)
+	}
+	if course.Modules[0].Content[1].Data != exercise {
+		t.Fatal("Public modified the original course")
+	}
+	if err := ValidateCourse(&public); err == nil {
+		t.Fatal("a public course must not pass validation for storage")
+	}
+}
@@ -1,34 +1,178 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
 
 type User struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name     string             `bson:"name" json:"name"`
-	Email    string             `bson:"email" json:"email"`
-	Password string             `bson:"password" json:"-"` // Don't return password in JSON
-	Role     string             `bson:"role" json:"role"` // "student", "admin"
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name            string             `bson:"name" json:"name"`
+	Email           string             `bson:"email" json:"email"`
+	Password        string             `bson:"password" json:"-"` // Don't return password in JSON
+	Role            string             `bson:"role" json:"role"`  // "student", "instructor", "admin"
+	EmailVerified   bool               `bson:"email_verified" json:"email_verified"`
+	EmailVerifiedAt *time.Time         `bson:"email_verified_at,omitempty" json:"email_verified_at,omitempty"`
+}
+
+// UnmarshalBSON decodes a user, treating users stored before email
+// verification existed, whose documents have no email_verified field, as
+// verified. Requiring verification then doesn't lock them out, and signing in
+// with a provider doesn't treat their account as unclaimed.
+func (u *User) UnmarshalBSON(data []byte) error {
+	type storedUser User
+	if err := bson.Unmarshal(data, (*storedUser)(u)); err != nil {
+		return err
+	}
+	if _, err := bson.Raw(data).LookupErr("email_verified"); errors.Is(err, bsoncore.ErrElementNotFound) {
+		u.EmailVerified = true
+	}
+	return nil
+}
+
+// User roles
+const (
+	RoleStudent    = "student"
+	RoleInstructor = "instructor"
+	RoleAdmin      = "admin"
+)
+
+// ValidRole reports whether role is one of the known user roles
+func ValidRole(role string) bool {
+	switch role {
+	case RoleStudent, RoleInstructor, RoleAdmin:
+		return true
+	}
+	return false
 }
 
 type Course struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Title       string             `bson:"title" json:"title"`
-	Description string             `bson:"description" json:"description"`
-	Modules     []Module           `bson:"modules" json:"modules"`
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Slug          string             `bson:"slug" json:"slug"` // Stable identifier, survives reseeding
+	Title         string             `bson:"title" json:"title"`
+	Description   string             `bson:"description" json:"description"`
+	Modules       []Module           `bson:"modules" json:"modules"`
+	Prerequisites []string           `bson:"prerequisites,omitempty" json:"prerequisites"` // Slugs of courses to complete before starting this one
+	Sequential    bool               `bson:"sequential,omitempty" json:"sequential"`       // Each module requires the one before it
+	Version       int                `bson:"version" json:"version"`                       // Incremented on every edit, for optimistic concurrency
 }
 
-// ContentBlock represents a single piece of content within a module
-// Supported types: "text", "code", "image", "callout", "exercise", "video"
+// CourseUpdate is a partial update to a course's own fields; nil fields are left unchanged
+type CourseUpdate struct {
+	Slug          *string   `json:"slug"`
+	Title         *string   `json:"title"`
+	Description   *string   `json:"description"`
+	Prerequisites *[]string `json:"prerequisites"`
+	Sequential    *bool     `json:"sequential"`
+}
+
+// ContentBlock represents a single piece of content within a module.
+// Data holds the typed payload for Type; see blocks.go for the registered types.
 type ContentBlock struct {
-	Type string                 `bson:"type" json:"type"`
-	Data map[string]interface{} `bson:"data" json:"data"`
+	Type string    `bson:"type" json:"type"`
+	Data BlockData `bson:"data" json:"data"`
 }
 
 type Module struct {
-	ID       string         `bson:"id" json:"id"`
-	Title    string         `bson:"title" json:"title"`
-	Content  []ContentBlock `bson:"content" json:"content"`
-	VideoURL string         `bson:"video_url" json:"video_url"`
+	ID                 string         `bson:"id" json:"id"`
+	Title              string         `bson:"title" json:"title"`
+	Content            []ContentBlock `bson:"content" json:"content"`
+	VideoURL           string         `bson:"video_url" json:"video_url"`
+	RequirePassingQuiz bool           `bson:"require_passing_quiz,omitempty" json:"require_passing_quiz"` // Learners must pass every quiz in the module to complete it
+	Prerequisites      []string       `bson:"prerequisites,omitempty" json:"prerequisites"`               // IDs of modules in the same course to complete first
+}
+
+// Module returns the course's module with the ID
+func (c *Course) Module(id string) (*Module, bool) {
+	for i := range c.Modules {
+		if c.Modules[i].ID == id {
+			return &c.Modules[i], true
+		}
+	}
+	return nil, false
+}
+
+// SameAs matches courses across databases by slug when both have one,
+// otherwise by title (courses seeded before slugs existed)
+func (c *Course) SameAs(other *Course) bool {
+	if c.Slug != "" && other.Slug != "" {
+		return c.Slug == other.Slug
+	}
+	return c.Title == other.Title
+}
+
+// CompletedModuleCount counts the course's modules whose IDs are in completed;
+// IDs that aren't modules of the course are ignored
+func (c *Course) CompletedModuleCount(completed []string) int {
+	done := make(map[string]bool, len(completed))
+	for _, id := range completed {
+		done[id] = true
+	}
+	count := 0
+	for _, module := range c.Modules {
+		if done[module.ID] {
+			count++
+		}
+	}
+	return count
+}
+
+// IsCompletedBy reports whether completed includes every module of the course.
+// A course without modules can't be completed.
+func (c *Course) IsCompletedBy(completed []string) bool {
+	return len(c.Modules) > 0 && c.CompletedModuleCount(completed) == len(c.Modules)
+}
+
+// ReconcileProgress brings a learner's progress in line with the course's
+// current modules: it drops completed IDs that are no longer modules of the
+// course, with their timestamps, and recomputes IsCompleted and CompletedAt
+// (set to now if the course becomes complete). It reports what changed, if anything.
+func (c *Course) ReconcileProgress(p *Progress, now time.Time) (ProgressChange, bool) {
+	change := ProgressChange{
+		UserID:         p.UserID,
+		CourseID:       c.ID,
+		RemovedModules: []string{},
+		WasCompleted:   p.IsCompleted,
+	}
+
+	kept := make([]string, 0, len(p.CompletedModules))
+	for _, id := range p.CompletedModules {
+		if _, ok := c.Module(id); ok && !containsString(kept, id) {
+			kept = append(kept, id)
+		} else {
+			change.RemovedModules = append(change.RemovedModules, id)
+		}
+	}
+	p.CompletedModules = kept
+	for id := range p.ModuleCompletedAt {
+		if !containsString(kept, id) {
+			delete(p.ModuleCompletedAt, id)
+		}
+	}
+	p.IsCompleted = c.IsCompletedBy(kept)
+	change.IsCompleted = p.IsCompleted
+	switch {
+	case !p.IsCompleted:
+		p.CompletedAt = nil
+	case !change.WasCompleted:
+		p.CompletedAt = &now
+	}
+
+	return change, len(change.RemovedModules) > 0 || change.WasCompleted != change.IsCompleted
+}
+
+// ModuleUpdate is a partial update to a module; nil fields are left unchanged.
+// Module IDs can't be changed because progress records refer to them.
+type ModuleUpdate struct {
+	Title              *string         `json:"title"`
+	VideoURL           *string         `json:"video_url"`
+	Content            *[]ContentBlock `json:"content"`
+	RequirePassingQuiz *bool           `json:"require_passing_quiz"`
+	Prerequisites      *[]string       `json:"prerequisites"`
 }
 
 type Progress struct {
@@ -37,14 +181,110 @@ type Progress struct {
 	CourseID         primitive.ObjectID `bson:"course_id" json:"course_id"`
 	CompletedModules []string           `bson:"completed_modules" json:"completed_modules"` // List of Module IDs
 	IsCompleted      bool               `bson:"is_completed" json:"is_completed"`
+	// ModuleCompletedAt records when each module was first completed, keyed by module ID.
+	// Modules completed before timestamps were recorded have no entry.
+	ModuleCompletedAt map[string]time.Time `bson:"module_completed_at,omitempty" json:"module_completed_at,omitempty"`
+	CompletedAt       *time.Time           `bson:"completed_at,omitempty" json:"completed_at,omitempty"` // When the course was completed
+}
+
+// ProgressChange describes how reconciling a learner's progress with a course changed it
+type ProgressChange struct {
+	UserID         primitive.ObjectID `json:"user_id"`
+	CourseID       primitive.ObjectID `json:"course_id"`
+	RemovedModules []string           `json:"removed_modules"` // Completed IDs that are no longer modules of the course
+	WasCompleted   bool               `json:"was_completed"`
+	IsCompleted    bool               `json:"is_completed"`
+}
+
+// Progress reset scopes
+const (
+	ResetModule = "module" // Un-complete one module
+	ResetCourse = "course" // Clear a learner's progress in one course
+	ResetAll    = "all"    // Clear a learner's progress in every course
+)
+
+// ProgressReset records who undid a learner's progress, and how much of it
+type ProgressReset struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID   primitive.ObjectID `bson:"user_id" json:"user_id"` // Learner whose progress was reset
+	Scope    string             `bson:"scope" json:"scope"`
+	CourseID primitive.ObjectID `bson:"course_id,omitempty" json:"course_id,omitempty"` // Unset for ResetAll
+	ModuleID string             `bson:"module_id,omitempty" json:"module_id,omitempty"` // Only set for ResetModule
+	ResetBy  primitive.ObjectID `bson:"reset_by" json:"reset_by"`
+	ResetAt  time.Time          `bson:"reset_at" json:"reset_at"`
 }
 
 // CourseWithProgress combines course data with user's progress
 type CourseWithProgress struct {
-	Course           Course   `json:"course"`
-	CompletedModules []string `json:"completed_modules"`
-	IsCompleted      bool     `json:"is_completed"`
-	ProgressPercent  float64  `json:"progress_percent"`
+	Course           Course     `json:"course"`
+	CompletedModules []string   `json:"completed_modules"`
+	IsCompleted      bool       `json:"is_completed"`
+	ProgressPercent  float64    `json:"progress_percent"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+	// Locked is set while any course in UnmetPrerequisites (by slug) is incomplete
+	Locked             bool           `json:"locked"`
+	UnmetPrerequisites []string       `json:"unmet_prerequisites"`
+	Modules            []ModuleStatus `json:"modules"` // In course order
 }
 
+// ModuleStatus reports whether a learner has completed a module and whether they can yet
+type ModuleStatus struct {
+	ModuleID           string     `json:"module_id"`
+	Completed          bool       `json:"completed"`
+	CompletedAt        *time.Time `json:"completed_at,omitempty"`
+	Locked             bool       `json:"locked"`
+	UnmetPrerequisites []string   `json:"unmet_prerequisites"` // Module IDs
+}
 
+// RefreshToken is a long-lived, single-use token that is exchanged for a new access token.
+// Only the SHA-256 hash of the token is stored. Every token rotated from the same login
+// shares a FamilyID so the whole chain can be revoked if a used token is replayed.
+type RefreshToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	FamilyID  string             `bson:"family_id" json:"family_id"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	RevokedAt *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+// RevokedAccessToken records an access token (by JWT ID) that was revoked before it expired
+type RevokedAccessToken struct {
+	JTI       string             `bson:"jti" json:"jti"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"` // Kept until the token would have expired anyway
+}
+
+// PasswordResetToken is a single-use token emailed to a user who forgot their password.
+// Only the SHA-256 hash of the token is stored.
+type PasswordResetToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty" json:"used_at,omitempty"`
+}
+
+// EmailVerificationToken is a single-use token emailed to a new user to confirm
+// they own their address. Only the SHA-256 hash of the token is stored.
+type EmailVerificationToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty" json:"used_at,omitempty"`
+}
+
+// UserIdentity links a user to their account at an external identity provider,
+// such as GitHub, so they can sign in there instead of with a password
+type UserIdentity struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Provider  string             `bson:"provider" json:"provider"`
+	Subject   string             `bson:"subject" json:"subject"` // The provider's stable ID for the account
+	Email     string             `bson:"email" json:"email"`     // As the provider reported it when linked
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
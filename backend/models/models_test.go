@@ -0,0 +1,32 @@
+package models
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUserUnmarshalBSONTreatsLegacyUsersAsVerified(t *testing.T) {
+	cases := []struct {
+		name string
+		doc  bson.M
+		want bool
+	}{
+		{"legacy user", bson.M{"email": "old@example.com"}, true},
+		{"unverified", bson.M{"email": "new@example.com", "email_verified": false}, false},
+		{"verified", bson.M{"email": "new@example.com", "email_verified": true}, true},
+	}
+	for _, tc := range cases {
+		raw, err := bson.Marshal(tc.doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var user User
+		if err := bson.Unmarshal(raw, &user); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if user.EmailVerified != tc.want || user.Email != tc.doc["email"] {
+			t.Errorf("%s: got %+v, want email_verified %v", tc.name, user, tc.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ModulePrerequisites returns the IDs of the modules a learner must complete
+// before the module: its own prerequisites and, in a sequential course, the
+// module before it
+func (c *Course) ModulePrerequisites(moduleID string) []string {
+	for i, module := range c.Modules {
+		if module.ID != moduleID {
+			continue
+		}
+
+		prerequisites := append([]string{}, module.Prerequisites...)
+		if c.Sequential && i > 0 && !containsString(prerequisites, c.Modules[i-1].ID) {
+			prerequisites = append(prerequisites, c.Modules[i-1].ID)
+		}
+		return prerequisites
+	}
+	return nil
+}
+
+// UnmetModulePrerequisites returns the module's prerequisites that aren't in completed
+func (c *Course) UnmetModulePrerequisites(moduleID string, completed []string) []string {
+	unmet := []string{}
+	for _, id := range c.ModulePrerequisites(moduleID) {
+		if !containsString(completed, id) {
+			unmet = append(unmet, id)
+		}
+	}
+	return unmet
+}
+
+// UnmetCoursePrerequisites returns the course's prerequisite slugs that aren't
+// in completed. Slugs that don't name an existing course, per exists, can never
+// be met and are ignored rather than locking the course forever.
+func (c *Course) UnmetCoursePrerequisites(completed []string, exists func(slug string) bool) []string {
+	unmet := []string{}
+	for _, slug := range c.Prerequisites {
+		if !containsString(completed, slug) && exists(slug) {
+			unmet = append(unmet, slug)
+		}
+	}
+	return unmet
+}
+
+// validateModulePrerequisites checks the parts of a module's prerequisites
+// that don't depend on the rest of the course
+func validateModulePrerequisites(module *Module) error {
+	seen := make(map[string]bool)
+	for _, id := range module.Prerequisites {
+		if id == module.ID {
+			return fmt.Errorf("module %q can't be its own prerequisite", module.ID)
+		}
+		if seen[id] {
+			return fmt.Errorf("module %q: duplicate prerequisite %q", module.ID, id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// ValidatePrerequisites checks that the course's prerequisites name other
+// courses once each, and that its modules' prerequisites, including the order
+// of a sequential course, name modules of the course without forming a cycle
+func ValidatePrerequisites(course *Course) error {
+	seen := make(map[string]bool)
+	for _, slug := range course.Prerequisites {
+		if !ValidSlug(slug) {
+			return fmt.Errorf("invalid prerequisite course slug %q", slug)
+		}
+		if slug == course.Slug {
+			return fmt.Errorf("course %q can't be its own prerequisite", course.Slug)
+		}
+		if seen[slug] {
+			return fmt.Errorf("duplicate prerequisite course %q", slug)
+		}
+		seen[slug] = true
+	}
+
+	for i := range course.Modules {
+		module := &course.Modules[i]
+		if err := validateModulePrerequisites(module); err != nil {
+			return err
+		}
+		for _, id := range module.Prerequisites {
+			if _, ok := course.Module(id); !ok {
+				return fmt.Errorf("module %q: prerequisite %q is not a module of the course", module.ID, id)
+			}
+		}
+	}
+
+	ids := make([]string, len(course.Modules))
+	for i, module := range course.Modules {
+		ids[i] = module.ID
+	}
+	if cycle := findCycle(ids, course.ModulePrerequisites); cycle != nil {
+		return fmt.Errorf("module prerequisites form a cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// ValidateCatalogPrerequisites checks that the prerequisites of the courses
+// don't form a cycle between courses, which would lock every course in it for
+// good. Prerequisites naming courses outside the catalog are ignored, as they
+// are for learners.
+func ValidateCatalogPrerequisites(courses []Course) error {
+	bySlug := make(map[string]*Course, len(courses))
+	slugs := make([]string, 0, len(courses))
+	for i := range courses {
+		bySlug[courses[i].Slug] = &courses[i]
+		slugs = append(slugs, courses[i].Slug)
+	}
+
+	prerequisites := func(slug string) []string {
+		var existing []string
+		for _, prerequisite := range bySlug[slug].Prerequisites {
+			if _, ok := bySlug[prerequisite]; ok {
+				existing = append(existing, prerequisite)
+			}
+		}
+		return existing
+	}
+	if cycle := findCycle(slugs, prerequisites); cycle != nil {
+		return fmt.Errorf("course prerequisites form a cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// findCycle does a depth-first search of the graph for a cycle and returns it,
+// starting and ending with the same node, or nil if there is none
+func findCycle(nodes []string, edges func(node string) []string) []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(node string) []string
+	visit = func(node string) []string {
+		switch state[node] {
+		case visiting:
+			start := 0
+			for path[start] != node {
+				start++
+			}
+			return append(append([]string{}, path[start:]...), node)
+		case done:
+			return nil
+		}
+
+		state[node] = visiting
+		path = append(path, node)
+		for _, next := range edges(node) {
+			if cycle := visit(next); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[node] = done
+		return nil
+	}
+	for _, node := range nodes {
+		if cycle := visit(node); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
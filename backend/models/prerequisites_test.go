@@ -0,0 +1,99 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func prerequisiteCourse(sequential bool) *Course {
+	return &Course{
+		Slug:       "go",
+		Title:      "Go",
+		Sequential: sequential,
+		Modules: []Module{
+			{ID: "basics", Title: "Basics"},
+			{ID: "types", Title: "Types"},
+			{ID: "generics", Title: "Generics", Prerequisites: []string{"basics"}},
+		},
+	}
+}
+
+func TestModulePrerequisites(t *testing.T) {
+	free := prerequisiteCourse(false)
+	if got := free.ModulePrerequisites("types"); len(got) != 0 {
+		t.Fatalf("types should have no prerequisites, got %v", got)
+	}
+	if got := free.UnmetModulePrerequisites("generics", []string{"types"}); !reflect.DeepEqual(got, []string{"basics"}) {
+		t.Fatalf("unexpected unmet prerequisites: %v", got)
+	}
+
+	sequential := prerequisiteCourse(true)
+	if got := sequential.ModulePrerequisites("basics"); len(got) != 0 {
+		t.Fatalf("the first module should have no prerequisites, got %v", got)
+	}
+	if got := sequential.ModulePrerequisites("generics"); !reflect.DeepEqual(got, []string{"basics", "types"}) {
+		t.Fatalf("sequential course should add the previous module: %v", got)
+	}
+	if got := sequential.UnmetModulePrerequisites("generics", []string{"basics", "types"}); len(got) != 0 {
+		t.Fatalf("expected no unmet prerequisites, got %v", got)
+	}
+}
+
+func TestUnmetCoursePrerequisites(t *testing.T) {
+	course := &Course{Slug: "web", Prerequisites: []string{"go", "http", "retired"}}
+	exists := func(slug string) bool { return slug != "retired" }
+
+	if got := course.UnmetCoursePrerequisites([]string{"go"}, exists); !reflect.DeepEqual(got, []string{"http"}) {
+		t.Fatalf("unexpected unmet courses: %v", got)
+	}
+}
+
+func TestValidatePrerequisites(t *testing.T) {
+	if err := ValidateCourse(prerequisiteCourse(true)); err != nil {
+		t.Fatalf("valid course: %v", err)
+	}
+
+	cases := map[string]struct {
+		edit func(c *Course)
+		want string
+	}{
+		"unknown module": {func(c *Course) { c.Modules[1].Prerequisites = []string{"missing"} }, "not a module"},
+		"self":           {func(c *Course) { c.Modules[1].Prerequisites = []string{"types"} }, "own prerequisite"},
+		"duplicate":      {func(c *Course) { c.Modules[2].Prerequisites = []string{"basics", "basics"} }, "duplicate prerequisite"},
+		"cycle":          {func(c *Course) { c.Modules[0].Prerequisites = []string{"generics"} }, "cycle: basics -> generics -> basics"},
+		"sequential cycle": {func(c *Course) {
+			c.Sequential = true
+			c.Modules[0].Prerequisites = []string{"types"}
+		}, "cycle"},
+		"course self":      {func(c *Course) { c.Prerequisites = []string{"go"} }, "own prerequisite"},
+		"course slug":      {func(c *Course) { c.Prerequisites = []string{"Not A Slug"} }, "invalid prerequisite"},
+		"course duplicate": {func(c *Course) { c.Prerequisites = []string{"http", "http"} }, "duplicate prerequisite course"},
+	}
+	for name, tc := range cases {
+		course := prerequisiteCourse(false)
+		tc.edit(course)
+		err := ValidateCourse(course)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, tc.want, err)
+		}
+	}
+}
+
+func TestValidateCatalogPrerequisites(t *testing.T) {
+	catalog := func(prerequisites map[string][]string) []Course {
+		var courses []Course
+		for _, slug := range []string{"go", "http", "web"} {
+			courses = append(courses, Course{Slug: slug, Prerequisites: prerequisites[slug]})
+		}
+		return courses
+	}
+
+	if err := ValidateCatalogPrerequisites(catalog(map[string][]string{"web": {"go", "http"}, "http": {"go", "retired"}})); err != nil {
+		t.Fatalf("valid catalog: %v", err)
+	}
+	err := ValidateCatalogPrerequisites(catalog(map[string][]string{"go": {"web"}, "web": {"http"}, "http": {"go"}}))
+	if err == nil || !strings.Contains(err.Error(), "course prerequisites form a cycle: go -> web -> http -> go") {
+		t.Fatalf("expected a cycle error, got %v", err)
+	}
+}
@@ -0,0 +1,353 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Quiz question kinds
+const (
+	QuestionSingleChoice   = "single_choice"   // Exactly one correct option
+	QuestionMultipleChoice = "multiple_choice" // Every correct option, and no others, must be chosen
+	QuestionShortAnswer    = "short_answer"    // Free text matched against accepted patterns
+)
+
+// QuizBlock is a graded set of questions. The answer key never leaves the
+// server: learners see a QuizPreview and submit answers to be scored.
+type QuizBlock struct {
+	ID           string         `bson:"id" json:"id" yaml:"id"`
+	Title        string         `bson:"title" json:"title" yaml:"title"`
+	PassingScore int            `bson:"passing_score,omitempty" json:"passing_score,omitempty" yaml:"passing_score"` // Percent needed to pass; 100 if unset
+	Questions    []QuizQuestion `bson:"questions" json:"questions" yaml:"questions"`
+}
+
+// QuizQuestion is one question of a quiz, including its answer key
+type QuizQuestion struct {
+	ID      string   `bson:"id" json:"id" yaml:"id"`
+	Kind    string   `bson:"kind" json:"kind" yaml:"kind"` // "single_choice", "multiple_choice", "short_answer"
+	Prompt  string   `bson:"prompt" json:"prompt" yaml:"prompt"`
+	Options []string `bson:"options,omitempty" json:"options,omitempty" yaml:"options"` // Choice questions only
+	Points  int      `bson:"points,omitempty" json:"points,omitempty" yaml:"points"`    // 1 if unset
+	// Answer key
+	Correct         []int    `bson:"correct,omitempty" json:"correct,omitempty" yaml:"correct"`                            // Indexes into Options
+	AcceptedAnswers []string `bson:"accepted_answers,omitempty" json:"accepted_answers,omitempty" yaml:"accepted_answers"` // Regular expressions, matched case-insensitively against the whole trimmed answer
+	Explanation     string   `bson:"explanation,omitempty" json:"explanation,omitempty" yaml:"explanation"`                // Shown after an attempt is scored
+}
+
+func (*QuizBlock) BlockType() string { return BlockQuiz }
+
+func (q *QuizBlock) Validate() error {
+	if !ValidSlug(q.ID) {
+		return fmt.Errorf("invalid quiz id %q: use lowercase letters, digits and hyphens", q.ID)
+	}
+	if q.PassingScore < 0 || q.PassingScore > 100 {
+		return errors.New("passing_score must be between 0 and 100")
+	}
+	if len(q.Questions) == 0 {
+		return errors.New("at least one question is required")
+	}
+
+	seen := make(map[string]bool)
+	for i := range q.Questions {
+		question := &q.Questions[i]
+		if !ValidSlug(question.ID) {
+			return fmt.Errorf("question %d: invalid id %q", i, question.ID)
+		}
+		if seen[question.ID] {
+			return fmt.Errorf("duplicate question id %q", question.ID)
+		}
+		seen[question.ID] = true
+		if err := question.validate(); err != nil {
+			return fmt.Errorf("question %q: %w", question.ID, err)
+		}
+	}
+	return nil
+}
+
+func (q *QuizQuestion) validate() error {
+	if strings.TrimSpace(q.Prompt) == "" {
+		return errors.New("prompt is required")
+	}
+	if q.Points < 0 {
+		return errors.New("points must not be negative")
+	}
+
+	switch q.Kind {
+	case QuestionSingleChoice, QuestionMultipleChoice:
+		if len(q.Options) < 2 {
+			return errors.New("at least two options are required")
+		}
+		if len(q.AcceptedAnswers) > 0 {
+			return errors.New("accepted_answers only applies to short answer questions")
+		}
+		if q.Kind == QuestionSingleChoice && len(q.Correct) != 1 {
+			return errors.New("exactly one correct option is required")
+		}
+		if len(q.Correct) == 0 {
+			return errors.New("at least one correct option is required")
+		}
+		chosen := make(map[int]bool)
+		for _, index := range q.Correct {
+			if index < 0 || index >= len(q.Options) || chosen[index] {
+				return fmt.Errorf("invalid correct option %d", index)
+			}
+			chosen[index] = true
+		}
+	case QuestionShortAnswer:
+		if len(q.Options) > 0 || len(q.Correct) > 0 {
+			return errors.New("options and correct only apply to choice questions")
+		}
+		if len(q.AcceptedAnswers) == 0 {
+			return errors.New("at least one accepted answer is required")
+		}
+		for _, pattern := range q.AcceptedAnswers {
+			if _, err := compileAnswerPattern(pattern); err != nil {
+				return fmt.Errorf("invalid accepted answer %q: %w", pattern, err)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid kind %q: use single_choice, multiple_choice or short_answer", q.Kind)
+	}
+	return nil
+}
+
+// compileAnswerPattern anchors an accepted-answer pattern and makes it case-insensitive
+func compileAnswerPattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile(`(?i)^(?:` + pattern + `)$`)
+}
+
+func (q *QuizQuestion) points() int {
+	if q.Points == 0 {
+		return 1
+	}
+	return q.Points
+}
+
+// QuizPreview is what learners see of a quiz: the questions without the answer key
+type QuizPreview struct {
+	ID           string                `json:"id"`
+	Title        string                `json:"title"`
+	PassingScore int                   `json:"passing_score"`
+	Questions    []QuizQuestionPreview `json:"questions"`
+}
+
+// QuizQuestionPreview is a question without its answer key
+type QuizQuestionPreview struct {
+	ID      string   `json:"id"`
+	Kind    string   `json:"kind"`
+	Prompt  string   `json:"prompt"`
+	Options []string `json:"options,omitempty"`
+	Points  int      `json:"points"`
+}
+
+func (*QuizPreview) BlockType() string { return BlockQuiz }
+
+// Validate rejects previews so a course read from a public response can't be stored
+func (*QuizPreview) Validate() error {
+	return errors.New("quiz preview has no answer key")
+}
+
+// Redacted returns the quiz without its answer key
+func (q *QuizBlock) Redacted() BlockData {
+	preview := &QuizPreview{
+		ID:           q.ID,
+		Title:        q.Title,
+		PassingScore: q.passingScore(),
+		Questions:    make([]QuizQuestionPreview, len(q.Questions)),
+	}
+	for i, question := range q.Questions {
+		preview.Questions[i] = QuizQuestionPreview{
+			ID:      question.ID,
+			Kind:    question.Kind,
+			Prompt:  question.Prompt,
+			Options: question.Options,
+			Points:  question.points(),
+		}
+	}
+	return preview
+}
+
+func (q *QuizBlock) passingScore() int {
+	if q.PassingScore == 0 {
+		return 100
+	}
+	return q.PassingScore
+}
+
+// QuizAnswer is a learner's answer to one question: chosen option indexes for
+// choice questions, or text for short answers
+type QuizAnswer struct {
+	Choices []int  `bson:"choices,omitempty" json:"choices,omitempty"`
+	Text    string `bson:"text,omitempty" json:"text,omitempty"`
+}
+
+// QuizQuestionResult is the outcome of one answered question
+type QuizQuestionResult struct {
+	QuestionID  string `bson:"question_id" json:"question_id"`
+	Correct     bool   `bson:"correct" json:"correct"`
+	Points      int    `bson:"points" json:"points"`
+	Explanation string `bson:"explanation,omitempty" json:"explanation,omitempty"`
+}
+
+// QuizResult is a scored set of answers
+type QuizResult struct {
+	Score     int                  `bson:"score" json:"score"`
+	MaxScore  int                  `bson:"max_score" json:"max_score"`
+	Percent   float64              `bson:"percent" json:"percent"`
+	Passed    bool                 `bson:"passed" json:"passed"`
+	Questions []QuizQuestionResult `bson:"questions" json:"questions,omitempty"` // Withheld from learners until they pass
+}
+
+// Score grades answers, keyed by question ID. Unanswered questions score zero;
+// answers to unknown questions or with invalid options are an error.
+func (q *QuizBlock) Score(answers map[string]QuizAnswer) (QuizResult, error) {
+	for id := range answers {
+		if q.question(id) == nil {
+			return QuizResult{}, fmt.Errorf("unknown question %q", id)
+		}
+	}
+
+	result := QuizResult{Questions: make([]QuizQuestionResult, 0, len(q.Questions))}
+	for i := range q.Questions {
+		question := &q.Questions[i]
+		answer, answered := answers[question.ID]
+
+		correct := false
+		if answered {
+			var err error
+			if correct, err = question.check(answer); err != nil {
+				return QuizResult{}, fmt.Errorf("question %q: %w", question.ID, err)
+			}
+		}
+
+		earned := 0
+		if correct {
+			earned = question.points()
+		}
+		result.Score += earned
+		result.MaxScore += question.points()
+		result.Questions = append(result.Questions, QuizQuestionResult{
+			QuestionID:  question.ID,
+			Correct:     correct,
+			Points:      earned,
+			Explanation: question.Explanation,
+		})
+	}
+
+	if result.MaxScore > 0 {
+		result.Percent = float64(result.Score) * 100 / float64(result.MaxScore)
+	}
+	// Compare in integers so e.g. 2 of 3 against a passing score of 67 isn't a rounding question
+	result.Passed = result.Score*100 >= q.passingScore()*result.MaxScore
+	return result, nil
+}
+
+func (q *QuizBlock) question(id string) *QuizQuestion {
+	for i := range q.Questions {
+		if q.Questions[i].ID == id {
+			return &q.Questions[i]
+		}
+	}
+	return nil
+}
+
+// check reports whether an answer is correct
+func (q *QuizQuestion) check(answer QuizAnswer) (bool, error) {
+	if q.Kind == QuestionShortAnswer {
+		text := strings.TrimSpace(answer.Text)
+		for _, pattern := range q.AcceptedAnswers {
+			re, err := compileAnswerPattern(pattern)
+			if err == nil && re.MatchString(text) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	chosen := make(map[int]bool)
+	for _, index := range answer.Choices {
+		if index < 0 || index >= len(q.Options) {
+			return false, fmt.Errorf("invalid option %d", index)
+		}
+		chosen[index] = true
+	}
+	if q.Kind == QuestionSingleChoice && len(chosen) > 1 {
+		return false, errors.New("choose one option")
+	}
+
+	correct := append([]int(nil), q.Correct...)
+	sort.Ints(correct)
+	if len(chosen) != len(correct) {
+		return false, nil
+	}
+	for _, index := range correct {
+		if !chosen[index] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// QuizAttempt is a learner's scored submission of a quiz
+type QuizAttempt struct {
+	ID          primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID    `bson:"user_id" json:"user_id"`
+	CourseID    primitive.ObjectID    `bson:"course_id" json:"course_id"`
+	ModuleID    string                `bson:"module_id" json:"module_id"`
+	QuizID      string                `bson:"quiz_id" json:"quiz_id"`
+	Answers     map[string]QuizAnswer `bson:"answers" json:"answers"`
+	Result      QuizResult            `bson:"result" json:"result"`
+	SubmittedAt time.Time             `bson:"submitted_at" json:"submitted_at"`
+}
+
+// WithoutQuestionResults returns the attempt with only its score and whether it
+// passed. Learners who haven't passed the quiz see attempts this way, so
+// resubmitting can't be used to work out the answer key one question at a time.
+func (a QuizAttempt) WithoutQuestionResults() QuizAttempt {
+	a.Result.Questions = nil
+	return a
+}
+
+// Quiz returns the quiz with the ID
+func (m *Module) Quiz(id string) (*QuizBlock, bool) {
+	for _, quiz := range m.Quizzes() {
+		if quiz.ID == id {
+			return quiz, true
+		}
+	}
+	return nil, false
+}
+
+// Quizzes returns the module's quizzes in order
+func (m *Module) Quizzes() []*QuizBlock {
+	var quizzes []*QuizBlock
+	for _, block := range m.Content {
+		if quiz, ok := block.Data.(*QuizBlock); ok {
+			quizzes = append(quizzes, quiz)
+		}
+	}
+	return quizzes
+}
+
+// validateQuizIDs checks that no two quizzes in a module share an ID, and that
+// a module requiring a passing quiz has one
+func validateQuizIDs(module *Module) error {
+	quizzes := module.Quizzes()
+	if module.RequirePassingQuiz && len(quizzes) == 0 {
+		return fmt.Errorf("module %q: require_passing_quiz is set but the module has no quiz", module.ID)
+	}
+	seen := make(map[string]bool)
+	for _, quiz := range quizzes {
+		if seen[quiz.ID] {
+			return fmt.Errorf("module %q: duplicate quiz id %q", module.ID, quiz.ID)
+		}
+		seen[quiz.ID] = true
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func sampleQuiz() *QuizBlock {
+	return &QuizBlock{
+		ID:           "loops-check",
+		Title:        "Loops",
+		PassingScore: 60,
+		Questions: []QuizQuestion{
+			{ID: "keyword", Kind: QuestionSingleChoice, Prompt: "Go's only loop keyword?", Options: []string{"while", "for", "loop"}, Correct: []int{1}},
+			{ID: "exits", Kind: QuestionMultipleChoice, Prompt: "Which leave a loop?", Options: []string{"break", "continue", "return"}, Correct: []int{0, 2}, Points: 2},
+			{ID: "range", Kind: QuestionShortAnswer, Prompt: "Keyword to iterate a slice?", AcceptedAnswers: []string{"range", `for\s+range`}, Explanation: "range yields index and value"},
+		},
+	}
+}
+
+func TestQuizScore(t *testing.T) {
+	quiz := sampleQuiz()
+	if err := quiz.Validate(); err != nil {
+		t.Fatalf("sample quiz should be valid: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		answers map[string]QuizAnswer
+		score   int
+		passed  bool
+	}{
+		{"all correct", map[string]QuizAnswer{
+			"keyword": {Choices: []int{1}},
+			"exits":   {Choices: []int{2, 0}},
+			"range":   {Text: "  For  Range "},
+		}, 4, true},
+		{"partial multiple choice scores nothing", map[string]QuizAnswer{
+			"keyword": {Choices: []int{1}},
+			"exits":   {Choices: []int{0}},
+			"range":   {Text: "range"},
+		}, 2, false},
+		{"patterns match the whole answer", map[string]QuizAnswer{
+			"exits": {Choices: []int{0, 2}},
+			"range": {Text: "ranges"},
+		}, 2, false},
+		{"unanswered", map[string]QuizAnswer{}, 0, false},
+		{"exactly the passing score", map[string]QuizAnswer{
+			"keyword": {Choices: []int{1}},
+			"exits":   {Choices: []int{0, 2}},
+		}, 3, true},
+	}
+	for _, tc := range cases {
+		result, err := quiz.Score(tc.answers)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if result.Score != tc.score || result.MaxScore != 4 || result.Passed != tc.passed {
+			t.Errorf("%s: got %d/%d passed=%v, want %d/4 passed=%v", tc.name, result.Score, result.MaxScore, result.Passed, tc.score, tc.passed)
+		}
+		if len(result.Questions) != 3 || result.Questions[2].Explanation == "" {
+			t.Errorf("%s: expected a result per question with explanations, got %+v", tc.name, result.Questions)
+		}
+	}
+
+	for _, answers := range []map[string]QuizAnswer{
+		{"nope": {Text: "x"}},
+		{"keyword": {Choices: []int{3}}},
+		{"keyword": {Choices: []int{0, 1}}},
+	} {
+		if _, err := quiz.Score(answers); err == nil {
+			t.Errorf("expected error scoring %+v", answers)
+		}
+	}
+}
+
+func TestQuizValidate(t *testing.T) {
+	invalid := map[string]func(q *QuizBlock){
+		"missing id":           func(q *QuizBlock) { q.ID = "" },
+		"passing score":        func(q *QuizBlock) { q.PassingScore = 101 },
+		"no questions":         func(q *QuizBlock) { q.Questions = nil },
+		"duplicate question":   func(q *QuizBlock) { q.Questions[1].ID = "keyword" },
+		"unknown kind":         func(q *QuizBlock) { q.Questions[0].Kind = "essay" },
+		"two single answers":   func(q *QuizBlock) { q.Questions[0].Correct = []int{0, 1} },
+		"correct out of range": func(q *QuizBlock) { q.Questions[1].Correct = []int{3} },
+		"one option": func(q *QuizBlock) {
+			q.Questions[0].Options = q.Questions[0].Options[:1]
+			q.Questions[0].Correct = []int{0}
+		},
+		"no accepted answers": func(q *QuizBlock) { q.Questions[2].AcceptedAnswers = nil },
+		"bad pattern":         func(q *QuizBlock) { q.Questions[2].AcceptedAnswers = []string{"(range"} },
+	}
+	for name, mutate := range invalid {
+		quiz := sampleQuiz()
+		mutate(quiz)
+		if err := quiz.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	module := &Module{ID: "go-1", Title: "Loops", RequirePassingQuiz: true}
+	if err := ValidateModule(module); err == nil || !strings.Contains(err.Error(), "no quiz") {
+		t.Fatalf("expected error for require_passing_quiz without a quiz, got %v", err)
+	}
+	module.Content = []ContentBlock{NewContentBlock(sampleQuiz()), NewContentBlock(sampleQuiz())}
+	if err := ValidateModule(module); err == nil || !strings.Contains(err.Error(), "duplicate quiz id") {
+		t.Fatalf("expected duplicate quiz id error, got %v", err)
+	}
+}
+
+func TestCoursePublicRedactsQuizzes(t *testing.T) {
+	course := Course{Modules: []Module{{ID: "go-1", Content: []ContentBlock{NewContentBlock(sampleQuiz())}}}}
+
+	raw, err := json.Marshal(course.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"correct", "accepted_answers", "for\\\\s+range", "explanation"} {
+		if strings.Contains(string(raw), secret) {
+			t.Fatalf("public course leaked %q: %s", secret, raw)
+		}
+	}
+
+	preview := course.Public().Modules[0].Content[0].Data.(*QuizPreview)
+	if len(preview.Questions) != 3 || preview.Questions[1].Points != 2 || preview.Questions[0].Points != 1 {
+		t.Fatalf("unexpected preview: %+v", preview)
+	}
+	if err := ValidateContentBlock(course.Public().Modules[0].Content[0]); err == nil {
+		t.Fatal("a redacted quiz must not pass validation")
+	}
+}
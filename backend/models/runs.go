@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExerciseTest is an instructor-supplied test case for a runnable exercise: the
+// learner's program is run with Input on stdin and must print Output.
+// Trailing whitespace on each line and trailing blank lines are ignored.
+type ExerciseTest struct {
+	Name   string `bson:"name" json:"name" yaml:"name"`
+	Input  string `bson:"input,omitempty" json:"input,omitempty" yaml:"input"`
+	Output string `bson:"output" json:"output" yaml:"output"`
+	Hidden bool   `bson:"hidden,omitempty" json:"hidden,omitempty" yaml:"hidden"` // Results only show the status and duration
+}
+
+// Run statuses
+const (
+	RunPassed       = "passed"        // Every test passed
+	RunFailed       = "failed"        // The program built but at least one test failed
+	RunCompileError = "compile_error" // The program didn't build; no tests ran
+)
+
+// Test case statuses
+const (
+	TestPassed       = "passed"
+	TestWrongOutput  = "wrong_output"
+	TestRuntimeError = "runtime_error" // Non-zero exit, panic or memory limit
+	TestTimeout      = "timeout"
+	TestOutputLimit  = "output_limit"
+)
+
+// TestResult is the outcome of running a submission against one test case
+type TestResult struct {
+	Name   string `bson:"name" json:"name"`
+	Status string `bson:"status" json:"status"`
+	// Only Name, Status and DurationMS are set for hidden tests
+	Input      string `bson:"input,omitempty" json:"input,omitempty"`
+	Expected   string `bson:"expected,omitempty" json:"expected,omitempty"`
+	Output     string `bson:"output" json:"output"`
+	Stderr     string `bson:"stderr,omitempty" json:"stderr,omitempty"`
+	DurationMS int64  `bson:"duration_ms" json:"duration_ms"`
+}
+
+// RunResult is the outcome of compiling and testing a submission
+type RunResult struct {
+	Status        string       `bson:"status" json:"status"`
+	CompileOutput string       `bson:"compile_output,omitempty" json:"compile_output,omitempty"`
+	Tests         []TestResult `bson:"tests" json:"tests"`
+	Passed        int          `bson:"passed" json:"passed"`
+	Total         int          `bson:"total" json:"total"`
+}
+
+// CodeRun is a learner's Go submission for an exercise and its test results
+type CodeRun struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	CourseID    primitive.ObjectID `bson:"course_id" json:"course_id"`
+	ModuleID    string             `bson:"module_id" json:"module_id"`
+	ExerciseID  string             `bson:"exercise_id" json:"exercise_id"`
+	Code        string             `bson:"code" json:"code"`
+	Result      RunResult          `bson:"result" json:"result"`
+	SubmittedAt time.Time          `bson:"submitted_at" json:"submitted_at"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// slugPattern matches course slugs and module IDs: lowercase words joined by hyphens.
+// Module IDs are used as keys in progress documents, so they must stay simple.
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ValidSlug reports whether s can be used as a course slug or module ID
+func ValidSlug(s string) bool {
+	return slugPattern.MatchString(s)
+}
+
+// ValidateCourse checks a course and all of its modules
+func ValidateCourse(course *Course) error {
+	if course.Title == "" {
+		return errors.New("course title is required")
+	}
+	if !ValidSlug(course.Slug) {
+		return fmt.Errorf("invalid course slug %q: use lowercase letters, digits and hyphens", course.Slug)
+	}
+
+	seen := make(map[string]bool)
+	for i := range course.Modules {
+		if err := ValidateModule(&course.Modules[i]); err != nil {
+			return err
+		}
+		if seen[course.Modules[i].ID] {
+			return fmt.Errorf("duplicate module id %q", course.Modules[i].ID)
+		}
+		seen[course.Modules[i].ID] = true
+	}
+	return ValidatePrerequisites(course)
+}
+
+// ValidateModule checks a module and its content blocks
+func ValidateModule(module *Module) error {
+	if !ValidSlug(module.ID) {
+		return fmt.Errorf("invalid module id %q: use lowercase letters, digits and hyphens", module.ID)
+	}
+	if module.Title == "" {
+		return fmt.Errorf("module %q: title is required", module.ID)
+	}
+	for i, block := range module.Content {
+		if err := ValidateContentBlock(block); err != nil {
+			return fmt.Errorf("module %q block %d: %w", module.ID, i, err)
+		}
+	}
+	if err := validateExerciseIDs(module); err != nil {
+		return err
+	}
+	if err := validateQuizIDs(module); err != nil {
+		return err
+	}
+	return validateModulePrerequisites(module)
+}
+
+// ValidateContentBlock checks that a block has a registered type and valid data
+func ValidateContentBlock(block ContentBlock) error {
+	if _, ok := blockTypes[block.Type]; !ok {
+		return fmt.Errorf("unsupported block type %q: use one of %s", block.Type, strings.Join(BlockTypes(), ", "))
+	}
+	if block.Data == nil {
+		return fmt.Errorf("%s block has no data", block.Type)
+	}
+	if block.Data.BlockType() != block.Type {
+		return fmt.Errorf("%s block has %s data", block.Type, block.Data.BlockType())
+	}
+	if err := block.Data.Validate(); err != nil {
+		return fmt.Errorf("%s block: %w", block.Type, err)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package models
+
+import "testing"
+
+func TestValidSlug(t *testing.T) {
+	cases := map[string]bool{
+		"git":             true,
+		"design-patterns": true,
+		"http-2":          true,
+		"101":             true,
+		"":                false,
+		"Git":             false,
+		"design_patterns": false,
+		"design patterns": false,
+		"-git":            false,
+		"git-":            false,
+		"git--basics":     false,
+		"café":            false,
+	}
+	for slug, want := range cases {
+		if got := ValidSlug(slug); got != want {
+			t.Errorf("ValidSlug(%q) = %v, want %v", slug, got, want)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MockIdP is an OpenID Connect provider for tests and local development. It
+// has no accounts: the sign-in page asks for an email and name and signs in
+// whoever is entered. A GET of the authorization URL with email (and
+// optionally name, sub and email_verified=false) added skips the page, which
+// is how tests sign in. Client credentials, redirect URIs and PKCE are checked
+// as a real provider would.
+//
+// The issuer is the URL the provider is reached at, e.g. httptest.Server.URL.
+type MockIdP struct {
+	ClientID     string
+	ClientSecret string // Empty allows any secret
+
+	key   *rsa.PrivateKey
+	mux   *http.ServeMux
+	mu    sync.Mutex
+	codes map[string]mockCode
+}
+
+// mockCode is an authorization code waiting to be exchanged
+type mockCode struct {
+	identity    Identity
+	clientID    string
+	redirectURI string
+	challenge   string
+	nonce       string
+	expiresAt   time.Time
+}
+
+var _ http.Handler = (*MockIdP)(nil)
+
+// NewMockIdP returns a provider accepting the client credentials
+func NewMockIdP(clientID, clientSecret string) (*MockIdP, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	idp := &MockIdP{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		key:          key,
+		mux:          http.NewServeMux(),
+		codes:        make(map[string]mockCode),
+	}
+	idp.mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	idp.mux.HandleFunc("/authorize", idp.authorize)
+	idp.mux.HandleFunc("/token", idp.token)
+	idp.mux.HandleFunc("/jwks", idp.jwks)
+	return idp, nil
+}
+
+func (idp *MockIdP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	idp.mux.ServeHTTP(w, r)
+}
+
+// issuer is the URL the request reached the provider at
+func issuer(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func (idp *MockIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	iss := issuer(r)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                iss,
+		"authorization_endpoint":                iss + "/authorize",
+		"token_endpoint":                        iss + "/token",
+		"jwks_uri":                              iss + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+var mockSignIn = template.Must(template.New("signin").Parse(`<!DOCTYPE html>
+<title>Mock identity provider</title>
+<h1>Sign in to the mock identity provider</h1>
+<form method="get">
+{{range $name, $values := .}}{{range $values}}<input type="hidden" name="{{$name}}" value="{{.}}">
+{{end}}{{end}}<p><label>Email <input name="email" type="email" required></label></p>
+<p><label>Name <input name="name"></label></p>
+<p><label><input name="email_verified" type="checkbox" value="true" checked> Email verified</label>
+<input name="email_verified" type="hidden" value="false"></p>
+<p><button>Sign in</button></p>
+</form>
+`))
+
+// authorize checks the authorization request, shows the sign-in page and, once
+// an email is given, redirects back with a code
+func (idp *MockIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI := query.Get("redirect_uri")
+	if query.Get("client_id") != idp.ClientID || redirectURI == "" {
+		http.Error(w, "unknown client_id or missing redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "expected response_type=code with an S256 code_challenge", http.StatusBadRequest)
+		return
+	}
+
+	if query.Get("email") == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		mockSignIn.Execute(w, query)
+		return
+	}
+
+	// A ticked checkbox comes before its hidden "false" fallback, so the first value wins
+	verified := query["email_verified"]
+	identity := Identity{
+		Subject:       query.Get("sub"),
+		Email:         query.Get("email"),
+		EmailVerified: len(verified) == 0 || verified[0] != "false",
+		Name:          query.Get("name"),
+	}
+	if identity.Subject == "" {
+		identity.Subject = "mock|" + identity.Email
+	}
+
+	code, err := randomString()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	idp.mu.Lock()
+	idp.codes[code] = mockCode{
+		identity:    identity,
+		clientID:    idp.ClientID,
+		redirectURI: redirectURI,
+		challenge:   query.Get("code_challenge"),
+		nonce:       query.Get("nonce"),
+		expiresAt:   time.Now().Add(time.Minute),
+	}
+	idp.mu.Unlock()
+
+	back, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	values := back.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	back.RawQuery = values.Encode()
+	http.Redirect(w, r, back.String(), http.StatusFound)
+}
+
+// token exchanges a code for an ID token, once
+func (idp *MockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != idp.ClientID || (idp.ClientSecret != "" && subtle.ConstantTimeCompare([]byte(clientSecret), []byte(idp.ClientSecret)) != 1) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	idp.mu.Lock()
+	code, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	if !ok || time.Now().After(code.expiresAt) || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("redirect_uri") != code.redirectURI || codeChallenge(r.PostForm.Get("code_verifier")) != code.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            issuer(r),
+		"sub":            code.identity.Subject,
+		"aud":            code.clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          code.nonce,
+		"email":          code.identity.Email,
+		"email_verified": code.identity.EmailVerified,
+		"name":           code.identity.Name,
+	})
+	idToken.Header["kid"] = "mock"
+	signed, err := idToken.SignedString(idp.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	accessToken, err := randomString()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+func (idp *MockIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	public := idp.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "mock",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,364 @@
+// Package oauth signs users in with external identity providers using the
+// OAuth 2.0 authorization code flow with PKCE.
+//
+// OpenID Connect providers, such as Google, are configured by their issuer:
+// endpoints are discovered and the ID token is verified against the provider's
+// published keys. Plain OAuth 2.0 providers, such as GitHub, set their
+// endpoints and an Identify function that looks the user up with the access
+// token. MockIdP is a local OpenID Connect provider for tests and development.
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Identity is who the provider says signed in
+type Identity struct {
+	Subject       string // The provider's stable ID for the user
+	Email         string
+	EmailVerified bool // Whether the provider has confirmed the user owns Email
+	Name          string
+}
+
+// Provider is an identity provider the application is registered with
+type Provider struct {
+	Name         string // Identifies the provider in URLs and linked identities, e.g. "github"
+	ClientID     string
+	ClientSecret string // Optional for OpenID Connect providers that allow public clients
+	Scopes       []string
+
+	// Issuer identifies an OpenID Connect provider; AuthURL, TokenURL and the
+	// signing keys are discovered from it
+	Issuer string
+
+	AuthURL  string
+	TokenURL string
+
+	// Identify looks up the user with the access token, for providers that
+	// don't issue ID tokens. Nil verifies the OpenID Connect ID token instead.
+	Identify func(ctx context.Context, client *http.Client, accessToken string) (Identity, error)
+
+	Client *http.Client // Nil uses a client with a 10s timeout
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      map[string]*rsa.PublicKey // Signing keys by key ID
+	keysAt    time.Time
+}
+
+// discovery is the part of an OpenID Connect discovery document the flow uses
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+var defaultClient = &http.Client{Timeout: 10 * time.Second}
+
+// Errors from Exchange that say something about the user rather than the provider
+var (
+	ErrInvalidIDToken = errors.New("invalid ID token")
+	ErrNoEmail        = errors.New("provider returned no email address")
+)
+
+// NewPKCE returns a random PKCE code verifier
+func NewPKCE() (string, error) {
+	return randomString()
+}
+
+// NewState returns a random value for the state or nonce parameters
+func NewState() (string, error) {
+	return randomString()
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// codeChallenge returns the S256 PKCE challenge for a verifier
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL returns the provider page that asks the user to sign in. The
+// provider sends them back to redirectURI with a code and the state.
+func (p *Provider) AuthCodeURL(ctx context.Context, redirectURI, state, nonce, verifier string) (string, error) {
+	authURL, _, err := p.endpoints(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", fmt.Errorf("%s authorization endpoint: %w", p.Name, err)
+	}
+	query := u.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("scope", strings.Join(p.Scopes, " "))
+	query.Set("state", state)
+	query.Set("code_challenge", codeChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+	if p.Identify == nil {
+		query.Set("nonce", nonce)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// tokenResponse is a token endpoint response, successful or not
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange trades the code the provider sent back for the user's identity.
+// redirectURI, nonce and verifier must be those given to AuthCodeURL.
+func (p *Provider) Exchange(ctx context.Context, code, redirectURI, nonce, verifier string) (Identity, error) {
+	_, tokenURL, err := p.endpoints(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {p.ClientID},
+		"code_verifier": {verifier},
+	}
+	if p.ClientSecret != "" {
+		form.Set("client_secret", p.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Identity{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json") // GitHub answers form-encoded otherwise
+
+	var token tokenResponse
+	if err := p.do(req, &token); err != nil && token.Error == "" {
+		return Identity{}, fmt.Errorf("%s token exchange: %w", p.Name, err)
+	}
+	// GitHub reports errors with a 200 status
+	if token.Error != "" {
+		return Identity{}, fmt.Errorf("%s token exchange: %s %s", p.Name, token.Error, token.ErrorDescription)
+	}
+
+	var identity Identity
+	if p.Identify != nil {
+		if token.AccessToken == "" {
+			return Identity{}, fmt.Errorf("%s token exchange: no access token", p.Name)
+		}
+		identity, err = p.Identify(ctx, p.client(), token.AccessToken)
+	} else {
+		identity, err = p.verifyIDToken(ctx, token.IDToken, nonce)
+	}
+	if err != nil {
+		return Identity{}, err
+	}
+	if identity.Email == "" {
+		return Identity{}, ErrNoEmail
+	}
+	return identity, nil
+}
+
+// idTokenClaims are the ID token claims the flow uses
+type idTokenClaims struct {
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified any    `json:"email_verified"` // Some providers send "true"
+	Name          string `json:"name"`
+	jwt.RegisteredClaims
+}
+
+// verifyIDToken checks the ID token's signature, issuer, audience, expiry and
+// nonce, and returns the identity it asserts
+func (p *Provider) verifyIDToken(ctx context.Context, idToken, nonce string) (Identity, error) {
+	if idToken == "" {
+		return Identity{}, fmt.Errorf("%w: %s returned no ID token", ErrInvalidIDToken, p.Name)
+	}
+	p.mu.Lock()
+	issuer := p.discovery.Issuer
+	p.mu.Unlock()
+
+	var claims idTokenClaims
+	_, err := jwt.ParseWithClaims(idToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.signingKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(p.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if claims.Nonce != nonce || claims.Subject == "" {
+		return Identity{}, fmt.Errorf("%w: nonce or subject mismatch", ErrInvalidIDToken)
+	}
+
+	verified, _ := claims.EmailVerified.(bool)
+	if s, ok := claims.EmailVerified.(string); ok {
+		verified = s == "true"
+	}
+	return Identity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: verified,
+		Name:          claims.Name,
+	}, nil
+}
+
+// endpoints returns the authorization and token endpoints, discovering them on
+// first use for OpenID Connect providers
+func (p *Provider) endpoints(ctx context.Context) (authURL, tokenURL string, err error) {
+	if p.Identify != nil {
+		return p.AuthURL, p.TokenURL, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery == nil {
+		wellKnown := strings.TrimSuffix(p.Issuer, "/") + "/.well-known/openid-configuration"
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+		if err != nil {
+			return "", "", err
+		}
+		var doc discovery
+		if err := p.do(req, &doc); err != nil {
+			return "", "", fmt.Errorf("%s discovery: %w", p.Name, err)
+		}
+		if doc.Issuer != p.Issuer {
+			return "", "", fmt.Errorf("%s discovery: issuer %q does not match %q", p.Name, doc.Issuer, p.Issuer)
+		}
+		p.discovery = &doc
+	}
+
+	authURL, tokenURL = p.discovery.AuthorizationEndpoint, p.discovery.TokenEndpoint
+	if p.AuthURL != "" {
+		authURL = p.AuthURL
+	}
+	if p.TokenURL != "" {
+		tokenURL = p.TokenURL
+	}
+	return authURL, tokenURL, nil
+}
+
+// jwks is a JSON Web Key Set
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// signingKey returns the provider's RSA key with the ID. Keys are fetched on
+// first use and refetched, at most once a minute, when a token names an
+// unknown key, as happens after the provider rotates its keys.
+func (p *Provider) signingKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysAt) < time.Minute {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.discovery.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set jwks
+	if err := p.do(req, &set); err != nil {
+		return nil, fmt.Errorf("%s signing keys: %w", p.Name, err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) > 4 {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	p.keys, p.keysAt = keys, time.Now()
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+func (p *Provider) client() *http.Client {
+	if p.Client != nil {
+		return p.Client
+	}
+	return defaultClient
+}
+
+// do sends the request and decodes the JSON response into v, which is also
+// decoded for error statuses so callers can read error fields
+func (p *Provider) do(req *http.Request, v any) error {
+	return getJSON(p.client(), req, v)
+}
+
+func getJSON(client *http.Client, req *http.Request, v any) error {
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	decodeErr := json.Unmarshal(body, v)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", req.URL.Redacted(), resp.Status)
+	}
+	return decodeErr
+}
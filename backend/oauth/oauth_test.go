@@ -0,0 +1,183 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// signIn follows an authorization URL through the mock provider, signing in as
+// the extra parameters say, and returns the code and state it redirects back with
+func signIn(t *testing.T, authURL string, extra url.Values) (code, state string) {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL + "&" + extra.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: expected 302, got %d", resp.StatusCode)
+	}
+	back, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return back.Query().Get("code"), back.Query().Get("state")
+}
+
+func TestProviderWithMockIdP(t *testing.T) {
+	idp, err := NewMockIdP("pathway", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(idp)
+	defer srv.Close()
+
+	ctx := context.Background()
+	const redirectURI = "http://localhost:8080/api/auth/oauth/mock/callback"
+	p := NewOIDC("mock", srv.URL, "pathway", "s3cret")
+
+	authorize := func(nonce, verifier string, extra url.Values) string {
+		t.Helper()
+		authURL, err := p.AuthCodeURL(ctx, redirectURI, "state-1", nonce, verifier)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, state := signIn(t, authURL, extra)
+		if state != "state-1" {
+			t.Fatalf("expected the state back, got %q", state)
+		}
+		return code
+	}
+
+	verifier, _ := NewPKCE()
+	code := authorize("nonce-1", verifier, url.Values{"email": {"ada@example.com"}, "name": {"Ada"}})
+	identity, err := p.Exchange(ctx, code, redirectURI, "nonce-1", verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity != (Identity{Subject: "mock|ada@example.com", Email: "ada@example.com", EmailVerified: true, Name: "Ada"}) {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+
+	// Codes work once
+	if _, err := p.Exchange(ctx, code, redirectURI, "nonce-1", verifier); err == nil {
+		t.Fatal("expected a reused code to fail")
+	}
+
+	// The verifier must match the challenge
+	code = authorize("nonce-2", verifier, url.Values{"email": {"ada@example.com"}})
+	other, _ := NewPKCE()
+	if _, err := p.Exchange(ctx, code, redirectURI, "nonce-2", other); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Fatalf("expected invalid_grant for the wrong verifier, got %v", err)
+	}
+
+	// The ID token must carry the nonce of the request
+	code = authorize("nonce-3", verifier, url.Values{"email": {"ada@example.com"}})
+	if _, err := p.Exchange(ctx, code, redirectURI, "nonce-other", verifier); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("expected ErrInvalidIDToken for the wrong nonce, got %v", err)
+	}
+
+	// Unverified emails are reported as such
+	code = authorize("nonce-4", verifier, url.Values{"email": {"bob@example.com"}, "email_verified": {"false"}, "sub": {"42"}})
+	identity, err = p.Exchange(ctx, code, redirectURI, "nonce-4", verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.EmailVerified || identity.Subject != "42" {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+
+	// A token signed by another provider with the same key ID is rejected
+	impostor, err := NewMockIdP("pathway", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	impostorSrv := httptest.NewServer(impostor)
+	defer impostorSrv.Close()
+	p.AuthURL, p.TokenURL = impostorSrv.URL+"/authorize", impostorSrv.URL+"/token"
+	code = authorize("nonce-5", verifier, url.Values{"email": {"ada@example.com"}})
+	if _, err := p.Exchange(ctx, code, redirectURI, "nonce-5", verifier); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("expected ErrInvalidIDToken for another provider's token, got %v", err)
+	}
+
+	// Wrong client credentials are refused
+	wrongSecret := NewOIDC("mock", srv.URL, "pathway", "guess")
+	authURL, err := wrongSecret.AuthCodeURL(ctx, redirectURI, "state-1", "nonce-6", verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ = signIn(t, authURL, url.Values{"email": {"ada@example.com"}})
+	if _, err := wrongSecret.Exchange(ctx, code, redirectURI, "nonce-6", verifier); err == nil || !strings.Contains(err.Error(), "invalid_client") {
+		t.Fatalf("expected invalid_client, got %v", err)
+	}
+}
+
+func TestGitHubIdentity(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gho_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/user":
+			w.Write([]byte(`{"id": 583231, "login": "octocat", "name": ""}`))
+		case "/user/emails":
+			w.Write([]byte(`[
+				{"email": "old@example.com", "primary": false, "verified": true},
+				{"email": "octocat@example.com", "primary": true, "verified": true}
+			]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+
+	identify := gitHubIdentity(api.URL)
+	identity, err := identify(context.Background(), http.DefaultClient, "gho_token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity != (Identity{Subject: "583231", Email: "octocat@example.com", EmailVerified: true, Name: "octocat"}) {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+
+	if _, err := identify(context.Background(), http.DefaultClient, "expired"); err == nil {
+		t.Fatal("expected an error for a rejected token")
+	}
+}
+
+func TestProvidersFromEnv(t *testing.T) {
+	t.Setenv("GITHUB_CLIENT_ID", "gh-id")
+	t.Setenv("GITHUB_CLIENT_SECRET", "gh-secret")
+	t.Setenv("OIDC_ISSUER", "https://login.example.com")
+	t.Setenv("OIDC_CLIENT_ID", "pathway")
+	t.Setenv("OIDC_NAME", "school")
+
+	providers, err := ProvidersFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(providers) != 2 || providers[0].Name != "github" || providers[1].Name != "school" ||
+		providers[1].Issuer != "https://login.example.com" || providers[1].ClientSecret != "" {
+		t.Fatalf("unexpected providers: %+v", providers)
+	}
+
+	for name, value := range map[string]string{
+		"GITHUB_CLIENT_SECRET": "",
+		"OIDC_CLIENT_ID":       "",
+		"OIDC_NAME":            "github",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := ProvidersFromEnv(); err == nil {
+				t.Fatalf("expected error with %s=%q", name, value)
+			}
+		})
+	}
+}
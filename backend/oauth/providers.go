@@ -0,0 +1,137 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// NewOIDC returns an OpenID Connect provider; its endpoints are discovered
+// from issuer on first use
+func NewOIDC(name, issuer, clientID, clientSecret string) *Provider {
+	return &Provider{
+		Name:         name,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Scopes:       []string{"openid", "email", "profile"},
+		Issuer:       issuer,
+	}
+}
+
+// NewGoogle returns Google sign-in, which is OpenID Connect
+func NewGoogle(clientID, clientSecret string) *Provider {
+	return NewOIDC("google", "https://accounts.google.com", clientID, clientSecret)
+}
+
+// NewGitHub returns GitHub sign-in. GitHub doesn't issue ID tokens, so the
+// user and their primary verified email are read from the REST API.
+func NewGitHub(clientID, clientSecret string) *Provider {
+	return &Provider{
+		Name:         "github",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Scopes:       []string{"read:user", "user:email"},
+		AuthURL:      "https://github.com/login/oauth/authorize",
+		TokenURL:     "https://github.com/login/oauth/access_token",
+		Identify:     gitHubIdentity("https://api.github.com"),
+	}
+}
+
+// gitHubIdentity reads the user from the GitHub API at apiURL. The email is
+// the user's primary address, and verified only if GitHub has verified it.
+func gitHubIdentity(apiURL string) func(context.Context, *http.Client, string) (Identity, error) {
+	get := func(ctx context.Context, client *http.Client, accessToken, path string, v any) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+path, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if err := getJSON(client, req, v); err != nil {
+			return fmt.Errorf("github %s: %w", path, err)
+		}
+		return nil
+	}
+
+	return func(ctx context.Context, client *http.Client, accessToken string) (Identity, error) {
+		var user struct {
+			ID    int64  `json:"id"`
+			Login string `json:"login"`
+			Name  string `json:"name"`
+		}
+		if err := get(ctx, client, accessToken, "/user", &user); err != nil {
+			return Identity{}, err
+		}
+		var emails []struct {
+			Email    string `json:"email"`
+			Primary  bool   `json:"primary"`
+			Verified bool   `json:"verified"`
+		}
+		if err := get(ctx, client, accessToken, "/user/emails", &emails); err != nil {
+			return Identity{}, err
+		}
+
+		identity := Identity{Subject: strconv.FormatInt(user.ID, 10), Name: user.Name}
+		if identity.Name == "" {
+			identity.Name = user.Login
+		}
+		for _, email := range emails {
+			if email.Primary {
+				identity.Email, identity.EmailVerified = email.Email, email.Verified
+			}
+		}
+		return identity, nil
+	}
+}
+
+// ProvidersFromEnv returns the providers whose client IDs are set:
+//
+//   - GITHUB_CLIENT_ID and GITHUB_CLIENT_SECRET for GitHub
+//   - GOOGLE_CLIENT_ID and GOOGLE_CLIENT_SECRET for Google
+//   - OIDC_ISSUER, OIDC_CLIENT_ID and optionally OIDC_CLIENT_SECRET for any
+//     other OpenID Connect provider, named by OIDC_NAME (default "oidc")
+func ProvidersFromEnv() ([]*Provider, error) {
+	var providers []*Provider
+	for _, configured := range []struct {
+		prefix string
+		new    func(clientID, clientSecret string) *Provider
+	}{
+		{"GITHUB", NewGitHub},
+		{"GOOGLE", NewGoogle},
+	} {
+		clientID := os.Getenv(configured.prefix + "_CLIENT_ID")
+		if clientID == "" {
+			continue
+		}
+		clientSecret := os.Getenv(configured.prefix + "_CLIENT_SECRET")
+		if clientSecret == "" {
+			return nil, fmt.Errorf("%s_CLIENT_SECRET is required with %s_CLIENT_ID", configured.prefix, configured.prefix)
+		}
+		providers = append(providers, configured.new(clientID, clientSecret))
+	}
+
+	issuer := os.Getenv("OIDC_ISSUER")
+	clientID := os.Getenv("OIDC_CLIENT_ID")
+	if issuer == "" && clientID == "" {
+		return providers, nil
+	}
+	if issuer == "" || clientID == "" {
+		return nil, fmt.Errorf("OIDC_ISSUER and OIDC_CLIENT_ID must be set together")
+	}
+	name := os.Getenv("OIDC_NAME")
+	if name == "" {
+		name = "oidc"
+	}
+	if strings.ContainsAny(name, "/?#") {
+		return nil, fmt.Errorf("invalid OIDC_NAME %q", name)
+	}
+	for _, p := range providers {
+		if p.Name == name {
+			return nil, fmt.Errorf("OIDC_NAME %q is already used", name)
+		}
+	}
+	return append(providers, NewOIDC(name, issuer, clientID, os.Getenv("OIDC_CLIENT_SECRET"))), nil
+}
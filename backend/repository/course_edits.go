@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/pathway/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// courseEdit applies a change to a course in memory and returns the fields it changed
+// as a MongoDB $set document, so only those paths are written back. Both repository
+// implementations share these, which keeps their authoring behaviour identical.
+type courseEdit func(course *models.Course) (bson.M, error)
+
+func editCourseFields(update models.CourseUpdate) courseEdit {
+	return func(course *models.Course) (bson.M, error) {
+		set := bson.M{}
+		if update.Slug != nil {
+			course.Slug = *update.Slug
+			set["slug"] = course.Slug
+		}
+		if update.Title != nil {
+			course.Title = *update.Title
+			set["title"] = course.Title
+		}
+		if update.Description != nil {
+			course.Description = *update.Description
+			set["description"] = course.Description
+		}
+		if update.Prerequisites != nil {
+			course.Prerequisites = *update.Prerequisites
+			set["prerequisites"] = course.Prerequisites
+		}
+		if update.Sequential != nil {
+			course.Sequential = *update.Sequential
+			set["sequential"] = course.Sequential
+		}
+		return set, nil
+	}
+}
+
+// editAddModule inserts a module at position; a negative or too-large position appends
+func editAddModule(module models.Module, position int) courseEdit {
+	return func(course *models.Course) (bson.M, error) {
+		if moduleIndex(course, module.ID) >= 0 {
+			return nil, ErrDuplicateModule
+		}
+		if module.Content == nil {
+			module.Content = []models.ContentBlock{}
+		}
+
+		if position < 0 || position >= len(course.Modules) {
+			// Setting the index one past the end appends without rewriting the array
+			course.Modules = append(course.Modules, module)
+			return bson.M{fmt.Sprintf("modules.%d", len(course.Modules)-1): module}, nil
+		}
+
+		course.Modules = append(course.Modules[:position], append([]models.Module{module}, course.Modules[position:]...)...)
+		return bson.M{"modules": course.Modules}, nil
+	}
+}
+
+func editUpdateModule(moduleID string, update models.ModuleUpdate) courseEdit {
+	return func(course *models.Course) (bson.M, error) {
+		i := moduleIndex(course, moduleID)
+		if i < 0 {
+			return nil, ErrModuleNotFound
+		}
+
+		module := &course.Modules[i]
+		prefix := fmt.Sprintf("modules.%d.", i)
+		set := bson.M{}
+		if update.Title != nil {
+			module.Title = *update.Title
+			set[prefix+"title"] = module.Title
+		}
+		if update.VideoURL != nil {
+			module.VideoURL = *update.VideoURL
+			set[prefix+"video_url"] = module.VideoURL
+		}
+		if update.Content != nil {
+			module.Content = *update.Content
+			set[prefix+"content"] = module.Content
+		}
+		if update.RequirePassingQuiz != nil {
+			module.RequirePassingQuiz = *update.RequirePassingQuiz
+			set[prefix+"require_passing_quiz"] = module.RequirePassingQuiz
+		}
+		if update.Prerequisites != nil {
+			module.Prerequisites = *update.Prerequisites
+			set[prefix+"prerequisites"] = module.Prerequisites
+		}
+		return set, nil
+	}
+}
+
+func editDeleteModule(moduleID string) courseEdit {
+	return func(course *models.Course) (bson.M, error) {
+		i := moduleIndex(course, moduleID)
+		if i < 0 {
+			return nil, ErrModuleNotFound
+		}
+
+		course.Modules = append(course.Modules[:i], course.Modules[i+1:]...)
+		return bson.M{"modules": course.Modules}, nil
+	}
+}
+
+// editReorderModules puts modules in the order of moduleIDs, which must name every module once
+func editReorderModules(moduleIDs []string) courseEdit {
+	return func(course *models.Course) (bson.M, error) {
+		if len(moduleIDs) != len(course.Modules) {
+			return nil, ErrInvalidOrder
+		}
+
+		reordered := make([]models.Module, 0, len(moduleIDs))
+		used := make(map[string]bool)
+		for _, id := range moduleIDs {
+			i := moduleIndex(course, id)
+			if i < 0 || used[id] {
+				return nil, ErrInvalidOrder
+			}
+			used[id] = true
+			reordered = append(reordered, course.Modules[i])
+		}
+
+		course.Modules = reordered
+		return bson.M{"modules": course.Modules}, nil
+	}
+}
+
+// editAddContentBlock inserts a block at position; a negative or too-large position appends
+func editAddContentBlock(moduleID string, block models.ContentBlock, position int) courseEdit {
+	return func(course *models.Course) (bson.M, error) {
+		i := moduleIndex(course, moduleID)
+		if i < 0 {
+			return nil, ErrModuleNotFound
+		}
+
+		module := &course.Modules[i]
+		if position < 0 || position >= len(module.Content) {
+			module.Content = append(module.Content, block)
+			return bson.M{fmt.Sprintf("modules.%d.content.%d", i, len(module.Content)-1): block}, nil
+		}
+
+		module.Content = append(module.Content[:position], append([]models.ContentBlock{block}, module.Content[position:]...)...)
+		return bson.M{fmt.Sprintf("modules.%d.content", i): module.Content}, nil
+	}
+}
+
+func editUpdateContentBlock(moduleID string, index int, block models.ContentBlock) courseEdit {
+	return func(course *models.Course) (bson.M, error) {
+		i := moduleIndex(course, moduleID)
+		if i < 0 {
+			return nil, ErrModuleNotFound
+		}
+		if index < 0 || index >= len(course.Modules[i].Content) {
+			return nil, ErrBlockNotFound
+		}
+
+		course.Modules[i].Content[index] = block
+		return bson.M{fmt.Sprintf("modules.%d.content.%d", i, index): block}, nil
+	}
+}
+
+func editDeleteContentBlock(moduleID string, index int) courseEdit {
+	return func(course *models.Course) (bson.M, error) {
+		i := moduleIndex(course, moduleID)
+		if i < 0 {
+			return nil, ErrModuleNotFound
+		}
+
+		module := &course.Modules[i]
+		if index < 0 || index >= len(module.Content) {
+			return nil, ErrBlockNotFound
+		}
+
+		module.Content = append(module.Content[:index], module.Content[index+1:]...)
+		return bson.M{fmt.Sprintf("modules.%d.content", i): module.Content}, nil
+	}
+}
+
+// editReorderContentBlocks rearranges a module's blocks; order lists the current
+// indexes in their new order and must include every block once
+func editReorderContentBlocks(moduleID string, order []int) courseEdit {
+	return func(course *models.Course) (bson.M, error) {
+		i := moduleIndex(course, moduleID)
+		if i < 0 {
+			return nil, ErrModuleNotFound
+		}
+
+		module := &course.Modules[i]
+		if len(order) != len(module.Content) {
+			return nil, ErrInvalidOrder
+		}
+
+		reordered := make([]models.ContentBlock, 0, len(order))
+		used := make(map[int]bool)
+		for _, index := range order {
+			if index < 0 || index >= len(module.Content) || used[index] {
+				return nil, ErrInvalidOrder
+			}
+			used[index] = true
+			reordered = append(reordered, module.Content[index])
+		}
+
+		module.Content = reordered
+		return bson.M{fmt.Sprintf("modules.%d.content", i): module.Content}, nil
+	}
+}
+
+// checkEdit rejects an edit that leaves the course invalid: every module it
+// added or changed must still pass models.ValidateModule, so exercise and quiz
+// IDs stay unique, and the prerequisites must stay consistent
+func checkEdit(before, after *models.Course) error {
+	for i := range after.Modules {
+		module := &after.Modules[i]
+		if original, ok := before.Module(module.ID); ok && reflect.DeepEqual(original, module) {
+			continue
+		}
+		if err := models.ValidateModule(module); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidModule, err)
+		}
+	}
+	return checkPrerequisites(after)
+}
+
+// checkCatalog rejects a course whose prerequisites would form a cycle with
+// other courses in the catalog. The course replaces the catalog's course with
+// the same ID, if there is one.
+func checkCatalog(catalog []models.Course, course *models.Course) error {
+	courses := make([]models.Course, 0, len(catalog)+1)
+	for _, existing := range catalog {
+		if existing.ID != course.ID {
+			courses = append(courses, existing)
+		}
+	}
+	courses = append(courses, *course)
+	if err := models.ValidateCatalogPrerequisites(courses); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPrerequisites, err)
+	}
+	return nil
+}
+
+// linksChanged reports whether an edit changed how the course links to other
+// courses, so the catalog needs checking again
+func linksChanged(before, after *models.Course) bool {
+	return before.Slug != after.Slug || !slices.Equal(before.Prerequisites, after.Prerequisites)
+}
+
+// checkPrerequisites rejects an edit that leaves the course's prerequisites
+// inconsistent, such as deleting a module that another module requires
+func checkPrerequisites(course *models.Course) error {
+	if err := models.ValidatePrerequisites(course); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPrerequisites, err)
+	}
+	return nil
+}
+
+// moduleIndex returns the index of the module with the ID, or -1
+func moduleIndex(course *models.Course, moduleID string) int {
+	for i := range course.Modules {
+		if course.Modules[i].ID == moduleID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pathway/backend/models"
+)
+
+func textBlock(markdown string) models.ContentBlock {
+	return models.NewContentBlock(&models.TextBlock{Markdown: markdown})
+}
+
+func markdownOf(block models.ContentBlock) string {
+	return block.Data.(*models.TextBlock).Markdown
+}
+
+func moduleIDs(course *models.Course) []string {
+	ids := make([]string, 0, len(course.Modules))
+	for _, m := range course.Modules {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+func TestMemoryCourseAuthoring(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	course := newTestCourse("git", "git-1", "git-2")
+	if err := repo.CreateCourse(ctx, course); err != nil {
+		t.Fatal(err)
+	}
+	id := course.ID.Hex()
+
+	got, err := repo.AddModule(ctx, id, models.Module{ID: "git-0", Title: "Intro"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := moduleIDs(got); len(ids) != 3 || ids[0] != "git-0" {
+		t.Fatalf("unexpected modules after insert: %v", ids)
+	}
+	if got.Version != 1 {
+		t.Fatalf("version = %d, want 1", got.Version)
+	}
+	if _, err := repo.AddModule(ctx, id, models.Module{ID: "git-1", Title: "Again"}, -1); !errors.Is(err, ErrDuplicateModule) {
+		t.Fatalf("duplicate module: expected ErrDuplicateModule, got %v", err)
+	}
+
+	got, err = repo.ReorderModules(ctx, id, []string{"git-2", "git-1", "git-0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := moduleIDs(got); ids[0] != "git-2" || ids[2] != "git-0" {
+		t.Fatalf("unexpected order: %v", ids)
+	}
+	if _, err := repo.ReorderModules(ctx, id, []string{"git-2", "git-2", "git-0"}); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("repeated ID: expected ErrInvalidOrder, got %v", err)
+	}
+
+	title := "Branches"
+	if got, err = repo.UpdateModule(ctx, id, "git-2", models.ModuleUpdate{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	if got.Modules[0].Title != "Branches" {
+		t.Fatalf("title not updated: %+v", got.Modules[0])
+	}
+
+	for _, text := range []string{"a", "b", "c"} {
+		if _, err := repo.AddContentBlock(ctx, id, "git-2", textBlock(text), -1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, err = repo.ReorderContentBlocks(ctx, id, "git-2", []int{2, 0, 1}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = repo.DeleteContentBlock(ctx, id, "git-2", 1); err != nil {
+		t.Fatal(err)
+	}
+	content := got.Modules[0].Content
+	if len(content) != 2 || markdownOf(content[0]) != "c" || markdownOf(content[1]) != "b" {
+		t.Fatalf("unexpected blocks: %+v", content)
+	}
+	if _, err := repo.UpdateContentBlock(ctx, id, "git-2", 5, textBlock("x")); !errors.Is(err, ErrBlockNotFound) {
+		t.Fatalf("bad index: expected ErrBlockNotFound, got %v", err)
+	}
+
+	if got, err = repo.DeleteModule(ctx, id, "git-1"); err != nil {
+		t.Fatal(err)
+	}
+	if ids := moduleIDs(got); len(ids) != 2 {
+		t.Fatalf("module not deleted: %v", ids)
+	}
+	if _, err := repo.DeleteModule(ctx, id, "git-1"); !errors.Is(err, ErrModuleNotFound) {
+		t.Fatalf("missing module: expected ErrModuleNotFound, got %v", err)
+	}
+
+	stored, _ := repo.GetCourseByID(ctx, id)
+	if stored.Version != got.Version {
+		t.Fatalf("stored version %d, returned %d", stored.Version, got.Version)
+	}
+}
+
+func TestMemoryUpdateCourseRejectsDuplicateSlug(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	git := newTestCourse("git")
+	http := newTestCourse("http")
+	_ = repo.CreateCourse(ctx, git)
+	_ = repo.CreateCourse(ctx, http)
+
+	if err := repo.CreateCourse(ctx, newTestCourse("git")); !errors.Is(err, ErrDuplicateSlug) {
+		t.Fatalf("CreateCourse: expected ErrDuplicateSlug, got %v", err)
+	}
+
+	slug := "git"
+	if _, err := repo.UpdateCourse(ctx, http.ID.Hex(), models.CourseUpdate{Slug: &slug}); !errors.Is(err, ErrDuplicateSlug) {
+		t.Fatalf("UpdateCourse: expected ErrDuplicateSlug, got %v", err)
+	}
+
+	if err := repo.DeleteCourse(ctx, git.ID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.UpdateCourse(ctx, http.ID.Hex(), models.CourseUpdate{Slug: &slug}); err != nil {
+		t.Fatalf("slug should be free after delete: %v", err)
+	}
+}
+
+func TestCourseEditsReturnPartialUpdates(t *testing.T) {
+	course := newTestCourse("git", "git-1", "git-2")
+
+	set, err := editAddModule(models.Module{ID: "git-3", Title: "Three"}, -1)(course)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := set["modules.2"]; !ok || len(set) != 1 {
+		t.Fatalf("append should set a single array element, got %v", set)
+	}
+
+	title := "One"
+	set, err = editUpdateModule("git-1", models.ModuleUpdate{Title: &title})(course)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set) != 1 || set["modules.0.title"] != "One" {
+		t.Fatalf("module update should only set its title, got %v", set)
+	}
+}
+
+func TestMemoryCourseEditsKeepPrerequisitesValid(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	course := newTestCourse("git", "git-1", "git-2", "git-3")
+	course.Modules[2].Prerequisites = []string{"git-1"}
+	if err := repo.CreateCourse(ctx, course); err != nil {
+		t.Fatal(err)
+	}
+	id := course.ID.Hex()
+
+	if _, err := repo.DeleteModule(ctx, id, "git-1"); !errors.Is(err, ErrInvalidPrerequisites) {
+		t.Fatalf("deleting a required module: expected ErrInvalidPrerequisites, got %v", err)
+	}
+
+	cycle := []string{"git-3"}
+	if _, err := repo.UpdateModule(ctx, id, "git-1", models.ModuleUpdate{Prerequisites: &cycle}); !errors.Is(err, ErrInvalidPrerequisites) {
+		t.Fatalf("cyclic prerequisites: expected ErrInvalidPrerequisites, got %v", err)
+	}
+
+	// git-1 then git-3 is fine in order, but not once git-3 comes first
+	sequential := true
+	if _, err := repo.UpdateCourse(ctx, id, models.CourseUpdate{Sequential: &sequential}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.ReorderModules(ctx, id, []string{"git-3", "git-1", "git-2"}); !errors.Is(err, ErrInvalidPrerequisites) {
+		t.Fatalf("reorder into a cycle: expected ErrInvalidPrerequisites, got %v", err)
+	}
+
+	stored, _ := repo.GetCourseByID(ctx, id)
+	if !stored.Sequential || !reflect.DeepEqual(moduleIDs(stored), []string{"git-1", "git-2", "git-3"}) {
+		t.Fatalf("rejected edits should leave the course unchanged: %+v", stored)
+	}
+}
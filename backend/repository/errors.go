@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrConflict means the course changed between reading and writing it; the edit can be retried
+	ErrConflict = errors.New("course was modified concurrently")
+	// ErrDuplicateSlug means another course already uses the slug
+	ErrDuplicateSlug = errors.New("course slug already in use")
+	// ErrDuplicateModule means the course already has a module with the ID
+	ErrDuplicateModule = errors.New("module id already in use")
+	// ErrModuleNotFound means the course has no module with the ID
+	ErrModuleNotFound = errors.New("module not found")
+	// ErrBlockNotFound means the module has no content block at the index
+	ErrBlockNotFound = errors.New("content block not found")
+	// ErrInvalidOrder means a reorder request isn't a permutation of the existing items
+	ErrInvalidOrder = errors.New("order must list every existing item exactly once")
+	// ErrInvalidModule means an edit would leave a module invalid, such as two exercises with one ID
+	ErrInvalidModule = errors.New("invalid module")
+	// ErrInvalidPrerequisites means an edit would leave prerequisites naming missing modules or forming a cycle
+	ErrInvalidPrerequisites = errors.New("invalid prerequisites")
+	// ErrPrerequisitesNotMet means the learner hasn't completed what a module requires
+	ErrPrerequisitesNotMet = errors.New("prerequisites not met")
+)
+
+// PrerequisitesError lists what a learner must complete before a module.
+// It matches ErrPrerequisitesNotMet with errors.Is.
+type PrerequisitesError struct {
+	Courses []string // Slugs of incomplete prerequisite courses
+	Modules []string // IDs of incomplete prerequisite modules
+}
+
+func (e *PrerequisitesError) Error() string {
+	var missing []string
+	if len(e.Courses) > 0 {
+		missing = append(missing, "courses "+strings.Join(e.Courses, ", "))
+	}
+	if len(e.Modules) > 0 {
+		missing = append(missing, "modules "+strings.Join(e.Modules, ", "))
+	}
+	return fmt.Sprintf("%v: complete %s first", ErrPrerequisitesNotMet, strings.Join(missing, " and "))
+}
+
+func (e *PrerequisitesError) Unwrap() error { return ErrPrerequisitesNotMet }
@@ -0,0 +1,1808 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pathway/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryRepository is an in-memory Repository for tests and local development.
+// It mirrors MongoRepository's behaviour, including "not found" errors
+// (mongo.ErrNoDocuments) and invalid ObjectID errors, so handlers behave the same
+// against either implementation. Operations fail fast with ctx.Err() when the
+// context is already done. It is safe for concurrent use.
+type MemoryRepository struct {
+	mu       sync.RWMutex
+	courses  []models.Course // Insertion order, like a MongoDB natural-order scan
+	users    []models.User
+	progress []models.Progress
+	resets   []models.ProgressReset
+
+	exerciseAttempts []models.ExerciseAttempt
+	exerciseReveals  []models.ExerciseReveal
+	codeRuns         []models.CodeRun
+	quizAttempts     []models.QuizAttempt
+	activity         []models.ActivityEvent
+	badges           []models.UserBadge
+	certificates     []models.Certificate
+	cohorts          []models.Cohort
+
+	refreshTokens []models.RefreshToken
+	revokedTokens map[string]models.RevokedAccessToken // Keyed by JWT ID
+	resetTokens   []models.PasswordResetToken
+	verifyTokens  []models.EmailVerificationToken
+
+	identities []models.UserIdentity
+}
+
+var _ Repository = (*MemoryRepository)(nil)
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		revokedTokens: make(map[string]models.RevokedAccessToken),
+	}
+}
+
+func (r *MemoryRepository) Close() {}
+
+// ==================== Course Methods ====================
+
+// GetAllCourses returns copies of all courses in insertion order
+func (r *MemoryRepository) GetAllCourses(ctx context.Context) ([]models.Course, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.allCourses(), nil
+}
+
+// GetCourseByID retrieves a specific course by ID
+func (r *MemoryRepository) GetCourseByID(ctx context.Context, id string) (*models.Course, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, course := range r.courses {
+		if course.ID == objectID {
+			c := cloneCourse(course)
+			return &c, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// GetCourseBySlug retrieves a specific course by its stable slug
+func (r *MemoryRepository) GetCourseBySlug(ctx context.Context, slug string) (*models.Course, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, course := range r.courses {
+		if course.Slug == slug {
+			c := cloneCourse(course)
+			return &c, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// CreateCourse stores a new course, assigning an ID if it has none
+func (r *MemoryRepository) CreateCourse(ctx context.Context, course *models.Course) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.slugTaken(course.Slug, course.ID) {
+		return ErrDuplicateSlug
+	}
+	if err := checkCatalog(r.courses, course); err != nil {
+		return err
+	}
+	if course.ID.IsZero() {
+		course.ID = primitive.NewObjectID()
+	}
+	r.courses = append(r.courses, cloneCourse(*course))
+	return nil
+}
+
+// UpsertCourseBySlug inserts a course or replaces the existing course with the same slug,
+// preserving its ID. Courses without a slug are adopted by matching on title.
+func (r *MemoryRepository) UpsertCourseBySlug(ctx context.Context, course *models.Course) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if course.Slug == "" {
+		return errors.New("course slug is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index := -1
+	for i, existing := range r.courses {
+		if existing.Slug == course.Slug {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		for i, existing := range r.courses {
+			if existing.Slug == "" && existing.Title == course.Title {
+				index = i
+				break
+			}
+		}
+	}
+
+	if index >= 0 {
+		course.ID = r.courses[index].ID
+		course.Version = r.courses[index].Version + 1
+		r.courses[index] = cloneCourse(*course)
+		return nil
+	}
+
+	course.ID = primitive.NewObjectID()
+	r.courses = append(r.courses, cloneCourse(*course))
+	return nil
+}
+
+// DeleteAllCourses removes all courses
+func (r *MemoryRepository) DeleteAllCourses(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.courses = nil
+	return nil
+}
+
+// ==================== Course Authoring Methods ====================
+
+// UpdateCourse applies a partial update to a course's own fields
+func (r *MemoryRepository) UpdateCourse(ctx context.Context, id string, update models.CourseUpdate) (*models.Course, error) {
+	return r.editCourse(ctx, id, editCourseFields(update))
+}
+
+// DeleteCourse removes a course. Progress records for it are left in place.
+func (r *MemoryRepository) DeleteCourse(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.courses {
+		if r.courses[i].ID == objectID {
+			r.courses = append(r.courses[:i], r.courses[i+1:]...)
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+// AddModule inserts a module at position; a negative position appends
+func (r *MemoryRepository) AddModule(ctx context.Context, courseID string, module models.Module, position int) (*models.Course, error) {
+	return r.editCourse(ctx, courseID, editAddModule(module, position))
+}
+
+// UpdateModule applies a partial update to one module
+func (r *MemoryRepository) UpdateModule(ctx context.Context, courseID string, moduleID string, update models.ModuleUpdate) (*models.Course, error) {
+	return r.editCourse(ctx, courseID, editUpdateModule(moduleID, update))
+}
+
+// DeleteModule removes a module from a course
+func (r *MemoryRepository) DeleteModule(ctx context.Context, courseID string, moduleID string) (*models.Course, error) {
+	return r.editCourse(ctx, courseID, editDeleteModule(moduleID))
+}
+
+// ReorderModules puts a course's modules in the given order
+func (r *MemoryRepository) ReorderModules(ctx context.Context, courseID string, moduleIDs []string) (*models.Course, error) {
+	return r.editCourse(ctx, courseID, editReorderModules(moduleIDs))
+}
+
+// AddContentBlock inserts a block into a module at position; a negative position appends
+func (r *MemoryRepository) AddContentBlock(ctx context.Context, courseID string, moduleID string, block models.ContentBlock, position int) (*models.Course, error) {
+	return r.editCourse(ctx, courseID, editAddContentBlock(moduleID, block, position))
+}
+
+// UpdateContentBlock replaces the block at index in a module
+func (r *MemoryRepository) UpdateContentBlock(ctx context.Context, courseID string, moduleID string, index int, block models.ContentBlock) (*models.Course, error) {
+	return r.editCourse(ctx, courseID, editUpdateContentBlock(moduleID, index, block))
+}
+
+// DeleteContentBlock removes the block at index from a module
+func (r *MemoryRepository) DeleteContentBlock(ctx context.Context, courseID string, moduleID string, index int) (*models.Course, error) {
+	return r.editCourse(ctx, courseID, editDeleteContentBlock(moduleID, index))
+}
+
+// ReorderContentBlocks rearranges a module's blocks; order lists current indexes in their new order
+func (r *MemoryRepository) ReorderContentBlocks(ctx context.Context, courseID string, moduleID string, order []int) (*models.Course, error) {
+	return r.editCourse(ctx, courseID, editReorderContentBlocks(moduleID, order))
+}
+
+// editCourse applies an edit to a copy of the course and stores it, bumping the version.
+// Holding the write lock makes the edit atomic, so ErrConflict never occurs here.
+func (r *MemoryRepository) editCourse(ctx context.Context, courseID string, edit courseEdit) (*models.Course, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.courses {
+		if r.courses[i].ID != objectID {
+			continue
+		}
+
+		course := cloneCourse(r.courses[i])
+		if _, err := edit(&course); err != nil {
+			return nil, err
+		}
+		if err := checkEdit(&r.courses[i], &course); err != nil {
+			return nil, err
+		}
+		if linksChanged(&r.courses[i], &course) {
+			if err := checkCatalog(r.courses, &course); err != nil {
+				return nil, err
+			}
+		}
+		if course.Slug != r.courses[i].Slug && r.slugTaken(course.Slug, course.ID) {
+			return nil, ErrDuplicateSlug
+		}
+
+		course.Version++
+		r.courses[i] = cloneCourse(course)
+		return &course, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// ==================== User Methods ====================
+
+// CreateUser stores a new user and sets its ID
+func (r *MemoryRepository) CreateUser(ctx context.Context, user *models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	r.users = append(r.users, *user)
+	return nil
+}
+
+// GetUserByEmail finds a user by their email address
+func (r *MemoryRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			u := user
+			return &u, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// GetUserByID finds a user by their ID
+func (r *MemoryRepository) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.ID == objectID {
+			u := user
+			return &u, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// GetUsersByIDs finds the users with the given IDs. IDs with no user are
+// skipped, and the order is unspecified.
+func (r *MemoryRepository) GetUsersByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := []models.User{}
+	for _, user := range r.users {
+		if containsObjectID(ids, user.ID) {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// UpdateUserRole changes a user's role
+func (r *MemoryRepository) UpdateUserRole(ctx context.Context, id string, role string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.users {
+		if r.users[i].ID == objectID {
+			r.users[i].Role = role
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+// UpdateUserPassword replaces a user's password hash
+func (r *MemoryRepository) UpdateUserPassword(ctx context.Context, id string, passwordHash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.users {
+		if r.users[i].ID == objectID {
+			r.users[i].Password = passwordHash
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+// SetUserEmailVerified records that a user confirmed their email address
+func (r *MemoryRepository) SetUserEmailVerified(ctx context.Context, id string, verifiedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.users {
+		if r.users[i].ID == objectID {
+			r.users[i].EmailVerified = true
+			r.users[i].EmailVerifiedAt = &verifiedAt
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+// ==================== Progress Methods ====================
+
+// GetUserProgress retrieves all progress records for a user
+func (r *MemoryRepository) GetUserProgress(ctx context.Context, userID string) ([]models.Progress, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.progressFor(objectID), nil
+}
+
+// InitializeUserProgress creates progress entries for all courses for a user
+func (r *MemoryRepository) InitializeUserProgress(ctx context.Context, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, course := range r.courses {
+		r.progress = append(r.progress, models.Progress{
+			ID:               primitive.NewObjectID(),
+			UserID:           userObjectID,
+			CourseID:         course.ID,
+			CompletedModules: []string{},
+			IsCompleted:      false,
+		})
+	}
+	return nil
+}
+
+// GetUserProgressWithCourses retrieves courses with user's progress data
+func (r *MemoryRepository) GetUserProgressWithCourses(ctx context.Context, userID string) ([]models.CourseWithProgress, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var cohorts []models.Cohort
+	for _, cohort := range r.cohorts {
+		if containsObjectID(cohort.MemberIDs, userObjectID) {
+			cohorts = append(cohorts, cohort)
+		}
+	}
+
+	return assignedCoursesOnly(combineCoursesWithProgress(r.allCourses(), r.progressFor(userObjectID)), cohorts), nil
+}
+
+// MarkModuleComplete marks a specific module as complete for a user,
+// with the same validation and rollup as MongoRepository.MarkModuleComplete
+func (r *MemoryRepository) MarkModuleComplete(ctx context.Context, userID string, courseID string, moduleID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	courseObjectID, err := primitive.ObjectIDFromHex(courseID)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	course := r.findCourse(courseObjectID)
+	if course == nil {
+		return mongo.ErrNoDocuments
+	}
+	if _, ok := course.Module(moduleID); !ok {
+		return ErrModuleNotFound
+	}
+
+	progress := r.findProgress(userObjectID, courseObjectID)
+	if progress == nil || !containsString(progress.CompletedModules, moduleID) {
+		var completedModules []string
+		if progress != nil {
+			completedModules = progress.CompletedModules
+		}
+		if err := prerequisitesError(course, moduleID, completedModules, r.completedCourseSlugs(userObjectID), r.slugExists); err != nil {
+			return err
+		}
+	}
+
+	// Equivalent of UpdateOne with $addToSet, falling back to an insert
+	now := time.Now().UTC()
+	if progress == nil {
+		r.progress = append(r.progress, models.Progress{
+			ID:               primitive.NewObjectID(),
+			UserID:           userObjectID,
+			CourseID:         courseObjectID,
+			CompletedModules: []string{},
+			IsCompleted:      false,
+		})
+		progress = &r.progress[len(r.progress)-1]
+	}
+	if !containsString(progress.CompletedModules, moduleID) {
+		progress.CompletedModules = append(progress.CompletedModules, moduleID)
+		if progress.ModuleCompletedAt == nil {
+			progress.ModuleCompletedAt = make(map[string]time.Time)
+		}
+		progress.ModuleCompletedAt[moduleID] = now
+	}
+
+	if !progress.IsCompleted && course.IsCompletedBy(progress.CompletedModules) {
+		progress.IsCompleted = true
+		progress.CompletedAt = &now
+	}
+
+	return nil
+}
+
+// ResetProgress undoes some of a learner's progress and records the reset,
+// like MongoRepository.ResetProgress
+func (r *MemoryRepository) ResetProgress(ctx context.Context, reset *models.ProgressReset) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := validateReset(reset); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reset.Scope != models.ResetAll {
+		course := r.findCourse(reset.CourseID)
+		if course == nil {
+			return mongo.ErrNoDocuments
+		}
+		if _, ok := course.Module(reset.ModuleID); reset.Scope == models.ResetModule && !ok {
+			return ErrModuleNotFound
+		}
+	}
+
+	for i := range r.progress {
+		p := &r.progress[i]
+		if p.UserID != reset.UserID || (reset.Scope != models.ResetAll && p.CourseID != reset.CourseID) {
+			continue
+		}
+		if reset.Scope == models.ResetModule {
+			p.CompletedModules = removeString(p.CompletedModules, reset.ModuleID)
+			delete(p.ModuleCompletedAt, reset.ModuleID)
+		} else {
+			p.CompletedModules = []string{}
+			p.ModuleCompletedAt = nil
+		}
+		p.IsCompleted = false
+		p.CompletedAt = nil
+	}
+
+	if reset.ID.IsZero() {
+		reset.ID = primitive.NewObjectID()
+	}
+	r.resets = append(r.resets, *reset)
+	return nil
+}
+
+// ReconcileCourseProgress brings every learner's progress in a course in line
+// with its current modules, like MongoRepository.ReconcileCourseProgress
+func (r *MemoryRepository) ReconcileCourseProgress(ctx context.Context, courseID string) ([]models.ProgressChange, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	course := r.findCourse(objectID)
+	if course == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	changes := []models.ProgressChange{}
+	for i := range r.progress {
+		if r.progress[i].CourseID != objectID {
+			continue
+		}
+		if change, changed := course.ReconcileProgress(&r.progress[i], time.Now().UTC()); changed {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// GetProgressResets returns the resets of a learner's progress, oldest first
+func (r *MemoryRepository) GetProgressResets(ctx context.Context, userID string) ([]models.ProgressReset, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var resets []models.ProgressReset
+	for _, reset := range r.resets {
+		if reset.UserID == userObjectID {
+			resets = append(resets, reset)
+		}
+	}
+	sort.SliceStable(resets, func(i, j int) bool {
+		return resets[i].ResetAt.Before(resets[j].ResetAt)
+	})
+	return resets, nil
+}
+
+// ==================== Exercise Methods ====================
+
+// CreateExerciseAttempt stores a learner's answer to an exercise and sets its ID
+func (r *MemoryRepository) CreateExerciseAttempt(ctx context.Context, attempt *models.ExerciseAttempt) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attempt.ID.IsZero() {
+		attempt.ID = primitive.NewObjectID()
+	}
+	r.exerciseAttempts = append(r.exerciseAttempts, *attempt)
+	return nil
+}
+
+// CountExerciseAttempts counts the answers a learner has submitted for an exercise
+func (r *MemoryRepository) CountExerciseAttempts(ctx context.Context, userID string, courseID string, moduleID string, exerciseID string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	userObjectID, courseObjectID, err := parseUserAndCourse(userID, courseID)
+	if err != nil {
+		return 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, a := range r.exerciseAttempts {
+		if a.UserID == userObjectID && a.CourseID == courseObjectID && a.ModuleID == moduleID && a.ExerciseID == exerciseID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// RecordExerciseReveal records that a learner revealed a hint or solution.
+// Revealing the same thing again keeps the original reveal time.
+func (r *MemoryRepository) RecordExerciseReveal(ctx context.Context, reveal *models.ExerciseReveal) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.exerciseReveals {
+		if existing.UserID == reveal.UserID && existing.CourseID == reveal.CourseID &&
+			existing.ModuleID == reveal.ModuleID && existing.ExerciseID == reveal.ExerciseID &&
+			existing.Kind == reveal.Kind && existing.HintIndex == reveal.HintIndex {
+			return nil
+		}
+	}
+
+	stored := *reveal
+	if stored.ID.IsZero() {
+		stored.ID = primitive.NewObjectID()
+	}
+	r.exerciseReveals = append(r.exerciseReveals, stored)
+	return nil
+}
+
+// GetExerciseReveals retrieves what a learner has revealed of an exercise, hints in order then the solution
+func (r *MemoryRepository) GetExerciseReveals(ctx context.Context, userID string, courseID string, moduleID string, exerciseID string) ([]models.ExerciseReveal, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	userObjectID, courseObjectID, err := parseUserAndCourse(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var reveals []models.ExerciseReveal
+	for _, reveal := range r.exerciseReveals {
+		if reveal.UserID == userObjectID && reveal.CourseID == courseObjectID &&
+			reveal.ModuleID == moduleID && reveal.ExerciseID == exerciseID {
+			reveals = append(reveals, reveal)
+		}
+	}
+	sort.Slice(reveals, func(i, j int) bool {
+		if reveals[i].Kind != reveals[j].Kind {
+			return reveals[i].Kind < reveals[j].Kind
+		}
+		return reveals[i].HintIndex < reveals[j].HintIndex
+	})
+	return reveals, nil
+}
+
+// CreateCodeRun stores a learner's Go submission and its test results and sets its ID
+func (r *MemoryRepository) CreateCodeRun(ctx context.Context, run *models.CodeRun) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if run.ID.IsZero() {
+		run.ID = primitive.NewObjectID()
+	}
+	r.codeRuns = append(r.codeRuns, cloneCodeRun(*run))
+	return nil
+}
+
+// GetCodeRuns retrieves a learner's runs of an exercise, oldest first
+func (r *MemoryRepository) GetCodeRuns(ctx context.Context, userID string, courseID string, moduleID string, exerciseID string) ([]models.CodeRun, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	userObjectID, courseObjectID, err := parseUserAndCourse(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var runs []models.CodeRun
+	for _, run := range r.codeRuns {
+		if run.UserID == userObjectID && run.CourseID == courseObjectID &&
+			run.ModuleID == moduleID && run.ExerciseID == exerciseID {
+			runs = append(runs, cloneCodeRun(run))
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].SubmittedAt.Before(runs[j].SubmittedAt)
+	})
+	return runs, nil
+}
+
+// ==================== Quiz Methods ====================
+
+// CreateQuizAttempt stores a learner's scored quiz submission and sets its ID
+func (r *MemoryRepository) CreateQuizAttempt(ctx context.Context, attempt *models.QuizAttempt) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attempt.ID.IsZero() {
+		attempt.ID = primitive.NewObjectID()
+	}
+	r.quizAttempts = append(r.quizAttempts, cloneQuizAttempt(*attempt))
+	return nil
+}
+
+// GetQuizAttempts retrieves a learner's attempts at a quiz, oldest first
+func (r *MemoryRepository) GetQuizAttempts(ctx context.Context, userID string, courseID string, moduleID string, quizID string) ([]models.QuizAttempt, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	userObjectID, courseObjectID, err := parseUserAndCourse(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var attempts []models.QuizAttempt
+	for _, attempt := range r.quizAttempts {
+		if attempt.UserID == userObjectID && attempt.CourseID == courseObjectID &&
+			attempt.ModuleID == moduleID && attempt.QuizID == quizID {
+			attempts = append(attempts, cloneQuizAttempt(attempt))
+		}
+	}
+	sort.SliceStable(attempts, func(i, j int) bool {
+		return attempts[i].SubmittedAt.Before(attempts[j].SubmittedAt)
+	})
+	return attempts, nil
+}
+
+// HasPassedQuiz reports whether any of a learner's attempts at a quiz passed
+func (r *MemoryRepository) HasPassedQuiz(ctx context.Context, userID string, courseID string, moduleID string, quizID string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	userObjectID, courseObjectID, err := parseUserAndCourse(userID, courseID)
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, attempt := range r.quizAttempts {
+		if attempt.UserID == userObjectID && attempt.CourseID == courseObjectID &&
+			attempt.ModuleID == moduleID && attempt.QuizID == quizID && attempt.Result.Passed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ==================== Dashboard Methods ====================
+
+// GetCourseCompletionCounts counts, per course, the learners in scope who have
+// started and completed it. Courses nobody in scope has progress in are left out.
+func (r *MemoryRepository) GetCourseCompletionCounts(ctx context.Context, scope models.ProgressScope) ([]models.CourseCompletionCount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// A learner with duplicate progress records counts once, as started or
+	// completed if any of the records is
+	type learner struct {
+		course, user       primitive.ObjectID
+		started, completed bool
+	}
+	var learners []learner
+	index := make(map[[2]primitive.ObjectID]int)
+	for _, p := range r.progress {
+		if !scope.Includes(p) {
+			continue
+		}
+		key := [2]primitive.ObjectID{p.CourseID, p.UserID}
+		i, ok := index[key]
+		if !ok {
+			i = len(learners)
+			index[key] = i
+			learners = append(learners, learner{course: p.CourseID})
+		}
+		learners[i].started = learners[i].started || len(p.CompletedModules) > 0
+		learners[i].completed = learners[i].completed || p.IsCompleted
+	}
+
+	var counts []models.CourseCompletionCount
+	courses := make(map[primitive.ObjectID]int)
+	for _, l := range learners {
+		i, ok := courses[l.course]
+		if !ok {
+			i = len(counts)
+			courses[l.course] = i
+			counts = append(counts, models.CourseCompletionCount{CourseID: l.course})
+		}
+		counts[i].Learners++
+		if l.started {
+			counts[i].Started++
+		}
+		if l.completed {
+			counts[i].Completed++
+		}
+	}
+	return counts, nil
+}
+
+// GetModuleCompletionCounts counts, per module ID, the learners in scope who
+// have completed that module of the course
+func (r *MemoryRepository) GetModuleCompletionCounts(ctx context.Context, courseID primitive.ObjectID, scope models.ProgressScope) (map[string]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	learners := make(map[string]map[primitive.ObjectID]bool)
+	for _, p := range r.progress {
+		if p.CourseID != courseID || !scope.Includes(p) {
+			continue
+		}
+		for _, moduleID := range p.CompletedModules {
+			if learners[moduleID] == nil {
+				learners[moduleID] = make(map[primitive.ObjectID]bool)
+			}
+			learners[moduleID][p.UserID] = true
+		}
+	}
+
+	counts := make(map[string]int, len(learners))
+	for moduleID, users := range learners {
+		counts[moduleID] = len(users)
+	}
+	return counts, nil
+}
+
+// GetScopedProgress returns the progress records in scope
+func (r *MemoryRepository) GetScopedProgress(ctx context.Context, scope models.ProgressScope) ([]models.Progress, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var progress []models.Progress
+	for _, p := range r.progress {
+		if scope.Includes(p) {
+			progress = append(progress, cloneProgress(p))
+		}
+	}
+	return progress, nil
+}
+
+// GetProgressUserIDs returns the distinct IDs of users with any progress record
+func (r *MemoryRepository) GetProgressUserIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var userIDs []primitive.ObjectID
+	seen := make(map[primitive.ObjectID]bool)
+	for _, p := range r.progress {
+		if !seen[p.UserID] {
+			seen[p.UserID] = true
+			userIDs = append(userIDs, p.UserID)
+		}
+	}
+	return userIDs, nil
+}
+
+// GetLastActivity returns when each of the users (every user, if nil) last did
+// something other than log in. Users with no such activity are left out.
+func (r *MemoryRepository) GetLastActivity(ctx context.Context, userIDs []primitive.ObjectID) ([]models.LearnerLastActivity, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var activity []models.LearnerLastActivity
+	index := make(map[primitive.ObjectID]int)
+	for _, event := range r.activity {
+		if event.Type == models.ActivityLogin || (userIDs != nil && !containsObjectID(userIDs, event.UserID)) {
+			continue
+		}
+		i, ok := index[event.UserID]
+		if !ok {
+			index[event.UserID] = len(activity)
+			activity = append(activity, models.LearnerLastActivity{UserID: event.UserID, LastActiveAt: event.OccurredAt})
+			continue
+		}
+		if event.OccurredAt.After(activity[i].LastActiveAt) {
+			activity[i].LastActiveAt = event.OccurredAt
+		}
+	}
+	return activity, nil
+}
+
+// ==================== Activity Methods ====================
+
+// RecordActivity appends an event to the activity log and sets its ID
+func (r *MemoryRepository) RecordActivity(ctx context.Context, event *models.ActivityEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	r.activity = append(r.activity, *event)
+	return nil
+}
+
+// GetActivity returns the events matching the filter, newest first
+func (r *MemoryRepository) GetActivity(ctx context.Context, filter models.ActivityFilter) ([]models.ActivityEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []models.ActivityEvent
+	for _, event := range r.activity {
+		if filter.Matches(event) {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].OccurredAt.After(events[j].OccurredAt)
+	})
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		events = events[:filter.Limit]
+	}
+	return events, nil
+}
+
+// ==================== Achievement Methods ====================
+
+// AwardBadge records that a user earned a badge and sets its ID. It returns
+// false, keeping the original award, if the user already has the badge.
+func (r *MemoryRepository) AwardBadge(ctx context.Context, badge *models.UserBadge) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.badges {
+		if existing.UserID == badge.UserID && existing.BadgeID == badge.BadgeID {
+			return false, nil
+		}
+	}
+
+	if badge.ID.IsZero() {
+		badge.ID = primitive.NewObjectID()
+	}
+	r.badges = append(r.badges, *badge)
+	return true, nil
+}
+
+// GetUserBadges returns the badges a user has earned, oldest first
+func (r *MemoryRepository) GetUserBadges(ctx context.Context, userID string) ([]models.UserBadge, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var badges []models.UserBadge
+	for _, badge := range r.badges {
+		if badge.UserID == userObjectID {
+			badges = append(badges, badge)
+		}
+	}
+	sort.SliceStable(badges, func(i, j int) bool {
+		return badges[i].EarnedAt.Before(badges[j].EarnedAt)
+	})
+	return badges, nil
+}
+
+// ==================== Certificate Methods ====================
+
+// IssueCertificate stores a certificate for the user and course and sets its
+// ID. If the user already has a certificate for the course, it returns false
+// and replaces cert with the existing one.
+func (r *MemoryRepository) IssueCertificate(ctx context.Context, cert *models.Certificate) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.certificates {
+		if existing.UserID == cert.UserID && existing.CourseID == cert.CourseID {
+			*cert = existing
+			return false, nil
+		}
+	}
+
+	if cert.ID.IsZero() {
+		cert.ID = primitive.NewObjectID()
+	}
+	r.certificates = append(r.certificates, *cert)
+	return true, nil
+}
+
+// GetCertificate retrieves a certificate by ID
+func (r *MemoryRepository) GetCertificate(ctx context.Context, id string) (*models.Certificate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, cert := range r.certificates {
+		if cert.ID == objectID {
+			found := cert
+			return &found, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// GetUserCertificates returns a user's certificates, oldest completion first
+func (r *MemoryRepository) GetUserCertificates(ctx context.Context, userID string) ([]models.Certificate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var certs []models.Certificate
+	for _, cert := range r.certificates {
+		if cert.UserID == userObjectID {
+			certs = append(certs, cert)
+		}
+	}
+	sort.SliceStable(certs, func(i, j int) bool {
+		return certs[i].CompletedAt.Before(certs[j].CompletedAt)
+	})
+	return certs, nil
+}
+
+// ==================== Cohort Methods ====================
+
+// CreateCohort stores a new cohort and sets its ID
+func (r *MemoryRepository) CreateCohort(ctx context.Context, cohort *models.Cohort) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cohort.ID.IsZero() {
+		cohort.ID = primitive.NewObjectID()
+	}
+	r.cohorts = append(r.cohorts, cloneCohort(*cohort))
+	return nil
+}
+
+// GetCohort retrieves a cohort by ID
+func (r *MemoryRepository) GetCohort(ctx context.Context, id string) (*models.Cohort, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cohort, err := r.findCohort(id)
+	if err != nil {
+		return nil, err
+	}
+	found := cloneCohort(*cohort)
+	return &found, nil
+}
+
+// GetCohorts returns the cohorts matching the filter, by start date
+func (r *MemoryRepository) GetCohorts(ctx context.Context, filter models.CohortFilter) ([]models.Cohort, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var cohorts []models.Cohort
+	for _, cohort := range r.cohorts {
+		if filter.Matches(cohort) {
+			cohorts = append(cohorts, cloneCohort(cohort))
+		}
+	}
+	sort.SliceStable(cohorts, func(i, j int) bool {
+		return cohorts[i].StartDate < cohorts[j].StartDate
+	})
+	return cohorts, nil
+}
+
+// AddCohortMembers adds users to a cohort, ignoring those already in it, and
+// returns the updated cohort
+func (r *MemoryRepository) AddCohortMembers(ctx context.Context, id string, userIDs []primitive.ObjectID) (*models.Cohort, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cohort, err := r.findCohort(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, userID := range userIDs {
+		if !containsObjectID(cohort.MemberIDs, userID) {
+			cohort.MemberIDs = append(cohort.MemberIDs, userID)
+		}
+	}
+
+	updated := cloneCohort(*cohort)
+	return &updated, nil
+}
+
+// RemoveCohortMember removes a user from a cohort and returns the updated cohort
+func (r *MemoryRepository) RemoveCohortMember(ctx context.Context, id string, userID primitive.ObjectID) (*models.Cohort, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cohort, err := r.findCohort(id)
+	if err != nil {
+		return nil, err
+	}
+	members := cohort.MemberIDs[:0]
+	for _, memberID := range cohort.MemberIDs {
+		if memberID != userID {
+			members = append(members, memberID)
+		}
+	}
+	cohort.MemberIDs = members
+
+	updated := cloneCohort(*cohort)
+	return &updated, nil
+}
+
+// findCohort returns the stored cohort with the given ID; callers must hold the lock
+func (r *MemoryRepository) findCohort(id string) (*models.Cohort, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range r.cohorts {
+		if r.cohorts[i].ID == objectID {
+			return &r.cohorts[i], nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// ==================== Token Methods ====================
+
+// CreateRefreshToken stores a new refresh token and sets its ID
+func (r *MemoryRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	r.refreshTokens = append(r.refreshTokens, *token)
+	return nil
+}
+
+// GetRefreshTokenByHash finds a refresh token by the hash of its value
+func (r *MemoryRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, token := range r.refreshTokens {
+		if token.TokenHash == tokenHash {
+			t := token
+			return &t, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// RevokeRefreshToken revokes a refresh token if it is still active and reports whether this call revoked it
+func (r *MemoryRepository) RevokeRefreshToken(ctx context.Context, tokenHash string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.revokeRefreshTokens(func(t *models.RefreshToken) bool { return t.TokenHash == tokenHash }) > 0, nil
+}
+
+// RevokeRefreshTokenFamily revokes every active token rotated from the same login
+func (r *MemoryRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.revokeRefreshTokens(func(t *models.RefreshToken) bool { return t.FamilyID == familyID })
+	return nil
+}
+
+// RevokeUserRefreshTokens revokes every active refresh token belonging to a user
+func (r *MemoryRepository) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.revokeRefreshTokens(func(t *models.RefreshToken) bool { return t.UserID == userObjectID })
+	return nil
+}
+
+// RevokeAccessToken adds an access token's JWT ID to the revocation list
+func (r *MemoryRepository) RevokeAccessToken(ctx context.Context, token *models.RevokedAccessToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Stand-in for the TTL index: drop entries whose tokens have expired anyway
+	now := time.Now()
+	for jti, revoked := range r.revokedTokens {
+		if revoked.ExpiresAt.Before(now) {
+			delete(r.revokedTokens, jti)
+		}
+	}
+
+	if _, exists := r.revokedTokens[token.JTI]; !exists {
+		r.revokedTokens[token.JTI] = *token
+	}
+	return nil
+}
+
+// IsAccessTokenRevoked reports whether an access token's JWT ID has been revoked
+func (r *MemoryRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, revoked := r.revokedTokens[jti]
+	return revoked, nil
+}
+
+// CreatePasswordResetToken stores a new password reset token and sets its ID
+func (r *MemoryRepository) CreatePasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	r.resetTokens = append(r.resetTokens, *token)
+	return nil
+}
+
+// UsePasswordResetToken marks an unused, unexpired reset token used, along with the
+// user's other outstanding reset tokens, and returns it
+func (r *MemoryRepository) UsePasswordResetToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	for i := range r.resetTokens {
+		token := r.resetTokens[i]
+		if token.TokenHash != tokenHash || token.UsedAt != nil || !token.ExpiresAt.After(now) {
+			continue
+		}
+
+		for j := range r.resetTokens {
+			if r.resetTokens[j].UserID == token.UserID && r.resetTokens[j].UsedAt == nil {
+				r.resetTokens[j].UsedAt = &now
+			}
+		}
+		used := r.resetTokens[i]
+		return &used, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// CreateEmailVerificationToken stores a new email verification token and sets its ID
+func (r *MemoryRepository) CreateEmailVerificationToken(ctx context.Context, token *models.EmailVerificationToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	r.verifyTokens = append(r.verifyTokens, *token)
+	return nil
+}
+
+// UseEmailVerificationToken marks an unused, unexpired verification token used,
+// along with the user's other outstanding verification tokens, and returns it
+func (r *MemoryRepository) UseEmailVerificationToken(ctx context.Context, tokenHash string) (*models.EmailVerificationToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	for i := range r.verifyTokens {
+		token := r.verifyTokens[i]
+		if token.TokenHash != tokenHash || token.UsedAt != nil || !token.ExpiresAt.After(now) {
+			continue
+		}
+
+		for j := range r.verifyTokens {
+			if r.verifyTokens[j].UserID == token.UserID && r.verifyTokens[j].UsedAt == nil {
+				r.verifyTokens[j].UsedAt = &now
+			}
+		}
+		used := r.verifyTokens[i]
+		return &used, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// ==================== Identity Methods ====================
+
+// LinkUserIdentity links a provider account to a user and sets its ID. It
+// returns false, keeping the original link, if the provider account is
+// already linked.
+func (r *MemoryRepository) LinkUserIdentity(ctx context.Context, identity *models.UserIdentity) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.identities {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return false, nil
+		}
+	}
+
+	if identity.ID.IsZero() {
+		identity.ID = primitive.NewObjectID()
+	}
+	r.identities = append(r.identities, *identity)
+	return true, nil
+}
+
+// GetUserIdentity finds the link for a provider account
+func (r *MemoryRepository) GetUserIdentity(ctx context.Context, provider string, subject string) (*models.UserIdentity, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			i := identity
+			return &i, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// ==================== Helpers ====================
+
+// parseUserAndCourse converts user and course IDs, failing like MongoRepository on invalid hex
+func parseUserAndCourse(userID string, courseID string) (primitive.ObjectID, primitive.ObjectID, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, err
+	}
+	courseObjectID, err := primitive.ObjectIDFromHex(courseID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, err
+	}
+	return userObjectID, courseObjectID, nil
+}
+
+// revokeRefreshTokens revokes active tokens matching the predicate and returns how many; callers must hold the lock
+func (r *MemoryRepository) revokeRefreshTokens(match func(*models.RefreshToken) bool) int {
+	now := time.Now().UTC()
+	revoked := 0
+	for i := range r.refreshTokens {
+		token := &r.refreshTokens[i]
+		if token.RevokedAt == nil && match(token) {
+			token.RevokedAt = &now
+			revoked++
+		}
+	}
+	return revoked
+}
+
+// allCourses returns copies of every course; callers must hold the lock
+func (r *MemoryRepository) allCourses() []models.Course {
+	var courses []models.Course
+	for _, course := range r.courses {
+		courses = append(courses, cloneCourse(course))
+	}
+	return courses
+}
+
+// slugTaken reports whether a course other than exceptID uses slug; callers must hold the lock
+func (r *MemoryRepository) slugTaken(slug string, exceptID primitive.ObjectID) bool {
+	if slug == "" {
+		return false
+	}
+	for _, course := range r.courses {
+		if course.Slug == slug && course.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// progressFor returns copies of a user's progress records; callers must hold the lock
+func (r *MemoryRepository) progressFor(userID primitive.ObjectID) []models.Progress {
+	var progress []models.Progress
+	for _, p := range r.progress {
+		if p.UserID == userID {
+			progress = append(progress, cloneProgress(p))
+		}
+	}
+	return progress
+}
+
+// findProgress returns the first progress record for a user and course; callers must hold the lock
+func (r *MemoryRepository) findProgress(userID, courseID primitive.ObjectID) *models.Progress {
+	for i := range r.progress {
+		if r.progress[i].UserID == userID && r.progress[i].CourseID == courseID {
+			return &r.progress[i]
+		}
+	}
+	return nil
+}
+
+// findCourse returns the stored course with the ID; callers must hold the lock
+func (r *MemoryRepository) findCourse(id primitive.ObjectID) *models.Course {
+	for i := range r.courses {
+		if r.courses[i].ID == id {
+			return &r.courses[i]
+		}
+	}
+	return nil
+}
+
+// completedCourseSlugs returns the slugs of the courses a user has completed; callers must hold the lock
+func (r *MemoryRepository) completedCourseSlugs(userID primitive.ObjectID) []string {
+	var slugs []string
+	for _, p := range r.progress {
+		if p.UserID != userID || !p.IsCompleted {
+			continue
+		}
+		if course := r.findCourse(p.CourseID); course != nil {
+			slugs = append(slugs, course.Slug)
+		}
+	}
+	return slugs
+}
+
+// slugExists reports whether a course uses the slug; callers must hold the lock
+func (r *MemoryRepository) slugExists(slug string) bool {
+	return r.slugTaken(slug, primitive.NilObjectID)
+}
+
+// cloneCourse copies a course deeply enough that callers can't mutate stored state
+func cloneCourse(course models.Course) models.Course {
+	course.Prerequisites = cloneStrings(course.Prerequisites)
+	if course.Modules == nil {
+		return course
+	}
+	modules := make([]models.Module, len(course.Modules))
+	for i, module := range course.Modules {
+		module.Prerequisites = cloneStrings(module.Prerequisites)
+		if module.Content == nil {
+			modules[i] = module
+			continue
+		}
+		content := make([]models.ContentBlock, len(module.Content))
+		for j, block := range module.Content {
+			content[j] = cloneBlock(block)
+		}
+		module.Content = content
+		modules[i] = module
+	}
+	course.Modules = modules
+	return course
+}
+
+// cloneProgress copies a progress record's slices, map and pointer
+func cloneProgress(p models.Progress) models.Progress {
+	p.CompletedModules = append([]string{}, p.CompletedModules...)
+	if p.ModuleCompletedAt != nil {
+		completedAt := make(map[string]time.Time, len(p.ModuleCompletedAt))
+		for id, t := range p.ModuleCompletedAt {
+			completedAt[id] = t
+		}
+		p.ModuleCompletedAt = completedAt
+	}
+	if p.CompletedAt != nil {
+		t := *p.CompletedAt
+		p.CompletedAt = &t
+	}
+	return p
+}
+
+// cloneStrings copies a slice, keeping nil as nil
+func cloneStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append([]string{}, values...)
+}
+
+func cloneCohort(cohort models.Cohort) models.Cohort {
+	clone := cohort
+	clone.InstructorIDs = append([]primitive.ObjectID{}, cohort.InstructorIDs...)
+	clone.MemberIDs = append([]primitive.ObjectID{}, cohort.MemberIDs...)
+	clone.CourseIDs = append([]primitive.ObjectID{}, cohort.CourseIDs...)
+	return clone
+}
+
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneBlock deep-copies a block by round-tripping it through BSON, which also
+// gives it the same shape it would have after a trip through MongoDB
+func cloneBlock(block models.ContentBlock) models.ContentBlock {
+	raw, err := bson.Marshal(block)
+	if err != nil {
+		return block
+	}
+	var clone models.ContentBlock
+	if err := bson.Unmarshal(raw, &clone); err != nil {
+		return block
+	}
+	return clone
+}
+
+// cloneCodeRun deep-copies a run's test results the same way
+func cloneCodeRun(run models.CodeRun) models.CodeRun {
+	raw, err := bson.Marshal(run)
+	if err != nil {
+		return run
+	}
+	var clone models.CodeRun
+	if err := bson.Unmarshal(raw, &clone); err != nil {
+		return run
+	}
+	return clone
+}
+
+// cloneQuizAttempt deep-copies an attempt's answers and results the same way
+func cloneQuizAttempt(attempt models.QuizAttempt) models.QuizAttempt {
+	raw, err := bson.Marshal(attempt)
+	if err != nil {
+		return attempt
+	}
+	var clone models.QuizAttempt
+	if err := bson.Unmarshal(raw, &clone); err != nil {
+		return attempt
+	}
+	return clone
+}
+
+// removeString returns values without any copies of value
+func removeString(values []string, value string) []string {
+	kept := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,674 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pathway/backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func newTestCourse(slug string, moduleIDs ...string) *models.Course {
+	course := &models.Course{Slug: slug, Title: slug}
+	for _, id := range moduleIDs {
+		course.Modules = append(course.Modules, models.Module{ID: id, Title: id})
+	}
+	return course
+}
+
+func TestMemoryUpsertCourseBySlugPreservesID(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	course := newTestCourse("git", "git-1")
+	if err := repo.UpsertCourseBySlug(ctx, course); err != nil {
+		t.Fatal(err)
+	}
+	originalID := course.ID
+
+	updated := newTestCourse("git", "git-1", "git-2")
+	if err := repo.UpsertCourseBySlug(ctx, updated); err != nil {
+		t.Fatal(err)
+	}
+	if updated.ID != originalID {
+		t.Fatalf("upsert changed ID: %s -> %s", originalID.Hex(), updated.ID.Hex())
+	}
+
+	courses, _ := repo.GetAllCourses(ctx)
+	if len(courses) != 1 || len(courses[0].Modules) != 2 {
+		t.Fatalf("expected one course with 2 modules, got %+v", courses)
+	}
+
+	if err := repo.UpsertCourseBySlug(ctx, &models.Course{Title: "No slug"}); err == nil {
+		t.Fatal("expected error for course without slug")
+	}
+}
+
+func TestMemoryUpsertCourseBySlugAdoptsLegacyCourse(t *testing.T) {
+	cases := []struct {
+		name   string
+		stored []models.Course
+		adopts int // Index into stored of the course the upsert replaces, or -1 to insert
+	}{
+		{"legacy course with the same title", []models.Course{{Title: "Git"}}, 0},
+		{"legacy course with another title", []models.Course{{Title: "Git basics"}}, -1},
+		{"course with another slug and the same title", []models.Course{{Slug: "git-basics", Title: "Git"}}, -1},
+		{"slug match wins over title", []models.Course{{Title: "Git"}, {Slug: "git", Title: "Version control"}}, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := NewMemoryRepository()
+			for i := range tc.stored {
+				if err := repo.CreateCourse(ctx, &tc.stored[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			course := &models.Course{Slug: "git", Title: "Git"}
+			if err := repo.UpsertCourseBySlug(ctx, course); err != nil {
+				t.Fatal(err)
+			}
+			courses, _ := repo.GetAllCourses(ctx)
+			if tc.adopts < 0 {
+				if len(courses) != len(tc.stored)+1 {
+					t.Fatalf("expected a new course, got %+v", courses)
+				}
+				return
+			}
+			if course.ID != tc.stored[tc.adopts].ID || len(courses) != len(tc.stored) {
+				t.Fatalf("expected %s to be adopted, got %s in %+v", tc.stored[tc.adopts].ID.Hex(), course.ID.Hex(), courses)
+			}
+		})
+	}
+}
+
+func TestMemoryNotFoundErrorsMatchMongo(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	if _, err := repo.GetUserByEmail(ctx, "missing@example.com"); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("GetUserByEmail: expected ErrNoDocuments, got %v", err)
+	}
+	if _, err := repo.GetCourseByID(ctx, primitive.NewObjectID().Hex()); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("GetCourseByID: expected ErrNoDocuments, got %v", err)
+	}
+	if _, err := repo.GetCourseByID(ctx, "not-hex"); !errors.Is(err, primitive.ErrInvalidHex) {
+		t.Fatalf("GetCourseByID: expected ErrInvalidHex, got %v", err)
+	}
+}
+
+func TestMemoryReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	course := newTestCourse("git", "git-1")
+	course.Modules[0].Content = []models.ContentBlock{textBlock("hi")}
+	_ = repo.UpsertCourseBySlug(ctx, course)
+
+	got, _ := repo.GetCourseBySlug(ctx, "git")
+	got.Modules[0].Title = "changed"
+	got.Modules[0].Content[0].Data.(*models.TextBlock).Markdown = "changed"
+
+	again, _ := repo.GetCourseBySlug(ctx, "git")
+	if again.Modules[0].Title != "git-1" || markdownOf(again.Modules[0].Content[0]) != "hi" {
+		t.Fatalf("stored course was mutated through a returned copy: %+v", again.Modules[0])
+	}
+}
+
+func TestMemoryMarkModuleCompleteConcurrent(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	course := newTestCourse("git", "git-1", "git-2", "git-3", "git-4")
+	_ = repo.UpsertCourseBySlug(ctx, course)
+
+	userID := primitive.NewObjectID().Hex()
+	if err := repo.InitializeUserProgress(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			moduleID := fmt.Sprintf("git-%d", i%4+1)
+			if err := repo.MarkModuleComplete(ctx, userID, course.ID.Hex(), moduleID); err != nil {
+				t.Error(err)
+			}
+			_, _ = repo.GetUserProgressWithCourses(ctx, userID)
+		}(i)
+	}
+	wg.Wait()
+
+	progress, _ := repo.GetUserProgress(ctx, userID)
+	if len(progress) != 1 {
+		t.Fatalf("expected 1 progress record, got %d", len(progress))
+	}
+	if len(progress[0].CompletedModules) != 4 || !progress[0].IsCompleted {
+		t.Fatalf("unexpected progress: %+v", progress[0])
+	}
+}
+
+func TestMemoryMarkModuleCompleteCreatesProgress(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	course := newTestCourse("git", "git-1", "git-2")
+	_ = repo.UpsertCourseBySlug(ctx, course)
+	userID := primitive.NewObjectID().Hex()
+
+	if err := repo.MarkModuleComplete(ctx, userID, course.ID.Hex(), "git-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	courses, _ := repo.GetUserProgressWithCourses(ctx, userID)
+	if len(courses) != 1 || courses[0].ProgressPercent != 50 || courses[0].IsCompleted {
+		t.Fatalf("unexpected progress: %+v", courses)
+	}
+}
+
+func TestMemoryMarkModuleCompleteValidatesModule(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	course := newTestCourse("git", "git-1", "git-2")
+	_ = repo.UpsertCourseBySlug(ctx, course)
+	userID := primitive.NewObjectID().Hex()
+
+	if err := repo.MarkModuleComplete(ctx, userID, primitive.NewObjectID().Hex(), "git-1"); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("unknown course: expected ErrNoDocuments, got %v", err)
+	}
+	for _, moduleID := range []string{"junk-1", "junk-2"} {
+		if err := repo.MarkModuleComplete(ctx, userID, course.ID.Hex(), moduleID); !errors.Is(err, ErrModuleNotFound) {
+			t.Fatalf("%s: expected ErrModuleNotFound, got %v", moduleID, err)
+		}
+	}
+	if progress, _ := repo.GetUserProgress(ctx, userID); len(progress) != 0 {
+		t.Fatalf("rejected completions shouldn't create progress: %+v", progress)
+	}
+}
+
+func TestCourseCompletionIgnoresStaleModules(t *testing.T) {
+	course := newTestCourse("git", "git-1", "git-2")
+
+	// git-0 was deleted from the course after the learner completed it
+	completed := []string{"git-0", "git-1"}
+	if course.CompletedModuleCount(completed) != 1 || course.IsCompletedBy(completed) {
+		t.Fatal("stale module IDs shouldn't count towards completion")
+	}
+
+	progress := []models.Progress{{CourseID: course.ID, CompletedModules: completed}}
+	if cwp := combineCoursesWithProgress([]models.Course{*course}, progress)[0]; cwp.ProgressPercent != 50 {
+		t.Fatalf("expected 50%%, got %v", cwp.ProgressPercent)
+	}
+}
+
+func TestMemoryHonoursCancelledContext(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := repo.GetAllCourses(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetAllCourses: expected context.Canceled, got %v", err)
+	}
+	if err := repo.CreateUser(ctx, &models.User{Email: "a@example.com"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("CreateUser: expected context.Canceled, got %v", err)
+	}
+}
+
+func TestMemoryMarkModuleCompleteChecksPrerequisites(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	basics := newTestCourse("go-basics", "intro")
+	advanced := newTestCourse("go-advanced", "generics", "concurrency", "testing")
+	advanced.Prerequisites = []string{"go-basics", "retired-course"}
+	advanced.Sequential = true
+	for _, course := range []*models.Course{basics, advanced} {
+		if err := repo.UpsertCourseBySlug(ctx, course); err != nil {
+			t.Fatal(err)
+		}
+	}
+	userID := primitive.NewObjectID().Hex()
+
+	// retired-course doesn't exist, so only go-basics is reported
+	err := repo.MarkModuleComplete(ctx, userID, advanced.ID.Hex(), "concurrency")
+	var unmet *PrerequisitesError
+	if !errors.As(err, &unmet) || !errors.Is(err, ErrPrerequisitesNotMet) {
+		t.Fatalf("expected PrerequisitesError, got %v", err)
+	}
+	if !reflect.DeepEqual(unmet.Courses, []string{"go-basics"}) || !reflect.DeepEqual(unmet.Modules, []string{"generics"}) {
+		t.Fatalf("unexpected unmet prerequisites: %+v", unmet)
+	}
+
+	courses, _ := repo.GetUserProgressWithCourses(ctx, userID)
+	if cwp := courses[1]; !cwp.Locked || !cwp.Modules[0].Locked || len(cwp.Modules[0].UnmetPrerequisites) != 0 {
+		t.Fatalf("advanced course should be locked: %+v", cwp)
+	}
+
+	if err := repo.MarkModuleComplete(ctx, userID, basics.ID.Hex(), "intro"); err != nil {
+		t.Fatal(err)
+	}
+	for _, moduleID := range []string{"generics", "concurrency"} {
+		if err := repo.MarkModuleComplete(ctx, userID, advanced.ID.Hex(), moduleID); err != nil {
+			t.Fatalf("%s: %v", moduleID, err)
+		}
+	}
+
+	courses, _ = repo.GetUserProgressWithCourses(ctx, userID)
+	cwp := courses[1]
+	if cwp.Locked || len(cwp.UnmetPrerequisites) != 0 {
+		t.Fatalf("advanced course should be unlocked: %+v", cwp)
+	}
+	for i, status := range cwp.Modules {
+		if status.Completed != (status.CompletedAt != nil) {
+			t.Fatalf("completed modules should have a completion time: %+v", status)
+		}
+		cwp.Modules[i].CompletedAt = nil
+	}
+	want := []models.ModuleStatus{
+		{ModuleID: "generics", Completed: true, UnmetPrerequisites: []string{}},
+		{ModuleID: "concurrency", Completed: true, UnmetPrerequisites: []string{}},
+		{ModuleID: "testing", UnmetPrerequisites: []string{}},
+	}
+	if !reflect.DeepEqual(cwp.Modules, want) {
+		t.Fatalf("unexpected module states: %+v", cwp.Modules)
+	}
+}
+
+func TestMemoryResetProgress(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	course := newTestCourse("git", "git-1", "git-2")
+	_ = repo.UpsertCourseBySlug(ctx, course)
+	userID := primitive.NewObjectID()
+	for _, moduleID := range []string{"git-1", "git-2"} {
+		if err := repo.MarkModuleComplete(ctx, userID.Hex(), course.ID.Hex(), moduleID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reset := &models.ProgressReset{UserID: userID, Scope: models.ResetModule, CourseID: course.ID, ModuleID: "git-1", ResetBy: userID}
+	if err := repo.ResetProgress(ctx, reset); err != nil {
+		t.Fatal(err)
+	}
+	progress, _ := repo.GetUserProgress(ctx, userID.Hex())
+	if progress[0].IsCompleted || !reflect.DeepEqual(progress[0].CompletedModules, []string{"git-2"}) {
+		t.Fatalf("unexpected progress after module reset: %+v", progress[0])
+	}
+	if reset.ID.IsZero() {
+		t.Fatal("reset should be given an ID")
+	}
+
+	for _, bad := range []*models.ProgressReset{
+		{UserID: userID, Scope: models.ResetCourse},
+		{UserID: userID, Scope: models.ResetModule, CourseID: course.ID},
+		{UserID: userID, Scope: "everything"},
+	} {
+		if err := repo.ResetProgress(ctx, bad); err == nil {
+			t.Errorf("expected error for %+v", bad)
+		}
+	}
+	if err := repo.ResetProgress(ctx, &models.ProgressReset{UserID: userID, Scope: models.ResetModule, CourseID: course.ID, ModuleID: "junk"}); !errors.Is(err, ErrModuleNotFound) {
+		t.Fatalf("unknown module: expected ErrModuleNotFound, got %v", err)
+	}
+
+	if resets, _ := repo.GetProgressResets(ctx, userID.Hex()); len(resets) != 1 {
+		t.Fatalf("only the successful reset should be recorded, got %+v", resets)
+	}
+}
+
+func TestMemoryReconcileCourseProgress(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	course := newTestCourse("git", "git-1", "git-2")
+	_ = repo.UpsertCourseBySlug(ctx, course)
+	finished, started := primitive.NewObjectID(), primitive.NewObjectID()
+	for _, moduleID := range []string{"git-1", "git-2"} {
+		_ = repo.MarkModuleComplete(ctx, finished.Hex(), course.ID.Hex(), moduleID)
+	}
+	_ = repo.MarkModuleComplete(ctx, started.Hex(), course.ID.Hex(), "git-2")
+
+	// Reseeding drops git-2 and adds git-3
+	if err := repo.UpsertCourseBySlug(ctx, newTestCourse("git", "git-1", "git-3")); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := repo.ReconcileCourseProgress(ctx, course.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.ProgressChange{
+		{UserID: finished, CourseID: course.ID, RemovedModules: []string{"git-2"}, WasCompleted: true, IsCompleted: false},
+		{UserID: started, CourseID: course.ID, RemovedModules: []string{"git-2"}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+
+	courses, _ := repo.GetUserProgressWithCourses(ctx, finished.Hex())
+	if cwp := courses[0]; cwp.IsCompleted || cwp.ProgressPercent != 50 || !reflect.DeepEqual(cwp.CompletedModules, []string{"git-1"}) {
+		t.Fatalf("unexpected progress: %+v", cwp)
+	}
+
+	// A second pass has nothing to do
+	if changes, _ := repo.ReconcileCourseProgress(ctx, course.ID.Hex()); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %+v", changes)
+	}
+	if _, err := repo.ReconcileCourseProgress(ctx, primitive.NewObjectID().Hex()); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("unknown course: expected ErrNoDocuments, got %v", err)
+	}
+}
+
+func TestMemoryGetActivity(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	learner, other := primitive.NewObjectID(), primitive.NewObjectID()
+	courseID := primitive.NewObjectID()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := []models.ActivityEvent{
+		{UserID: learner, Type: models.ActivityLogin, OccurredAt: start},
+		{UserID: learner, Type: models.ActivityModuleOpened, CourseID: courseID, ModuleID: "m1", OccurredAt: start.Add(time.Hour)},
+		{UserID: other, Type: models.ActivityLogin, OccurredAt: start.Add(time.Hour)},
+		{UserID: learner, Type: models.ActivityModuleCompleted, CourseID: courseID, ModuleID: "m1", OccurredAt: start.Add(2 * time.Hour)},
+	}
+	for i := range events {
+		if err := repo.RecordActivity(ctx, &events[i]); err != nil {
+			t.Fatalf("RecordActivity: %v", err)
+		}
+		if events[i].ID.IsZero() {
+			t.Fatal("RecordActivity didn't assign an ID")
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		filter models.ActivityFilter
+		want   []string
+	}{
+		"user":   {models.ActivityFilter{UserID: learner}, []string{models.ActivityModuleCompleted, models.ActivityModuleOpened, models.ActivityLogin}},
+		"course": {models.ActivityFilter{UserID: learner, CourseID: courseID}, []string{models.ActivityModuleCompleted, models.ActivityModuleOpened}},
+		"types":  {models.ActivityFilter{UserID: learner, Types: []string{models.ActivityLogin}}, []string{models.ActivityLogin}},
+		"range":  {models.ActivityFilter{UserID: learner, Since: start.Add(time.Hour), Until: start.Add(2 * time.Hour)}, []string{models.ActivityModuleOpened}},
+		"limit":  {models.ActivityFilter{UserID: learner, Limit: 2}, []string{models.ActivityModuleCompleted, models.ActivityModuleOpened}},
+	} {
+		got, err := repo.GetActivity(ctx, tc.filter)
+		if err != nil {
+			t.Fatalf("%s: GetActivity: %v", name, err)
+		}
+		var types []string
+		for _, event := range got {
+			types = append(types, event.Type)
+		}
+		if !reflect.DeepEqual(types, tc.want) {
+			t.Errorf("%s: expected %v, got %v", name, tc.want, types)
+		}
+	}
+}
+
+func TestMemoryAwardBadge(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	userID := primitive.NewObjectID()
+	earnedAt := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	badge := &models.UserBadge{UserID: userID, BadgeID: "streak-7", EarnedAt: earnedAt}
+	if isNew, err := repo.AwardBadge(ctx, badge); err != nil || !isNew || badge.ID.IsZero() {
+		t.Fatalf("first award: new=%v err=%v badge=%+v", isNew, err, badge)
+	}
+	again := &models.UserBadge{UserID: userID, BadgeID: "streak-7", EarnedAt: earnedAt.Add(time.Hour)}
+	if isNew, err := repo.AwardBadge(ctx, again); err != nil || isNew {
+		t.Fatalf("second award: new=%v err=%v", isNew, err)
+	}
+	if _, err := repo.AwardBadge(ctx, &models.UserBadge{UserID: userID, BadgeID: "first-course", EarnedAt: earnedAt.Add(-time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.AwardBadge(ctx, &models.UserBadge{UserID: primitive.NewObjectID(), BadgeID: "streak-7", EarnedAt: earnedAt}); err != nil {
+		t.Fatal(err)
+	}
+
+	badges, err := repo.GetUserBadges(ctx, userID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(badges) != 2 || badges[0].BadgeID != "first-course" || !badges[1].EarnedAt.Equal(earnedAt) {
+		t.Fatalf("unexpected badges: %+v", badges)
+	}
+	if _, err := repo.GetUserBadges(ctx, "not-an-id"); !errors.Is(err, primitive.ErrInvalidHex) {
+		t.Fatalf("expected ErrInvalidHex, got %v", err)
+	}
+}
+
+func TestMemoryIssueCertificate(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	userID, courseID := primitive.NewObjectID(), primitive.NewObjectID()
+
+	cert := &models.Certificate{UserID: userID, CourseID: courseID, LearnerName: "Ada", CourseTitle: "Git"}
+	if isNew, err := repo.IssueCertificate(ctx, cert); err != nil || !isNew || cert.ID.IsZero() {
+		t.Fatalf("first issue: new=%v err=%v cert=%+v", isNew, err, cert)
+	}
+	again := &models.Certificate{UserID: userID, CourseID: courseID, LearnerName: "Renamed", CourseTitle: "Git"}
+	if isNew, err := repo.IssueCertificate(ctx, again); err != nil || isNew || !reflect.DeepEqual(again, cert) {
+		t.Fatalf("second issue should return the original: new=%v err=%v cert=%+v", isNew, err, again)
+	}
+
+	found, err := repo.GetCertificate(ctx, cert.ID.Hex())
+	if err != nil || !reflect.DeepEqual(found, cert) {
+		t.Fatalf("GetCertificate: %+v, %v", found, err)
+	}
+	if _, err := repo.GetCertificate(ctx, primitive.NewObjectID().Hex()); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("expected ErrNoDocuments, got %v", err)
+	}
+	if certs, err := repo.GetUserCertificates(ctx, userID.Hex()); err != nil || len(certs) != 1 {
+		t.Fatalf("GetUserCertificates: %+v, %v", certs, err)
+	}
+}
+
+func TestMemoryCohortsFilterProgressCourses(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	git, http := newTestCourse("git", "git-1"), newTestCourse("http", "http-1")
+	for _, course := range []*models.Course{git, http} {
+		if err := repo.CreateCourse(ctx, course); err != nil {
+			t.Fatal(err)
+		}
+	}
+	member, instructor := primitive.NewObjectID(), primitive.NewObjectID()
+
+	cohort := &models.Cohort{
+		Name:          "Bootcamp",
+		InstructorIDs: []primitive.ObjectID{instructor},
+		MemberIDs:     []primitive.ObjectID{},
+		CourseIDs:     []primitive.ObjectID{http.ID},
+		StartDate:     "2025-01-01",
+		EndDate:       "2025-02-01",
+	}
+	if err := repo.CreateCohort(ctx, cohort); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := repo.AddCohortMembers(ctx, cohort.ID.Hex(), []primitive.ObjectID{member, member})
+	if err != nil || len(updated.MemberIDs) != 1 {
+		t.Fatalf("AddCohortMembers: %+v, %v", updated, err)
+	}
+
+	for name, filter := range map[string]models.CohortFilter{
+		"member":     {MemberID: member},
+		"instructor": {InstructorID: instructor},
+		"all":        {},
+	} {
+		if cohorts, err := repo.GetCohorts(ctx, filter); err != nil || len(cohorts) != 1 {
+			t.Errorf("%s: %+v, %v", name, cohorts, err)
+		}
+	}
+	if cohorts, _ := repo.GetCohorts(ctx, models.CohortFilter{MemberID: instructor}); len(cohorts) != 0 {
+		t.Errorf("instructor isn't a member: %+v", cohorts)
+	}
+
+	progress, err := repo.GetUserProgressWithCourses(ctx, member.Hex())
+	if err != nil || len(progress) != 1 || progress[0].Course.ID != http.ID {
+		t.Fatalf("member should only see http: %+v, %v", progress, err)
+	}
+	if progress, _ := repo.GetUserProgressWithCourses(ctx, primitive.NewObjectID().Hex()); len(progress) != 2 {
+		t.Fatalf("non-members should see every course: %+v", progress)
+	}
+
+	if _, err := repo.RemoveCohortMember(ctx, cohort.ID.Hex(), member); err != nil {
+		t.Fatal(err)
+	}
+	if progress, _ := repo.GetUserProgressWithCourses(ctx, member.Hex()); len(progress) != 2 {
+		t.Fatalf("removed member should see every course: %+v", progress)
+	}
+	if _, err := repo.GetCohort(ctx, primitive.NewObjectID().Hex()); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("expected ErrNoDocuments, got %v", err)
+	}
+}
+
+func TestMemoryDashboardAggregations(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	course := newTestCourse("git", "git-1", "git-2")
+	if err := repo.CreateCourse(ctx, course); err != nil {
+		t.Fatal(err)
+	}
+	finisher, starter, outsider := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	for user, modules := range map[primitive.ObjectID][]string{
+		finisher: {"git-1", "git-2"},
+		starter:  {"git-1"},
+		outsider: {"git-1", "git-2"},
+	} {
+		for _, moduleID := range modules {
+			if err := repo.MarkModuleComplete(ctx, user.Hex(), course.ID.Hex(), moduleID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	scope := models.ProgressScope{UserIDs: []primitive.ObjectID{finisher, starter}}
+
+	counts, err := repo.GetCourseCompletionCounts(ctx, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.CourseCompletionCount{{CourseID: course.ID, Learners: 2, Started: 2, Completed: 1}}
+	if !reflect.DeepEqual(counts, want) {
+		t.Fatalf("expected %+v, got %+v", want, counts)
+	}
+
+	modules, err := repo.GetModuleCompletionCounts(ctx, course.ID, scope)
+	if err != nil || !reflect.DeepEqual(modules, map[string]int{"git-1": 2, "git-2": 1}) {
+		t.Fatalf("unexpected module counts: %v, %v", modules, err)
+	}
+	if progress, err := repo.GetScopedProgress(ctx, scope); err != nil || len(progress) != 2 {
+		t.Fatalf("unexpected scoped progress: %+v, %v", progress, err)
+	}
+	if userIDs, err := repo.GetProgressUserIDs(ctx); err != nil || len(userIDs) != 3 {
+		t.Fatalf("expected each learner once: %v, %v", userIDs, err)
+	}
+
+	now := time.Now().UTC()
+	for _, event := range []models.ActivityEvent{
+		{UserID: finisher, Type: models.ActivityModuleOpened, OccurredAt: now.Add(-time.Hour)},
+		{UserID: finisher, Type: models.ActivityModuleCompleted, OccurredAt: now.Add(-2 * time.Hour)},
+		{UserID: finisher, Type: models.ActivityLogin, OccurredAt: now},
+		{UserID: starter, Type: models.ActivityLogin, OccurredAt: now},
+		{UserID: outsider, Type: models.ActivityModuleOpened, OccurredAt: now},
+	} {
+		event := event
+		if err := repo.RecordActivity(ctx, &event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	activity, err := repo.GetLastActivity(ctx, scope.UserIDs)
+	if err != nil || len(activity) != 1 || activity[0].UserID != finisher || !activity[0].LastActiveAt.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("logins shouldn't count and other users are out of scope: %+v, %v", activity, err)
+	}
+}
+
+// Duplicate progress records, e.g. from a race in initialising progress, count once
+func TestMemoryCourseCompletionCountsDedupeLearners(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	course := newTestCourse("git", "git-1")
+	if err := repo.CreateCourse(ctx, course); err != nil {
+		t.Fatal(err)
+	}
+	learner := primitive.NewObjectID()
+	repo.progress = append(repo.progress,
+		models.Progress{ID: primitive.NewObjectID(), UserID: learner, CourseID: course.ID},
+		models.Progress{ID: primitive.NewObjectID(), UserID: learner, CourseID: course.ID, CompletedModules: []string{"git-1"}, IsCompleted: true},
+	)
+
+	counts, err := repo.GetCourseCompletionCounts(ctx, models.ProgressScope{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.CourseCompletionCount{{CourseID: course.ID, Learners: 1, Started: 1, Completed: 1}}
+	if !reflect.DeepEqual(counts, want) {
+		t.Fatalf("expected %+v, got %+v", want, counts)
+	}
+}
+
+func TestMemoryUsePasswordResetToken(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	userID, otherUserID := primitive.NewObjectID(), primitive.NewObjectID()
+	now := time.Now().UTC()
+
+	for _, token := range []*models.PasswordResetToken{
+		{UserID: userID, TokenHash: "expired", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)},
+		{UserID: userID, TokenHash: "older", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{UserID: userID, TokenHash: "newer", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{UserID: otherUserID, TokenHash: "other", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+	} {
+		if err := repo.CreatePasswordResetToken(ctx, token); err != nil || token.ID.IsZero() {
+			t.Fatalf("create %s: %v", token.TokenHash, err)
+		}
+	}
+
+	if _, err := repo.UsePasswordResetToken(ctx, "expired"); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("expired token: expected ErrNoDocuments, got %v", err)
+	}
+	used, err := repo.UsePasswordResetToken(ctx, "newer")
+	if err != nil || used.UserID != userID || used.UsedAt == nil {
+		t.Fatalf("UsePasswordResetToken: %+v, %v", used, err)
+	}
+
+	// Using a token uses up the user's other tokens, but nobody else's
+	for _, hash := range []string{"newer", "older"} {
+		if _, err := repo.UsePasswordResetToken(ctx, hash); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Fatalf("%s token after use: expected ErrNoDocuments, got %v", hash, err)
+		}
+	}
+	if _, err := repo.UsePasswordResetToken(ctx, "other"); err != nil {
+		t.Fatalf("other user's token: %v", err)
+	}
+}
+
+func TestMemoryLinkUserIdentity(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	userID, otherUserID := primitive.NewObjectID(), primitive.NewObjectID()
+
+	linked, err := repo.LinkUserIdentity(ctx, &models.UserIdentity{UserID: userID, Provider: "github", Subject: "42"})
+	if err != nil || !linked {
+		t.Fatalf("first link: %v, %v", linked, err)
+	}
+	// The same subject at another provider is another account
+	if linked, err := repo.LinkUserIdentity(ctx, &models.UserIdentity{UserID: otherUserID, Provider: "google", Subject: "42"}); err != nil || !linked {
+		t.Fatalf("other provider: %v, %v", linked, err)
+	}
+	// A provider account links to one user only
+	if linked, err := repo.LinkUserIdentity(ctx, &models.UserIdentity{UserID: otherUserID, Provider: "github", Subject: "42"}); err != nil || linked {
+		t.Fatalf("duplicate link: expected false, got %v, %v", linked, err)
+	}
+
+	identity, err := repo.GetUserIdentity(ctx, "github", "42")
+	if err != nil || identity.UserID != userID {
+		t.Fatalf("GetUserIdentity: %+v, %v", identity, err)
+	}
+	if _, err := repo.GetUserIdentity(ctx, "github", "43"); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("unknown subject: expected ErrNoDocuments, got %v", err)
+	}
+}
@@ -2,7 +2,10 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"os"
 	"time"
 
 	"github.com/pathway/backend/models"
@@ -15,28 +18,143 @@ import (
 type Repository interface {
 	Close()
 	// Course methods
-	GetAllCourses() ([]models.Course, error)
-	GetCourseByID(id string) (*models.Course, error)
-	CreateCourse(course *models.Course) error
-	DeleteAllCourses() error
+	GetAllCourses(ctx context.Context) ([]models.Course, error)
+	GetCourseByID(ctx context.Context, id string) (*models.Course, error)
+	GetCourseBySlug(ctx context.Context, slug string) (*models.Course, error)
+	CreateCourse(ctx context.Context, course *models.Course) error
+	UpsertCourseBySlug(ctx context.Context, course *models.Course) error
+	DeleteAllCourses(ctx context.Context) error
+	// Course authoring methods; each returns the updated course
+	UpdateCourse(ctx context.Context, id string, update models.CourseUpdate) (*models.Course, error)
+	DeleteCourse(ctx context.Context, id string) error
+	AddModule(ctx context.Context, courseID string, module models.Module, position int) (*models.Course, error)
+	UpdateModule(ctx context.Context, courseID string, moduleID string, update models.ModuleUpdate) (*models.Course, error)
+	DeleteModule(ctx context.Context, courseID string, moduleID string) (*models.Course, error)
+	ReorderModules(ctx context.Context, courseID string, moduleIDs []string) (*models.Course, error)
+	AddContentBlock(ctx context.Context, courseID string, moduleID string, block models.ContentBlock, position int) (*models.Course, error)
+	UpdateContentBlock(ctx context.Context, courseID string, moduleID string, index int, block models.ContentBlock) (*models.Course, error)
+	DeleteContentBlock(ctx context.Context, courseID string, moduleID string, index int) (*models.Course, error)
+	ReorderContentBlocks(ctx context.Context, courseID string, moduleID string, order []int) (*models.Course, error)
 	// User methods
-	CreateUser(user *models.User) error
-	GetUserByEmail(email string) (*models.User, error)
-	GetUserByID(id string) (*models.User, error)
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+	GetUsersByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.User, error)
+	UpdateUserRole(ctx context.Context, id string, role string) error
+	UpdateUserPassword(ctx context.Context, id string, passwordHash string) error
+	SetUserEmailVerified(ctx context.Context, id string, verifiedAt time.Time) error
 	// Progress methods
-	GetUserProgress(userID string) ([]models.Progress, error)
-	InitializeUserProgress(userID string) error
-	GetUserProgressWithCourses(userID string) ([]models.CourseWithProgress, error)
-	MarkModuleComplete(userID string, courseID string, moduleID string) error
+	GetUserProgress(ctx context.Context, userID string) ([]models.Progress, error)
+	InitializeUserProgress(ctx context.Context, userID string) error
+	GetUserProgressWithCourses(ctx context.Context, userID string) ([]models.CourseWithProgress, error)
+	MarkModuleComplete(ctx context.Context, userID string, courseID string, moduleID string) error
+	ResetProgress(ctx context.Context, reset *models.ProgressReset) error
+	ReconcileCourseProgress(ctx context.Context, courseID string) ([]models.ProgressChange, error)
+	GetProgressResets(ctx context.Context, userID string) ([]models.ProgressReset, error)
+	// Dashboard methods; aggregate progress and activity for instructors
+	GetCourseCompletionCounts(ctx context.Context, scope models.ProgressScope) ([]models.CourseCompletionCount, error)
+	GetModuleCompletionCounts(ctx context.Context, courseID primitive.ObjectID, scope models.ProgressScope) (map[string]int, error)
+	GetScopedProgress(ctx context.Context, scope models.ProgressScope) ([]models.Progress, error)
+	GetProgressUserIDs(ctx context.Context) ([]primitive.ObjectID, error)
+	GetLastActivity(ctx context.Context, userIDs []primitive.ObjectID) ([]models.LearnerLastActivity, error)
+	// Exercise methods
+	CreateExerciseAttempt(ctx context.Context, attempt *models.ExerciseAttempt) error
+	CountExerciseAttempts(ctx context.Context, userID string, courseID string, moduleID string, exerciseID string) (int, error)
+	RecordExerciseReveal(ctx context.Context, reveal *models.ExerciseReveal) error
+	GetExerciseReveals(ctx context.Context, userID string, courseID string, moduleID string, exerciseID string) ([]models.ExerciseReveal, error)
+	CreateCodeRun(ctx context.Context, run *models.CodeRun) error
+	GetCodeRuns(ctx context.Context, userID string, courseID string, moduleID string, exerciseID string) ([]models.CodeRun, error)
+	// Quiz methods
+	CreateQuizAttempt(ctx context.Context, attempt *models.QuizAttempt) error
+	GetQuizAttempts(ctx context.Context, userID string, courseID string, moduleID string, quizID string) ([]models.QuizAttempt, error)
+	HasPassedQuiz(ctx context.Context, userID string, courseID string, moduleID string, quizID string) (bool, error)
+	// Activity methods; events are append-only
+	RecordActivity(ctx context.Context, event *models.ActivityEvent) error
+	GetActivity(ctx context.Context, filter models.ActivityFilter) ([]models.ActivityEvent, error)
+	// Achievement methods; a badge is awarded to a user at most once
+	AwardBadge(ctx context.Context, badge *models.UserBadge) (bool, error)
+	GetUserBadges(ctx context.Context, userID string) ([]models.UserBadge, error)
+	// Certificate methods; a user gets at most one certificate per course
+	IssueCertificate(ctx context.Context, cert *models.Certificate) (bool, error)
+	GetCertificate(ctx context.Context, id string) (*models.Certificate, error)
+	GetUserCertificates(ctx context.Context, userID string) ([]models.Certificate, error)
+	// Cohort methods
+	CreateCohort(ctx context.Context, cohort *models.Cohort) error
+	GetCohort(ctx context.Context, id string) (*models.Cohort, error)
+	GetCohorts(ctx context.Context, filter models.CohortFilter) ([]models.Cohort, error)
+	AddCohortMembers(ctx context.Context, id string, userIDs []primitive.ObjectID) (*models.Cohort, error)
+	RemoveCohortMember(ctx context.Context, id string, userID primitive.ObjectID) (*models.Cohort, error)
+	// Token methods
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, tokenHash string) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeUserRefreshTokens(ctx context.Context, userID string) error
+	RevokeAccessToken(ctx context.Context, token *models.RevokedAccessToken) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	CreatePasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error
+	UsePasswordResetToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	CreateEmailVerificationToken(ctx context.Context, token *models.EmailVerificationToken) error
+	UseEmailVerificationToken(ctx context.Context, tokenHash string) (*models.EmailVerificationToken, error)
+	// Identity methods; each provider account links to at most one user
+	LinkUserIdentity(ctx context.Context, identity *models.UserIdentity) (bool, error)
+	GetUserIdentity(ctx context.Context, provider string, subject string) (*models.UserIdentity, error)
+}
+
+// Timeouts bounds how long MongoDB operations may run. They are applied on top of
+// the caller's context, so a request that is cancelled or has an earlier deadline
+// still stops the operation sooner.
+type Timeouts struct {
+	Connect   time.Duration // Connecting, pinging and disconnecting
+	Operation time.Duration // Single reads and writes
+	Bulk      time.Duration // Multi-document writes, e.g. InitializeUserProgress
+}
+
+// DefaultTimeouts returns the timeouts used when none are configured
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Connect:   10 * time.Second,
+		Operation: 5 * time.Second,
+		Bulk:      10 * time.Second,
+	}
+}
+
+// TimeoutsFromEnv reads DB_CONNECT_TIMEOUT, DB_OPERATION_TIMEOUT and DB_BULK_TIMEOUT
+// (Go durations such as "5s"), falling back to DefaultTimeouts for unset values
+func TimeoutsFromEnv() (Timeouts, error) {
+	timeouts := DefaultTimeouts()
+	for name, target := range map[string]*time.Duration{
+		"DB_CONNECT_TIMEOUT":   &timeouts.Connect,
+		"DB_OPERATION_TIMEOUT": &timeouts.Operation,
+		"DB_BULK_TIMEOUT":      &timeouts.Bulk,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return Timeouts{}, fmt.Errorf("invalid %s %q: must be a positive duration", name, value)
+		}
+		*target = d
+	}
+	return timeouts, nil
 }
 
 type MongoRepository struct {
-	client *mongo.Client
-	db     *mongo.Database
+	client   *mongo.Client
+	db       *mongo.Database
+	timeouts Timeouts
 }
 
+// NewMongoRepository connects to MongoDB using DefaultTimeouts
 func NewMongoRepository(uri string, dbName string) (*MongoRepository, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	return NewMongoRepositoryWithTimeouts(uri, dbName, DefaultTimeouts())
+}
+
+// NewMongoRepositoryWithTimeouts connects to MongoDB using the given timeouts
+func NewMongoRepositoryWithTimeouts(uri string, dbName string, timeouts Timeouts) (*MongoRepository, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.Connect)
 	defer cancel()
 
 	clientOptions := options.Client().ApplyURI(uri)
@@ -51,14 +169,104 @@ func NewMongoRepository(uri string, dbName string) (*MongoRepository, error) {
 	}
 
 	log.Println("Connected to MongoDB!")
-	return &MongoRepository{
-		client: client,
-		db:     client.Database(dbName),
-	}, nil
+	repo := &MongoRepository{
+		client:   client,
+		db:       client.Database(dbName),
+		timeouts: timeouts,
+	}
+
+	if err := repo.ensureIndexes(ctx); err != nil {
+		// Not fatal: the server works without indexes, just without the guarantees
+		log.Printf("Warning: failed to create indexes: %v", err)
+	}
+
+	return repo, nil
+}
+
+// ensureIndexes creates the indexes the repository relies on for uniqueness and expiry
+func (r *MongoRepository) ensureIndexes(ctx context.Context) error {
+	indexes := map[string][]mongo.IndexModel{
+		// Unique course slugs; legacy courses without a slug are excluded
+		"courses": {{
+			Keys: bson.D{{Key: "slug", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"slug": bson.M{"$gt": ""}}),
+		}},
+		// Token documents are removed by MongoDB once they expire
+		"refresh_tokens": {
+			{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "family_id", Value: 1}}},
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"password_reset_tokens": {
+			{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"email_verification_tokens": {
+			{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"user_identities": {
+			{Keys: bson.D{{Key: "provider", Value: 1}, {Key: "subject", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		},
+		"revoked_tokens": {
+			{Keys: bson.D{{Key: "jti", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"exercise_attempts": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "course_id", Value: 1}, {Key: "module_id", Value: 1}, {Key: "exercise_id", Value: 1}}},
+		},
+		// One record per revealed hint or solution, keeping the first reveal time
+		"exercise_reveals": {{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1}, {Key: "course_id", Value: 1}, {Key: "module_id", Value: 1},
+				{Key: "exercise_id", Value: 1}, {Key: "kind", Value: 1}, {Key: "hint_index", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		}},
+		"code_runs": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "course_id", Value: 1}, {Key: "module_id", Value: 1}, {Key: "exercise_id", Value: 1}, {Key: "submitted_at", Value: 1}}},
+		},
+		"quiz_attempts": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "course_id", Value: 1}, {Key: "module_id", Value: 1}, {Key: "quiz_id", Value: 1}, {Key: "submitted_at", Value: 1}}},
+		},
+		"activity_events": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "occurred_at", Value: -1}}},
+			{Keys: bson.D{{Key: "course_id", Value: 1}, {Key: "occurred_at", Value: -1}}},
+			{Keys: bson.D{{Key: "occurred_at", Value: -1}}},
+		},
+		"user_badges": {{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "badge_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}},
+		"certificates": {{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "course_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}},
+		"cohorts": {
+			{Keys: bson.D{{Key: "member_ids", Value: 1}}},
+			{Keys: bson.D{{Key: "instructor_ids", Value: 1}}},
+		},
+		"progress_resets": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "reset_at", Value: 1}}},
+		},
+	}
+
+	for collection, specs := range indexes {
+		if _, err := r.db.Collection(collection).Indexes().CreateMany(ctx, specs); err != nil {
+			return fmt.Errorf("%s: %w", collection, err)
+		}
+	}
+	return nil
 }
 
 func (r *MongoRepository) Close() {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeouts.Connect)
 	defer cancel()
 	if err := r.client.Disconnect(ctx); err != nil {
 		log.Printf("Error disconnecting from MongoDB: %v", err)
@@ -73,8 +281,8 @@ func (r *MongoRepository) GetDB() *mongo.Database {
 // ==================== Course Methods ====================
 
 // GetAllCourses retrieves all courses from the database
-func (r *MongoRepository) GetAllCourses() ([]models.Course, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *MongoRepository) GetAllCourses(ctx context.Context) ([]models.Course, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
 	defer cancel()
 
 	cursor, err := r.db.Collection("courses").Find(ctx, bson.M{})
@@ -92,8 +300,8 @@ func (r *MongoRepository) GetAllCourses() ([]models.Course, error) {
 }
 
 // GetCourseByID retrieves a specific course by ID
-func (r *MongoRepository) GetCourseByID(id string) (*models.Course, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *MongoRepository) GetCourseByID(ctx context.Context, id string) (*models.Course, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
 	defer cancel()
 
 	objectID, err := primitive.ObjectIDFromHex(id)
@@ -110,29 +318,218 @@ func (r *MongoRepository) GetCourseByID(id string) (*models.Course, error) {
 	return &course, nil
 }
 
-// CreateCourse inserts a new course into the database
-func (r *MongoRepository) CreateCourse(course *models.Course) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// GetCourseBySlug retrieves a specific course by its stable slug
+func (r *MongoRepository) GetCourseBySlug(ctx context.Context, slug string) (*models.Course, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	var course models.Course
+	err := r.db.Collection("courses").FindOne(ctx, bson.M{"slug": slug}).Decode(&course)
+	if err != nil {
+		return nil, err
+	}
+
+	return &course, nil
+}
+
+// CreateCourse inserts a new course into the database and sets its ID
+func (r *MongoRepository) CreateCourse(ctx context.Context, course *models.Course) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
 	defer cancel()
 
-	_, err := r.db.Collection("courses").InsertOne(ctx, course)
+	catalog, err := r.GetAllCourses(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkCatalog(catalog, course); err != nil {
+		return err
+	}
+	if course.ID.IsZero() {
+		course.ID = primitive.NewObjectID()
+	}
+
+	_, err = r.db.Collection("courses").InsertOne(ctx, course)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateSlug
+	}
 	return err
 }
 
+// UpsertCourseBySlug inserts a course or replaces the existing course with the same slug.
+// The existing _id is preserved so progress documents keyed by course_id stay attached.
+// Courses stored before slugs existed are adopted by matching on title.
+func (r *MongoRepository) UpsertCourseBySlug(ctx context.Context, course *models.Course) error {
+	if course.Slug == "" {
+		return errors.New("course slug is required")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	collection := r.db.Collection("courses")
+
+	var existing models.Course
+	err := collection.FindOne(ctx, bson.M{"slug": course.Slug}).Decode(&existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Legacy course without a slug ($in null also matches a missing field)
+		err = collection.FindOne(ctx, bson.M{
+			"title": course.Title,
+			"slug":  bson.M{"$in": bson.A{nil, ""}},
+		}).Decode(&existing)
+	}
+
+	switch {
+	case err == nil:
+		course.ID = existing.ID
+		course.Version = existing.Version + 1
+		_, err = collection.ReplaceOne(ctx, bson.M{"_id": existing.ID}, course)
+		return err
+	case errors.Is(err, mongo.ErrNoDocuments):
+		course.ID = primitive.NewObjectID()
+		_, err = collection.InsertOne(ctx, course)
+		return err
+	default:
+		return err
+	}
+}
+
 // DeleteAllCourses removes all courses from the database (useful for seeding)
-func (r *MongoRepository) DeleteAllCourses() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *MongoRepository) DeleteAllCourses(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
 	defer cancel()
 
 	_, err := r.db.Collection("courses").DeleteMany(ctx, bson.M{})
 	return err
 }
 
+// ==================== Course Authoring Methods ====================
+
+// UpdateCourse applies a partial update to a course's own fields
+func (r *MongoRepository) UpdateCourse(ctx context.Context, id string, update models.CourseUpdate) (*models.Course, error) {
+	return r.editCourse(ctx, id, editCourseFields(update))
+}
+
+// DeleteCourse removes a course. Progress records for it are left in place.
+func (r *MongoRepository) DeleteCourse(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Collection("courses").DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// AddModule inserts a module at position; a negative position appends
+func (r *MongoRepository) AddModule(ctx context.Context, courseID string, module models.Module, position int) (*models.Course, error) {
+	return r.editCourse(ctx, courseID, editAddModule(module, position))
+}
+
+// UpdateModule applies a partial update to one module
+func (r *MongoRepository) UpdateModule(ctx context.Context, courseID string, moduleID string, update models.ModuleUpdate) (*models.Course, error) {
+	return r.editCourse(ctx, courseID, editUpdateModule(moduleID, update))
+}
+
+// DeleteModule removes a module from a course
+func (r *MongoRepository) DeleteModule(ctx context.Context, courseID string, moduleID string) (*models.Course, error) {
+	return r.editCourse(ctx, courseID, editDeleteModule(moduleID))
+}
+
+// ReorderModules puts a course's modules in the given order
+func (r *MongoRepository) ReorderModules(ctx context.Context, courseID string, moduleIDs []string) (*models.Course, error) {
+	return r.editCourse(ctx, courseID, editReorderModules(moduleIDs))
+}
+
+// AddContentBlock inserts a block into a module at position; a negative position appends
+func (r *MongoRepository) AddContentBlock(ctx context.Context, courseID string, moduleID string, block models.ContentBlock, position int) (*models.Course, error) {
+	return r.editCourse(ctx, courseID, editAddContentBlock(moduleID, block, position))
+}
+
+// UpdateContentBlock replaces the block at index in a module
+func (r *MongoRepository) UpdateContentBlock(ctx context.Context, courseID string, moduleID string, index int, block models.ContentBlock) (*models.Course, error) {
+	return r.editCourse(ctx, courseID, editUpdateContentBlock(moduleID, index, block))
+}
+
+// DeleteContentBlock removes the block at index from a module
+func (r *MongoRepository) DeleteContentBlock(ctx context.Context, courseID string, moduleID string, index int) (*models.Course, error) {
+	return r.editCourse(ctx, courseID, editDeleteContentBlock(moduleID, index))
+}
+
+// ReorderContentBlocks rearranges a module's blocks; order lists current indexes in their new order
+func (r *MongoRepository) ReorderContentBlocks(ctx context.Context, courseID string, moduleID string, order []int) (*models.Course, error) {
+	return r.editCourse(ctx, courseID, editReorderContentBlocks(moduleID, order))
+}
+
+// editCourse loads a course, applies the edit and writes back only the changed fields.
+// The write is conditional on the version that was read, so a concurrent edit
+// results in ErrConflict instead of being silently overwritten.
+func (r *MongoRepository) editCourse(ctx context.Context, courseID string, edit courseEdit) (*models.Course, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	course, err := r.GetCourseByID(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := cloneCourse(*course)
+	set, err := edit(course)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEdit(&before, course); err != nil {
+		return nil, err
+	}
+	if linksChanged(&before, course) {
+		catalog, err := r.GetAllCourses(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkCatalog(catalog, course); err != nil {
+			return nil, err
+		}
+	}
+
+	filter := bson.M{"_id": course.ID, "version": course.Version}
+	if course.Version == 0 {
+		// Courses stored before versioning have no version field
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	update := bson.M{"$inc": bson.M{"version": 1}}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+
+	result, err := r.db.Collection("courses").UpdateOne(ctx, filter, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrDuplicateSlug
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrConflict
+	}
+
+	course.Version++
+	return course, nil
+}
+
 // ==================== User Methods ====================
 
 // CreateUser inserts a new user into the database
-func (r *MongoRepository) CreateUser(user *models.User) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *MongoRepository) CreateUser(ctx context.Context, user *models.User) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
 	defer cancel()
 
 	result, err := r.db.Collection("users").InsertOne(ctx, user)
@@ -146,8 +543,8 @@ func (r *MongoRepository) CreateUser(user *models.User) error {
 }
 
 // GetUserByEmail finds a user by their email address
-func (r *MongoRepository) GetUserByEmail(email string) (*models.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *MongoRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
 	defer cancel()
 
 	var user models.User
@@ -160,8 +557,8 @@ func (r *MongoRepository) GetUserByEmail(email string) (*models.User, error) {
 }
 
 // GetUserByID finds a user by their ID
-func (r *MongoRepository) GetUserByID(id string) (*models.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *MongoRepository) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
 	defer cancel()
 
 	objectID, err := primitive.ObjectIDFromHex(id)
@@ -178,11 +575,103 @@ func (r *MongoRepository) GetUserByID(id string) (*models.User, error) {
 	return &user, nil
 }
 
+// GetUsersByIDs finds the users with the given IDs in one query. IDs with no
+// user are skipped, and the order is unspecified.
+func (r *MongoRepository) GetUsersByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	users := []models.User{}
+	if len(ids) == 0 {
+		return users, nil
+	}
+	cursor, err := r.db.Collection("users").Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// UpdateUserRole changes a user's role
+func (r *MongoRepository) UpdateUserRole(ctx context.Context, id string, role string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Collection("users").UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$set": bson.M{"role": role},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// UpdateUserPassword replaces a user's password hash
+func (r *MongoRepository) UpdateUserPassword(ctx context.Context, id string, passwordHash string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Collection("users").UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$set": bson.M{"password": passwordHash},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// SetUserEmailVerified records that a user confirmed their email address
+func (r *MongoRepository) SetUserEmailVerified(ctx context.Context, id string, verifiedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Collection("users").UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$set": bson.M{"email_verified": true, "email_verified_at": verifiedAt},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
 // ==================== Progress Methods ====================
 
 // GetUserProgress retrieves all progress records for a user
-func (r *MongoRepository) GetUserProgress(userID string) ([]models.Progress, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *MongoRepository) GetUserProgress(ctx context.Context, userID string) ([]models.Progress, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
 	defer cancel()
 
 	objectID, err := primitive.ObjectIDFromHex(userID)
@@ -205,8 +694,8 @@ func (r *MongoRepository) GetUserProgress(userID string) ([]models.Progress, err
 }
 
 // InitializeUserProgress creates progress entries for all courses for a new user
-func (r *MongoRepository) InitializeUserProgress(userID string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoRepository) InitializeUserProgress(ctx context.Context, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Bulk)
 	defer cancel()
 
 	userObjectID, err := primitive.ObjectIDFromHex(userID)
@@ -215,7 +704,7 @@ func (r *MongoRepository) InitializeUserProgress(userID string) error {
 	}
 
 	// Get all courses
-	courses, err := r.GetAllCourses()
+	courses, err := r.GetAllCourses(ctx)
 	if err != nil {
 		return err
 	}
@@ -243,8 +732,8 @@ func (r *MongoRepository) InitializeUserProgress(userID string) error {
 }
 
 // GetUserProgressWithCourses retrieves courses with user's progress data
-func (r *MongoRepository) GetUserProgressWithCourses(userID string) ([]models.CourseWithProgress, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *MongoRepository) GetUserProgressWithCourses(ctx context.Context, userID string) ([]models.CourseWithProgress, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
 	defer cancel()
 
 	userObjectID, err := primitive.ObjectIDFromHex(userID)
@@ -253,7 +742,7 @@ func (r *MongoRepository) GetUserProgressWithCourses(userID string) ([]models.Co
 	}
 
 	// Get all courses
-	courses, err := r.GetAllCourses()
+	courses, err := r.GetAllCourses(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -270,12 +759,52 @@ func (r *MongoRepository) GetUserProgressWithCourses(userID string) ([]models.Co
 		return nil, err
 	}
 
+	cohorts, err := r.GetCohorts(ctx, models.CohortFilter{MemberID: userObjectID})
+	if err != nil {
+		return nil, err
+	}
+
+	return assignedCoursesOnly(combineCoursesWithProgress(courses, progressList), cohorts), nil
+}
+
+// assignedCoursesOnly keeps the courses assigned to the learner's cohorts. A
+// learner in no cohort is self-serve and keeps every course. Locks are worked
+// out beforehand, so a prerequisite needn't be assigned to count. The result is
+// empty, not nil, when none of the learner's cohorts assign a course.
+func assignedCoursesOnly(courses []models.CourseWithProgress, cohorts []models.Cohort) []models.CourseWithProgress {
+	assigned := models.AssignedCourseIDs(cohorts)
+	if assigned == nil {
+		return courses
+	}
+
+	kept := []models.CourseWithProgress{}
+	for _, course := range courses {
+		if assigned[course.Course.ID] {
+			kept = append(kept, course)
+		}
+	}
+	return kept
+}
+
+// combineCoursesWithProgress pairs each course with the user's progress record for it
+// and works out which courses and modules the user has unlocked
+func combineCoursesWithProgress(courses []models.Course, progressList []models.Progress) []models.CourseWithProgress {
 	// Create a map for quick lookup
 	progressMap := make(map[string]models.Progress)
 	for _, p := range progressList {
 		progressMap[p.CourseID.Hex()] = p
 	}
 
+	slugs := make(map[string]bool)
+	var completedCourses []string
+	for _, course := range courses {
+		slugs[course.Slug] = true
+		if progressMap[course.ID.Hex()].IsCompleted {
+			completedCourses = append(completedCourses, course.Slug)
+		}
+	}
+	exists := func(slug string) bool { return slugs[slug] }
+
 	// Combine courses with progress
 	var result []models.CourseWithProgress
 	for _, course := range courses {
@@ -285,27 +814,118 @@ func (r *MongoRepository) GetUserProgressWithCourses(userID string) ([]models.Co
 			IsCompleted:      false,
 		}
 
-		if progress, exists := progressMap[course.ID.Hex()]; exists {
+		progress, hasProgress := progressMap[course.ID.Hex()]
+		if hasProgress {
 			cwp.CompletedModules = progress.CompletedModules
 			cwp.IsCompleted = progress.IsCompleted
+			cwp.CompletedAt = progress.CompletedAt
 		}
 
-		// Calculate progress percentage
+		// Calculate progress percentage, counting only modules the course still has
 		totalModules := len(course.Modules)
-		completedCount := len(cwp.CompletedModules)
+		completedCount := course.CompletedModuleCount(cwp.CompletedModules)
 		if totalModules > 0 {
 			cwp.ProgressPercent = float64(completedCount) / float64(totalModules) * 100
 		}
 
+		cwp.UnmetPrerequisites = course.UnmetCoursePrerequisites(completedCourses, exists)
+		cwp.Locked = len(cwp.UnmetPrerequisites) > 0
+		cwp.Modules = make([]models.ModuleStatus, 0, len(course.Modules))
+		for _, module := range course.Modules {
+			unmet := course.UnmetModulePrerequisites(module.ID, cwp.CompletedModules)
+			status := models.ModuleStatus{
+				ModuleID:           module.ID,
+				Completed:          containsString(cwp.CompletedModules, module.ID),
+				Locked:             cwp.Locked || len(unmet) > 0,
+				UnmetPrerequisites: unmet,
+			}
+			if completedAt, ok := progress.ModuleCompletedAt[module.ID]; ok && status.Completed {
+				status.CompletedAt = &completedAt
+			}
+			cwp.Modules = append(cwp.Modules, status)
+		}
+
 		result = append(result, cwp)
 	}
 
-	return result, nil
+	return result
+}
+
+// prerequisitesError returns a *PrerequisitesError if a learner who has
+// completed completedModules of the course, and the courses with the slugs in
+// completedCourses, can't complete the module yet
+func prerequisitesError(course *models.Course, moduleID string, completedModules []string, completedCourses []string, courseExists func(slug string) bool) error {
+	unmet := &PrerequisitesError{
+		Courses: course.UnmetCoursePrerequisites(completedCourses, courseExists),
+		Modules: course.UnmetModulePrerequisites(moduleID, completedModules),
+	}
+	if len(unmet.Courses) > 0 || len(unmet.Modules) > 0 {
+		return unmet
+	}
+	return nil
+}
+
+// checkModulePrerequisites returns a *PrerequisitesError if the user can't
+// complete the module yet. Completing a module again is always allowed.
+func (r *MongoRepository) checkModulePrerequisites(ctx context.Context, userID primitive.ObjectID, course *models.Course, moduleID string) error {
+	var progress models.Progress
+	err := r.db.Collection("progress").FindOne(ctx, bson.M{"user_id": userID, "course_id": course.ID}).Decode(&progress)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	if containsString(progress.CompletedModules, moduleID) {
+		return nil
+	}
+
+	// Find which prerequisite courses exist and which of those the user has completed
+	existing := make(map[string]bool)
+	var completedCourses []string
+	if len(course.Prerequisites) > 0 {
+		cursor, err := r.db.Collection("courses").Find(ctx,
+			bson.M{"slug": bson.M{"$in": course.Prerequisites}},
+			options.Find().SetProjection(bson.M{"slug": 1}),
+		)
+		if err != nil {
+			return err
+		}
+		var prerequisites []models.Course
+		if err := cursor.All(ctx, &prerequisites); err != nil {
+			return err
+		}
+
+		slugByID := make(map[primitive.ObjectID]string)
+		courseIDs := make([]primitive.ObjectID, 0, len(prerequisites))
+		for _, prerequisite := range prerequisites {
+			existing[prerequisite.Slug] = true
+			slugByID[prerequisite.ID] = prerequisite.Slug
+			courseIDs = append(courseIDs, prerequisite.ID)
+		}
+
+		cursor, err = r.db.Collection("progress").Find(ctx, bson.M{
+			"user_id":      userID,
+			"course_id":    bson.M{"$in": courseIDs},
+			"is_completed": true,
+		})
+		if err != nil {
+			return err
+		}
+		var completed []models.Progress
+		if err := cursor.All(ctx, &completed); err != nil {
+			return err
+		}
+		for _, p := range completed {
+			completedCourses = append(completedCourses, slugByID[p.CourseID])
+		}
+	}
+
+	return prerequisitesError(course, moduleID, progress.CompletedModules, completedCourses, func(slug string) bool { return existing[slug] })
 }
 
-// MarkModuleComplete marks a specific module as complete for a user
-func (r *MongoRepository) MarkModuleComplete(userID string, courseID string, moduleID string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// MarkModuleComplete marks a specific module as complete for a user. It returns
+// mongo.ErrNoDocuments if the course doesn't exist and ErrModuleNotFound if it
+// has no such module. The course is complete once every one of its modules is.
+func (r *MongoRepository) MarkModuleComplete(ctx context.Context, userID string, courseID string, moduleID string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
 	defer cancel()
 
 	userObjectID, err := primitive.ObjectIDFromHex(userID)
@@ -313,16 +933,21 @@ func (r *MongoRepository) MarkModuleComplete(userID string, courseID string, mod
 		return err
 	}
 
-	courseObjectID, err := primitive.ObjectIDFromHex(courseID)
+	course, err := r.GetCourseByID(ctx, courseID)
 	if err != nil {
 		return err
 	}
+	if _, ok := course.Module(moduleID); !ok {
+		return ErrModuleNotFound
+	}
+	if err := r.checkModulePrerequisites(ctx, userObjectID, course, moduleID); err != nil {
+		return err
+	}
 
-	// First, check if the module is already in completed_modules
 	// Use $addToSet to avoid duplicates
 	filter := bson.M{
 		"user_id":   userObjectID,
-		"course_id": courseObjectID,
+		"course_id": course.ID,
 	}
 
 	update := bson.M{
@@ -331,6 +956,7 @@ func (r *MongoRepository) MarkModuleComplete(userID string, courseID string, mod
 		},
 	}
 
+	now := time.Now().UTC()
 	result, err := r.db.Collection("progress").UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
@@ -339,40 +965,1080 @@ func (r *MongoRepository) MarkModuleComplete(userID string, courseID string, mod
 	// If no document was updated, create one
 	if result.MatchedCount == 0 {
 		progress := models.Progress{
-			UserID:           userObjectID,
-			CourseID:         courseObjectID,
-			CompletedModules: []string{moduleID},
-			IsCompleted:      false,
+			UserID:            userObjectID,
+			CourseID:          course.ID,
+			CompletedModules:  []string{moduleID},
+			IsCompleted:       false,
+			ModuleCompletedAt: map[string]time.Time{moduleID: now},
 		}
 		_, err = r.db.Collection("progress").InsertOne(ctx, progress)
 		if err != nil {
 			return err
 		}
-	}
-
-	// Now check if all modules in the course are complete
-	course, err := r.GetCourseByID(courseID)
-	if err != nil {
-		return nil // Don't fail the whole operation just for this check
+	} else if result.ModifiedCount > 0 {
+		// Only a first completion is timestamped
+		_, err = r.db.Collection("progress").UpdateOne(ctx, filter, bson.M{
+			"$set": bson.M{"module_completed_at." + moduleID: now},
+		})
+		if err != nil {
+			return err
+		}
 	}
 
 	// Get updated progress
 	var progress models.Progress
-	err = r.db.Collection("progress").FindOne(ctx, filter).Decode(&progress)
-	if err != nil {
-		return nil
+	if err := r.db.Collection("progress").FindOne(ctx, filter).Decode(&progress); err != nil {
+		return err
 	}
 
-	// Check if all modules are complete
-	if len(progress.CompletedModules) >= len(course.Modules) {
-		// Mark course as completed
-		_, err = r.db.Collection("progress").UpdateOne(ctx, filter, bson.M{
-			"$set": bson.M{"is_completed": true},
-		})
+	// Mark the course as completed once every module is, ignoring stale IDs
+	if !progress.IsCompleted && course.IsCompletedBy(progress.CompletedModules) {
+		_, err = r.db.Collection("progress").UpdateOne(ctx,
+			bson.M{"_id": progress.ID, "is_completed": bson.M{"$ne": true}},
+			bson.M{"$set": bson.M{"is_completed": true, "completed_at": now}},
+		)
+		return err
+	}
+
+	return nil
+}
+
+// ResetProgress undoes some of a learner's progress, depending on reset.Scope,
+// and records the reset. ResetModule un-completes reset.ModuleID, which also
+// un-completes the course; ResetCourse clears the course; ResetAll clears
+// every course. The course and module must exist, as for MarkModuleComplete.
+func (r *MongoRepository) ResetProgress(ctx context.Context, reset *models.ProgressReset) error {
+	timeout := r.timeouts.Operation
+	if reset.Scope == models.ResetAll {
+		timeout = r.timeouts.Bulk
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := validateReset(reset); err != nil {
+		return err
+	}
+	if reset.Scope != models.ResetAll {
+		course, err := r.GetCourseByID(ctx, reset.CourseID.Hex())
 		if err != nil {
-			return nil
+			return err
+		}
+		if _, ok := course.Module(reset.ModuleID); reset.Scope == models.ResetModule && !ok {
+			return ErrModuleNotFound
+		}
+	}
+
+	progress := r.db.Collection("progress")
+	filter := bson.M{"user_id": reset.UserID, "course_id": reset.CourseID}
+	var err error
+	switch reset.Scope {
+	case models.ResetModule:
+		_, err = progress.UpdateOne(ctx, filter, bson.M{
+			"$pull":  bson.M{"completed_modules": reset.ModuleID},
+			"$set":   bson.M{"is_completed": false},
+			"$unset": bson.M{"module_completed_at." + reset.ModuleID: "", "completed_at": ""},
+		})
+	case models.ResetCourse:
+		_, err = progress.UpdateOne(ctx, filter, clearProgress)
+	case models.ResetAll:
+		_, err = progress.UpdateMany(ctx, bson.M{"user_id": reset.UserID}, clearProgress)
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Collection("progress_resets").InsertOne(ctx, reset)
+	if err != nil {
+		return err
+	}
+
+	reset.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetProgressResets returns the resets of a learner's progress, oldest first
+func (r *MongoRepository) GetProgressResets(ctx context.Context, userID string) ([]models.ProgressReset, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.db.Collection("progress_resets").Find(ctx, bson.M{"user_id": userObjectID},
+		options.Find().SetSort(bson.D{{Key: "reset_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var resets []models.ProgressReset
+	if err = cursor.All(ctx, &resets); err != nil {
+		return nil, err
+	}
+
+	return resets, nil
+}
+
+// reconcileRetries bounds how often ReconcileCourseProgress re-reads a
+// progress record that a learner changed while it was being reconciled
+const reconcileRetries = 3
+
+// ReconcileCourseProgress brings every learner's progress in a course in line
+// with its current modules (see models.Course.ReconcileProgress) and returns
+// the records that changed. Run it after modules are added or removed.
+func (r *MongoRepository) ReconcileCourseProgress(ctx context.Context, courseID string) ([]models.ProgressChange, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Bulk)
+	defer cancel()
+
+	course, err := r.GetCourseByID(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := r.db.Collection("progress")
+	cursor, err := collection.Find(ctx, bson.M{"course_id": course.ID})
+	if err != nil {
+		return nil, err
+	}
+	var progressList []models.Progress
+	if err := cursor.All(ctx, &progressList); err != nil {
+		return nil, err
+	}
+
+	changes := []models.ProgressChange{}
+	for _, progress := range progressList {
+		for attempt := 0; ; attempt++ {
+			completed := progress.CompletedModules
+			change, changed := course.ReconcileProgress(&progress, time.Now().UTC())
+			if !changed {
+				break
+			}
+
+			// Only write over the modules that were read, so a completion
+			// made in the meantime isn't lost
+			result, err := collection.UpdateOne(ctx,
+				bson.M{"_id": progress.ID, "completed_modules": completed},
+				reconciledUpdate(&progress),
+			)
+			if err != nil {
+				return nil, err
+			}
+			if result.MatchedCount > 0 {
+				changes = append(changes, change)
+				break
+			}
+			if attempt == reconcileRetries {
+				return nil, ErrConflict
+			}
+			err = collection.FindOne(ctx, bson.M{"_id": progress.ID}).Decode(&progress)
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// clearProgress empties a progress record
+var clearProgress = bson.M{
+	"$set":   bson.M{"completed_modules": []string{}, "is_completed": false},
+	"$unset": bson.M{"module_completed_at": "", "completed_at": ""},
+}
+
+// reconciledUpdate writes back a progress record's completion fields
+func reconciledUpdate(progress *models.Progress) bson.M {
+	set := bson.M{"completed_modules": progress.CompletedModules, "is_completed": progress.IsCompleted}
+	unset := bson.M{}
+	if len(progress.ModuleCompletedAt) > 0 {
+		set["module_completed_at"] = progress.ModuleCompletedAt
+	} else {
+		unset["module_completed_at"] = ""
+	}
+	if progress.CompletedAt != nil {
+		set["completed_at"] = progress.CompletedAt
+	} else {
+		unset["completed_at"] = ""
+	}
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		// MongoDB rejects an empty $unset
+		update["$unset"] = unset
+	}
+	return update
+}
+
+// validateReset checks that a reset names what its scope needs
+func validateReset(reset *models.ProgressReset) error {
+	switch reset.Scope {
+	case models.ResetModule:
+		if reset.ModuleID == "" {
+			return errors.New("module reset needs a module ID")
+		}
+		fallthrough
+	case models.ResetCourse:
+		if reset.CourseID.IsZero() {
+			return fmt.Errorf("%s reset needs a course ID", reset.Scope)
 		}
+	case models.ResetAll:
+	default:
+		return fmt.Errorf("unknown progress reset scope %q", reset.Scope)
+	}
+	return nil
+}
+
+// ==================== Exercise Methods ====================
+
+// CreateExerciseAttempt stores a learner's answer to an exercise and sets its ID
+func (r *MongoRepository) CreateExerciseAttempt(ctx context.Context, attempt *models.ExerciseAttempt) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	result, err := r.db.Collection("exercise_attempts").InsertOne(ctx, attempt)
+	if err != nil {
+		return err
+	}
+
+	attempt.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// CountExerciseAttempts counts the answers a learner has submitted for an exercise
+func (r *MongoRepository) CountExerciseAttempts(ctx context.Context, userID string, courseID string, moduleID string, exerciseID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	filter, err := exerciseFilter(userID, courseID, moduleID, exerciseID)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := r.db.Collection("exercise_attempts").CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+// RecordExerciseReveal records that a learner revealed a hint or solution.
+// Revealing the same thing again keeps the original reveal time.
+func (r *MongoRepository) RecordExerciseReveal(ctx context.Context, reveal *models.ExerciseReveal) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	_, err := r.db.Collection("exercise_reveals").UpdateOne(ctx,
+		bson.M{
+			"user_id":     reveal.UserID,
+			"course_id":   reveal.CourseID,
+			"module_id":   reveal.ModuleID,
+			"exercise_id": reveal.ExerciseID,
+			"kind":        reveal.Kind,
+			"hint_index":  reveal.HintIndex,
+		},
+		bson.M{"$setOnInsert": reveal},
+		options.Update().SetUpsert(true),
+	)
+	// A concurrent reveal of the same thing may win the upsert; either way it's recorded
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// GetExerciseReveals retrieves what a learner has revealed of an exercise, hints in order then the solution
+func (r *MongoRepository) GetExerciseReveals(ctx context.Context, userID string, courseID string, moduleID string, exerciseID string) ([]models.ExerciseReveal, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	filter, err := exerciseFilter(userID, courseID, moduleID, exerciseID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.db.Collection("exercise_reveals").Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "kind", Value: 1}, {Key: "hint_index", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reveals []models.ExerciseReveal
+	if err = cursor.All(ctx, &reveals); err != nil {
+		return nil, err
+	}
+
+	return reveals, nil
+}
+
+// CreateCodeRun stores a learner's Go submission and its test results and sets its ID
+func (r *MongoRepository) CreateCodeRun(ctx context.Context, run *models.CodeRun) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	result, err := r.db.Collection("code_runs").InsertOne(ctx, run)
+	if err != nil {
+		return err
 	}
 
+	run.ID = result.InsertedID.(primitive.ObjectID)
 	return nil
 }
+
+// GetCodeRuns retrieves a learner's runs of an exercise, oldest first
+func (r *MongoRepository) GetCodeRuns(ctx context.Context, userID string, courseID string, moduleID string, exerciseID string) ([]models.CodeRun, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	filter, err := exerciseFilter(userID, courseID, moduleID, exerciseID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.db.Collection("code_runs").Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "submitted_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var runs []models.CodeRun
+	if err = cursor.All(ctx, &runs); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// exerciseFilter matches a learner's records for one exercise
+func exerciseFilter(userID string, courseID string, moduleID string, exerciseID string) (bson.M, error) {
+	return moduleItemFilter(userID, courseID, moduleID, "exercise_id", exerciseID)
+}
+
+// moduleItemFilter matches a learner's records for one item of a module, such as an exercise or quiz
+func moduleItemFilter(userID string, courseID string, moduleID string, itemKey string, itemID string) (bson.M, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	courseObjectID, err := primitive.ObjectIDFromHex(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return bson.M{
+		"user_id":   userObjectID,
+		"course_id": courseObjectID,
+		"module_id": moduleID,
+		itemKey:     itemID,
+	}, nil
+}
+
+// ==================== Quiz Methods ====================
+
+// CreateQuizAttempt stores a learner's scored quiz submission and sets its ID
+func (r *MongoRepository) CreateQuizAttempt(ctx context.Context, attempt *models.QuizAttempt) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	result, err := r.db.Collection("quiz_attempts").InsertOne(ctx, attempt)
+	if err != nil {
+		return err
+	}
+
+	attempt.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetQuizAttempts retrieves a learner's attempts at a quiz, oldest first
+func (r *MongoRepository) GetQuizAttempts(ctx context.Context, userID string, courseID string, moduleID string, quizID string) ([]models.QuizAttempt, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	filter, err := moduleItemFilter(userID, courseID, moduleID, "quiz_id", quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.db.Collection("quiz_attempts").Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "submitted_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var attempts []models.QuizAttempt
+	if err = cursor.All(ctx, &attempts); err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// HasPassedQuiz reports whether any of a learner's attempts at a quiz passed
+func (r *MongoRepository) HasPassedQuiz(ctx context.Context, userID string, courseID string, moduleID string, quizID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	filter, err := moduleItemFilter(userID, courseID, moduleID, "quiz_id", quizID)
+	if err != nil {
+		return false, err
+	}
+	filter["result.passed"] = true
+
+	count, err := r.db.Collection("quiz_attempts").CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// ==================== Dashboard Methods ====================
+
+// scopeQuery matches the progress records in scope
+func scopeQuery(scope models.ProgressScope) bson.M {
+	query := bson.M{}
+	if scope.UserIDs != nil {
+		query["user_id"] = bson.M{"$in": scope.UserIDs}
+	}
+	if scope.CourseIDs != nil {
+		query["course_id"] = bson.M{"$in": scope.CourseIDs}
+	}
+	return query
+}
+
+// GetCourseCompletionCounts counts, per course, the learners in scope who have
+// started and completed it. Courses nobody in scope has progress in are left out.
+func (r *MongoRepository) GetCourseCompletionCounts(ctx context.Context, scope models.ProgressScope) ([]models.CourseCompletionCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: scopeQuery(scope)}},
+		// One row per learner first, so duplicate progress records count once
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"course_id": "$course_id", "user_id": "$user_id"},
+			"started": bson.M{"$max": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$completed_modules", bson.A{}}}}, 0}}, 1, 0,
+			}}},
+			"completed": bson.M{"$max": bson.M{"$cond": bson.A{"$is_completed", 1, 0}}},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$_id.course_id",
+			"learners":  bson.M{"$sum": 1},
+			"started":   bson.M{"$sum": "$started"},
+			"completed": bson.M{"$sum": "$completed"},
+		}}},
+	}
+	cursor, err := r.db.Collection("progress").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var counts []models.CourseCompletionCount
+	if err = cursor.All(ctx, &counts); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// GetModuleCompletionCounts counts, per module ID, the learners in scope who
+// have completed that module of the course
+func (r *MongoRepository) GetModuleCompletionCounts(ctx context.Context, courseID primitive.ObjectID, scope models.ProgressScope) (map[string]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	match := scopeQuery(scope)
+	match["course_id"] = courseID
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$completed_modules"}},
+		// A set, so a learner with duplicate progress records counts once
+		{{Key: "$group", Value: bson.M{"_id": "$completed_modules", "learners": bson.M{"$addToSet": "$user_id"}}}},
+		{{Key: "$project", Value: bson.M{"count": bson.M{"$size": "$learners"}}}},
+	}
+	cursor, err := r.db.Collection("progress").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		ModuleID string `bson:"_id"`
+		Count    int    `bson:"count"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(results))
+	for _, result := range results {
+		counts[result.ModuleID] = result.Count
+	}
+	return counts, nil
+}
+
+// GetScopedProgress returns the progress records in scope
+func (r *MongoRepository) GetScopedProgress(ctx context.Context, scope models.ProgressScope) ([]models.Progress, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	cursor, err := r.db.Collection("progress").Find(ctx, scopeQuery(scope))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var progress []models.Progress
+	if err = cursor.All(ctx, &progress); err != nil {
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+// GetProgressUserIDs returns the distinct IDs of users with any progress record
+func (r *MongoRepository) GetProgressUserIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	values, err := r.db.Collection("progress").Distinct(ctx, "user_id", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			userIDs = append(userIDs, id)
+		}
+	}
+	return userIDs, nil
+}
+
+// GetLastActivity returns when each of the users (every user, if nil) last did
+// something other than log in. Users with no such activity are left out.
+func (r *MongoRepository) GetLastActivity(ctx context.Context, userIDs []primitive.ObjectID) ([]models.LearnerLastActivity, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	match := bson.M{"type": bson.M{"$ne": models.ActivityLogin}}
+	if userIDs != nil {
+		match["user_id"] = bson.M{"$in": userIDs}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": "$user_id", "last_active_at": bson.M{"$max": "$occurred_at"}}}},
+	}
+	cursor, err := r.db.Collection("activity_events").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var activity []models.LearnerLastActivity
+	if err = cursor.All(ctx, &activity); err != nil {
+		return nil, err
+	}
+
+	return activity, nil
+}
+
+// ==================== Activity Methods ====================
+
+// RecordActivity appends an event to the activity log and sets its ID
+func (r *MongoRepository) RecordActivity(ctx context.Context, event *models.ActivityEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	result, err := r.db.Collection("activity_events").InsertOne(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	event.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetActivity returns the events matching the filter, newest first
+func (r *MongoRepository) GetActivity(ctx context.Context, filter models.ActivityFilter) ([]models.ActivityEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	query := bson.M{}
+	if !filter.UserID.IsZero() {
+		query["user_id"] = filter.UserID
+	}
+	if !filter.CourseID.IsZero() {
+		query["course_id"] = filter.CourseID
+	}
+	if len(filter.Types) > 0 {
+		query["type"] = bson.M{"$in": filter.Types}
+	}
+	occurred := bson.M{}
+	if !filter.Since.IsZero() {
+		occurred["$gte"] = filter.Since
+	}
+	if !filter.Until.IsZero() {
+		occurred["$lt"] = filter.Until
+	}
+	if len(occurred) > 0 {
+		query["occurred_at"] = occurred
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "occurred_at", Value: -1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+	cursor, err := r.db.Collection("activity_events").Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []models.ActivityEvent
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// ==================== Achievement Methods ====================
+
+// AwardBadge records that a user earned a badge and sets its ID. It returns
+// false, keeping the original award, if the user already has the badge.
+func (r *MongoRepository) AwardBadge(ctx context.Context, badge *models.UserBadge) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	result, err := r.db.Collection("user_badges").InsertOne(ctx, badge)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	badge.ID = result.InsertedID.(primitive.ObjectID)
+	return true, nil
+}
+
+// GetUserBadges returns the badges a user has earned, oldest first
+func (r *MongoRepository) GetUserBadges(ctx context.Context, userID string) ([]models.UserBadge, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.db.Collection("user_badges").Find(ctx, bson.M{"user_id": userObjectID},
+		options.Find().SetSort(bson.D{{Key: "earned_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var badges []models.UserBadge
+	if err = cursor.All(ctx, &badges); err != nil {
+		return nil, err
+	}
+
+	return badges, nil
+}
+
+// ==================== Certificate Methods ====================
+
+// IssueCertificate stores a certificate for the user and course and sets its
+// ID. If the user already has a certificate for the course, it returns false
+// and replaces cert with the existing one.
+func (r *MongoRepository) IssueCertificate(ctx context.Context, cert *models.Certificate) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	collection := r.db.Collection("certificates")
+	filter := bson.M{"user_id": cert.UserID, "course_id": cert.CourseID}
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$setOnInsert": cert}, options.Update().SetUpsert(true))
+	// A concurrent request may win the upsert; either way there's one certificate
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return false, err
+	}
+	if err == nil && result.UpsertedID != nil {
+		cert.ID = result.UpsertedID.(primitive.ObjectID)
+		return true, nil
+	}
+
+	return false, collection.FindOne(ctx, filter).Decode(cert)
+}
+
+// GetCertificate retrieves a certificate by ID
+func (r *MongoRepository) GetCertificate(ctx context.Context, id string) (*models.Certificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var cert models.Certificate
+	if err := r.db.Collection("certificates").FindOne(ctx, bson.M{"_id": objectID}).Decode(&cert); err != nil {
+		return nil, err
+	}
+
+	return &cert, nil
+}
+
+// GetUserCertificates returns a user's certificates, oldest completion first
+func (r *MongoRepository) GetUserCertificates(ctx context.Context, userID string) ([]models.Certificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.db.Collection("certificates").Find(ctx, bson.M{"user_id": userObjectID},
+		options.Find().SetSort(bson.D{{Key: "completed_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var certs []models.Certificate
+	if err = cursor.All(ctx, &certs); err != nil {
+		return nil, err
+	}
+
+	return certs, nil
+}
+
+// ==================== Cohort Methods ====================
+
+// CreateCohort stores a new cohort and sets its ID
+func (r *MongoRepository) CreateCohort(ctx context.Context, cohort *models.Cohort) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	result, err := r.db.Collection("cohorts").InsertOne(ctx, cohort)
+	if err != nil {
+		return err
+	}
+
+	cohort.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetCohort retrieves a cohort by ID
+func (r *MongoRepository) GetCohort(ctx context.Context, id string) (*models.Cohort, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var cohort models.Cohort
+	if err := r.db.Collection("cohorts").FindOne(ctx, bson.M{"_id": objectID}).Decode(&cohort); err != nil {
+		return nil, err
+	}
+
+	return &cohort, nil
+}
+
+// GetCohorts returns the cohorts matching the filter, by start date
+func (r *MongoRepository) GetCohorts(ctx context.Context, filter models.CohortFilter) ([]models.Cohort, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	query := bson.M{}
+	if !filter.MemberID.IsZero() {
+		query["member_ids"] = filter.MemberID
+	}
+	if !filter.InstructorID.IsZero() {
+		query["instructor_ids"] = filter.InstructorID
+	}
+
+	cursor, err := r.db.Collection("cohorts").Find(ctx, query,
+		options.Find().SetSort(bson.D{{Key: "start_date", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var cohorts []models.Cohort
+	if err = cursor.All(ctx, &cohorts); err != nil {
+		return nil, err
+	}
+
+	return cohorts, nil
+}
+
+// AddCohortMembers adds users to a cohort, ignoring those already in it, and
+// returns the updated cohort
+func (r *MongoRepository) AddCohortMembers(ctx context.Context, id string, userIDs []primitive.ObjectID) (*models.Cohort, error) {
+	return r.updateCohort(ctx, id, bson.M{"$addToSet": bson.M{"member_ids": bson.M{"$each": userIDs}}})
+}
+
+// RemoveCohortMember removes a user from a cohort and returns the updated cohort
+func (r *MongoRepository) RemoveCohortMember(ctx context.Context, id string, userID primitive.ObjectID) (*models.Cohort, error) {
+	return r.updateCohort(ctx, id, bson.M{"$pull": bson.M{"member_ids": userID}})
+}
+
+func (r *MongoRepository) updateCohort(ctx context.Context, id string, update bson.M) (*models.Cohort, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var cohort models.Cohort
+	err = r.db.Collection("cohorts").FindOneAndUpdate(ctx, bson.M{"_id": objectID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&cohort)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cohort, nil
+}
+
+// ==================== Token Methods ====================
+
+// CreateRefreshToken stores a new refresh token and sets its ID
+func (r *MongoRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	result, err := r.db.Collection("refresh_tokens").InsertOne(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	token.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetRefreshTokenByHash finds a refresh token by the hash of its value
+func (r *MongoRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	var token models.RefreshToken
+	err := r.db.Collection("refresh_tokens").FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// RevokeRefreshToken revokes a refresh token if it is still active.
+// It reports whether this call revoked it, so concurrent rotations of the same token can't both succeed.
+func (r *MongoRepository) RevokeRefreshToken(ctx context.Context, tokenHash string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	result, err := r.db.Collection("refresh_tokens").UpdateOne(ctx,
+		bson.M{"token_hash": tokenHash, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": time.Now().UTC()}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+// RevokeRefreshTokenFamily revokes every active token rotated from the same login
+func (r *MongoRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	_, err := r.db.Collection("refresh_tokens").UpdateMany(ctx,
+		bson.M{"family_id": familyID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": time.Now().UTC()}},
+	)
+	return err
+}
+
+// RevokeUserRefreshTokens revokes every active refresh token belonging to a user
+func (r *MongoRepository) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Collection("refresh_tokens").UpdateMany(ctx,
+		bson.M{"user_id": userObjectID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": time.Now().UTC()}},
+	)
+	return err
+}
+
+// RevokeAccessToken adds an access token's JWT ID to the revocation list
+func (r *MongoRepository) RevokeAccessToken(ctx context.Context, token *models.RevokedAccessToken) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	// Upsert so revoking the same token twice isn't an error
+	_, err := r.db.Collection("revoked_tokens").UpdateOne(ctx,
+		bson.M{"jti": token.JTI},
+		bson.M{"$setOnInsert": token},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// IsAccessTokenRevoked reports whether an access token's JWT ID has been revoked
+func (r *MongoRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	count, err := r.db.Collection("revoked_tokens").CountDocuments(ctx, bson.M{"jti": jti}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// CreatePasswordResetToken stores a new password reset token and sets its ID
+func (r *MongoRepository) CreatePasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	result, err := r.db.Collection("password_reset_tokens").InsertOne(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	token.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// UsePasswordResetToken marks an unused, unexpired reset token used and returns it.
+// The user's other outstanding reset tokens are used up too. Returns
+// mongo.ErrNoDocuments if there is no such token, so each token works at most once.
+func (r *MongoRepository) UsePasswordResetToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	now := time.Now().UTC()
+	collection := r.db.Collection("password_reset_tokens")
+
+	var token models.PasswordResetToken
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"token_hash": tokenHash, "used_at": nil, "expires_at": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{"used_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := collection.UpdateMany(ctx,
+		bson.M{"user_id": token.UserID, "used_at": nil},
+		bson.M{"$set": bson.M{"used_at": now}},
+	); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// CreateEmailVerificationToken stores a new email verification token and sets its ID
+func (r *MongoRepository) CreateEmailVerificationToken(ctx context.Context, token *models.EmailVerificationToken) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	result, err := r.db.Collection("email_verification_tokens").InsertOne(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	token.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// UseEmailVerificationToken marks an unused, unexpired verification token used,
+// along with the user's other outstanding verification tokens, and returns it.
+// Returns mongo.ErrNoDocuments if there is no such token.
+func (r *MongoRepository) UseEmailVerificationToken(ctx context.Context, tokenHash string) (*models.EmailVerificationToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	now := time.Now().UTC()
+	collection := r.db.Collection("email_verification_tokens")
+
+	var token models.EmailVerificationToken
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"token_hash": tokenHash, "used_at": nil, "expires_at": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{"used_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := collection.UpdateMany(ctx,
+		bson.M{"user_id": token.UserID, "used_at": nil},
+		bson.M{"$set": bson.M{"used_at": now}},
+	); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// ==================== Identity Methods ====================
+
+// LinkUserIdentity links a provider account to a user and sets its ID. It
+// returns false, keeping the original link, if the provider account is
+// already linked.
+func (r *MongoRepository) LinkUserIdentity(ctx context.Context, identity *models.UserIdentity) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	result, err := r.db.Collection("user_identities").InsertOne(ctx, identity)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	identity.ID = result.InsertedID.(primitive.ObjectID)
+	return true, nil
+}
+
+// GetUserIdentity finds the link for a provider account
+func (r *MongoRepository) GetUserIdentity(ctx context.Context, provider string, subject string) (*models.UserIdentity, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Operation)
+	defer cancel()
+
+	var identity models.UserIdentity
+	err := r.db.Collection("user_identities").FindOne(ctx, bson.M{"provider": provider, "subject": subject}).Decode(&identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
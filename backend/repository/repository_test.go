@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeoutsFromEnv(t *testing.T) {
+	t.Setenv("DB_OPERATION_TIMEOUT", "750ms")
+
+	timeouts, err := TimeoutsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults := DefaultTimeouts()
+	if timeouts.Operation != 750*time.Millisecond {
+		t.Fatalf("Operation = %v, want 750ms", timeouts.Operation)
+	}
+	if timeouts.Connect != defaults.Connect || timeouts.Bulk != defaults.Bulk {
+		t.Fatalf("unset timeouts should keep defaults, got %+v", timeouts)
+	}
+
+	for _, bad := range []string{"soon", "-1s", "0"} {
+		t.Setenv("DB_BULK_TIMEOUT", bad)
+		if _, err := TimeoutsFromEnv(); err == nil {
+			t.Fatalf("DB_BULK_TIMEOUT=%q: expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,492 @@
+// Package runner compiles learners' Go submissions and runs them against an
+// exercise's test cases in a sandboxed process.
+//
+// Each submission is built and run in its own temporary directory, in a child
+// process with its own user, mount, PID and network namespaces: it sees a
+// read-only file system holding only what it needs, has no network access and
+// no capabilities, and runs with memory and process limits, a wall-clock
+// timeout and capped output. Only the standard library can be imported:
+// modules are never downloaded.
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pathway/backend/models"
+)
+
+// ErrUnavailable means submissions can't be run on this host, e.g. because the
+// Go toolchain is missing or the sandbox can't be set up
+var ErrUnavailable = errors.New("code runner unavailable")
+
+// Runner runs a Go program against test cases
+type Runner interface {
+	Run(ctx context.Context, code string, tests []models.ExerciseTest) (*models.RunResult, error)
+}
+
+// Limits bounds the resources a submission may use
+type Limits struct {
+	CompileTimeout     time.Duration
+	CompileMemoryBytes int64
+	CompileProcesses   int           // Processes and threads, including the compiler's
+	RunTimeout         time.Duration // Per test case
+	MemoryBytes        int64         // Per test case
+	Processes          int           // Processes and threads, per test case
+	OutputBytes        int           // Per stream, per test case
+	Concurrency        int           // Submissions built or run at once
+}
+
+// DefaultLimits returns limits suitable for short teaching exercises
+func DefaultLimits() Limits {
+	return Limits{
+		CompileTimeout:     30 * time.Second,
+		CompileMemoryBytes: 1 << 30,
+		CompileProcesses:   256,
+		RunTimeout:         2 * time.Second,
+		MemoryBytes:        256 << 20,
+		Processes:          32,
+		OutputBytes:        64 << 10,
+		Concurrency:        2,
+	}
+}
+
+// LimitsFromEnv returns DefaultLimits overridden by RUNNER_COMPILE_TIMEOUT,
+// RUNNER_RUN_TIMEOUT (durations), RUNNER_MEMORY_MB, RUNNER_PROCESSES,
+// RUNNER_OUTPUT_BYTES and RUNNER_CONCURRENCY (positive integers)
+func LimitsFromEnv() (Limits, error) {
+	limits := DefaultLimits()
+	for name, target := range map[string]*time.Duration{
+		"RUNNER_COMPILE_TIMEOUT": &limits.CompileTimeout,
+		"RUNNER_RUN_TIMEOUT":     &limits.RunTimeout,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return Limits{}, fmt.Errorf("invalid %s %q: must be a positive duration", name, value)
+		}
+		*target = d
+	}
+
+	for name, set := range map[string]func(n int){
+		"RUNNER_MEMORY_MB":    func(n int) { limits.MemoryBytes = int64(n) << 20 },
+		"RUNNER_PROCESSES":    func(n int) { limits.Processes = n },
+		"RUNNER_OUTPUT_BYTES": func(n int) { limits.OutputBytes = n },
+		"RUNNER_CONCURRENCY":  func(n int) { limits.Concurrency = n },
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return Limits{}, fmt.Errorf("invalid %s %q: must be a positive integer", name, value)
+		}
+		set(n)
+	}
+	return limits, nil
+}
+
+// Paths inside the sandbox
+const (
+	workDir   = "/work"   // The submission and the program built from it
+	cacheDir  = "/cache"  // The Go build cache, when building
+	configDir = "/config" // The go command's configuration, when building
+	tmpDir    = "/tmp"    // Private to each process; HOME and TMPDIR
+)
+
+// sandboxGOMAXPROCS keeps Go programs in the sandbox, the toolchain included,
+// from starting a thread per host CPU, which could exceed the process limits
+const sandboxGOMAXPROCS = "GOMAXPROCS=2"
+
+// warmUpTimeout bounds the build at startup that fills the shared build cache,
+// which compiles much of the standard library
+const warmUpTimeout = 10 * time.Minute
+
+// warmUpProgram imports the packages exercises commonly use, so that building
+// it at startup puts them in the shared build cache
+const warmUpProgram = `package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	_ = bytes.Compare
+	_ = errors.New
+	_ = math.Sqrt
+	_ = sort.Ints
+	_ = strconv.Itoa
+	_ = unicode.IsUpper
+)
+
+func main() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fmt.Println(strings.ToUpper(scanner.Text()))
+	}
+}
+`
+
+// GoRunner builds submissions with the local Go toolchain and runs them sandboxed
+type GoRunner struct {
+	limits   Limits
+	goroot   string
+	cacheDir string        // Build cache shared between submissions, so the standard library is compiled once
+	slots    chan struct{} // Bounds concurrent submissions
+}
+
+var _ Runner = (*GoRunner)(nil)
+
+// NewGoRunner finds the Go toolchain and fills the shared build cache by
+// building and running a program in the sandbox, which also checks that the
+// host supports it. It returns ErrUnavailable if the host can't sandbox
+// submissions.
+func NewGoRunner(limits Limits) (*GoRunner, error) {
+	if !sandboxSupported {
+		return nil, fmt.Errorf("%w: sandboxing requires Linux", ErrUnavailable)
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		return nil, fmt.Errorf("%w: go toolchain not found", ErrUnavailable)
+	}
+	out, err := exec.Command(goBin, "env", "GOROOT").Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: go env GOROOT: %v", ErrUnavailable, err)
+	}
+	goroot, err := filepath.EvalSymlinks(strings.TrimSpace(string(out)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	// Submissions only ever see a copy-on-write view of the cache, so it only
+	// holds what the toolchain compiled while warming up
+	cacheDir := filepath.Join(os.TempDir(), "pathway-runner-gocache")
+	if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+		return nil, err
+	}
+	uid, gid := sandboxUser()
+	if err := os.Chown(cacheDir, uid, gid); err != nil {
+		return nil, err
+	}
+	if limits.Concurrency < 1 {
+		limits.Concurrency = 1
+	}
+
+	r := &GoRunner{
+		limits:   limits,
+		goroot:   goroot,
+		cacheDir: cacheDir,
+		slots:    make(chan struct{}, limits.Concurrency),
+	}
+	if err := r.warmUp(); err != nil {
+		if errors.Is(err, ErrUnavailable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return r, nil
+}
+
+// warmUp builds and runs warmUpProgram with write access to the shared build cache
+func (r *GoRunner) warmUp() error {
+	ctx, cancel := context.WithTimeout(context.Background(), warmUpTimeout)
+	defer cancel()
+	limits := r.limits
+	limits.CompileTimeout = warmUpTimeout
+
+	result, err := r.run(ctx, limits, warmUpProgram, []models.ExerciseTest{{Name: "warm-up", Input: "go\n", Output: "GO"}}, true)
+	if err != nil {
+		return err
+	}
+	if result.Status != models.RunPassed {
+		return fmt.Errorf("sandbox check failed: %s%+v", result.CompileOutput, result.Tests)
+	}
+	return nil
+}
+
+// Run builds code as a main package and runs it once per test case. A program
+// that fails to build is a result, not an error; errors mean the submission
+// couldn't be judged at all.
+func (r *GoRunner) Run(ctx context.Context, code string, tests []models.ExerciseTest) (*models.RunResult, error) {
+	select {
+	case r.slots <- struct{}{}:
+		defer func() { <-r.slots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return r.run(ctx, r.limits, code, tests, false)
+}
+
+// run builds and runs code. Only warmUp may fill the shared build cache;
+// submissions build with a private layer over it.
+func (r *GoRunner) run(ctx context.Context, limits Limits, code string, tests []models.ExerciseTest, fillCache bool) (*models.RunResult, error) {
+	dir, err := os.MkdirTemp("", "pathway-run-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	// The sandbox's user must be able to reach the directories and write the program
+	work, root, config := filepath.Join(dir, "work"), filepath.Join(dir, "root"), filepath.Join(dir, "config")
+	for _, d := range []string{work, root, filepath.Join(config, "go", "telemetry")} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(work, "main.go"), []byte(code), 0o644); err != nil {
+		return nil, err
+	}
+	// Without telemetry the go command doesn't look for its own executable in /proc
+	if err := os.WriteFile(filepath.Join(config, "go", "telemetry", "mode"), []byte("off"), 0o644); err != nil {
+		return nil, err
+	}
+	uid, gid := sandboxUser()
+	for _, path := range []string{dir, work, root} {
+		if err := os.Chown(path, uid, gid); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &models.RunResult{Tests: []models.TestResult{}, Total: len(tests)}
+
+	build, err := r.exec(ctx, process{
+		root: root,
+		mounts: []mount{
+			{Source: r.goroot, Target: r.goroot},
+			{Source: work, Target: workDir, Writable: true},
+			{Source: config, Target: configDir},
+			{Source: r.cacheDir, Target: cacheDir, Writable: fillCache, Overlay: !fillCache},
+		},
+		dir:       workDir,
+		env:       r.buildEnv(),
+		memory:    limits.CompileMemoryBytes,
+		processes: limits.CompileProcesses,
+		timeout:   limits.CompileTimeout,
+		output:    limits.OutputBytes,
+		args:      []string{filepath.Join(r.goroot, "bin", "go"), "build", "-o", "prog", "main.go"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if build.timedOut || build.exitErr != nil {
+		result.Status = models.RunCompileError
+		result.CompileOutput = strings.ReplaceAll(build.stderr+build.stdout, workDir+"/", "")
+		if build.timedOut {
+			result.CompileOutput += "\nbuild timed out"
+		}
+		return result, nil
+	}
+
+	for _, test := range tests {
+		run, err := r.exec(ctx, process{
+			root:      root,
+			mounts:    []mount{{Source: work, Target: workDir}},
+			dir:       workDir,
+			env:       []string{"HOME=" + tmpDir, "TMPDIR=" + tmpDir, sandboxGOMAXPROCS},
+			stdin:     test.Input,
+			memory:    limits.MemoryBytes,
+			processes: limits.Processes,
+			timeout:   limits.RunTimeout,
+			output:    limits.OutputBytes,
+			args:      []string{workDir + "/prog"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		testResult := models.TestResult{
+			Name:       test.Name,
+			Status:     judge(run, test.Output),
+			DurationMS: run.duration.Milliseconds(),
+		}
+		// A program can echo its stdin, so hidden tests don't show what it printed either
+		if !test.Hidden {
+			testResult.Input = test.Input
+			testResult.Expected = test.Output
+			testResult.Output = run.stdout
+			testResult.Stderr = run.stderr
+		}
+		if testResult.Status == models.TestPassed {
+			result.Passed++
+		}
+		result.Tests = append(result.Tests, testResult)
+	}
+
+	result.Status = models.RunPassed
+	if result.Passed < result.Total {
+		result.Status = models.RunFailed
+	}
+	return result, nil
+}
+
+// buildEnv keeps the go command offline and away from any configuration
+func (r *GoRunner) buildEnv() []string {
+	return []string{
+		"HOME=" + tmpDir,
+		"TMPDIR=" + tmpDir,
+		"PATH=" + filepath.Join(r.goroot, "bin"),
+		"GOROOT=" + r.goroot,
+		"GOCACHE=" + cacheDir,
+		"GOPATH=" + tmpDir + "/gopath",
+		"GOPROXY=off",
+		"GOTOOLCHAIN=local",
+		"GOFLAGS=-mod=mod",
+		"GOENV=off",
+		"GOWORK=off",
+		"XDG_CONFIG_HOME=" + configDir,
+		"CGO_ENABLED=0",
+		sandboxGOMAXPROCS,
+	}
+}
+
+// judge decides a test's status from how the program ran and what it printed
+func judge(run *execResult, expected string) string {
+	switch {
+	case run.truncated:
+		return models.TestOutputLimit
+	case run.timedOut:
+		return models.TestTimeout
+	case run.exitErr != nil:
+		return models.TestRuntimeError
+	case normalizeOutput(run.stdout) != normalizeOutput(expected):
+		return models.TestWrongOutput
+	default:
+		return models.TestPassed
+	}
+}
+
+// normalizeOutput ignores line endings, trailing spaces and trailing blank lines
+func normalizeOutput(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// process describes one sandboxed command
+type process struct {
+	root      string  // Empty directory to mount the sandbox's file system on
+	mounts    []mount // Besides /tmp and a few devices
+	dir       string
+	env       []string
+	stdin     string
+	memory    int64
+	processes int
+	timeout   time.Duration
+	output    int
+	args      []string
+}
+
+// mount makes a host directory visible in the sandbox, read-only unless
+// Writable. Overlay gives the sandbox a writable view whose changes are
+// discarded with it.
+type mount struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Writable bool   `json:"writable,omitempty"`
+	Overlay  bool   `json:"overlay,omitempty"`
+}
+
+type execResult struct {
+	stdout    string
+	stderr    string
+	exitErr   error
+	timedOut  bool
+	truncated bool // Stdout passed the output limit
+	duration  time.Duration
+}
+
+// exec runs p in the sandbox
+func (r *GoRunner) exec(ctx context.Context, p process) (*execResult, error) {
+	runCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	cmd, setupErrors, err := sandboxCommand(runCtx, p)
+	if err != nil {
+		return nil, err
+	}
+	defer setupErrors.Close()
+	cmd.Stdin = strings.NewReader(p.stdin)
+	// Only stdout is judged; a long panic trace on stderr is just cut short
+	stdout := &limitedBuffer{limit: p.output, stop: true}
+	stderr := &limitedBuffer{limit: p.output}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err = cmd.Start()
+	cmd.ExtraFiles[0].Close()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	// Reading returns once the sandbox has exec'd the command or failed
+	if msg, _ := io.ReadAll(setupErrors); len(msg) > 0 {
+		cmd.Wait()
+		return nil, fmt.Errorf("%w: sandbox setup: %s", ErrUnavailable, msg)
+	}
+	start := time.Now()
+	waitErr := cmd.Wait()
+	duration := time.Since(start)
+
+	// The caller giving up isn't the program's fault
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return &execResult{
+		stdout:    stdout.String(),
+		stderr:    stderr.String(),
+		exitErr:   waitErr,
+		timedOut:  errors.Is(runCtx.Err(), context.DeadlineExceeded),
+		truncated: stdout.truncated,
+		duration:  duration,
+	}, nil
+}
+
+var errOutputLimit = errors.New("output limit exceeded")
+
+// limitedBuffer keeps the first limit bytes written. If stop is set, writing
+// more fails, which closes the pipe so a program printing in a loop is stopped
+// by SIGPIPE; otherwise the rest is discarded. (The buffer isn't embedded: its
+// ReadFrom would let io.Copy bypass the limit.)
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	stop      bool
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); len(p) > remaining {
+		b.buf.Write(p[:remaining])
+		b.truncated = true
+		if b.stop {
+			return remaining, errOutputLimit
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string { return b.buf.String() }
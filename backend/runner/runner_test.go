@@ -0,0 +1,201 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pathway/backend/models"
+)
+
+// newTestRunner returns a GoRunner with tight limits, skipping the test if
+// the host can't sandbox submissions
+func newTestRunner(t *testing.T) *GoRunner {
+	t.Helper()
+	limits := DefaultLimits()
+	limits.RunTimeout = time.Second
+	limits.MemoryBytes = 64 << 20
+	limits.OutputBytes = 1 << 10
+
+	r, err := NewGoRunner(limits)
+	if err != nil {
+		t.Skipf("runner unavailable: %v", err)
+	}
+	if _, err := r.Run(context.Background(), "package main\nfunc main() {}\n", nil); errors.Is(err, ErrUnavailable) {
+		t.Skipf("sandbox unavailable: %v", err)
+	}
+	return r
+}
+
+const echoUpper = `package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func main() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fmt.Println(strings.ToUpper(scanner.Text()) + "  ")
+	}
+}
+`
+
+func TestRunJudgesTests(t *testing.T) {
+	r := newTestRunner(t)
+
+	result, err := r.Run(context.Background(), echoUpper, []models.ExerciseTest{
+		{Name: "one line", Input: "go\n", Output: "GO"},
+		{Name: "two lines", Input: "a\nb\n", Output: "A\nB\n\n"},
+		{Name: "wrong", Input: "x\n", Output: "y", Hidden: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != models.RunFailed || result.Passed != 2 || result.Total != 3 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if got := result.Tests[2]; got.Status != models.TestWrongOutput || got.Input != "" || got.Expected != "" || got.Output != "" || got.Stderr != "" {
+		t.Fatalf("hidden test result: %+v", got)
+	}
+	if got := result.Tests[0]; got.Input != "go\n" || got.Expected != "GO" || got.Output != "GO  \n" {
+		t.Fatalf("visible test should show input, expected and actual output: %+v", got)
+	}
+}
+
+func TestRunHidesHiddenTestInput(t *testing.T) {
+	r := newTestRunner(t)
+	echo := "package main\nimport (\n\"io\"\n\"os\"\n)\nfunc main() {\nio.Copy(os.Stdout, os.Stdin)\nio.Copy(os.Stderr, os.Stdin)\n}\n"
+
+	result, err := r.Run(context.Background(), echo, []models.ExerciseTest{
+		{Name: "secret", Input: "hidden-input-42\n", Output: "anything", Hidden: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := result.Tests[0]
+	if got.Status != models.TestWrongOutput || got.Output != "" || got.Stderr != "" || got.Input != "" || got.Expected != "" {
+		t.Fatalf("hidden test result should only show its status: %+v", got)
+	}
+}
+
+func TestRunCompileError(t *testing.T) {
+	r := newTestRunner(t)
+
+	for name, code := range map[string]string{
+		"syntax":         "package main\nfunc main() {\n",
+		"non-std import": "package main\nimport _ \"github.com/example/pkg\"\nfunc main() {}\n",
+	} {
+		result, err := r.Run(context.Background(), code, []models.ExerciseTest{{Name: "t", Output: ""}})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if result.Status != models.RunCompileError || len(result.Tests) != 0 || result.CompileOutput == "" {
+			t.Fatalf("%s: unexpected result: %+v", name, result)
+		}
+		if strings.Contains(result.CompileOutput, "pathway-run-") {
+			t.Fatalf("%s: compile output leaks the build directory: %s", name, result.CompileOutput)
+		}
+	}
+}
+
+func TestRunEnforcesLimits(t *testing.T) {
+	r := newTestRunner(t)
+
+	cases := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"timeout", "for {}", models.TestTimeout},
+		{"output", `for { fmt.Println("spam") }`, models.TestOutputLimit},
+		{"memory", `var b [][]byte; for i := 0; i < 1024; i++ { b = append(b, make([]byte, 1<<20)); b[i][0] = 1 }; fmt.Println(len(b))`, models.TestRuntimeError},
+		{"panic", `panic("boom")`, models.TestRuntimeError},
+		{"network", `_, err := net.DialTimeout("tcp", "1.1.1.1:80", 500*time.Millisecond); fmt.Println(err != nil)`, models.TestPassed},
+	}
+	for _, tc := range cases {
+		code := "package main\nimport (\n\"fmt\"\n\"net\"\n\"time\"\n)\nvar _ = fmt.Sprint\nvar _ = net.Dial\nvar _ = time.Now\nfunc main() {\n" + tc.body + "\n}\n"
+		result, err := r.Run(context.Background(), code, []models.ExerciseTest{{Name: tc.name, Output: "true"}})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if result.Status == models.RunCompileError {
+			t.Fatalf("%s: %s", tc.name, result.CompileOutput)
+		}
+		if got := result.Tests[0].Status; got != tc.want {
+			t.Errorf("%s: status %s, want %s (output %q, stderr %q)", tc.name, got, tc.want, result.Tests[0].Output, result.Tests[0].Stderr)
+		}
+	}
+}
+
+func TestRunIsolatesSubmission(t *testing.T) {
+	r := newTestRunner(t)
+	hostFile, err := filepath.Abs("runner.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		body string
+	}{
+		{"read host files", `_, err := os.ReadFile("` + hostFile + `"); fmt.Println(err != nil)`},
+		{"write build cache", `err := os.WriteFile("/cache/poison", nil, 0o644); fmt.Println(err != nil)`},
+		{"write root", `err := os.WriteFile("/poison", nil, 0o644); fmt.Println(err != nil)`},
+		{"write submission", `err := os.WriteFile("/work/prog", nil, 0o755); fmt.Println(err != nil)`},
+		{"remount root", `err := syscall.Mount("", "/", "", syscall.MS_REMOUNT|syscall.MS_BIND, ""); fmt.Println(err != nil)`},
+		{"process limit", `failed := false
+for i := 0; i < 100 && !failed; i++ {
+	cmd := exec.Command("/work/prog", "sleep")
+	failed = cmd.Start() != nil
+}
+fmt.Println(failed)`},
+		{"own pid namespace", `fmt.Println(os.Getpid() == 1)`},
+	}
+	for _, tc := range cases {
+		code := "package main\nimport (\n\"fmt\"\n\"os\"\n\"os/exec\"\n\"syscall\"\n\"time\"\n)\nvar _ = exec.Command\nvar _ = syscall.Mount\n" +
+			"func main() {\nif len(os.Args) > 1 { time.Sleep(time.Minute); return }\n" + tc.body + "\n}\n"
+		result, err := r.Run(context.Background(), code, []models.ExerciseTest{{Name: tc.name, Output: "true"}})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if result.Status == models.RunCompileError {
+			t.Fatalf("%s: %s", tc.name, result.CompileOutput)
+		}
+		if got := result.Tests[0]; got.Status != models.TestPassed {
+			t.Errorf("%s: status %s (output %q, stderr %q)", tc.name, got.Status, got.Output, got.Stderr)
+		}
+	}
+}
+
+func TestNormalizeOutput(t *testing.T) {
+	if normalizeOutput("a \r\nb\t\n\n") != normalizeOutput("a\nb") {
+		t.Fatal("trailing whitespace and blank lines should be ignored")
+	}
+	if normalizeOutput(" a") == normalizeOutput("a") {
+		t.Fatal("leading whitespace is significant")
+	}
+}
+
+func TestLimitsFromEnv(t *testing.T) {
+	t.Setenv("RUNNER_RUN_TIMEOUT", "5s")
+	t.Setenv("RUNNER_MEMORY_MB", "128")
+	limits, err := LimitsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits.RunTimeout != 5*time.Second || limits.MemoryBytes != 128<<20 || limits.Concurrency != DefaultLimits().Concurrency {
+		t.Fatalf("unexpected limits: %+v", limits)
+	}
+
+	t.Setenv("RUNNER_CONCURRENCY", "0")
+	if _, err := LimitsFromEnv(); err == nil {
+		t.Fatal("expected error for RUNNER_CONCURRENCY=0")
+	}
+}
@@ -0,0 +1,330 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const sandboxSupported = true
+
+// sandboxInitArg0 is the argv[0] the server binary is re-executed with to set
+// up a sandbox before running the sandboxed command; see init
+const sandboxInitArg0 = "pathway-sandbox-init"
+
+// sandboxErrFD is the pipe the sandbox setup reports failures on. It's closed
+// on exec, so the parent reads EOF once the command is running.
+const sandboxErrFD = 3
+
+// Sizes of the sandbox's tmpfs mounts
+const (
+	sandboxRootBytes  = 1 << 20  // Only holds mount points
+	sandboxTmpBytes   = 64 << 20 // /tmp, which is also HOME and TMPDIR
+	sandboxLayerBytes = 64 << 20 // Writable layer over a shared build cache
+)
+
+// The unprivileged user sandboxes run as when the server runs as root: the
+// kernel doesn't apply process limits to root
+const (
+	nobodyUID = 65534
+	nobodyGID = 65534
+)
+
+// sandboxUser returns the host user and group sandboxed processes run as
+func sandboxUser() (uid, gid int) {
+	if os.Getuid() == 0 {
+		return nobodyUID, nobodyGID
+	}
+	return os.Getuid(), os.Getgid()
+}
+
+// Device nodes bound into every sandbox
+var sandboxDevices = []string{"/dev/null", "/dev/zero", "/dev/urandom"}
+
+// sandboxSpec is what the sandbox setup receives from the parent process
+type sandboxSpec struct {
+	Root      string   `json:"root"` // Empty host directory to mount the new root on
+	Mounts    []mount  `json:"mounts"`
+	Dir       string   `json:"dir"`
+	Env       []string `json:"env"`
+	Args      []string `json:"args"`
+	Memory    int64    `json:"memory"`
+	Processes int      `json:"processes"`
+}
+
+// init turns the process into the sandbox setup when the runner re-executes
+// the server binary; it never returns in that case
+func init() {
+	if len(os.Args) == 2 && os.Args[0] == sandboxInitArg0 {
+		sandboxInit(os.Args[1])
+	}
+}
+
+// sandboxCommand returns a command that runs p in new user, mount, PID,
+// network, IPC and UTS namespaces. The command sees a read-only root holding
+// only p's mounts, a private /tmp and a few device nodes, has no network
+// interfaces but loopback, no capabilities, and limits on memory and
+// processes. It runs in its own process group, so a timeout kills everything
+// it started.
+//
+// Sandbox setup failures are reported on the returned pipe, which reaches EOF
+// once the command is running. The caller must close the write end,
+// cmd.ExtraFiles[0], after starting the command.
+func sandboxCommand(ctx context.Context, p process) (*exec.Cmd, *os.File, error) {
+	spec, err := json.Marshal(sandboxSpec{
+		Root:      p.root,
+		Mounts:    p.mounts,
+		Dir:       p.dir,
+		Env:       p.env,
+		Args:      p.args,
+		Memory:    p.memory,
+		Processes: p.processes,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	errRead, errWrite, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "/proc/self/exe", string(spec))
+	cmd.Args[0] = sandboxInitArg0
+	cmd.Env = []string{}
+	cmd.ExtraFiles = []*os.File{errWrite}
+	uid, gid := sandboxUser()
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID |
+			syscall.CLONE_NEWNET | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: uid, Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: gid, Size: 1}},
+		// Become the namespace's root, which holds the capabilities to set it up
+		Credential: &syscall.Credential{Uid: 0, Gid: 0, NoSetGroups: true},
+		Setpgid:    true,
+		Pdeathsig:  syscall.SIGKILL,
+	}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	// Don't wait for orphaned children holding the output pipes
+	cmd.WaitDelay = time.Second
+	return cmd, errRead, nil
+}
+
+// sandboxInit runs in the re-executed binary, as root of the new user
+// namespace: it builds the sandbox's file system, drops every capability and
+// execs the command. Failures are written to sandboxErrFD.
+func sandboxInit(arg string) {
+	// Capabilities and securebits are per thread; they're changed on the one that execs
+	runtime.LockOSThread()
+	syscall.CloseOnExec(sandboxErrFD)
+	errPipe := os.NewFile(sandboxErrFD, "sandbox-errors")
+
+	var spec sandboxSpec
+	err := json.Unmarshal([]byte(arg), &spec)
+	if err == nil {
+		err = enterSandbox(&spec)
+	}
+	if err == nil {
+		err = syscall.Exec(spec.Args[0], spec.Args, spec.Env)
+	}
+	fmt.Fprint(errPipe, err)
+	os.Exit(1)
+}
+
+func enterSandbox(spec *sandboxSpec) error {
+	// Keep the mounts below out of the host's mount namespace
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	root := spec.Root
+	if err := mountTmpfs(root, sandboxRootBytes, "0755"); err != nil {
+		return err
+	}
+	if err := mountTmpfs(filepath.Join(root, "tmp"), sandboxTmpBytes, "1777"); err != nil {
+		return err
+	}
+	for _, device := range sandboxDevices {
+		if err := bindMount(device, filepath.Join(root, device), true); err != nil {
+			return err
+		}
+	}
+	for _, m := range spec.Mounts {
+		target := filepath.Join(root, m.Target)
+		var err error
+		if m.Overlay {
+			err = overlayMount(m.Source, target, root)
+		} else {
+			err = bindMount(m.Source, target, m.Writable)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Swap the root for the new one and detach the host's file system
+	oldRoot := filepath.Join(root, ".old")
+	if err := os.Mkdir(oldRoot, 0o700); err != nil {
+		return err
+	}
+	if err := syscall.PivotRoot(root, oldRoot); err != nil {
+		return fmt.Errorf("pivot_root: %w", err)
+	}
+	if err := syscall.Chdir("/"); err != nil {
+		return err
+	}
+	if err := syscall.Unmount("/.old", syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("detach host root: %w", err)
+	}
+	if err := os.Remove("/.old"); err != nil {
+		return err
+	}
+	if err := syscall.Mount("", "/", "", syscall.MS_REMOUNT|syscall.MS_BIND|syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("make root read-only: %w", err)
+	}
+	if err := syscall.Chdir(spec.Dir); err != nil {
+		return err
+	}
+
+	for resource, limit := range map[int]uint64{
+		syscall.RLIMIT_DATA: uint64(spec.Memory),
+		rlimitNproc:         uint64(spec.Processes),
+		syscall.RLIMIT_CORE: 0,
+	} {
+		if err := syscall.Setrlimit(resource, &syscall.Rlimit{Cur: limit, Max: limit}); err != nil {
+			return fmt.Errorf("setrlimit %d: %w", resource, err)
+		}
+	}
+	return dropCapabilities()
+}
+
+func mountTmpfs(target string, size int64, mode string) error {
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		return err
+	}
+	options := fmt.Sprintf("size=%d,mode=%s", size, mode)
+	if err := syscall.Mount("tmpfs", target, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, options); err != nil {
+		return fmt.Errorf("mount tmpfs on %s: %w", target, err)
+	}
+	return nil
+}
+
+// bindMount makes source visible at target, read-only unless writable
+func bindMount(source, target string, writable bool) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		err = os.MkdirAll(target, 0o755)
+	} else if err = os.MkdirAll(filepath.Dir(target), 0o755); err == nil {
+		err = os.WriteFile(target, nil, 0o644)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := syscall.Mount(source, target, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+		return fmt.Errorf("bind %s: %w", source, err)
+	}
+	if writable {
+		return nil
+	}
+	// A remount must keep the flags the host locked on the source's mount
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(target, &st); err != nil {
+		return err
+	}
+	flags := uintptr(syscall.MS_REMOUNT | syscall.MS_BIND | syscall.MS_RDONLY | syscall.MS_NOSUID)
+	flags |= uintptr(st.Flags) & (syscall.MS_NODEV | syscall.MS_NOEXEC | syscall.MS_NOATIME | syscall.MS_NODIRATIME)
+	if st.Flags&stRelatime != 0 {
+		flags |= syscall.MS_RELATIME
+	}
+	if err := syscall.Mount("", target, "", flags, ""); err != nil {
+		return fmt.Errorf("make %s read-only: %w", source, err)
+	}
+	return nil
+}
+
+// overlayMount mounts a writable copy-on-write view of source at target.
+// Writes go to a tmpfs layer that disappears with the sandbox; source is
+// never modified.
+func overlayMount(source, target, root string) error {
+	layer := filepath.Join(root, ".layer")
+	if err := mountTmpfs(layer, sandboxLayerBytes, "0700"); err != nil {
+		return err
+	}
+	upper, work := filepath.Join(layer, "upper"), filepath.Join(layer, "work")
+	for _, dir := range []string{upper, work, target} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	options := fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s,userxattr", source, upper, work)
+	if err := syscall.Mount("overlay", target, "overlay", syscall.MS_NOSUID|syscall.MS_NODEV, options); err != nil {
+		return fmt.Errorf("overlay %s: %w", source, err)
+	}
+	// The overlay keeps the layer alive; it needn't stay reachable
+	if err := syscall.Unmount(layer, syscall.MNT_DETACH); err != nil {
+		return err
+	}
+	return os.Remove(layer)
+}
+
+// Not all of these are in the syscall package
+const (
+	rlimitNproc         = 6
+	stRelatime          = 0x1000
+	prSetNoNewPrivs     = 38
+	secbitNoroot        = 1 << 0
+	secbitNorootLocked  = 1 << 1
+	secbitNoSetuidFixup = 1 << 2
+	secbitNoSetuidLock  = 1 << 3
+	secbitKeepCapsLock  = 1 << 5
+	secbitNoAmbientRise = 1 << 6
+	secbitNoAmbientLock = 1 << 7
+	capabilityVersion3  = 0x20080522
+	maxCapability       = 63
+)
+
+// dropCapabilities leaves the command with no capabilities in the sandbox's
+// user namespace, so it can't undo the mounts, and makes sure exec can't
+// grant any back: it runs as the namespace's root, which would otherwise get
+// a full set
+func dropCapabilities() error {
+	securebits := secbitNoroot | secbitNorootLocked | secbitNoSetuidFixup | secbitNoSetuidLock |
+		secbitKeepCapsLock | secbitNoAmbientRise | secbitNoAmbientLock
+	if err := prctl(syscall.PR_SET_SECUREBITS, uintptr(securebits)); err != nil {
+		return fmt.Errorf("set securebits: %w", err)
+	}
+	for c := 0; c <= maxCapability; c++ {
+		// Capabilities the kernel doesn't know are reported as invalid
+		if err := prctl(syscall.PR_CAPBSET_DROP, uintptr(c)); err != nil && err != syscall.EINVAL {
+			return fmt.Errorf("drop capability %d: %w", c, err)
+		}
+	}
+
+	header := struct {
+		version uint32
+		pid     int32
+	}{version: capabilityVersion3}
+	var data [2]struct{ effective, permitted, inheritable uint32 }
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_CAPSET, uintptr(unsafe.Pointer(&header)), uintptr(unsafe.Pointer(&data[0])), 0); errno != 0 {
+		return fmt.Errorf("capset: %w", errno)
+	}
+	return prctl(prSetNoNewPrivs, 1)
+}
+
+func prctl(option, arg uintptr) error {
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, option, arg, 0, 0, 0, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Sandboxing relies on Linux namespaces; NewGoRunner refuses to start elsewhere
+const sandboxSupported = false
+
+func sandboxUser() (uid, gid int) { return os.Getuid(), os.Getgid() }
+
+func sandboxCommand(ctx context.Context, p process) (*exec.Cmd, *os.File, error) {
+	return nil, nil, fmt.Errorf("%w: sandboxing requires Linux", ErrUnavailable)
+}
@@ -4,6 +4,7 @@ import "github.com/pathway/backend/models"
 
 func courseCodeConcepts() models.Course {
 	return models.Course{
+		Slug:        "code-concepts",
 		Title:       "Code Concepts",
 		Description: "Fundamental programming paradigms and concepts that every developer should master.",
 		Modules: []models.Module{
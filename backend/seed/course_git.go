@@ -4,6 +4,7 @@ import "github.com/pathway/backend/models"
 
 func courseGit() models.Course {
 	return models.Course{
+		Slug:        "git",
 		Title:       "Git",
 		Description: "Learn version control with Git - the essential tool for modern software development. Master branching, merging, and collaboration workflows.",
 		Modules: []models.Module{
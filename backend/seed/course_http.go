@@ -4,6 +4,7 @@ import "github.com/pathway/backend/models"
 
 func courseHTTP() models.Course {
 	return models.Course{
+		Slug:        "http",
 		Title:       "HTTP Networking",
 		Description: "Deep dive into HTTP protocols, REST APIs, request/response cycles, and modern web communication patterns.",
 		Modules: []models.Module{
@@ -4,6 +4,7 @@ import "github.com/pathway/backend/models"
 
 func courseDesignPatterns() models.Course {
 	return models.Course{
+		Slug:        "design-patterns",
 		Title:       "Design Patterns",
 		Description: "Learn proven software design patterns to solve common programming challenges elegantly and efficiently.",
 		Modules: []models.Module{
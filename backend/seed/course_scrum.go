@@ -4,6 +4,7 @@ import "github.com/pathway/backend/models"
 
 func courseScrum() models.Course {
 	return models.Course{
+		Slug:        "scrum",
 		Title:       "SCRUM",
 		Description: "Learn the SCRUM framework for agile project management. Understand sprints, standups, and how to deliver value iteratively.",
 		Modules: []models.Module{
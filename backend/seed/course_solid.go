@@ -4,6 +4,7 @@ import "github.com/pathway/backend/models"
 
 func courseSolid() models.Course {
 	return models.Course{
+		Slug:        "solid",
 		Title:       "Architecture - SOLID",
 		Description: "Master the SOLID principles of object-oriented design to write maintainable, scalable, and robust software architectures.",
 		Modules: []models.Module{
@@ -4,6 +4,7 @@ import "github.com/pathway/backend/models"
 
 func courseTesting() models.Course {
 	return models.Course{
+		Slug:        "testing",
 		Title:       "Testing",
 		Description: "Master software testing strategies including unit tests, integration tests, and test-driven development (TDD).",
 		Modules: []models.Module{
@@ -246,11 +246,13 @@ int result = Double(21); // 21 is the argument
 
 const result = double(21);`),
 			exerciseBlock(
+				"if-else-and-functions",
 				"1) In the C# if/else example above, what does it print when age is 17? 2) Write a function called Triple that takes a number and returns that number times 3.",
 				"1) It prints Minor. 2) One solution: static int Triple(int n) { return n * 3; } and then call Triple(10) to get 30.",
 				[]string{"If age is less than 18, the else branch runs", "A parameter is the name inside the parentheses; the argument is the value you pass in"},
 			),
 			exerciseBlock(
+				"why-typescript",
 				"Write down one reason a team might choose TypeScript over JavaScript for a large project.",
 				"TypeScript helps catch errors before runtime and makes code easier to understand by making data types explicit. This reduces bugs and improves teamwork in large codebases.",
 				[]string{"Think about teamwork and catching mistakes early", "Types are like labels that explain what a value is"},
@@ -66,6 +66,7 @@ while (lives > 0) {
 }`),
 			calloutBlock("tip", "The most common bug in beginner loops is an off-by-one error (starting at 0 vs 1, or stopping too early/late)."),
 			exerciseBlock(
+				"even-or-odd-loop",
 				"Write a loop that prints the numbers 1 through 20. For each number, print whether it is even or odd.",
 				"A common solution uses a for loop and the modulo operator (%). Example: if (n % 2 === 0) it's even; otherwise it's odd.",
 				[]string{"Use n % 2 to check remainder", "If remainder is 0, it's even"},
@@ -90,6 +90,7 @@ ls
 # Check Git status
 git status`),
 			exerciseBlock(
+				"set-up-vs-code",
 				"Open VS Code, install the ESLint and Prettier extensions, and open your my-first-repo folder. What do you see in the sidebar?",
 				"You should see the Explorer sidebar showing your project files, including HelloWorld.txt and the .git folder (if hidden files are shown). The file tree shows your entire project structure.",
 				[]string{"Look at the left sidebar", "You should see your HelloWorld.txt file", "The Explorer view shows your folder contents"},
@@ -77,6 +77,7 @@ export default function App() {
 }`),
 			calloutBlock("info", "Notice the separation: the class does the logic; the React component just displays results. This is a professional habit."),
 			exerciseBlock(
+				"add-bazz-rule",
 				"Change the engine so that it also returns 'Bazz' for numbers divisible by 7. How would you add this without breaking the original rules?",
 				"You can add another divisibility check (n % 7 === 0) and decide how to combine labels. A clean approach is to build a string result and append parts (e.g., result += 'Fizz').",
 				[]string{"Try building the output string step-by-step", "Think about combining multiple rules"},
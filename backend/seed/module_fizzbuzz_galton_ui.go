@@ -228,6 +228,7 @@ export default function App() {
 }`),
 			calloutBlock("tip", "If the balls all land in 'Number', check your FizzBuzz rules first. The UI is only as correct as the engine!"),
 			exerciseBlock(
+				"pause-and-reset",
 				"Add two buttons: Pause/Resume and Reset Counts. (Hint: store a boolean in state and only create the interval when running.)",
 				"A typical solution uses a running boolean in state and conditionally starts/stops the interval in useEffect. Reset counts by setting the counts state back to zeros and clearing balls.",
 				[]string{"Add a `running` state boolean", "In useEffect, only setInterval when running is true", "Reset counts by setting state back to the initial object"},
@@ -26,6 +26,7 @@ Version control is a system that records changes to files over time so you can r
 
 Git is a *distributed* version control system, which means you have the complete history on your local machine.`),
 			exerciseBlock(
+				"why-distributed",
 				"Why might a distributed version control system be better for open source projects?",
 				"Distributed systems allow developers to work offline, have faster operations since most are local, provide better redundancy (every clone is a backup), and enable more flexible workflows like forking.",
 				[]string{"Think about what happens if the central server goes down", "Consider developers in different time zones"},
@@ -74,6 +75,7 @@ To push code to GitHub, you need to authenticate. The recommended method is usin
 
 You're now ready to start using Git and GitHub together!`),
 			exerciseBlock(
+				"configure-git",
 				"Configure Git with your name and email. What commands did you use?",
 				`git config --global user.name "Your Name"
 git config --global user.email "your.email@example.com"`,
@@ -44,6 +44,7 @@ A commit is a snapshot of your staged changes. Always include a meaningful messa
 			textBlock("## Checking Status\n\nUse `git status` frequently to see what's going on:"),
 			codeBlock("bash", `git status`),
 			exerciseBlock(
+				"first-commit",
 				"Initialize a new repository, create a file called 'hello.txt', stage it, and commit it with the message 'Initial commit'",
 				`git init
 echo "Hello World" > hello.txt
@@ -102,6 +103,7 @@ Create your first commit with a meaningful message:`),
 
 **Keep this repository open** - we'll push it to GitHub in the next module!`),
 			exerciseBlock(
+				"view-history",
 				"You've created HelloWorld.txt and committed it. What command shows you the history of commits in your repository?",
 				"git log - This shows all commits in reverse chronological order, including commit hash, author, date, and message.",
 				[]string{"Think about what command shows history", "It's a short three-letter command"},
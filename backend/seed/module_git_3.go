@@ -44,6 +44,7 @@ git fetch`),
 To get a copy of an existing remote repository:`),
 			codeBlock("bash", `git clone https://github.com/username/repo.git`),
 			exerciseBlock(
+				"fetch-vs-pull",
 				"What's the difference between git fetch and git pull?",
 				"git fetch downloads changes from the remote but doesn't integrate them into your working branch. git pull does both - it fetches AND merges the changes. Using fetch is safer when you want to review changes before merging.",
 				[]string{"Think about what happens to your local files", "Consider when you might want to see changes before applying them"},
@@ -82,6 +83,7 @@ git push -u origin main`),
 
 **Congratulations!** Your code is now on GitHub for the world to see!`),
 			exerciseBlock(
+				"add-remote",
 				"You've pushed your first repository! What command did you use to connect your local repo to GitHub?",
 				"git remote add origin https://github.com/YOUR-USERNAME/my-first-repo.git - This adds a remote called 'origin' pointing to your GitHub repository.",
 				[]string{"Think about adding a remote", "The remote is conventionally called 'origin'"},
@@ -49,6 +49,7 @@ git merge feature-login`),
 1. **Fast-forward merge**: If main hasn't changed, Git just moves the pointer forward
 2. **Three-way merge**: If both branches have new commits, Git creates a merge commit`),
 			exerciseBlock(
+				"create-branch",
 				"Create a branch called 'add-readme', switch to it, and then switch back to main",
 				`git checkout -b add-readme
 # or: git branch add-readme && git checkout add-readme
@@ -107,6 +108,7 @@ git push -u origin development`),
 4. Switch to it and verify your bio is there!`),
 			calloutBlock("info", "You now have two branches: 'main' with your original Hello World, and 'development' with your added bio. This is exactly how professional developers work!"),
 			exerciseBlock(
+				"create-and-switch",
 				"You created a development branch and pushed it to GitHub. What command creates a new branch AND switches to it in one step?",
 				"git checkout -b development - The -b flag tells git to create the branch and then switch to it immediately.",
 				[]string{"Think about the checkout command", "There's a flag that combines create and switch"},
@@ -60,6 +60,7 @@ Used in open source projects:
 4. Push to your fork
 5. Open a PR to the original repo`),
 			exerciseBlock(
+				"contribute-to-open-source",
 				"You want to contribute to an open source project. What are the steps?",
 				"1. Fork the repository on GitHub\n2. Clone your fork: git clone https://github.com/YOUR-USERNAME/repo.git\n3. Create a feature branch: git checkout -b my-feature\n4. Make changes and commit them\n5. Push to your fork: git push origin my-feature\n6. Open a Pull Request from your fork to the original repository",
 				[]string{"Start by getting your own copy of the repo", "You can't push directly to someone else's repo"},
@@ -160,6 +161,7 @@ In this hands-on project, you've completed a full Git workflow:
 
 This is exactly how professional developers work every day!`),
 			exerciseBlock(
+				"pull-request-outcomes",
 				"You've completed your first Pull Request! What are the three possible outcomes when a reviewer looks at your PR?",
 				"1. Approve - The reviewer is happy with your changes and you can merge\n2. Request changes - The reviewer wants you to modify something before merging\n3. Comment - The reviewer has questions or suggestions but hasn't made a decision yet\n\nAll three are normal parts of the code review process!",
 				[]string{"Think about what a reviewer might do after looking at your code", "There are three main actions a reviewer can take"},
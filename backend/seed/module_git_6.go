@@ -45,6 +45,7 @@ To minimize conflicts:
 - Break large changes into smaller PRs`),
 			calloutBlock("tip", "Many editors (VS Code, IntelliJ) have built-in merge conflict resolution tools that make this process much easier!"),
 			exerciseBlock(
+				"conflict-markers",
 				"You see conflict markers in your file. What are the three sections and what do they represent?",
 				"The three sections are:\n1. <<<<<<< HEAD - The start of your current branch's changes\n2. ======= - The separator between the two versions\n3. >>>>>>> branch-name - The end marker showing the incoming branch's changes\n\nEverything between HEAD and ======= is your version. Everything between ======= and the branch name is the incoming version.",
 				[]string{"Look at the markers: HEAD, =======, and the branch name", "HEAD refers to your current position"},
@@ -58,6 +58,7 @@ git stash apply stash@{2}`),
 - Want to pull changes but have local modifications
 - Need to quickly context-switch to fix a bug`),
 			exerciseBlock(
+				"stash-for-hotfix",
 				"You're working on a feature but need to urgently fix a bug on main. You're not ready to commit your feature work. What do you do?",
 				`git stash                    # Save your work-in-progress
 git checkout main           # Switch to main
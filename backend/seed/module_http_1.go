@@ -74,6 +74,7 @@ Content-Length: 156
 - **Query**: ?id=123
 - **Fragment**: #section`),
 			exerciseBlock(
+				"stateless-http",
 				"What does it mean that HTTP is stateless?",
 				"HTTP is stateless means each request is independent - the server doesn't remember previous requests. Each request must contain all information needed to process it. This is why we use cookies, sessions, and tokens to maintain state across requests.",
 				[]string{"Think about what 'state' means", "How do websites remember you're logged in?"},
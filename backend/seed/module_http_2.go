@@ -115,6 +115,7 @@ Content-Type: application/json
 DELETE /api/users/123`),
 			calloutBlock("tip", "Use the right method for the right purpose. GET for reading, POST for creating, PUT/PATCH for updating, DELETE for removing."),
 			exerciseBlock(
+				"put-vs-patch",
 				"What's the difference between PUT and PATCH?",
 				"PUT replaces the entire resource - you must send all fields, even unchanged ones. PATCH performs a partial update - you only send the fields you want to change. PUT is idempotent (replacing with same data has same effect), and PATCH should also be idempotent.",
 				[]string{"Think about what 'replace' vs 'update' means", "What data do you need to send?"},
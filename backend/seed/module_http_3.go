@@ -128,6 +128,7 @@ fetch('/api/users')
   .catch(error => console.error(error));`),
 			calloutBlock("warning", "Use status codes correctly. 401 for authentication issues, 403 for authorization issues. 400 for client errors, 500 for server errors."),
 			exerciseBlock(
+				"forbidden-status",
 				"A user tries to access a resource they don't have permission for. They're logged in. What status code should you return?",
 				"Return 403 Forbidden. The user is authenticated (logged in), so it's not 401. They simply don't have permission to access this resource, which is exactly what 403 means.",
 				[]string{"What's the difference between 401 and 403?", "Is the user authenticated?"},
@@ -119,6 +119,7 @@ Set-Cookie: sessionId=abc123; Path=/; HttpOnly; Secure; SameSite=Strict
 - Stateless authentication`),
 			calloutBlock("tip", "Use HttpOnly cookies for sensitive data like session IDs. Use Local Storage for non-sensitive data that needs JavaScript access."),
 			exerciseBlock(
+				"httponly-cookies",
 				"Why should authentication tokens be stored in HttpOnly cookies rather than Local Storage?",
 				"HttpOnly cookies are not accessible to JavaScript, which protects them from XSS (Cross-Site Scripting) attacks. If an attacker injects malicious JavaScript, they can't steal tokens from HttpOnly cookies. Local Storage is accessible to JavaScript, making it vulnerable to XSS attacks.",
 				[]string{"Think about XSS attacks", "What can JavaScript access?"},
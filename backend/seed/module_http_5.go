@@ -122,6 +122,7 @@ GET /api/users?page=1&limit=10
 GET /api/users?search=alice`),
 			calloutBlock("tip", "Keep URLs clean and intuitive. Use query parameters for optional things like filtering and pagination, not for required resource identification."),
 			exerciseBlock(
+				"design-blog-api",
 				"Design RESTful endpoints for a blog API with posts and comments. Include endpoints for listing, creating, updating, and deleting.",
 				"Posts:\n- GET /api/posts (list)\n- POST /api/posts (create)\n- GET /api/posts/123 (get one)\n- PUT /api/posts/123 (replace)\n- PATCH /api/posts/123 (update)\n- DELETE /api/posts/123 (delete)\n\nComments:\n- GET /api/posts/123/comments (list comments for post)\n- POST /api/posts/123/comments (create comment)\n- GET /api/comments/456 (get comment)\n- PATCH /api/comments/456 (update)\n- DELETE /api/comments/456 (delete)",
 				[]string{"Think about resource hierarchy", "What are the nouns?"},
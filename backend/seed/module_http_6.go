@@ -136,6 +136,7 @@ function handleGetUser(request) {
 }`),
 			calloutBlock("info", "Understanding the request/response cycle helps debug issues. Is it a DNS problem? Network issue? Server error? Client error?"),
 			exerciseBlock(
+				"client-timeout",
 				"What happens if a server takes 30 seconds to process a request, but the client times out after 10 seconds?",
 				"The client will close the connection after 10 seconds, even though the server is still processing. The server may complete processing, but the response won't reach the client. This is why it's important to:\n1. Set appropriate timeout values\n2. Optimize server processing time\n3. Use async processing for long operations\n4. Provide progress updates for long-running tasks",
 				[]string{"Think about what happens to the connection", "Can the server still send a response?"},
@@ -128,6 +128,7 @@ app.use((req, res, next) => {
 });`),
 			calloutBlock("tip", "Security is not optional. Always use HTTPS in production, validate all inputs, use parameterized queries, and set security headers."),
 			exerciseBlock(
+				"http-vs-https",
 				"What's the difference between HTTP and HTTPS, and why should you always use HTTPS?",
 				"HTTP sends data in plain text, while HTTPS encrypts data using TLS/SSL. HTTPS provides:\n1. Encryption - data can't be read if intercepted\n2. Authentication - verifies you're talking to the real server\n3. Integrity - detects if data was tampered with\n\nYou should always use HTTPS because:\n- Protects user data (passwords, personal info)\n- Prevents man-in-the-middle attacks\n- Required for modern web features\n- Builds user trust\n- Better SEO rankings",
 				[]string{"Think about what happens to data in transit", "What can attackers do with unencrypted data?"},
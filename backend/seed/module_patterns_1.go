@@ -62,6 +62,7 @@ Most patterns describe:
 - Collaborations
 - Consequences`),
 			exerciseBlock(
+				"what-is-a-pattern",
 				"What is a design pattern, and how is it different from a library or framework?",
 				"A design pattern is a general, reusable solution to a commonly occurring problem in software design. It's a template or blueprint, not code you can directly use.\n\nA library is code you can call, and a framework is code that calls you. Design patterns are conceptual solutions that you implement yourself, while libraries and frameworks are actual code you use.\n\nPatterns are language-agnostic concepts, while libraries and frameworks are specific implementations.",
 				[]string{"Think about the difference between concepts and code", "What can you directly use vs. what you implement?"},
@@ -167,6 +167,7 @@ const query = new QueryBuilder()
   .build();`),
 			calloutBlock("tip", "Factory is great when you don't know the exact type. Builder is great when you have many optional parameters."),
 			exerciseBlock(
+				"singleton-vs-factory",
 				"When would you use Singleton vs Factory pattern?",
 				"Use Singleton when you need exactly one instance of a class that should be globally accessible (like a database connection or logger).\n\nUse Factory when you need to create objects but don't know the exact type at compile time, or when you want to decouple object creation from usage (like creating different payment methods based on user choice).\n\nThey solve different problems: Singleton ensures one instance, Factory handles object creation logic.",
 				[]string{"What problem does each solve?", "Think about when you'd need one instance vs. multiple types"},
@@ -179,6 +179,7 @@ const computer = new Computer();
 computer.start();`),
 			calloutBlock("tip", "Facade simplifies complex systems. Adapter makes incompatible interfaces work together. Decorator adds behavior dynamically."),
 			exerciseBlock(
+				"adapter-vs-facade",
 				"What's the difference between Adapter and Facade patterns?",
 				"Adapter makes incompatible interfaces work together - it translates between two different interfaces so they can collaborate.\n\nFacade provides a simplified interface to a complex subsystem - it hides complexity behind a simple interface.\n\nAdapter is about compatibility (making A work with B), while Facade is about simplicity (hiding complexity behind a simple interface).",
 				[]string{"What problem does each solve?", "Think about compatibility vs. simplicity"},
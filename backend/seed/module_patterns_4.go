@@ -204,6 +204,7 @@ invoker.execute(command); // Light on
 invoker.undo(); // Light off`),
 			calloutBlock("tip", "Observer is about notifications. Strategy is about interchangeable algorithms. Command is about encapsulating operations."),
 			exerciseBlock(
+				"observer-vs-strategy",
 				"When would you use Observer vs Strategy pattern?",
 				"Use Observer when you need one object to notify multiple other objects about changes (like an event system, where one event triggers multiple handlers).\n\nUse Strategy when you have multiple ways to accomplish the same task and want to switch between them (like different sorting algorithms, payment methods, or validation rules).\n\nObserver is about notifications and dependencies. Strategy is about interchangeable algorithms.",
 				[]string{"What problem does each solve?", "Think about notifications vs. algorithms"},
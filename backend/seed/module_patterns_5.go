@@ -143,6 +143,7 @@ view.handleAddUser('Alice');`),
 - MVC: Controller handles input
 - MVVM: ViewModel provides data binding`),
 			exerciseBlock(
+				"mvc-validation",
 				"In MVC, where should validation logic go - Model, View, or Controller?",
 				"Validation logic should primarily go in the Model, because:\n1. Business rules belong in the Model\n2. Validation should be reusable across different Views/Controllers\n3. Ensures data integrity regardless of how it's entered\n\nHowever, the View can have basic input validation (format checking) for better UX, and the Controller can coordinate validation, but the Model should have the authoritative validation rules.",
 				[]string{"Where does business logic belong?", "What needs to be reusable?"},
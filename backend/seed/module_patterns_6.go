@@ -161,6 +161,7 @@ const testService = new UserService(mockRepo);`),
 - One DAO per table
 - Less business logic`),
 			exerciseBlock(
+				"repository-benefits",
 				"What are the main benefits of the Repository pattern, and when would you use it?",
 				"Main benefits:\n1. Abstraction - business logic doesn't depend on data source\n2. Testability - easy to mock for unit tests\n3. Flexibility - can switch databases without changing business logic\n4. Separation - clear boundary between data access and business logic\n\nUse it when:\n- You need to abstract data access\n- You want to test business logic independently\n- You might need to change data sources\n- You want clean architecture with clear layers",
 				[]string{"Think about testability", "What problems does it solve?"},
@@ -99,6 +99,7 @@ Need to handle interactions?
 - Premature optimization
 - Over-engineering for future needs that may never come`),
 			exerciseBlock(
+				"repository-for-crud",
 				"You have a simple CRUD application with one database. Should you use the Repository pattern?",
 				"It depends on your context:\n\nUse Repository if:\n- You plan to add more data sources\n- You want to test business logic easily\n- You're building a larger application\n- You want clean architecture\n\nSkip Repository if:\n- It's a simple, one-off project\n- You won't need to test business logic separately\n- You won't change data sources\n- It adds unnecessary complexity\n\nGenerally, for a simple CRUD app, you might skip it initially and add it later if needed. But if you're building something that will grow, starting with Repository is a good idea.",
 				[]string{"Think about future needs", "What's the cost vs. benefit?"},
@@ -120,6 +120,7 @@ Try adding these below the button:`),
         <li>React and Vite</li>
       </ul>`),
 			exerciseBlock(
+				"customize-page",
 				"Customize your React page with your real name, hobbies, and favorite color. Add at least one new section about yourself. What happens when you save the file?",
 				"When you save the file, Vite's hot module replacement (HMR) automatically updates your browser without a full page refresh. Your changes appear instantly! The page should now show your personalized content.",
 				[]string{"Edit the variables at the top of App.jsx", "Save with Ctrl+S", "Watch your browser update automatically"},
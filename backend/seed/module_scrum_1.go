@@ -42,6 +42,7 @@ SCRUM helps teams:
 - Increase transparency
 - Reduce risk`),
 			exerciseBlock(
+				"agile-vs-scrum",
 				"What's the difference between Agile and SCRUM?",
 				"Agile is a philosophy and set of values about how to approach software development. SCRUM is a specific framework that implements Agile principles. You can be Agile without using SCRUM (using Kanban, XP, etc.), but SCRUM is always Agile.",
 				[]string{"Think about philosophy vs. implementation", "Is SCRUM the only way to be Agile?"},
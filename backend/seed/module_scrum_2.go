@@ -44,6 +44,7 @@ Development Team:
 - Estimates work collectively`),
 			calloutBlock("warning", "The Development Team is NOT managed by the Scrum Master. The team is self-organizing and decides how to accomplish the work."),
 			exerciseBlock(
+				"who-decides-how",
 				"Who is responsible for deciding HOW to implement a feature in SCRUM?",
 				"The Development Team is responsible for deciding HOW to implement features. The Product Owner decides WHAT to build, but the team decides the technical approach. The Scrum Master helps ensure the team can work effectively.",
 				[]string{"Think about the separation of concerns", "Who has the technical expertise?"},
@@ -79,6 +79,7 @@ During a sprint:
 
 **Output**: Actionable improvements`),
 			exerciseBlock(
+				"time-boxed-events",
 				"Why are SCRUM events time-boxed?",
 				"Time-boxing ensures events don't drag on unnecessarily, creates predictability, and forces focus. It also prevents over-planning and ensures the team spends most of their time on actual development work rather than meetings.",
 				[]string{"Think about what happens without time limits", "What's the cost of long meetings?"},
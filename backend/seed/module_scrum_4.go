@@ -70,6 +70,7 @@ The Increment is:
 - Prevents technical debt`),
 			calloutBlock("warning", "An increment must be 'done' according to the Definition of Done. Partially done work doesn't count as an increment."),
 			exerciseBlock(
+				"product-vs-sprint-backlog",
 				"What's the difference between the Product Backlog and Sprint Backlog?",
 				"The Product Backlog contains ALL work for the product, ordered by value. The Sprint Backlog contains only the work selected for the current sprint, plus the plan for how to do it. The Sprint Backlog is a subset of the Product Backlog.",
 				[]string{"Think about scope - what's the difference in size?", "When is each created and updated?"},
@@ -74,6 +74,7 @@ By the end of Sprint Planning:
 
 Note: Commitment doesn't mean guarantee. It means the team will do their best to achieve the goal.`),
 			exerciseBlock(
+				"overcommitted-sprint",
 				"During Sprint Planning, the team realizes they can't complete all the items the Product Owner wanted. What should happen?",
 				"The team should communicate this to the Product Owner. They can either:\n1. Reduce the scope to what's achievable\n2. Extend the sprint (not recommended - breaks time-boxing)\n3. Adjust the Sprint Goal to be more realistic\n\nThe Product Owner and team collaborate to find the right balance. The team should never commit to more than they believe they can deliver.",
 				[]string{"Think about transparency and honesty", "What's the purpose of the Sprint Goal?"},
@@ -83,6 +83,7 @@ Good user stories are:
 - **S**mall: Can be completed in one sprint
 - **T**estable: Has clear acceptance criteria`),
 			exerciseBlock(
+				"large-story",
 				"A user story is estimated at 13 points. The team's average velocity is 20 points per sprint. Is this story too large?",
 				"Yes, this story is likely too large. At 13 points, it's 65% of the team's capacity, which makes it risky. Large stories should be broken down into smaller stories (ideally 1-8 points) to reduce risk and increase predictability. The team should split this into 2-3 smaller stories.",
 				[]string{"Think about risk and predictability", "What happens if a large story isn't finished?"},
@@ -100,6 +100,7 @@ A sprint can be cancelled if:
 - ❌ Scrum Master as team manager`),
 			calloutBlock("warning", "SCRUM is simple but not easy. It requires discipline, transparency, and a commitment to continuous improvement."),
 			exerciseBlock(
+				"mid-sprint-bug",
 				"Halfway through a sprint, a critical bug is discovered in production. The Product Owner wants the team to drop everything and fix it. How should this be handled?",
 				"This depends on the severity:\n\n1. If it's truly critical (system down, data loss), the Product Owner can cancel the sprint and the team addresses it immediately.\n\n2. If it's important but not critical, the Product Owner and team discuss:\n   - Can it wait until next sprint?\n   - Can we swap it for a lower-priority item?\n   - Does it change the Sprint Goal?\n\nThe key is transparency and collaboration, not just dictating changes.",
 				[]string{"Think about the Sprint Goal", "What's the impact of mid-sprint changes?"},
@@ -37,6 +37,7 @@ SOLID principles are tools, not goals. Over-applying them can lead to:
 
 Use them when they solve real problems, not just because they exist.`),
 			exerciseBlock(
+				"solid-problem",
 				"What problem do SOLID principles primarily solve?",
 				"SOLID principles solve the problem of code that becomes difficult to maintain, test, and extend as a project grows. They help prevent code rot and technical debt by promoting good design practices.",
 				[]string{"Think about what happens to code over time", "Consider what makes code hard to change"},
@@ -66,6 +66,7 @@ class EmailValidator {
 }`),
 			calloutBlock("tip", "Ask yourself: 'If the requirements for X change, would I need to modify this class?' If the answer is yes for multiple X's, you're violating SRP."),
 			exerciseBlock(
+				"refactor-for-srp",
 				"A class handles user authentication, logs all login attempts, and sends welcome emails. How would you refactor this to follow SRP?",
 				"Split into three classes:\n1. AuthService - handles authentication logic\n2. LoginLogger - logs login attempts\n3. EmailService - sends welcome emails\n\nThe User class would coordinate these services but not contain their logic.",
 				[]string{"Each responsibility should be its own class", "Think about what changes independently"},
@@ -77,6 +77,7 @@ class Triangle extends Shape {
 }`),
 			calloutBlock("warning", "OCP doesn't mean you can never modify code. It means you shouldn't have to modify stable, tested code to add new features."),
 			exerciseBlock(
+				"refactor-for-ocp",
 				"You have a PaymentProcessor class with if/else statements for different payment methods (credit card, PayPal). How would you refactor this to follow OCP?",
 				"Create a PaymentMethod interface/abstract class with a process() method. Each payment method (CreditCard, PayPal, etc.) implements this interface. The PaymentProcessor can then work with any PaymentMethod without knowing the specific type, and new payment methods can be added without modifying existing code.",
 				[]string{"Think about polymorphism", "How can you make it extensible without changing existing code?"},
@@ -93,6 +93,7 @@ class Square extends Shape {
 }`),
 			calloutBlock("tip", "If you find yourself checking the type of a subclass before using it, you're likely violating LSP. The code should work with the base type."),
 			exerciseBlock(
+				"penguin-lsp",
 				"A Bird class has a fly() method. A Penguin class extends Bird but can't fly. How does this violate LSP and how would you fix it?",
 				"This violates LSP because code expecting a Bird to fly would break with a Penguin. Solutions:\n1. Don't make Penguin extend Bird if it can't fulfill Bird's contract\n2. Create a FlyingBird subclass that Bird extends, and have Penguin extend Bird directly\n3. Use composition: have a Flyable interface that only flying birds implement",
 				[]string{"Think about what guarantees Bird makes", "Can all birds fly?"},
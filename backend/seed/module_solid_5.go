@@ -68,6 +68,7 @@ class Robot implements Workable {
 - **Clarity**: Interfaces clearly communicate their purpose
 - **Testability**: Easier to mock and test specific behaviors`),
 			exerciseBlock(
+				"split-printer-interface",
 				"You have a Printer interface with print(), scan(), fax(), and copy() methods. A basic printer only needs print(). How would you refactor this?",
 				"Split into separate interfaces:\n- Printable: print()\n- Scannable: scan()\n- Faxable: fax()\n- Copyable: copy()\n\nA basic printer implements only Printable. Advanced printers can implement multiple interfaces as needed.",
 				[]string{"Not all printers have all capabilities", "Think about what each device actually needs"},
@@ -75,6 +75,7 @@ const userService = new UserService(new PostgreSQLDatabase());`),
 - **Maintainability**: Changes to low-level modules don't affect high-level ones
 - **Reusability**: High-level modules can work with any implementation`),
 			exerciseBlock(
+				"refactor-for-dip",
 				"A NotificationService directly creates instances of EmailSender and SMSSender. How would you refactor this to follow DIP?",
 				"Create a Notifier interface with a send() method. EmailSender and SMSSender implement this interface. NotificationService depends on the Notifier interface and receives it via dependency injection. This allows easy swapping of notification methods and better testing.",
 				[]string{"What abstraction could represent both email and SMS?", "How can you inject the dependency?"},
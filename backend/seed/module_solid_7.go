@@ -79,6 +79,7 @@ class OrderService {
 - Requirements are still changing rapidly
 - You're not sure what the future needs will be`),
 			exerciseBlock(
+				"refactor-user-class",
 				"Your codebase has a User class that handles authentication, sends emails, logs activity, and saves to database. How would you refactor this using SOLID principles?",
 				"1. SRP: Split into User (data), AuthService, EmailService, Logger, UserRepository\n2. OCP: Make services extensible via interfaces\n3. LSP: Ensure all implementations follow their contracts\n4. ISP: Create focused interfaces (Authenticatable, Emailable, Loggable)\n5. DIP: UserService depends on these interfaces, receives them via injection",
 				[]string{"Each principle addresses a different aspect", "Think about how they work together"},
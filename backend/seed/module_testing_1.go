@@ -73,6 +73,7 @@ The testing pyramid shows the ideal distribution of tests:
 - **Timely**: Written before or with code`),
 			calloutBlock("tip", "Follow the AAA pattern: Arrange (set up), Act (execute), Assert (verify). This makes tests clear and readable."),
 			exerciseBlock(
+				"testing-pyramid",
 				"Why is the testing pyramid shape (many unit tests, fewer integration tests, fewest E2E tests) recommended?",
 				"The pyramid shape is recommended because:\n1. Unit tests are fast and cheap - you can have many\n2. Integration tests are slower - have fewer\n3. E2E tests are slowest and most brittle - have fewest\n\nThis maximizes test coverage and confidence while minimizing execution time. Having too many slow tests makes the test suite slow, which means developers run tests less often.",
 				[]string{"Think about speed and cost", "What happens if you have too many slow tests?"},
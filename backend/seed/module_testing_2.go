@@ -143,6 +143,7 @@ describe('UserService', () => {
 });`),
 			calloutBlock("tip", "Test one thing per test. If a test fails, you should immediately know what's wrong."),
 			exerciseBlock(
+				"good-unit-test",
 				"What makes a good unit test?",
 				"A good unit test:\n1. Tests one thing (single responsibility)\n2. Is fast (runs in milliseconds)\n3. Is independent (doesn't rely on other tests)\n4. Is repeatable (same result every time)\n5. Is readable (clear what it's testing)\n6. Uses AAA pattern (Arrange, Act, Assert)\n7. Has a descriptive name\n8. Tests behavior, not implementation\n9. Uses mocks for external dependencies\n10. Covers happy path, edge cases, and errors",
 				[]string{"Think about what makes tests useful", "What happens when a test fails?"},
@@ -127,6 +127,7 @@ describe('UserRepository', () => {
 });`),
 			calloutBlock("warning", "Never use production database for tests! Always use a separate test database or in-memory database."),
 			exerciseBlock(
+				"unit-vs-integration",
 				"What's the difference between unit tests and integration tests?",
 				"Unit tests:\n- Test individual components in isolation\n- Use mocks for dependencies\n- Fast execution\n- Many tests\n- Focus on single function/class\n\nIntegration tests:\n- Test multiple components together\n- May use real dependencies (database, APIs)\n- Slower execution\n- Fewer tests\n- Focus on interactions between components\n\nUnit tests answer 'Does this function work?' Integration tests answer 'Do these components work together?'",
 				[]string{"Think about isolation vs. interaction", "What dependencies do each use?"},
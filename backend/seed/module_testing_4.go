@@ -111,6 +111,7 @@ test('subtracts two numbers', () => {
 - One-off scripts
 - When requirements are unclear`),
 			exerciseBlock(
+				"tdd-steps",
 				"What are the three steps of TDD, and why is the order important?",
 				"The three steps are:\n1. Red - Write a failing test\n2. Green - Write minimal code to pass\n3. Refactor - Improve the code\n\nThe order is important because:\n- Writing the test first (Red) defines what you're building before you build it\n- Making it pass (Green) ensures you have working code\n- Refactoring (Refactor) improves code quality while tests ensure you don't break anything\n\nThis cycle ensures you always have tests, your code works, and you can safely improve it. Skipping steps (like writing code before tests) defeats the purpose of TDD.",
 				[]string{"Why write test first?", "What happens if you skip steps?"},
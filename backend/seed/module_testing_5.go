@@ -109,6 +109,7 @@ test('gets user', async () => {
 - Mock adds more complexity than value`),
 			calloutBlock("warning", "Don't over-mock! Mocking everything makes tests less valuable. Sometimes you want to test the real integration."),
 			exerciseBlock(
+				"when-to-mock",
 				"When should you mock a dependency in tests?",
 				"You should mock a dependency when:\n1. It's slow (database, network calls) - speeds up tests\n2. It's unreliable (external APIs) - makes tests deterministic\n3. You're testing error conditions - can simulate failures\n4. It doesn't exist yet - allows TDD\n5. It has side effects (sends emails, charges credit cards) - prevents real actions\n6. You want to isolate the unit under test\n\nYou shouldn't mock when:\n- The dependency is fast and simple\n- You're writing integration tests\n- The mock adds more complexity than value\n- You want to test the real integration",
 				[]string{"Think about test speed and reliability", "What are you actually testing?"},
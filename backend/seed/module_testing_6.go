@@ -125,6 +125,7 @@ test('creates user', () => {
 });`),
 			calloutBlock("tip", "Good tests are like good code - readable, maintainable, and focused. Treat test code with the same care as production code."),
 			exerciseBlock(
+				"maintainable-tests",
 				"What makes a test maintainable and easy to understand?",
 				"A maintainable test:\n1. Has a clear, descriptive name\n2. Tests one thing\n3. Uses AAA pattern (Arrange, Act, Assert)\n4. Is independent (doesn't rely on other tests)\n5. Has minimal setup\n6. Uses factories for test data\n7. Is well-organized (grouped logically)\n8. Has clear assertions\n9. Doesn't have complex logic\n10. Is readable - someone else can understand it\n\nMaintainable tests are easy to update when requirements change and easy to debug when they fail.",
 				[]string{"Think about what makes code maintainable", "What happens when a test fails?"},
@@ -110,6 +110,7 @@ Deploy to Production`),
 - Generate coverage reports`),
 			calloutBlock("tip", "Make tests part of your workflow. Run them frequently, fix failures immediately, and keep them fast."),
 			exerciseBlock(
+				"continuous-testing",
 				"Why is continuous testing important, and how does it help development?",
 				"Continuous testing is important because:\n1. Immediate feedback - catch bugs as soon as you write them\n2. Prevents regressions - know immediately if you broke something\n3. Enables refactoring - safe to improve code with test safety net\n4. Faster development - less time debugging later\n5. Better code quality - tests force better design\n6. Confidence - know your code works\n\nIt helps development by:\n- Reducing debugging time\n- Making code changes safer\n- Providing documentation\n- Enabling faster iteration\n- Catching integration issues early",
 				[]string{"Think about the feedback loop", "What happens without continuous testing?"},
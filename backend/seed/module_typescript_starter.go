@@ -98,6 +98,7 @@ npm start
 npm run dev`),
 			textBlock(`Watch the terminal output. You'll see the results of the code examples!`),
 			exerciseBlock(
+				"run-the-starter",
 				"Clone the starter repository, open it in VS Code, and run 'npm install' followed by 'npm start'. What output do you see in the terminal?",
 				"You should see output from the TypeScript examples, including console.log statements showing variables, conditional results, and loop iterations. The exact output depends on the repository content your instructor provides.",
 				[]string{"Make sure you run npm install first", "The output appears in your terminal", "Look for console.log output"},
@@ -1,6 +1,7 @@
 package seed
 
 import (
+	"context"
 	"log"
 
 	"github.com/pathway/backend/models"
@@ -9,78 +10,39 @@ import (
 
 // Helper function to create a text block
 func textBlock(markdown string) models.ContentBlock {
-	return models.ContentBlock{
-		Type: "text",
-		Data: map[string]interface{}{
-			"markdown": markdown,
-		},
-	}
+	return models.NewContentBlock(&models.TextBlock{Markdown: markdown})
 }
 
 // Helper function to create a code block
 func codeBlock(language, code string) models.ContentBlock {
-	return models.ContentBlock{
-		Type: "code",
-		Data: map[string]interface{}{
-			"language": language,
-			"code":     code,
-		},
-	}
+	return models.NewContentBlock(&models.CodeBlock{Language: language, Code: code})
 }
 
 // Helper function to create an image block
 func imageBlock(url, alt, caption string) models.ContentBlock {
-	return models.ContentBlock{
-		Type: "image",
-		Data: map[string]interface{}{
-			"url":     url,
-			"alt":     alt,
-			"caption": caption,
-		},
-	}
+	return models.NewContentBlock(&models.ImageBlock{URL: url, Alt: alt, Caption: caption})
 }
 
 // Helper function to create a callout block
+// variant is one of "info", "tip", "warning", "danger"
 func calloutBlock(variant, text string) models.ContentBlock {
-	return models.ContentBlock{
-		Type: "callout",
-		Data: map[string]interface{}{
-			"variant": variant, // "info", "tip", "warning", "danger"
-			"text":    text,
-		},
-	}
+	return models.NewContentBlock(&models.CalloutBlock{Variant: variant, Text: text})
 }
 
 // Helper function to create an exercise block
-func exerciseBlock(prompt, solution string, hints []string) models.ContentBlock {
-	return models.ContentBlock{
-		Type: "exercise",
-		Data: map[string]interface{}{
-			"prompt":   prompt,
-			"solution": solution,
-			"hints":    hints,
-		},
-	}
+// id must stay the same across edits; learners' attempts are stored against it
+func exerciseBlock(id, prompt, solution string, hints []string) models.ContentBlock {
+	return models.NewContentBlock(&models.ExerciseBlock{ID: id, Prompt: prompt, Solution: solution, Hints: hints})
 }
 
 // Helper function to create a video block
 func videoBlock(url, title string) models.ContentBlock {
-	return models.ContentBlock{
-		Type: "video",
-		Data: map[string]interface{}{
-			"url":   url,
-			"title": title,
-		},
-	}
+	return models.NewContentBlock(&models.VideoBlock{URL: url, Title: title})
 }
 
-func SeedCourses(repo repository.Repository) error {
-	// Clear existing courses
-	if err := repo.DeleteAllCourses(); err != nil {
-		return err
-	}
-
-	courses := []models.Course{
+// Courses returns the built-in courses
+func Courses() []models.Course {
+	return []models.Course{
 		courseGit(),
 		courseSolid(),
 		courseScrum(),
@@ -90,16 +52,62 @@ func SeedCourses(repo repository.Repository) error {
 		courseDevelopmentTools(),
 		courseCodeConcepts(),
 	}
+}
+
+// SeedCourses upserts every built-in course by slug. Existing courses keep
+// their IDs, so learner progress survives a reseed; it is reconciled with the
+// new modules, and the records that changed are returned.
+func SeedCourses(ctx context.Context, repo repository.Repository) ([]models.ProgressChange, error) {
+	return UpsertCourses(ctx, repo, Courses())
+}
+
+// UpsertCourses upserts the given courses by slug, e.g. ones loaded with the
+// content package, and reconciles learners' progress in each
+func UpsertCourses(ctx context.Context, repo repository.Repository, courses []models.Course) ([]models.ProgressChange, error) {
+	if err := checkCatalog(ctx, repo, courses); err != nil {
+		return nil, err
+	}
 
-	// Insert all courses
+	changes := []models.ProgressChange{}
 	for _, course := range courses {
-		if err := repo.CreateCourse(&course); err != nil {
-			log.Printf("Error creating course %s: %v", course.Title, err)
-			return err
+		if err := repo.UpsertCourseBySlug(ctx, &course); err != nil {
+			log.Printf("Error seeding course %s: %v", course.Title, err)
+			return nil, err
+		}
+		log.Printf("Seeded course: %s (%s)", course.Title, course.ID.Hex())
+
+		courseChanges, err := repo.ReconcileCourseProgress(ctx, course.ID.Hex())
+		if err != nil {
+			log.Printf("Error reconciling progress in course %s: %v", course.Title, err)
+			return nil, err
+		}
+		if len(courseChanges) > 0 {
+			log.Printf("Reconciled progress of %d learners in %s", len(courseChanges), course.Title)
 		}
-		log.Printf("Created course: %s", course.Title)
+		changes = append(changes, courseChanges...)
 	}
 
 	log.Println("Successfully seeded all courses!")
-	return nil
+	return changes, nil
+}
+
+// checkCatalog rejects courses whose prerequisites would form a cycle once they
+// replace the stored courses with the same slugs, before anything is written
+func checkCatalog(ctx context.Context, repo repository.Repository, courses []models.Course) error {
+	existing, err := repo.GetAllCourses(ctx)
+	if err != nil {
+		return err
+	}
+
+	seeded := make(map[string]bool, len(courses))
+	for _, course := range courses {
+		seeded[course.Slug] = true
+	}
+	catalog := append([]models.Course{}, courses...)
+	for _, course := range existing {
+		if !seeded[course.Slug] {
+			catalog = append(catalog, course)
+		}
+	}
+	return models.ValidateCatalogPrerequisites(catalog)
 }
@@ -0,0 +1,46 @@
+package seed
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pathway/backend/models"
+	"github.com/pathway/backend/repository"
+)
+
+// Seed courses must pass the same validation as courses created through the authoring API
+func TestSeedCoursesAreValid(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	if _, err := SeedCourses(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	courses, err := repo.GetAllCourses(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range courses {
+		if err := models.ValidateCourse(&courses[i]); err != nil {
+			t.Errorf("%s: %v", courses[i].Slug, err)
+		}
+	}
+}
+
+func TestUpsertCoursesRejectsPrerequisiteCycles(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	if _, err := UpsertCourses(ctx, repo, []models.Course{{Slug: "go", Title: "Go", Prerequisites: []string{"web"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The cycle only closes with the course already stored
+	_, err := UpsertCourses(ctx, repo, []models.Course{{Slug: "web", Title: "Web", Prerequisites: []string{"go"}}})
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected a cycle error, got %v", err)
+	}
+	if _, err := repo.GetCourseBySlug(ctx, "web"); err == nil {
+		t.Fatal("no course should be stored when seeding fails")
+	}
+}
@@ -0,0 +1,82 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps failure counts in process memory. Counts are lost on
+// restart and aren't shared, so use it only when running a single server.
+// It is safe for concurrent use.
+type MemoryStore struct {
+	mu        sync.Mutex
+	records   map[string]Record
+	lastSweep time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+// Get returns the record for key, or a zero Record if there is none
+func (s *MemoryStore) Get(ctx context.Context, key string) (Record, error) {
+	if err := ctx.Err(); err != nil {
+		return Record{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.records[key], nil
+}
+
+// RecordFailure adds a failure, starting from zero if the record has expired
+func (s *MemoryStore) RecordFailure(ctx context.Context, key string, at time.Time, expiresAt time.Time) (Record, error) {
+	if err := ctx.Err(); err != nil {
+		return Record{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(at)
+	record, ok := s.records[key]
+	if !ok || !record.ExpiresAt.After(at) {
+		record = Record{Key: key}
+	}
+	record.Failures++
+	record.LastFailureAt = at
+	record.ExpiresAt = expiresAt
+	s.records[key] = record
+	return record, nil
+}
+
+// Clear forgets the key's failures
+func (s *MemoryStore) Clear(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// sweep drops expired records at most once a minute, standing in for MongoDB's
+// TTL index; callers must hold the lock
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, record := range s.records {
+		if !record.ExpiresAt.After(now) {
+			delete(s.records, key)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore keeps failure counts in a MongoDB collection, so every server
+// behind a load balancer sees the same counts. Expired records are removed by
+// a TTL index. Updates use pipelines, which need MongoDB 4.2 or later.
+type MongoStore struct {
+	collection *mongo.Collection
+	timeout    time.Duration // Per operation
+}
+
+var _ Store = (*MongoStore)(nil)
+
+// NewMongoStore returns a store using the login_failures collection of db.
+// Call EnsureIndexes to have expired records removed.
+func NewMongoStore(db *mongo.Database, timeout time.Duration) *MongoStore {
+	return &MongoStore{collection: db.Collection("login_failures"), timeout: timeout}
+}
+
+// EnsureIndexes creates the TTL index that removes expired records
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Get returns the record for key, or a zero Record if there is none
+func (s *MongoStore) Get(ctx context.Context, key string) (Record, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var record Record
+	err := s.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&record)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Record{}, nil
+	}
+	return record, err
+}
+
+// RecordFailure adds a failure in a single atomic upsert, starting from zero if
+// the record has expired but not yet been removed
+func (s *MongoStore) RecordFailure(ctx context.Context, key string, at time.Time, expiresAt time.Time) (Record, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	failures := bson.M{"$cond": bson.A{
+		bson.M{"$gt": bson.A{"$expires_at", at}},
+		bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$failures", 0}}, 1}},
+		1,
+	}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"failures":        failures,
+		"last_failure_at": at,
+		"expires_at":      expiresAt,
+	}}}}
+
+	var record Record
+	err := s.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&record)
+	return record, err
+}
+
+// Clear forgets the key's failures
+func (s *MongoStore) Clear(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
@@ -0,0 +1,209 @@
+// Package throttle slows down password guessing by tracking failed logins per
+// account and per client IP.
+//
+// Each failure beyond a policy's free attempts doubles the wait before the next
+// attempt is allowed, and enough failures lock the key out entirely. Failures
+// are forgotten once a window passes without any. Counts live in a Store:
+// MemoryStore for a single server, MongoStore to share them between instances.
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record is what a Store keeps for one key
+type Record struct {
+	Key           string    `bson:"_id"`
+	Failures      int       `bson:"failures"`
+	LastFailureAt time.Time `bson:"last_failure_at"`
+	ExpiresAt     time.Time `bson:"expires_at"` // Failures are forgotten after this
+}
+
+// Store counts failures. Implementations must make RecordFailure atomic so
+// concurrent failures are all counted.
+type Store interface {
+	// Get returns the record for key, or a zero Record if there is none
+	Get(ctx context.Context, key string) (Record, error)
+	// RecordFailure adds a failure at the given time and returns the updated
+	// record. A record that has expired starts again from zero.
+	RecordFailure(ctx context.Context, key string, at time.Time, expiresAt time.Time) (Record, error)
+	// Clear forgets the key's failures
+	Clear(ctx context.Context, key string) error
+}
+
+// Policy decides how long a key must wait after its failures
+type Policy struct {
+	FreeAttempts int           // Failures allowed before any wait
+	BaseDelay    time.Duration // Wait after the first failure past FreeAttempts, doubling after each one after
+	MaxFailures  int           // Failures that lock the key out for Lockout
+	Lockout      time.Duration // Also caps the backoff
+	Window       time.Duration // Failures are forgotten this long after the last one
+}
+
+// DefaultAccountPolicy locks an account for 15 minutes after 10 failed logins
+func DefaultAccountPolicy() Policy {
+	return Policy{FreeAttempts: 3, BaseDelay: time.Second, MaxFailures: 10, Lockout: 15 * time.Minute, Window: time.Hour}
+}
+
+// DefaultIPPolicy is looser than the account policy, since many users can share
+// an address behind NAT, and locks an IP for an hour after 100 failed logins
+func DefaultIPPolicy() Policy {
+	return Policy{FreeAttempts: 10, BaseDelay: time.Second, MaxFailures: 100, Lockout: time.Hour, Window: time.Hour}
+}
+
+// BlockedUntil returns when the key may next try, given its record. A zero
+// time means it may try now.
+func (p Policy) BlockedUntil(record Record) time.Time {
+	switch {
+	case record.Failures >= p.MaxFailures:
+		return record.LastFailureAt.Add(p.Lockout)
+	case record.Failures <= p.FreeAttempts:
+		return time.Time{}
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < record.Failures && delay < p.Lockout; i++ {
+		delay *= 2
+	}
+	if delay > p.Lockout {
+		delay = p.Lockout
+	}
+	return record.LastFailureAt.Add(delay)
+}
+
+// retention is how long a record must be kept: until its window passes or, if
+// longer, its lockout ends
+func (p Policy) retention() time.Duration {
+	if p.Lockout > p.Window {
+		return p.Lockout
+	}
+	return p.Window
+}
+
+// PoliciesFromEnv returns the default account and IP policies overridden by
+// LOGIN_ACCOUNT_MAX_FAILURES, LOGIN_IP_MAX_FAILURES (positive integers),
+// LOGIN_ACCOUNT_LOCKOUT and LOGIN_IP_LOCKOUT (durations)
+func PoliciesFromEnv() (account Policy, ip Policy, err error) {
+	account, ip = DefaultAccountPolicy(), DefaultIPPolicy()
+	for name, target := range map[string]*time.Duration{
+		"LOGIN_ACCOUNT_LOCKOUT": &account.Lockout,
+		"LOGIN_IP_LOCKOUT":      &ip.Lockout,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return Policy{}, Policy{}, fmt.Errorf("invalid %s %q: must be a positive duration", name, value)
+		}
+		*target = d
+	}
+
+	for name, target := range map[string]*int{
+		"LOGIN_ACCOUNT_MAX_FAILURES": &account.MaxFailures,
+		"LOGIN_IP_MAX_FAILURES":      &ip.MaxFailures,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return Policy{}, Policy{}, fmt.Errorf("invalid %s %q: must be a positive integer", name, value)
+		}
+		*target = n
+	}
+
+	// Lowering the lockout threshold below the free attempts just means no backoff
+	for _, p := range []*Policy{&account, &ip} {
+		if p.FreeAttempts >= p.MaxFailures {
+			p.FreeAttempts = p.MaxFailures - 1
+		}
+	}
+	return account, ip, nil
+}
+
+// Limiter applies the account and IP policies to login attempts
+type Limiter struct {
+	Store   Store
+	Account Policy
+	IP      Policy
+	Now     func() time.Time // Defaults to time.Now
+}
+
+// NewLimiter returns a limiter with the default policies
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{Store: store, Account: DefaultAccountPolicy(), IP: DefaultIPPolicy(), Now: time.Now}
+}
+
+// RetryAfter returns how long a login for email from ip must wait; zero means
+// it may go ahead
+func (l *Limiter) RetryAfter(ctx context.Context, ip string, email string) (time.Duration, error) {
+	now := l.now()
+	var until time.Time
+	for _, check := range l.checks(ip, email) {
+		record, err := l.Store.Get(ctx, check.key)
+		if err != nil {
+			return 0, err
+		}
+		if !record.ExpiresAt.After(now) {
+			continue
+		}
+		if blocked := check.policy.BlockedUntil(record); blocked.After(until) {
+			until = blocked
+		}
+	}
+
+	if !until.After(now) {
+		return 0, nil
+	}
+	return until.Sub(now), nil
+}
+
+// Failed records a failed login for email from ip
+func (l *Limiter) Failed(ctx context.Context, ip string, email string) error {
+	now := l.now()
+	for _, check := range l.checks(ip, email) {
+		if _, err := l.Store.RecordFailure(ctx, check.key, now, now.Add(check.policy.retention())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Succeeded forgets the account's failures. The IP's are kept, so an attacker
+// can't reset them by logging in to an account of their own.
+func (l *Limiter) Succeeded(ctx context.Context, email string) error {
+	return l.Store.Clear(ctx, accountKey(email))
+}
+
+type check struct {
+	key    string
+	policy Policy
+}
+
+func (l *Limiter) checks(ip string, email string) []check {
+	return []check{
+		{key: accountKey(email), policy: l.Account},
+		{key: "ip:" + ip, policy: l.IP},
+	}
+}
+
+func (l *Limiter) now() time.Time {
+	if l.Now == nil {
+		return time.Now()
+	}
+	return l.Now()
+}
+
+// accountKey identifies an account by email, ignoring case so variants of the
+// same address share a count
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,125 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPolicyBlockedUntil(t *testing.T) {
+	policy := Policy{FreeAttempts: 2, BaseDelay: time.Second, MaxFailures: 6, Lockout: time.Minute, Window: time.Hour}
+	last := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for failures, want := range map[int]time.Duration{
+		0: 0,
+		2: 0,
+		3: time.Second,
+		4: 2 * time.Second,
+		5: 4 * time.Second,
+		6: time.Minute, // Locked out
+		9: time.Minute,
+	} {
+		got := policy.BlockedUntil(Record{Failures: failures, LastFailureAt: last})
+		if want == 0 {
+			if !got.IsZero() {
+				t.Errorf("%d failures: expected no wait, got until %s", failures, got)
+			}
+			continue
+		}
+		if !got.Equal(last.Add(want)) {
+			t.Errorf("%d failures: expected to wait %s, got until %s", failures, want, got)
+		}
+	}
+
+	// The backoff never exceeds the lockout
+	capped := Policy{FreeAttempts: 0, BaseDelay: time.Minute, MaxFailures: 100, Lockout: 10 * time.Minute}
+	if got := capped.BlockedUntil(Record{Failures: 50, LastFailureAt: last}); !got.Equal(last.Add(10 * time.Minute)) {
+		t.Fatalf("expected backoff capped at the lockout, got until %s", got)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(NewMemoryStore())
+	limiter.Account = Policy{FreeAttempts: 1, BaseDelay: time.Second, MaxFailures: 3, Lockout: time.Minute, Window: time.Hour}
+	limiter.IP = Policy{FreeAttempts: 5, BaseDelay: time.Second, MaxFailures: 10, Lockout: time.Hour, Window: time.Hour}
+	limiter.Now = func() time.Time { return now }
+
+	retryAfter := func(ip, email string) time.Duration {
+		t.Helper()
+		wait, err := limiter.RetryAfter(ctx, ip, email)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return wait
+	}
+	fail := func(ip, email string) {
+		t.Helper()
+		if err := limiter.Failed(ctx, ip, email); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fail("10.0.0.1", "ada@example.com")
+	if wait := retryAfter("10.0.0.1", "ada@example.com"); wait != 0 {
+		t.Fatalf("first failure is free, got wait %s", wait)
+	}
+	fail("10.0.0.1", "Ada@Example.com ")
+	if wait := retryAfter("10.0.0.2", "ada@example.com"); wait != time.Second {
+		t.Fatalf("account backoff applies from any IP and ignores case: got %s", wait)
+	}
+	if wait := retryAfter("10.0.0.1", "bob@example.com"); wait != 0 {
+		t.Fatalf("other accounts from the same IP aren't held up yet, got %s", wait)
+	}
+
+	fail("10.0.0.1", "ada@example.com")
+	if wait := retryAfter("10.0.0.1", "ada@example.com"); wait != time.Minute {
+		t.Fatalf("expected a lockout, got %s", wait)
+	}
+
+	// Waiting out the lockout lets the account try again
+	now = now.Add(time.Minute)
+	if wait := retryAfter("10.0.0.1", "ada@example.com"); wait != 0 {
+		t.Fatalf("lockout should have ended, got %s", wait)
+	}
+
+	// A successful login clears the account but not the IP
+	for i := 0; i < 5; i++ {
+		fail("10.0.0.1", "bob@example.com")
+	}
+	if err := limiter.Succeeded(ctx, "bob@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if wait := retryAfter("10.0.0.1", "carol@example.com"); wait != 4*time.Second {
+		t.Fatalf("IP backoff for 8 failures should remain after another account's success, got %s", wait)
+	}
+
+	// Failures are forgotten once the window passes
+	now = now.Add(2 * time.Hour)
+	if wait := retryAfter("10.0.0.1", "bob@example.com"); wait != 0 {
+		t.Fatalf("expired failures should be ignored, got %s", wait)
+	}
+	fail("10.0.0.1", "ada@example.com")
+	record, _ := limiter.Store.Get(ctx, accountKey("ada@example.com"))
+	if record.Failures != 1 {
+		t.Fatalf("expired record should start again, got %d failures", record.Failures)
+	}
+}
+
+func TestPoliciesFromEnv(t *testing.T) {
+	t.Setenv("LOGIN_ACCOUNT_MAX_FAILURES", "2")
+	t.Setenv("LOGIN_IP_LOCKOUT", "5m")
+	account, ip, err := PoliciesFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.MaxFailures != 2 || account.FreeAttempts != 1 || ip.Lockout != 5*time.Minute || ip.MaxFailures != DefaultIPPolicy().MaxFailures {
+		t.Fatalf("unexpected policies: %+v %+v", account, ip)
+	}
+
+	t.Setenv("LOGIN_IP_MAX_FAILURES", "0")
+	if _, _, err := PoliciesFromEnv(); err == nil {
+		t.Fatal("expected error for LOGIN_IP_MAX_FAILURES=0")
+	}
+}
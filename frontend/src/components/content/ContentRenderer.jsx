@@ -16,7 +16,7 @@ const blockComponents = {
   video: VideoBlock,
 };
 
-const ContentRenderer = ({ content }) => {
+const ContentRenderer = ({ content, courseId, moduleId }) => {
   if (!content || !Array.isArray(content)) {
     return null;
   }
@@ -33,7 +33,7 @@ const ContentRenderer = ({ content }) => {
 
         return (
           <div key={index} className={`content-block content-block--${block.type}`}>
-            <BlockComponent data={block.data} />
+            <BlockComponent data={block.data} courseId={courseId} moduleId={moduleId} />
           </div>
         );
       })}
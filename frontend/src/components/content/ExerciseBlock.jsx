@@ -1,14 +1,97 @@
-import React, { useState } from 'react';
+import React, { useState, useEffect } from 'react';
+import { useAuth } from '../../context/AuthContext';
+import { API_URL } from '../../config/api';
 
-const ExerciseBlock = ({ data }) => {
+// Hints and solutions aren't part of the course; they're revealed through the
+// exercise endpoints, which record each reveal and apply the reveal policy
+const ExerciseBlock = ({ data, courseId, moduleId }) => {
+  const { authFetch } = useAuth();
+  const [state, setState] = useState(null);
   const [showSolution, setShowSolution] = useState(false);
   const [showHints, setShowHints] = useState(false);
+  const [revealing, setRevealing] = useState(false);
+  const [error, setError] = useState(null);
+
+  const exercisePath = `${API_URL}/user/courses/${courseId}/modules/${moduleId}/exercises/${data?.id}`;
+
+  // Load the hints and solution this learner has already revealed
+  useEffect(() => {
+    if (!data?.id || !courseId || !moduleId) {
+      return;
+    }
+
+    const fetchState = async () => {
+      try {
+        const response = await authFetch(exercisePath);
+        if (response.ok) {
+          const exerciseState = await response.json();
+          setState(exerciseState);
+          setShowHints(exerciseState.hints?.length > 0);
+        }
+      } catch (err) {
+        console.error('Error loading exercise:', err);
+      }
+    };
+
+    fetchState();
+  }, [authFetch, exercisePath, data?.id, courseId, moduleId]);
 
   if (!data?.prompt) {
     return null;
   }
 
-  const { prompt, solution, hints = [] } = data;
+  const { prompt, hint_count: hintCount = 0, has_solution: hasSolution } = data;
+  const hints = state?.hints || [];
+  const solutionRevealed = !!state?.solution_revealed;
+
+  const reveal = async (kind) => {
+    setRevealing(true);
+    setError(null);
+    try {
+      const response = await authFetch(`${exercisePath}/${kind}`, { method: 'POST' });
+      const body = await response.json();
+      if (!response.ok) {
+        throw new Error(body.error || `Failed to reveal ${kind}`);
+      }
+      setState(body);
+      return true;
+    } catch (err) {
+      setError(err.message);
+      return false;
+    } finally {
+      setRevealing(false);
+    }
+  };
+
+  const handleHints = async () => {
+    if (!showHints && hints.length > 0) {
+      setShowHints(true);
+    } else if (hints.length < hintCount) {
+      // Hints are revealed one at a time, in order
+      if (await reveal('hints')) {
+        setShowHints(true);
+      }
+    } else {
+      setShowHints(false);
+    }
+  };
+
+  const handleSolution = async () => {
+    if (!solutionRevealed && !(await reveal('solution'))) {
+      return;
+    }
+    setShowSolution(!showSolution);
+  };
+
+  const hintLabel = () => {
+    if (!showHints && hints.length > 0) {
+      return 'Show Hints';
+    }
+    if (hints.length < hintCount) {
+      return hints.length > 0 ? 'Reveal Next Hint' : 'Reveal a Hint';
+    }
+    return 'Hide Hints';
+  };
 
   return (
     <div className="exercise-block">
@@ -16,34 +99,40 @@ const ExerciseBlock = ({ data }) => {
         <span className="exercise-block__icon">✏️</span>
         <span className="exercise-block__title">Exercise</span>
       </div>
-      
+
       <div className="exercise-block__prompt">
         <p>{prompt}</p>
       </div>
 
       <div className="exercise-block__actions">
-        {hints.length > 0 && (
-          <button 
+        {hintCount > 0 && (
+          <button
             className="exercise-block__btn exercise-block__btn--hint"
-            onClick={() => setShowHints(!showHints)}
+            onClick={handleHints}
+            disabled={revealing}
           >
-            {showHints ? 'Hide Hints' : 'Show Hints'}
+            {hintLabel()}
           </button>
         )}
-        
-        {solution && (
-          <button 
+
+        {hasSolution && (
+          <button
             className="exercise-block__btn exercise-block__btn--solution"
-            onClick={() => setShowSolution(!showSolution)}
+            onClick={handleSolution}
+            disabled={revealing}
           >
             {showSolution ? 'Hide Solution' : 'Reveal Solution'}
           </button>
         )}
       </div>
 
+      {error && (
+        <p className="exercise-block__error">{error}</p>
+      )}
+
       {showHints && hints.length > 0 && (
         <div className="exercise-block__hints">
-          <strong>Hints:</strong>
+          <strong>Hints ({hints.length} of {hintCount}):</strong>
           <ul>
             {hints.map((hint, index) => (
               <li key={index}>{hint}</li>
@@ -52,10 +141,10 @@ const ExerciseBlock = ({ data }) => {
         </div>
       )}
 
-      {showSolution && solution && (
+      {showSolution && solutionRevealed && (
         <div className="exercise-block__solution">
           <strong>Solution:</strong>
-          <pre>{solution}</pre>
+          <pre>{state.solution}</pre>
         </div>
       )}
     </div>
@@ -63,4 +152,3 @@ const ExerciseBlock = ({ data }) => {
 };
 
 export default ExerciseBlock;
-
@@ -1,5 +1,5 @@
 /* eslint-disable react-refresh/only-export-components */
-import React, { createContext, useContext, useState, useEffect } from 'react';
+import React, { createContext, useContext, useState, useEffect, useRef, useCallback } from 'react';
 import { API_URL } from '../config/api';
 
 const AuthContext = createContext(null);
@@ -9,40 +9,106 @@ export const AuthProvider = ({ children }) => {
   const [token, setToken] = useState(localStorage.getItem('token'));
   const [loading, setLoading] = useState(true);
 
+  // Latest tokens for authFetch, which may run between renders
+  const tokenRef = useRef(localStorage.getItem('token'));
+  const refreshTokenRef = useRef(localStorage.getItem('refreshToken'));
+  // In-flight refresh, shared so concurrent 401s only rotate the refresh token once
+  const refreshingRef = useRef(null);
+
+  const storeSession = useCallback((data) => {
+    localStorage.setItem('token', data.token);
+    localStorage.setItem('refreshToken', data.refresh_token);
+    tokenRef.current = data.token;
+    refreshTokenRef.current = data.refresh_token;
+    setToken(data.token);
+  }, []);
+
+  const clearSession = useCallback(() => {
+    localStorage.removeItem('token');
+    localStorage.removeItem('refreshToken');
+    tokenRef.current = null;
+    refreshTokenRef.current = null;
+    setToken(null);
+    setUser(null);
+  }, []);
+
+  // Exchange the refresh token for new tokens; resolves to the new access token, or null
+  const refreshSession = useCallback(() => {
+    if (!refreshingRef.current) {
+      refreshingRef.current = (async () => {
+        const refreshToken = refreshTokenRef.current;
+        if (!refreshToken) {
+          return null;
+        }
+        try {
+          const response = await fetch(`${API_URL}/auth/refresh`, {
+            method: 'POST',
+            headers: {
+              'Content-Type': 'application/json',
+            },
+            body: JSON.stringify({ refresh_token: refreshToken }),
+          });
+          if (!response.ok) {
+            clearSession();
+            return null;
+          }
+          const data = await response.json();
+          storeSession(data);
+          setUser(data.user);
+          return data.token;
+        } catch (error) {
+          console.error('Token refresh failed:', error);
+          return null;
+        } finally {
+          refreshingRef.current = null;
+        }
+      })();
+    }
+    return refreshingRef.current;
+  }, [clearSession, storeSession]);
+
+  // fetch with the access token attached, refreshing it and retrying once if it has expired
+  const authFetch = useCallback(async (url, options = {}) => {
+    const send = (accessToken) => fetch(url, {
+      ...options,
+      headers: {
+        ...options.headers,
+        'Authorization': `Bearer ${accessToken}`,
+      },
+    });
+
+    const response = await send(tokenRef.current);
+    if (response.status !== 401) {
+      return response;
+    }
+    const newToken = await refreshSession();
+    return newToken ? send(newToken) : response;
+  }, [refreshSession]);
+
   // Check for existing token on mount
   useEffect(() => {
     const initAuth = async () => {
-      const storedToken = localStorage.getItem('token');
-      if (storedToken) {
+      if (tokenRef.current || refreshTokenRef.current) {
         try {
-          const response = await fetch(`${API_URL}/user/me`, {
-            headers: {
-              'Authorization': `Bearer ${storedToken}`
-            }
-          });
-          
+          const response = await authFetch(`${API_URL}/user/me`);
+
           if (response.ok) {
             const userData = await response.json();
             setUser(userData);
-            setToken(storedToken);
           } else {
-            // Token is invalid, clear it
-            localStorage.removeItem('token');
-            setToken(null);
-            setUser(null);
+            // Neither token is valid any more, clear them
+            clearSession();
           }
         } catch (error) {
           console.error('Auth check failed:', error);
-          localStorage.removeItem('token');
-          setToken(null);
-          setUser(null);
+          clearSession();
         }
       }
       setLoading(false);
     };
 
     initAuth();
-  }, []);
+  }, [authFetch, clearSession]);
 
   const login = async (email, password) => {
     const response = await fetch(`${API_URL}/auth/login`, {
@@ -59,8 +125,7 @@ export const AuthProvider = ({ children }) => {
     }
 
     const data = await response.json();
-    localStorage.setItem('token', data.token);
-    setToken(data.token);
+    storeSession(data);
     setUser(data.user);
     return data;
   };
@@ -80,16 +145,35 @@ export const AuthProvider = ({ children }) => {
     }
 
     const data = await response.json();
-    localStorage.setItem('token', data.token);
-    setToken(data.token);
+    storeSession(data);
     setUser(data.user);
     return data;
   };
 
-  const logout = () => {
-    localStorage.removeItem('token');
-    setToken(null);
-    setUser(null);
+  const logout = async () => {
+    // Revoke both tokens server-side; the local session is cleared regardless
+    const revoke = (accessToken) => fetch(`${API_URL}/auth/logout`, {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+        'Authorization': `Bearer ${accessToken}`
+      },
+      body: JSON.stringify({ refresh_token: refreshTokenRef.current }),
+    });
+
+    try {
+      const response = await revoke(tokenRef.current);
+      if (response.status === 401) {
+        // The access token expired; refreshing rotates the refresh token, so revoke the new one
+        const newToken = await refreshSession();
+        if (newToken) {
+          await revoke(newToken);
+        }
+      }
+    } catch (error) {
+      console.error('Logout failed:', error);
+    }
+    clearSession();
   };
 
   const value = {
@@ -97,6 +181,7 @@ export const AuthProvider = ({ children }) => {
     token,
     loading,
     isAuthenticated: !!token && !!user,
+    authFetch,
     login,
     register,
     logout,
@@ -118,4 +203,3 @@ export const useAuth = () => {
 };
 
 export default AuthContext;
-
@@ -8,7 +8,7 @@ import './ChapterDetailView.css';
 
 const ChapterDetailView = () => {
   const { chapterId } = useParams();
-  const { token } = useAuth();
+  const { token, authFetch } = useAuth();
   const [coursesWithProgress, setCoursesWithProgress] = useState([]);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState(null);
@@ -18,11 +18,7 @@ const ChapterDetailView = () => {
   useEffect(() => {
     const fetchProgress = async () => {
       try {
-        const response = await fetch(`${API_URL}/user/progress`, {
-          headers: {
-            'Authorization': `Bearer ${token}`
-          }
-        });
+        const response = await authFetch(`${API_URL}/user/progress`);
 
         if (!response.ok) {
           throw new Error('Failed to fetch progress');
@@ -40,7 +36,7 @@ const ChapterDetailView = () => {
     if (token) {
       fetchProgress();
     }
-  }, [token]);
+  }, [token, authFetch]);
 
   // Create a map of courses by title for easy lookup
   const coursesMap = {};
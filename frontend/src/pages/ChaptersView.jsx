@@ -6,7 +6,7 @@ import ChapterCard from '../components/ChapterCard';
 import './ChaptersView.css';
 
 const ChaptersView = () => {
-  const { user, token } = useAuth();
+  const { user, token, authFetch } = useAuth();
   const [coursesWithProgress, setCoursesWithProgress] = useState([]);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState(null);
@@ -14,11 +14,7 @@ const ChaptersView = () => {
   useEffect(() => {
     const fetchProgress = async () => {
       try {
-        const response = await fetch(`${API_URL}/user/progress`, {
-          headers: {
-            'Authorization': `Bearer ${token}`
-          }
-        });
+        const response = await authFetch(`${API_URL}/user/progress`);
 
         if (!response.ok) {
           throw new Error('Failed to fetch progress');
@@ -36,7 +32,7 @@ const ChaptersView = () => {
     if (token) {
       fetchProgress();
     }
-  }, [token]);
+  }, [token, authFetch]);
 
   // Create a map of courses by title for easy lookup
   const coursesMap = {};
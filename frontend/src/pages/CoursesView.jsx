@@ -6,7 +6,7 @@ import CourseProgressCard from '../components/CourseProgressCard';
 import './CoursesView.css';
 
 const CoursesView = () => {
-  const { token } = useAuth();
+  const { token, authFetch } = useAuth();
   const [coursesWithProgress, setCoursesWithProgress] = useState([]);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState(null);
@@ -14,11 +14,7 @@ const CoursesView = () => {
   useEffect(() => {
     const fetchProgress = async () => {
       try {
-        const response = await fetch(`${API_URL}/user/progress`, {
-          headers: {
-            'Authorization': `Bearer ${token}`
-          }
-        });
+        const response = await authFetch(`${API_URL}/user/progress`);
 
         if (!response.ok) {
           throw new Error('Failed to fetch progress');
@@ -36,7 +32,7 @@ const CoursesView = () => {
     if (token) {
       fetchProgress();
     }
-  }, [token]);
+  }, [token, authFetch]);
 
   if (loading) {
     return (
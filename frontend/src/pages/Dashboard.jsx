@@ -5,7 +5,7 @@ import CourseProgressCard from '../components/CourseProgressCard';
 import './Dashboard.css';
 
 const Dashboard = () => {
-  const { user, token } = useAuth();
+  const { user, token, authFetch } = useAuth();
   const [coursesWithProgress, setCoursesWithProgress] = useState([]);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState(null);
@@ -13,11 +13,7 @@ const Dashboard = () => {
   useEffect(() => {
     const fetchProgress = async () => {
       try {
-        const response = await fetch(`${API_URL}/user/progress`, {
-          headers: {
-            'Authorization': `Bearer ${token}`
-          }
-        });
+        const response = await authFetch(`${API_URL}/user/progress`);
 
         if (!response.ok) {
           throw new Error('Failed to fetch progress');
@@ -35,7 +31,7 @@ const Dashboard = () => {
     if (token) {
       fetchProgress();
     }
-  }, [token]);
+  }, [token, authFetch]);
 
   if (loading) {
     return (
@@ -9,7 +9,7 @@ import './ModuleView.css';
 const ModuleView = () => {
   const { courseId, moduleId } = useParams();
   const navigate = useNavigate();
-  const { token } = useAuth();
+  const { token, authFetch } = useAuth();
   
   const [course, setCourse] = useState(null);
   const [currentModule, setCurrentModule] = useState(null);
@@ -43,11 +43,7 @@ const ModuleView = () => {
 
         // Fetch user progress to check if module is completed
         if (token) {
-          const progressResponse = await fetch(`${API_URL}/user/progress`, {
-            headers: {
-              'Authorization': `Bearer ${token}`
-            }
-          });
+          const progressResponse = await authFetch(`${API_URL}/user/progress`);
           if (progressResponse.ok) {
             const progressData = await progressResponse.json();
             const courseProgress = progressData.find(p => p.course.id === courseId);
@@ -64,18 +60,17 @@ const ModuleView = () => {
     };
 
     fetchCourseAndProgress();
-  }, [courseId, moduleId, token]);
+  }, [courseId, moduleId, token, authFetch]);
 
   const handleMarkComplete = async () => {
     if (completing || isCompleted) return;
     
     setCompleting(true);
     try {
-      const response = await fetch(`${API_URL}/user/progress/complete`, {
+      const response = await authFetch(`${API_URL}/user/progress/complete`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
-          'Authorization': `Bearer ${token}`
         },
         body: JSON.stringify({
           course_id: courseId,
@@ -186,7 +181,7 @@ const ModuleView = () => {
         {/* Module Content */}
         <main className="module-content">
           {currentModule?.content && currentModule.content.length > 0 ? (
-            <ContentRenderer content={currentModule.content} />
+            <ContentRenderer content={currentModule.content} courseId={courseId} moduleId={moduleId} />
           ) : (
             <div className="module-empty">
               <p>Content coming soon...</p>
@@ -6,7 +6,7 @@ import Badge from '../components/Badge';
 import './ProfileView.css';
 
 const ProfileView = () => {
-  const { user, token } = useAuth();
+  const { user, token, authFetch } = useAuth();
   const [coursesWithProgress, setCoursesWithProgress] = useState([]);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState(null);
@@ -14,11 +14,7 @@ const ProfileView = () => {
   useEffect(() => {
     const fetchProgress = async () => {
       try {
-        const response = await fetch(`${API_URL}/user/progress`, {
-          headers: {
-            'Authorization': `Bearer ${token}`
-          }
-        });
+        const response = await authFetch(`${API_URL}/user/progress`);
 
         if (!response.ok) {
           throw new Error('Failed to fetch progress');
@@ -36,7 +32,7 @@ const ProfileView = () => {
     if (token) {
       fetchProgress();
     }
-  }, [token]);
+  }, [token, authFetch]);
 
   // Calculate stats
   const totalCourses = coursesWithProgress.length;